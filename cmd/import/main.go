@@ -0,0 +1,96 @@
+// Command import loads a JSONL chat transcript into the messages collection. See package
+// importer for the transcript format.
+//
+//	go run ./cmd/import -source slack -file history.jsonl -handles handles.json
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"log"
+	"os"
+
+	"github.com/dk5761/go-serv/configs"
+	authRepo "github.com/dk5761/go-serv/internal/domain/auth/repository"
+	"github.com/dk5761/go-serv/internal/domain/chat/importer"
+	"github.com/dk5761/go-serv/internal/domain/chat/repository"
+	"github.com/dk5761/go-serv/internal/infrastructure/database"
+	"github.com/dk5761/go-serv/internal/infrastructure/encryption"
+	"github.com/dk5761/go-serv/internal/infrastructure/logging"
+	"github.com/dk5761/go-serv/migrations"
+)
+
+func main() {
+	source := flag.String("source", "", "name of the tool the transcript was exported from")
+	file := flag.String("file", "", "path to the JSONL transcript")
+	handlesFile := flag.String("handles", "", "optional JSON file mapping source handles to usernames")
+	flag.Parse()
+
+	if *source == "" || *file == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	config, err := configs.LoadConfig()
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+	logging.InitLogger()
+
+	opts := importer.Options{Source: *source}
+	if *handlesFile != "" {
+		data, err := os.ReadFile(*handlesFile)
+		if err != nil {
+			log.Fatalf("Failed to read handles: %v", err)
+		}
+		if err := json.Unmarshal(data, &opts.Handles); err != nil {
+			log.Fatalf("Handles must be a JSON object of handle to username: %v", err)
+		}
+	}
+
+	transcript, err := os.Open(*file)
+	if err != nil {
+		log.Fatalf("Failed to open transcript: %v", err)
+	}
+	defer transcript.Close()
+
+	db, err := database.InitPostgresDB(config.Postgres)
+	if err != nil {
+		log.Fatalf("Failed to connect to PostgreSQL: %v", err)
+	}
+	defer db.Close()
+
+	mongoDB, err := database.InitMongoDB(config.MongoDB)
+	if err != nil {
+		log.Fatalf("Failed to connect to MongoDB: %v", err)
+	}
+	defer mongoDB.Client().Disconnect(context.Background())
+
+	// The unique import index must exist before inserting, or re-runs would duplicate messages
+	if err := migrations.RunMigrations(mongoDB); err != nil {
+		log.Fatalf("Failed to run MongoDB migrations: %v", err)
+	}
+
+	// Imported messages are encrypted at rest like any other
+	keyring, err := encryption.LoadKeyring(config.Encryption)
+	if err != nil {
+		log.Fatalf("Failed to load encryption keys: %v", err)
+	}
+
+	transcriptImporter := importer.NewImporter(
+		repository.NewMongoMessageRepository(mongoDB, keyring),
+		repository.NewMongoConversationSettingsRepository(mongoDB),
+		authRepo.NewPostgresUserRepository(db),
+	)
+
+	result, importErr := transcriptImporter.Import(context.Background(), transcript, opts)
+	if result != nil {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		_ = encoder.Encode(result)
+	}
+	if importErr != nil {
+		log.Fatalf("Import stopped, it is safe to re-run: %v", importErr)
+	}
+}
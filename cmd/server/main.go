@@ -13,6 +13,7 @@ import (
 	"github.com/dk5761/go-serv/internal/infrastructure/cache"
 	"github.com/dk5761/go-serv/internal/infrastructure/container"
 	"github.com/dk5761/go-serv/internal/infrastructure/database"
+	"github.com/dk5761/go-serv/internal/infrastructure/encryption"
 	"github.com/dk5761/go-serv/internal/infrastructure/logging"
 	"github.com/dk5761/go-serv/internal/infrastructure/storage"
 	"github.com/dk5761/go-serv/internal/infrastructure/tracing"
@@ -50,8 +51,11 @@ func main() {
 	// Initialize Storage Service
 	storageService := initStorage(config)
 
+	// Load the master keys for encryption at rest
+	keyring := initKeyring(config)
+
 	// Set up Dependency Container
-	cont := container.NewContainer(db, mongoDB, cacheClient, storageService, config)
+	cont := container.NewContainer(db, mongoDB, cacheClient, storageService, keyring, config)
 
 	// Set up Gin router
 	router := setupRouter()
@@ -59,8 +63,11 @@ func main() {
 	// Initialize routes
 	routes.InitRoutes(router, cont)
 
+	// Start background workers
+	stopWorkers := startWorkers(cont)
+
 	// Start the server
-	startServerWithGracefulShutdown(router, config, db, mongoDB)
+	startServerWithGracefulShutdown(router, config, db, mongoDB, stopWorkers)
 }
 
 // initConfig loads the application configuration
@@ -157,6 +164,29 @@ func initStorage(config *configs.Config) storage.StorageService {
 	return storageService
 }
 
+// initKeyring loads the master keys that encrypt message content at rest
+func initKeyring(config *configs.Config) *encryption.Keyring {
+	keyring, err := encryption.LoadKeyring(config.Encryption)
+	if err != nil {
+		logging.Logger.Fatal("Failed to load encryption keys", zap.Error(err))
+	}
+	if keyring == nil {
+		logging.Logger.Warn("No master key configured, messages are stored in plaintext")
+	} else {
+		logging.Logger.Info("Encryption at rest enabled", zap.String("active_key_id", keyring.ActiveKeyID()))
+	}
+	return keyring
+}
+
+// startWorkers runs the container's background workers and returns a function that stops them
+func startWorkers(cont *container.Container) context.CancelFunc {
+	ctx, cancel := context.WithCancel(context.Background())
+	for _, w := range cont.Workers {
+		go w.Run(ctx)
+	}
+	return cancel
+}
+
 // setupRouter configures Gin router with middleware
 func setupRouter() *gin.Engine {
 	router := gin.New()
@@ -165,7 +195,7 @@ func setupRouter() *gin.Engine {
 }
 
 // startServer starts the HTTP server
-func startServerWithGracefulShutdown(router *gin.Engine, config *configs.Config, db *pgxpool.Pool, mongoDB *mongo.Database) {
+func startServerWithGracefulShutdown(router *gin.Engine, config *configs.Config, db *pgxpool.Pool, mongoDB *mongo.Database, stopWorkers context.CancelFunc) {
 	// Create an http.Server with the Gin router
 	server := &http.Server{
 		Addr:    config.Server.Address,
@@ -197,6 +227,9 @@ func startServerWithGracefulShutdown(router *gin.Engine, config *configs.Config,
 		logging.Logger.Fatal("Server forced to shutdown", zap.Error(err))
 	}
 
+	// Stop background workers before closing the connections they use
+	stopWorkers()
+
 	// Close database connections
 	db.Close()
 	if err := mongoDB.Client().Disconnect(ctx); err != nil {
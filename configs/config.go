@@ -1,18 +1,31 @@
 package configs
 
 import (
-	"fmt"
-
 	"github.com/spf13/viper"
 )
 
 type Config struct {
-	Server   ServerConfig
-	Postgres PostgresConfig
-	MongoDB  MongoDBConfig
-	Redis    RedisConfig
-	JWT      JWTConfig
-	Storage  StorageConfig
+	Server     ServerConfig
+	Postgres   PostgresConfig
+	MongoDB    MongoDBConfig
+	Redis      RedisConfig
+	JWT        JWTConfig
+	Storage    StorageConfig
+	Scheduler  SchedulerConfig
+	Sweeper    SweeperConfig
+	PollCloser PollCloserConfig
+	Export     ExportConfig
+	Retention  RetentionConfig
+	Moderation ModerationConfig
+	Encryption EncryptionConfig
+	Redelivery RedeliveryConfig
+	Push       PushConfig
+	Mail       MailConfig
+	Digest     DigestConfig
+	Bot        BotConfig
+	Call       CallConfig
+	Voice      VoiceConfig
+	Webhook    WebhookConfig
 }
 
 type ServerConfig struct {
@@ -45,6 +58,163 @@ type JWTConfig struct {
 	TokenDuration int // in minutes
 }
 
+type SchedulerConfig struct {
+	PollInterval  int // in seconds
+	LeaseDuration int // in seconds
+	MaxAttempts   int
+}
+
+type SweeperConfig struct {
+	Interval  int // in seconds
+	BatchSize int
+}
+
+// PollCloserConfig tunes the worker closing polls whose close time has passed.
+type PollCloserConfig struct {
+	Interval  int // in seconds
+	BatchSize int
+}
+
+// RedeliveryConfig tunes the worker retrying messages stuck in stored for connected receivers.
+type RedeliveryConfig struct {
+	Interval    int // in seconds
+	BatchSize   int
+	MaxAttempts int
+	BaseBackoff int // in seconds, doubled after every failed attempt
+	MaxBackoff  int // in seconds
+	GracePeriod int // in seconds; newer messages are left to the live send path
+}
+
+// PushConfig configures push notifications for messages to users with no connected device.
+// Platforms without credentials are left out; Fake keeps notifications in memory instead.
+type PushConfig struct {
+	Workers        int
+	QueueSize      int
+	MaxAttempts    int
+	CollapseWindow int // in seconds; further messages of a conversation within it share one notification
+	Fake           bool
+	FCM            FCMConfig
+	APNs           APNsConfig
+}
+
+type FCMConfig struct {
+	ProjectID       string
+	CredentialsFile string // Service account JSON
+}
+
+type APNsConfig struct {
+	KeyFile string // .p8 signing key
+	KeyID   string
+	TeamID  string
+	Topic   string // The app's bundle ID
+	Sandbox bool
+}
+
+// MailConfig points at the SMTP server outgoing email is sent through. Without a Host no
+// email is sent. Username and Password are optional, e.g. for a local SMTP sink.
+type MailConfig struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string // Sender address, optionally with a display name
+}
+
+// DigestConfig tunes the emails summarizing unread conversations.
+type DigestConfig struct {
+	Interval         int    // in seconds, how often users are checked for a due digest
+	BatchSize        int    // Users loaded per query
+	IdleAfter        int    // in seconds; unread messages younger than this are left out
+	MaxConversations int    // Conversations listed in one digest
+	BaseURL          string // Public URL of the server, used for unsubscribe links
+	// UnsubscribeSecret signs unsubscribe links; the JWT secret is used when empty
+	UnsubscribeSecret string
+}
+
+// BotConfig tunes the delivery of messages to bots' webhooks.
+type BotConfig struct {
+	Workers     int
+	QueueSize   int
+	MaxAttempts int
+	Timeout     int // in seconds, per webhook request
+}
+
+// CallConfig tunes call signaling and lists the STUN and TURN servers handed to clients.
+type CallConfig struct {
+	RingTimeout int // in seconds, before an unanswered call is recorded as missed
+	ICEServers  []ICEServerConfig
+}
+
+// ICEServerConfig is a STUN or TURN server. A TURN server with a Secret gets short-lived
+// credentials derived from it for every user, following the TURN REST API convention that
+// coturn's use-auth-secret implements; otherwise Username and Credential are served as is.
+type ICEServerConfig struct {
+	URLs          []string
+	Username      string
+	Credential    string
+	Secret        string
+	CredentialTTL int // in seconds, of credentials derived from Secret
+}
+
+// VoiceConfig limits the voice notes users can upload.
+type VoiceConfig struct {
+	MaxSize         int64 // in bytes
+	MaxDuration     int   // in seconds
+	WaveformSamples int   // Levels in a voice note's waveform
+}
+
+// WebhookConfig tunes incoming webhooks.
+type WebhookConfig struct {
+	RateLimit  int    // Messages a hook may post per RateWindow
+	RateWindow int    // in seconds
+	BaseURL    string // Public URL of the server, used for hook URLs
+}
+
+type ExportConfig struct {
+	PollInterval    int // in seconds
+	LeaseDuration   int // in seconds
+	MaxAttempts     int
+	MaxSyncMessages int // Larger conversations are exported in the background
+}
+
+// RetentionConfig holds the global retention policy, which conversations may override.
+type RetentionConfig struct {
+	MaxAgeDays int // 0 keeps messages regardless of age
+	KeepLast   int // 0 keeps every message of a conversation
+	Interval   int // in seconds
+	BatchSize  int
+	DryRun     bool // Only report what the purge worker would delete
+}
+
+// ModerationConfig selects the moderation stages every message passes through before it is
+// stored. Only the stages listed in Stages run, in that order.
+type ModerationConfig struct {
+	Stages          []string // Any of max_length, profanity, links and classifier
+	MaxLength       int      // in characters
+	ProfanityWords  []string
+	ProfanityAction string // "redact" (default) or "reject"
+	BlockedDomains  []string
+	LinkAction      string // "reject" (default) or "redact"
+	Classifier      ClassifierConfig
+}
+
+type ClassifierConfig struct {
+	URL       string   // Endpoint of the external classifier; empty uses the local fake
+	Timeout   int      // in seconds
+	Threshold float64  // Score at which a message is rejected
+	FakeTerms []string // Terms the local fake classifier flags
+}
+
+// EncryptionConfig configures encryption at rest of message content and file URLs. Without
+// a master key, messages are stored in plaintext.
+type EncryptionConfig struct {
+	MasterKey         string // base64-encoded 32-byte key
+	MasterKeyID       string // Name stored with data keys wrapped by MasterKey; defaults to "config"
+	KeyFile           string // Local JSON keyfile holding several master keys and the active one
+	RotationInterval  int    // in seconds
+	RotationBatchSize int
+}
+
 type StorageConfig struct {
 	Provider     string
 	S3Config     S3Config
@@ -78,7 +248,5 @@ func LoadConfig() (*Config, error) {
 		return nil, err
 	}
 
-	fmt.Println(config)
-
 	return &config, nil
 }
@@ -22,3 +22,24 @@ func NewAuthHandler(db *pgxpool.Pool, config *configs.Config) *handler.AuthHandl
 	// Return a new handler with all dependencies set up
 	return handler.NewAuthHandler(authService, jwtService, userRepo)
 }
+
+// NewBlockHandler initializes and returns a BlockHandler backed by the given user repository.
+func NewBlockHandler(db *pgxpool.Pool, userRepo repository.UserRepository) *handler.BlockHandler {
+	blockRepo := repository.NewPostgresBlockRepository(db)
+	blockService := service.NewBlockService(blockRepo, userRepo)
+	return handler.NewBlockHandler(blockService, blockRepo)
+}
+
+// NewKeyHandler initializes and returns a KeyHandler for the end-to-end encryption key
+// directory. notify is told when a user's one-time prekeys run low.
+func NewKeyHandler(db *pgxpool.Pool, blockRepo repository.BlockRepository, notify service.LowPrekeyNotifier) *handler.KeyHandler {
+	keyRepo := repository.NewPostgresKeyRepository(db)
+	keyService := service.NewKeyService(keyRepo, blockRepo, notify)
+	return handler.NewKeyHandler(keyService)
+}
+
+// NewBotHandler initializes and returns a BotHandler for administering bot accounts.
+func NewBotHandler(db *pgxpool.Pool, userRepo repository.UserRepository) *handler.BotHandler {
+	botRepo := repository.NewPostgresBotRepository(db)
+	return handler.NewBotHandler(service.NewBotService(botRepo, userRepo), botRepo)
+}
@@ -20,3 +20,39 @@ type RefreshTokenRequest struct {
 type UpdateProfileRequest struct {
 	Email string `json:"email" binding:"omitempty"`
 }
+
+// SetIdentityKeyRequest represents the request body for publishing an identity key.
+// Keys are base64 encoded.
+type SetIdentityKeyRequest struct {
+	RegistrationID int    `json:"registration_id" binding:"required"`
+	PublicKey      []byte `json:"public_key" binding:"required"`
+}
+
+// SetSignedPrekeyRequest represents the request body for publishing a signed prekey.
+type SetSignedPrekeyRequest struct {
+	KeyID     int    `json:"key_id"`
+	PublicKey []byte `json:"public_key" binding:"required"`
+	Signature []byte `json:"signature" binding:"required"`
+}
+
+// OneTimePrekeyRequest is one key of an UploadPrekeysRequest.
+type OneTimePrekeyRequest struct {
+	KeyID     int    `json:"key_id"`
+	PublicKey []byte `json:"public_key" binding:"required"`
+}
+
+// UploadPrekeysRequest represents the request body for uploading a batch of one-time prekeys.
+type UploadPrekeysRequest struct {
+	Prekeys []OneTimePrekeyRequest `json:"prekeys" binding:"required,dive"`
+}
+
+// CreateBotRequest represents the request body for creating a bot account.
+type CreateBotRequest struct {
+	Username   string `json:"username" binding:"required"`
+	WebhookURL string `json:"webhook_url" binding:"required"`
+}
+
+// SetBotWebhookRequest represents the request body for moving a bot's webhook.
+type SetBotWebhookRequest struct {
+	WebhookURL string `json:"webhook_url" binding:"required"`
+}
@@ -0,0 +1,111 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/dk5761/go-serv/internal/domain/auth/repository"
+	"github.com/dk5761/go-serv/internal/domain/auth/service"
+	"github.com/dk5761/go-serv/internal/domain/common"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type BlockHandler struct {
+	BlockService service.BlockService
+	BlockRepo    repository.BlockRepository
+}
+
+func NewBlockHandler(blockService service.BlockService, blockRepo repository.BlockRepository) *BlockHandler {
+	return &BlockHandler{
+		BlockService: blockService,
+		BlockRepo:    blockRepo,
+	}
+}
+
+// BlockUser adds the user in the path to the authenticated user's block list
+func (h *BlockHandler) BlockUser(c *gin.Context) {
+	blockerID, blockedID, ok := blockParams(c)
+	if !ok {
+		return
+	}
+
+	if err := h.BlockService.BlockUser(c.Request.Context(), blockerID, blockedID); err != nil {
+		switch {
+		case errors.Is(err, common.ErrInvalidInput):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, common.ErrNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to block user"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "User blocked"})
+}
+
+// UnblockUser removes the user in the path from the authenticated user's block list
+func (h *BlockHandler) UnblockUser(c *gin.Context) {
+	blockerID, blockedID, ok := blockParams(c)
+	if !ok {
+		return
+	}
+
+	if err := h.BlockService.UnblockUser(c.Request.Context(), blockerID, blockedID); err != nil {
+		if errors.Is(err, common.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "User is not blocked"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to unblock user"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "User unblocked"})
+}
+
+// GetBlockedUsers lists the authenticated user's block list
+func (h *BlockHandler) GetBlockedUsers(c *gin.Context) {
+	userID, ok := authenticatedUserID(c)
+	if !ok {
+		return
+	}
+
+	blocked, err := h.BlockService.GetBlockedUsers(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve blocked users"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"blocked_users": blocked})
+}
+
+// blockParams reads the authenticated user and the target user from the path
+func blockParams(c *gin.Context) (uuid.UUID, uuid.UUID, bool) {
+	userID, ok := authenticatedUserID(c)
+	if !ok {
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	targetID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return uuid.Nil, uuid.Nil, false
+	}
+	return userID, targetID, true
+}
+
+// authenticatedUserID returns the user ID set by the JWT middleware
+func authenticatedUserID(c *gin.Context) (uuid.UUID, bool) {
+	userIDValue, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return uuid.Nil, false
+	}
+	userID, ok := userIDValue.(uuid.UUID)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid user ID"})
+		return uuid.Nil, false
+	}
+	return userID, true
+}
@@ -0,0 +1,167 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/dk5761/go-serv/internal/domain/auth/dto"
+	"github.com/dk5761/go-serv/internal/domain/auth/repository"
+	"github.com/dk5761/go-serv/internal/domain/auth/service"
+	"github.com/dk5761/go-serv/internal/domain/common"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type BotHandler struct {
+	BotService service.BotService
+	BotRepo    repository.BotRepository
+}
+
+func NewBotHandler(botService service.BotService, botRepo repository.BotRepository) *BotHandler {
+	return &BotHandler{
+		BotService: botService,
+		BotRepo:    botRepo,
+	}
+}
+
+// CreateBot creates a bot account. The response carries the bot's token and webhook
+// secret, which cannot be retrieved later.
+func (h *BotHandler) CreateBot(c *gin.Context) {
+	adminID, ok := authenticatedUserID(c)
+	if !ok {
+		return
+	}
+
+	var req dto.CreateBotRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request parameters"})
+		return
+	}
+
+	bot, credentials, err := h.BotService.CreateBot(c.Request.Context(), adminID, req.Username, req.WebhookURL)
+	if err != nil {
+		respondBotError(c, err, "Failed to create bot")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"bot": bot, "credentials": credentials})
+}
+
+// ListBots lists every bot account
+func (h *BotHandler) ListBots(c *gin.Context) {
+	bots, err := h.BotService.ListBots(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve bots"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"bots": bots})
+}
+
+// GetBot returns a bot account
+func (h *BotHandler) GetBot(c *gin.Context) {
+	botID, ok := botParam(c)
+	if !ok {
+		return
+	}
+
+	bot, err := h.BotService.GetBot(c.Request.Context(), botID)
+	if err != nil {
+		respondBotError(c, err, "Failed to retrieve bot")
+		return
+	}
+
+	c.JSON(http.StatusOK, bot)
+}
+
+// SetWebhook moves a bot's webhook to a new URL
+func (h *BotHandler) SetWebhook(c *gin.Context) {
+	botID, ok := botParam(c)
+	if !ok {
+		return
+	}
+
+	var req dto.SetBotWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request parameters"})
+		return
+	}
+
+	bot, err := h.BotService.SetWebhookURL(c.Request.Context(), botID, req.WebhookURL)
+	if err != nil {
+		respondBotError(c, err, "Failed to update bot webhook")
+		return
+	}
+
+	c.JSON(http.StatusOK, bot)
+}
+
+// RegenerateToken issues a new bot token and revokes the old one
+func (h *BotHandler) RegenerateToken(c *gin.Context) {
+	botID, ok := botParam(c)
+	if !ok {
+		return
+	}
+
+	credentials, err := h.BotService.RegenerateToken(c.Request.Context(), botID)
+	if err != nil {
+		respondBotError(c, err, "Failed to regenerate bot token")
+		return
+	}
+
+	c.JSON(http.StatusOK, credentials)
+}
+
+// RegenerateWebhookSecret issues a new secret for signing the bot's webhook events
+func (h *BotHandler) RegenerateWebhookSecret(c *gin.Context) {
+	botID, ok := botParam(c)
+	if !ok {
+		return
+	}
+
+	credentials, err := h.BotService.RegenerateWebhookSecret(c.Request.Context(), botID)
+	if err != nil {
+		respondBotError(c, err, "Failed to regenerate webhook secret")
+		return
+	}
+
+	c.JSON(http.StatusOK, credentials)
+}
+
+// DeleteBot deletes a bot account
+func (h *BotHandler) DeleteBot(c *gin.Context) {
+	botID, ok := botParam(c)
+	if !ok {
+		return
+	}
+
+	if err := h.BotService.DeleteBot(c.Request.Context(), botID); err != nil {
+		respondBotError(c, err, "Failed to delete bot")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Bot deleted"})
+}
+
+// botParam reads the bot's user ID from the path
+func botParam(c *gin.Context) (uuid.UUID, bool) {
+	botID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid bot ID"})
+		return uuid.Nil, false
+	}
+	return botID, true
+}
+
+func respondBotError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, common.ErrInvalidInput):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, common.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Bot not found"})
+	case errors.Is(err, common.ErrConflict):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"errors"
 	"fmt"
 	"math"
 	"net/http"
@@ -60,6 +61,10 @@ func (h *AuthHandler) Login(c *gin.Context) {
 	}
 
 	token, err := h.AuthService.Login(c.Request.Context(), req.Email, req.Password)
+	if errors.Is(err, service.ErrAccountSuspended) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Account is suspended"})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid email or password"})
 		return
@@ -203,6 +208,11 @@ func (h *AuthHandler) GetUserByUsername(c *gin.Context) {
 }
 
 func (h *AuthHandler) GetUsers(c *gin.Context) {
+	userID, ok := authenticatedUserID(c)
+	if !ok {
+		return
+	}
+
 	q := c.Query("q") // Retrieve the `q` query parameter for search
 	if q == "" {
 		c.JSON(http.StatusOK, gin.H{"message": "No query parameter provided"})
@@ -216,7 +226,7 @@ func (h *AuthHandler) GetUsers(c *gin.Context) {
 	}
 
 	// Call the service with limit and offset
-	users, totalItems, err := h.AuthService.GetUsers(c.Request.Context(), q, limit, offset)
+	users, totalItems, err := h.AuthService.GetUsers(c.Request.Context(), userID, q, limit, offset)
 
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve users"})
@@ -0,0 +1,137 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/dk5761/go-serv/internal/domain/auth/dto"
+	"github.com/dk5761/go-serv/internal/domain/auth/models"
+	"github.com/dk5761/go-serv/internal/domain/auth/service"
+	"github.com/dk5761/go-serv/internal/domain/common"
+	"github.com/gin-gonic/gin"
+)
+
+type KeyHandler struct {
+	KeyService service.KeyService
+}
+
+func NewKeyHandler(keyService service.KeyService) *KeyHandler {
+	return &KeyHandler{KeyService: keyService}
+}
+
+// SetIdentityKey publishes the authenticated user's identity key. A new identity key
+// discards the user's prekeys, which must be uploaded again.
+func (h *KeyHandler) SetIdentityKey(c *gin.Context) {
+	userID, ok := authenticatedUserID(c)
+	if !ok {
+		return
+	}
+
+	var req dto.SetIdentityKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request parameters"})
+		return
+	}
+
+	key := &models.IdentityKey{RegistrationID: req.RegistrationID, PublicKey: req.PublicKey}
+	if err := h.KeyService.SetIdentityKey(c.Request.Context(), userID, key); err != nil {
+		respondKeyError(c, err, "Failed to save identity key")
+		return
+	}
+
+	c.JSON(http.StatusOK, key)
+}
+
+// SetSignedPrekey publishes the authenticated user's signed prekey
+func (h *KeyHandler) SetSignedPrekey(c *gin.Context) {
+	userID, ok := authenticatedUserID(c)
+	if !ok {
+		return
+	}
+
+	var req dto.SetSignedPrekeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request parameters"})
+		return
+	}
+
+	prekey := &models.SignedPrekey{KeyID: req.KeyID, PublicKey: req.PublicKey, Signature: req.Signature}
+	if err := h.KeyService.SetSignedPrekey(c.Request.Context(), userID, prekey); err != nil {
+		respondKeyError(c, err, "Failed to save signed prekey")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Signed prekey saved"})
+}
+
+// UploadPrekeys adds a batch of one-time prekeys
+func (h *KeyHandler) UploadPrekeys(c *gin.Context) {
+	userID, ok := authenticatedUserID(c)
+	if !ok {
+		return
+	}
+
+	var req dto.UploadPrekeysRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request parameters"})
+		return
+	}
+
+	prekeys := make([]*models.OneTimePrekey, len(req.Prekeys))
+	for i, prekey := range req.Prekeys {
+		prekeys[i] = &models.OneTimePrekey{KeyID: prekey.KeyID, PublicKey: prekey.PublicKey}
+	}
+
+	count, err := h.KeyService.AddOneTimePrekeys(c.Request.Context(), userID, prekeys)
+	if err != nil {
+		respondKeyError(c, err, "Failed to save prekeys")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"count": count})
+}
+
+// CountPrekeys reports how many one-time prekeys the authenticated user has left
+func (h *KeyHandler) CountPrekeys(c *gin.Context) {
+	userID, ok := authenticatedUserID(c)
+	if !ok {
+		return
+	}
+
+	count, err := h.KeyService.CountOneTimePrekeys(c.Request.Context(), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count prekeys"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"count": count, "low_threshold": service.LowPrekeyThreshold})
+}
+
+// GetPrekeyBundle returns the prekey bundle of the user in the path, consuming one of
+// their one-time prekeys
+func (h *KeyHandler) GetPrekeyBundle(c *gin.Context) {
+	requesterID, userID, ok := blockParams(c)
+	if !ok {
+		return
+	}
+
+	bundle, err := h.KeyService.GetPrekeyBundle(c.Request.Context(), requesterID, userID)
+	if err != nil {
+		if errors.Is(err, common.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "User has not published keys"})
+			return
+		}
+		respondKeyError(c, err, "Failed to retrieve prekey bundle")
+		return
+	}
+
+	c.JSON(http.StatusOK, bundle)
+}
+
+func respondKeyError(c *gin.Context, err error, fallback string) {
+	if errors.Is(err, common.ErrInvalidInput) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+}
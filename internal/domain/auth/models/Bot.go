@@ -0,0 +1,32 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Bot is the integration behind a bot account. Messages addressed to the bot are posted
+// to WebhookURL, signed with WebhookSecret.
+type Bot struct {
+	UserID        uuid.UUID  `json:"user_id"`
+	Username      string     `json:"username"`
+	WebhookURL    string     `json:"webhook_url"`
+	WebhookSecret string     `json:"-"`
+	CreatedBy     *uuid.UUID `json:"created_by,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+// BotCredentials are a bot's secrets, shown once when they are generated.
+type BotCredentials struct {
+	Token         string `json:"token,omitempty"`
+	WebhookSecret string `json:"webhook_secret,omitempty"`
+}
+
+// BotCommand is a slash command a bot handles in its conversations.
+type BotCommand struct {
+	Name        string `json:"name"`
+	Usage       string `json:"usage"`
+	Description string `json:"description"`
+}
@@ -0,0 +1,39 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// IdentityKey is a user's long-term public key for end-to-end encryption. Keys are raw
+// bytes and travel as base64 in JSON.
+type IdentityKey struct {
+	RegistrationID int       `json:"registration_id"`
+	PublicKey      []byte    `json:"public_key"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// SignedPrekey is a medium-term public key signed with the user's identity key. Clients
+// verify the signature before starting a session.
+type SignedPrekey struct {
+	KeyID     int    `json:"key_id"`
+	PublicKey []byte `json:"public_key"`
+	Signature []byte `json:"signature"`
+}
+
+// OneTimePrekey is a public key handed out to a single peer and then deleted.
+type OneTimePrekey struct {
+	KeyID     int    `json:"key_id"`
+	PublicKey []byte `json:"public_key"`
+}
+
+// PrekeyBundle is everything a peer needs to start an encrypted session with a user.
+// OneTimePrekey is nil once the user has run out of one-time prekeys.
+type PrekeyBundle struct {
+	UserID         uuid.UUID      `json:"user_id"`
+	RegistrationID int            `json:"registration_id"`
+	IdentityKey    []byte         `json:"identity_key"`
+	SignedPrekey   *SignedPrekey  `json:"signed_prekey"`
+	OneTimePrekey  *OneTimePrekey `json:"one_time_prekey,omitempty"`
+}
@@ -6,14 +6,30 @@ import (
 	"github.com/google/uuid"
 )
 
+// Role grants access to administrative endpoints.
+type Role string
+
+const (
+	RoleUser      Role = "user"
+	RoleModerator Role = "moderator" // Reviews abuse reports
+	RoleAdmin     Role = "admin"
+)
+
 type User struct {
-	ID             uuid.UUID `json:"id"`
-	Email          string    `json:"email"`
-	Username       string    `json:"username"`
-	PasswordHash   string    `json:"-"`
-	CreatedAt      time.Time `json:"created_at"`
-	UpdatedAt      time.Time `json:"updated_at"`
-	LastLogin      time.Time `json:"last_login"`
-	LastLoginToken time.Time `json:"-"` // Used to validate token timestamps
+	ID             uuid.UUID  `json:"id"`
+	Email          string     `json:"email"`
+	Username       string     `json:"username"`
+	Role           Role       `json:"role"`
+	PasswordHash   string     `json:"-"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+	LastLogin      time.Time  `json:"last_login"`
+	LastLoginToken time.Time  `json:"-"` // Used to validate token timestamps
+	SuspendedUntil *time.Time `json:"suspended_until,omitempty"`
+	IsBot          bool       `json:"is_bot"` // Bot accounts are created by admins and act through a bot token
+}
 
+// IsSuspended reports whether a moderator has suspended the user at the given time
+func (u *User) IsSuspended(now time.Time) bool {
+	return u.SuspendedUntil != nil && now.Before(*u.SuspendedUntil)
 }
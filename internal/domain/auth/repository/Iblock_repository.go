@@ -0,0 +1,23 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/dk5761/go-serv/internal/domain/auth/models"
+	"github.com/google/uuid"
+)
+
+// BlockRepository defines the interface for the per-user block list.
+type BlockRepository interface {
+	// BlockUser adds blockedID to blockerID's block list. Blocking twice is a no-op.
+	BlockUser(ctx context.Context, blockerID, blockedID uuid.UUID) error
+
+	// UnblockUser removes blockedID from blockerID's block list.
+	UnblockUser(ctx context.Context, blockerID, blockedID uuid.UUID) error
+
+	// GetBlockedUsers lists the users blockerID has blocked, most recent first.
+	GetBlockedUsers(ctx context.Context, blockerID uuid.UUID) ([]*models.BlockedUser, error)
+
+	// IsBlocked reports whether blockerID has blocked blockedID.
+	IsBlocked(ctx context.Context, blockerID, blockedID uuid.UUID) (bool, error)
+}
@@ -0,0 +1,33 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/dk5761/go-serv/internal/domain/auth/models"
+	"github.com/google/uuid"
+)
+
+// BotRepository stores bot accounts. Bot tokens are only ever stored hashed.
+type BotRepository interface {
+	// CreateBot inserts the bot's user account and its integration together.
+	CreateBot(ctx context.Context, user *models.User, bot *models.Bot, tokenHash string) error
+
+	// GetBot returns the bot with the given user ID, or common.ErrNotFound if the user is not a bot.
+	GetBot(ctx context.Context, userID uuid.UUID) (*models.Bot, error)
+
+	// GetBotByTokenHash returns the bot whose token hashes to tokenHash, or common.ErrNotFound.
+	GetBotByTokenHash(ctx context.Context, tokenHash string) (*models.Bot, error)
+
+	ListBots(ctx context.Context) ([]*models.Bot, error)
+
+	SetWebhook(ctx context.Context, userID uuid.UUID, webhookURL, webhookSecret string) error
+	SetTokenHash(ctx context.Context, userID uuid.UUID, tokenHash string) error
+
+	// SetCommands replaces the slash commands the bot handles.
+	SetCommands(ctx context.Context, botID uuid.UUID, commands []*models.BotCommand) error
+	// ListCommands returns the bot's slash commands sorted by name.
+	ListCommands(ctx context.Context, botID uuid.UUID) ([]*models.BotCommand, error)
+
+	// DeleteBot deletes the bot's user account along with the integration.
+	DeleteBot(ctx context.Context, userID uuid.UUID) error
+}
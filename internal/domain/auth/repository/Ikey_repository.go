@@ -0,0 +1,26 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/dk5761/go-serv/internal/domain/auth/models"
+	"github.com/google/uuid"
+)
+
+// KeyRepository is the directory of public keys for end-to-end encryption.
+type KeyRepository interface {
+	// SetIdentityKey stores the user's identity key. Replacing it with a different key
+	// deletes the user's prekeys, which were signed with or paired with the old one.
+	SetIdentityKey(ctx context.Context, userID uuid.UUID, key *models.IdentityKey) error
+	GetIdentityKey(ctx context.Context, userID uuid.UUID) (*models.IdentityKey, error)
+	SetSignedPrekey(ctx context.Context, userID uuid.UUID, prekey *models.SignedPrekey) error
+
+	// AddOneTimePrekeys stores a batch of one-time prekeys, skipping key IDs already
+	// stored, and returns how many were added.
+	AddOneTimePrekeys(ctx context.Context, userID uuid.UUID, prekeys []*models.OneTimePrekey) (int, error)
+	CountOneTimePrekeys(ctx context.Context, userID uuid.UUID) (int, error)
+
+	// FetchPrekeyBundle returns the user's bundle, deleting the one-time prekey it hands
+	// out so that no two peers receive the same one.
+	FetchPrekeyBundle(ctx context.Context, userID uuid.UUID) (*models.PrekeyBundle, error)
+}
@@ -17,6 +17,10 @@ type UserRepository interface {
 	GetUserByEmail(ctx context.Context, email string) (*models.User, error)
 	GetUserByUsername(ctx context.Context, username string) (*models.User, error)
 
+	// GetUserIDsByUsernames looks up several usernames at once and maps each existing one to
+	// its user ID; unknown usernames are missing from the map.
+	GetUserIDsByUsernames(ctx context.Context, usernames []string) (map[string]uuid.UUID, error)
+
 	// GetUserByID retrieves a user by their unique ID.
 	GetUserByID(ctx context.Context, userID uuid.UUID) (*models.User, error)
 
@@ -26,7 +30,13 @@ type UserRepository interface {
 	// UpdateUserTimestamps updates the updated_at field for a user.
 	UpdateUserTimestamps(ctx context.Context, userID uuid.UUID, updatedAt time.Time) error
 
+	// SuspendUser suspends the user until the given time; a zero time lifts the suspension.
+	SuspendUser(ctx context.Context, userID uuid.UUID, until time.Time) error
+
 	UpdateUser(ctx context.Context, user *models.User) error
 	DeleteUser(ctx context.Context, userID uuid.UUID) error
-	GetUsers(ctx context.Context, q string, limit, offset int) ([]*models.User, int, error)
+
+	// GetUsers searches users by username on behalf of viewerID, leaving out users that
+	// viewerID has blocked or that have blocked viewerID.
+	GetUsers(ctx context.Context, viewerID uuid.UUID, q string, limit, offset int) ([]*models.User, int, error)
 }
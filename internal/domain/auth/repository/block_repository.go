@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/dk5761/go-serv/internal/domain/auth/models"
+	"github.com/dk5761/go-serv/internal/domain/common"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+type postgresBlockRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewPostgresBlockRepository(db *pgxpool.Pool) BlockRepository {
+	return &postgresBlockRepository{db}
+}
+
+// BlockUser inserts a block, ignoring one that already exists
+func (r *postgresBlockRepository) BlockUser(ctx context.Context, blockerID, blockedID uuid.UUID) error {
+	query := `
+        INSERT INTO user_blocks (blocker_id, blocked_id, created_at)
+        VALUES ($1, $2, NOW())
+        ON CONFLICT (blocker_id, blocked_id) DO NOTHING
+    `
+	_, err := r.db.Exec(ctx, query, blockerID, blockedID)
+	return err
+}
+
+// UnblockUser deletes a block
+func (r *postgresBlockRepository) UnblockUser(ctx context.Context, blockerID, blockedID uuid.UUID) error {
+	query := `DELETE FROM user_blocks WHERE blocker_id = $1 AND blocked_id = $2`
+
+	cmdTag, err := r.db.Exec(ctx, query, blockerID, blockedID)
+	if err != nil {
+		return err
+	}
+	if cmdTag.RowsAffected() == 0 {
+		return common.ErrNotFound // The user was not blocked
+	}
+	return nil
+}
+
+// GetBlockedUsers lists the blocked users with the time they were blocked
+func (r *postgresBlockRepository) GetBlockedUsers(ctx context.Context, blockerID uuid.UUID) ([]*models.BlockedUser, error) {
+	query := `
+        SELECT u.id, u.username, b.created_at
+        FROM user_blocks b
+        JOIN users u ON u.id = b.blocked_id
+        WHERE b.blocker_id = $1
+        ORDER BY b.created_at DESC
+    `
+	rows, err := r.db.Query(ctx, query, blockerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	blocked := []*models.BlockedUser{}
+	for rows.Next() {
+		var user models.BlockedUser
+		if err := rows.Scan(&user.ID, &user.Username, &user.BlockedAt); err != nil {
+			return nil, err
+		}
+		blocked = append(blocked, &user)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return blocked, nil
+}
+
+// IsBlocked checks a single direction of the block list
+func (r *postgresBlockRepository) IsBlocked(ctx context.Context, blockerID, blockedID uuid.UUID) (bool, error) {
+	query := `SELECT EXISTS (SELECT 1 FROM user_blocks WHERE blocker_id = $1 AND blocked_id = $2)`
+
+	var blocked bool
+	if err := r.db.QueryRow(ctx, query, blockerID, blockedID).Scan(&blocked); err != nil {
+		return false, err
+	}
+	return blocked, nil
+}
@@ -0,0 +1,180 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/dk5761/go-serv/internal/domain/auth/models"
+	"github.com/dk5761/go-serv/internal/domain/common"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+const botColumns = `b.user_id, u.username, b.webhook_url, b.webhook_secret, b.created_by, b.created_at, b.updated_at`
+
+type postgresBotRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewPostgresBotRepository(db *pgxpool.Pool) BotRepository {
+	return &postgresBotRepository{db}
+}
+
+// CreateBot inserts the bot's user row, flagged as a bot, and its bots row in one transaction
+func (r *postgresBotRepository) CreateBot(ctx context.Context, user *models.User, bot *models.Bot, tokenHash string) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, `
+        INSERT INTO users (id, email, username, password_hash, role, is_bot, created_at, updated_at, last_login, last_login_token)
+        VALUES ($1, $2, $3, '', $4, TRUE, $5, $5, $5, $5)
+    `, user.ID, user.Email, user.Username, user.Role, user.CreatedAt)
+	if err != nil {
+		return common.ErrConflict // e.g. the username is taken
+	}
+
+	_, err = tx.Exec(ctx, `
+        INSERT INTO bots (user_id, token_hash, webhook_url, webhook_secret, created_by, created_at, updated_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $6)
+    `, bot.UserID, tokenHash, bot.WebhookURL, bot.WebhookSecret, bot.CreatedBy, bot.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// GetBot retrieves a bot by its user ID
+func (r *postgresBotRepository) GetBot(ctx context.Context, userID uuid.UUID) (*models.Bot, error) {
+	row := r.db.QueryRow(ctx, `SELECT `+botColumns+` FROM bots b JOIN users u ON u.id = b.user_id WHERE b.user_id = $1`, userID)
+	return scanBot(row)
+}
+
+// GetBotByTokenHash retrieves the bot a token belongs to
+func (r *postgresBotRepository) GetBotByTokenHash(ctx context.Context, tokenHash string) (*models.Bot, error) {
+	row := r.db.QueryRow(ctx, `SELECT `+botColumns+` FROM bots b JOIN users u ON u.id = b.user_id WHERE b.token_hash = $1`, tokenHash)
+	return scanBot(row)
+}
+
+// ListBots lists every bot, newest first
+func (r *postgresBotRepository) ListBots(ctx context.Context) ([]*models.Bot, error) {
+	rows, err := r.db.Query(ctx, `SELECT `+botColumns+` FROM bots b JOIN users u ON u.id = b.user_id ORDER BY b.created_at DESC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	bots := []*models.Bot{}
+	for rows.Next() {
+		bot, err := scanBot(rows)
+		if err != nil {
+			return nil, err
+		}
+		bots = append(bots, bot)
+	}
+	return bots, rows.Err()
+}
+
+// SetWebhook changes where the bot's events are posted and the secret signing them
+func (r *postgresBotRepository) SetWebhook(ctx context.Context, userID uuid.UUID, webhookURL, webhookSecret string) error {
+	cmdTag, err := r.db.Exec(ctx, `
+        UPDATE bots
+        SET webhook_url = $1, webhook_secret = $2, updated_at = $3
+        WHERE user_id = $4
+    `, webhookURL, webhookSecret, time.Now(), userID)
+	if err != nil {
+		return err
+	}
+	if cmdTag.RowsAffected() == 0 {
+		return common.ErrNotFound // No bot with this ID
+	}
+	return nil
+}
+
+// SetTokenHash replaces the bot's token, invalidating the previous one
+func (r *postgresBotRepository) SetTokenHash(ctx context.Context, userID uuid.UUID, tokenHash string) error {
+	cmdTag, err := r.db.Exec(ctx, `
+        UPDATE bots
+        SET token_hash = $1, updated_at = $2
+        WHERE user_id = $3
+    `, tokenHash, time.Now(), userID)
+	if err != nil {
+		return err
+	}
+	if cmdTag.RowsAffected() == 0 {
+		return common.ErrNotFound // No bot with this ID
+	}
+	return nil
+}
+
+// SetCommands replaces the bot's commands in one transaction
+func (r *postgresBotRepository) SetCommands(ctx context.Context, botID uuid.UUID, commands []*models.BotCommand) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `DELETE FROM bot_commands WHERE bot_id = $1`, botID); err != nil {
+		return err
+	}
+	for _, command := range commands {
+		_, err := tx.Exec(ctx, `
+            INSERT INTO bot_commands (bot_id, name, usage, description)
+            VALUES ($1, $2, $3, $4)
+        `, botID, command.Name, command.Usage, command.Description)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+// ListCommands retrieves the bot's commands
+func (r *postgresBotRepository) ListCommands(ctx context.Context, botID uuid.UUID) ([]*models.BotCommand, error) {
+	rows, err := r.db.Query(ctx, `SELECT name, usage, description FROM bot_commands WHERE bot_id = $1 ORDER BY name`, botID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	commands := []*models.BotCommand{}
+	for rows.Next() {
+		var command models.BotCommand
+		if err := rows.Scan(&command.Name, &command.Usage, &command.Description); err != nil {
+			return nil, err
+		}
+		commands = append(commands, &command)
+	}
+	return commands, rows.Err()
+}
+
+// DeleteBot deletes the bot's user, which cascades to the bots row
+func (r *postgresBotRepository) DeleteBot(ctx context.Context, userID uuid.UUID) error {
+	cmdTag, err := r.db.Exec(ctx, `DELETE FROM users WHERE id = $1 AND is_bot`, userID)
+	if err != nil {
+		return err
+	}
+	if cmdTag.RowsAffected() == 0 {
+		return common.ErrNotFound // No bot with this ID
+	}
+	return nil
+}
+
+func scanBot(row pgx.Row) (*models.Bot, error) {
+	var bot models.Bot
+	err := row.Scan(&bot.UserID, &bot.Username, &bot.WebhookURL, &bot.WebhookSecret, &bot.CreatedBy, &bot.CreatedAt, &bot.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, common.ErrNotFound
+		}
+		return nil, err
+	}
+	return &bot, nil
+}
@@ -0,0 +1,162 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"errors"
+
+	"github.com/dk5761/go-serv/internal/domain/auth/models"
+	"github.com/dk5761/go-serv/internal/domain/common"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+type postgresKeyRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewPostgresKeyRepository(db *pgxpool.Pool) KeyRepository {
+	return &postgresKeyRepository{db}
+}
+
+// SetIdentityKey upserts the identity key, clearing the prekeys when the key changes
+func (r *postgresKeyRepository) SetIdentityKey(ctx context.Context, userID uuid.UUID, key *models.IdentityKey) error {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	var current []byte
+	err = tx.QueryRow(ctx, `SELECT public_key FROM identity_keys WHERE user_id = $1 FOR UPDATE`, userID).Scan(&current)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return err
+	}
+
+	if current != nil && !bytes.Equal(current, key.PublicKey) {
+		if _, err := tx.Exec(ctx, `DELETE FROM one_time_prekeys WHERE user_id = $1`, userID); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(ctx, `DELETE FROM signed_prekeys WHERE user_id = $1`, userID); err != nil {
+			return err
+		}
+	}
+
+	query := `
+        INSERT INTO identity_keys (user_id, registration_id, public_key, updated_at)
+        VALUES ($1, $2, $3, NOW())
+        ON CONFLICT (user_id) DO UPDATE
+        SET registration_id = EXCLUDED.registration_id, public_key = EXCLUDED.public_key, updated_at = EXCLUDED.updated_at
+        RETURNING updated_at
+    `
+	if err := tx.QueryRow(ctx, query, userID, key.RegistrationID, key.PublicKey).Scan(&key.UpdatedAt); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// GetIdentityKey retrieves the user's identity key
+func (r *postgresKeyRepository) GetIdentityKey(ctx context.Context, userID uuid.UUID) (*models.IdentityKey, error) {
+	query := `SELECT registration_id, public_key, updated_at FROM identity_keys WHERE user_id = $1`
+
+	var key models.IdentityKey
+	err := r.db.QueryRow(ctx, query, userID).Scan(&key.RegistrationID, &key.PublicKey, &key.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, common.ErrNotFound
+		}
+		return nil, err
+	}
+	return &key, nil
+}
+
+// SetSignedPrekey replaces the user's signed prekey
+func (r *postgresKeyRepository) SetSignedPrekey(ctx context.Context, userID uuid.UUID, prekey *models.SignedPrekey) error {
+	query := `
+        INSERT INTO signed_prekeys (user_id, key_id, public_key, signature, created_at)
+        VALUES ($1, $2, $3, $4, NOW())
+        ON CONFLICT (user_id) DO UPDATE
+        SET key_id = EXCLUDED.key_id, public_key = EXCLUDED.public_key, signature = EXCLUDED.signature, created_at = EXCLUDED.created_at
+    `
+	_, err := r.db.Exec(ctx, query, userID, prekey.KeyID, prekey.PublicKey, prekey.Signature)
+	return err
+}
+
+// AddOneTimePrekeys inserts the batch in one round trip
+func (r *postgresKeyRepository) AddOneTimePrekeys(ctx context.Context, userID uuid.UUID, prekeys []*models.OneTimePrekey) (int, error) {
+	query := `
+        INSERT INTO one_time_prekeys (user_id, key_id, public_key, created_at)
+        VALUES ($1, $2, $3, NOW())
+        ON CONFLICT (user_id, key_id) DO NOTHING
+    `
+	batch := &pgx.Batch{}
+	for _, prekey := range prekeys {
+		batch.Queue(query, userID, prekey.KeyID, prekey.PublicKey)
+	}
+
+	results := r.db.SendBatch(ctx, batch)
+	defer results.Close()
+
+	added := 0
+	for range prekeys {
+		cmdTag, err := results.Exec()
+		if err != nil {
+			return added, err
+		}
+		added += int(cmdTag.RowsAffected())
+	}
+	return added, nil
+}
+
+// CountOneTimePrekeys counts the one-time prekeys the user has left
+func (r *postgresKeyRepository) CountOneTimePrekeys(ctx context.Context, userID uuid.UUID) (int, error) {
+	var count int
+	err := r.db.QueryRow(ctx, `SELECT COUNT(*) FROM one_time_prekeys WHERE user_id = $1`, userID).Scan(&count)
+	return count, err
+}
+
+// FetchPrekeyBundle reads the bundle and consumes the lowest one-time prekey. Concurrent
+// fetches skip keys locked by each other, so each one-time prekey is handed out once.
+func (r *postgresKeyRepository) FetchPrekeyBundle(ctx context.Context, userID uuid.UUID) (*models.PrekeyBundle, error) {
+	query := `
+        SELECT i.registration_id, i.public_key, s.key_id, s.public_key, s.signature
+        FROM identity_keys i
+        JOIN signed_prekeys s ON s.user_id = i.user_id
+        WHERE i.user_id = $1
+    `
+	bundle := &models.PrekeyBundle{UserID: userID, SignedPrekey: &models.SignedPrekey{}}
+	err := r.db.QueryRow(ctx, query, userID).Scan(
+		&bundle.RegistrationID, &bundle.IdentityKey,
+		&bundle.SignedPrekey.KeyID, &bundle.SignedPrekey.PublicKey, &bundle.SignedPrekey.Signature,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, common.ErrNotFound // No identity key or no signed prekey yet
+		}
+		return nil, err
+	}
+
+	consume := `
+        DELETE FROM one_time_prekeys
+        WHERE (user_id, key_id) = (
+            SELECT user_id, key_id FROM one_time_prekeys
+            WHERE user_id = $1
+            ORDER BY key_id
+            LIMIT 1
+            FOR UPDATE SKIP LOCKED
+        )
+        RETURNING key_id, public_key
+    `
+	var prekey models.OneTimePrekey
+	err = r.db.QueryRow(ctx, consume, userID).Scan(&prekey.KeyID, &prekey.PublicKey)
+	switch {
+	case err == nil:
+		bundle.OneTimePrekey = &prekey
+	case !errors.Is(err, pgx.ErrNoRows):
+		return nil, err
+	}
+
+	return bundle, nil
+}
@@ -40,14 +40,14 @@ func (r *postgresUserRepository) CreateUser(ctx context.Context, user *models.Us
 // GetUserByEmail retrieves a user by email, including the updated timestamp fields
 func (r *postgresUserRepository) GetUserByEmail(ctx context.Context, email string) (*models.User, error) {
 	query := `
-        SELECT id, email, password_hash, created_at, updated_at, last_login, last_login_token
+        SELECT id, email, role, password_hash, created_at, updated_at, last_login, last_login_token, suspended_until, is_bot
         FROM users
         WHERE email = $1
     `
 	row := r.db.QueryRow(ctx, query, email)
 
 	var user models.User
-	err := row.Scan(&user.ID, &user.Email, &user.PasswordHash, &user.CreatedAt, &user.UpdatedAt, &user.LastLogin, &user.LastLoginToken)
+	err := row.Scan(&user.ID, &user.Email, &user.Role, &user.PasswordHash, &user.CreatedAt, &user.UpdatedAt, &user.LastLogin, &user.LastLoginToken, &user.SuspendedUntil, &user.IsBot)
 	if err != nil {
 		if err == pgx.ErrNoRows {
 			return nil, common.ErrNotFound
@@ -60,14 +60,14 @@ func (r *postgresUserRepository) GetUserByEmail(ctx context.Context, email strin
 // GetUserByUsername retrieves a user by username, including the updated timestamp fields
 func (r *postgresUserRepository) GetUserByUsername(ctx context.Context, username string) (*models.User, error) {
 	query := `
-        SELECT id, email, username, password_hash, created_at, updated_at, last_login, last_login_token
+        SELECT id, email, username, role, password_hash, created_at, updated_at, last_login, last_login_token, suspended_until, is_bot
         FROM users
-        WHERE username = $2
+        WHERE username = $1
     `
 	row := r.db.QueryRow(ctx, query, username)
 
 	var user models.User
-	err := row.Scan(&user.ID, &user.Email, &user.Username, &user.PasswordHash, &user.CreatedAt, &user.UpdatedAt, &user.LastLogin, &user.LastLoginToken)
+	err := row.Scan(&user.ID, &user.Email, &user.Username, &user.Role, &user.PasswordHash, &user.CreatedAt, &user.UpdatedAt, &user.LastLogin, &user.LastLoginToken, &user.SuspendedUntil, &user.IsBot)
 	if err != nil {
 		if err == pgx.ErrNoRows {
 			return nil, common.ErrNotFound
@@ -77,21 +77,58 @@ func (r *postgresUserRepository) GetUserByUsername(ctx context.Context, username
 	return &user, nil
 }
 
-func (r *postgresUserRepository) GetUsers(ctx context.Context, q string, limit, offset int) ([]*models.User, int, error) {
+// GetUserIDsByUsernames resolves the usernames in a single query
+func (r *postgresUserRepository) GetUserIDsByUsernames(ctx context.Context, usernames []string) (map[string]uuid.UUID, error) {
+	userIDs := make(map[string]uuid.UUID)
+	if len(usernames) == 0 {
+		return userIDs, nil
+	}
+
+	query := `
+        SELECT id, username
+        FROM users
+        WHERE username = ANY($1)
+    `
+	rows, err := r.db.Query(ctx, query, usernames)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id uuid.UUID
+		var username string
+		if err := rows.Scan(&id, &username); err != nil {
+			return nil, err
+		}
+		userIDs[username] = id
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return userIDs, nil
+}
+
+func (r *postgresUserRepository) GetUsers(ctx context.Context, viewerID uuid.UUID, q string, limit, offset int) ([]*models.User, int, error) {
 	query := `
         WITH users_with_count AS (
             SELECT 
-                id, username, email, created_at, updated_at,
+                id, username, email, is_bot, created_at, updated_at,
                 COUNT(*) OVER() AS total_count
             FROM users
             WHERE username ILIKE '%' || $1 || '%'
+              AND NOT EXISTS (
+                  SELECT 1 FROM user_blocks b
+                  WHERE (b.blocker_id = $4 AND b.blocked_id = users.id)
+                     OR (b.blocker_id = users.id AND b.blocked_id = $4)
+              )
             ORDER BY created_at DESC
             LIMIT $2 OFFSET $3
         )
-        SELECT id, username, email, created_at, updated_at, total_count FROM users_with_count;
+        SELECT id, username, email, is_bot, created_at, updated_at, total_count FROM users_with_count;
     `
 
-	rows, err := r.db.Query(ctx, query, q, limit, offset)
+	rows, err := r.db.Query(ctx, query, q, limit, offset, viewerID)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -101,7 +138,7 @@ func (r *postgresUserRepository) GetUsers(ctx context.Context, q string, limit,
 	var totalItems int
 	for rows.Next() {
 		var user models.User
-		if err := rows.Scan(&user.ID, &user.Username, &user.Email, &user.CreatedAt, &user.UpdatedAt, &totalItems); err != nil {
+		if err := rows.Scan(&user.ID, &user.Username, &user.Email, &user.IsBot, &user.CreatedAt, &user.UpdatedAt, &totalItems); err != nil {
 			return nil, 0, err
 		}
 		users = append(users, &user)
@@ -134,14 +171,14 @@ func (r *postgresUserRepository) UpdateLastLogin(ctx context.Context, userID uui
 // GetUserByID retrieves a user by ID, including the updated timestamp fields
 func (r *postgresUserRepository) GetUserByID(ctx context.Context, userID uuid.UUID) (*models.User, error) {
 	query := `
-        SELECT id, email, username, password_hash, created_at, updated_at, last_login, last_login_token
+        SELECT id, email, username, role, password_hash, created_at, updated_at, last_login, last_login_token, suspended_until, is_bot
         FROM users
         WHERE id = $1
     `
 	row := r.db.QueryRow(ctx, query, userID)
 
 	var user models.User
-	err := row.Scan(&user.ID, &user.Email, &user.Username, &user.PasswordHash, &user.CreatedAt, &user.UpdatedAt, &user.LastLogin, &user.LastLoginToken)
+	err := row.Scan(&user.ID, &user.Email, &user.Username, &user.Role, &user.PasswordHash, &user.CreatedAt, &user.UpdatedAt, &user.LastLogin, &user.LastLoginToken, &user.SuspendedUntil, &user.IsBot)
 	if err != nil {
 		if err == pgx.ErrNoRows {
 			return nil, common.ErrNotFound
@@ -151,6 +188,28 @@ func (r *postgresUserRepository) GetUserByID(ctx context.Context, userID uuid.UU
 	return &user, nil
 }
 
+// SuspendUser suspends the user until the given time; a zero time lifts the suspension
+func (r *postgresUserRepository) SuspendUser(ctx context.Context, userID uuid.UUID, until time.Time) error {
+	var suspendedUntil *time.Time
+	if !until.IsZero() {
+		suspendedUntil = &until
+	}
+
+	query := `
+        UPDATE users
+        SET suspended_until = $1, updated_at = $2
+        WHERE id = $3
+    `
+	cmdTag, err := r.db.Exec(ctx, query, suspendedUntil, time.Now(), userID)
+	if err != nil {
+		return err
+	}
+	if cmdTag.RowsAffected() == 0 {
+		return common.ErrNotFound // No user with this ID
+	}
+	return nil
+}
+
 // UpdateUserTimestamps updates the updated_at field of the user to reflect changes
 func (r *postgresUserRepository) UpdateUserTimestamps(ctx context.Context, userID uuid.UUID, updatedAt time.Time) error {
 	query := `
@@ -0,0 +1,14 @@
+package service
+
+import (
+	"context"
+
+	"github.com/dk5761/go-serv/internal/domain/auth/models"
+	"github.com/google/uuid"
+)
+
+type BlockService interface {
+	BlockUser(ctx context.Context, blockerID, blockedID uuid.UUID) error
+	UnblockUser(ctx context.Context, blockerID, blockedID uuid.UUID) error
+	GetBlockedUsers(ctx context.Context, blockerID uuid.UUID) ([]*models.BlockedUser, error)
+}
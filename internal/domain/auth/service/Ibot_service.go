@@ -0,0 +1,23 @@
+package service
+
+import (
+	"context"
+
+	"github.com/dk5761/go-serv/internal/domain/auth/models"
+	"github.com/google/uuid"
+)
+
+type BotService interface {
+	// CreateBot creates a bot account, returning its token and webhook secret, which are
+	// not shown again.
+	CreateBot(ctx context.Context, creatorID uuid.UUID, username, webhookURL string) (*models.Bot, *models.BotCredentials, error)
+	ListBots(ctx context.Context) ([]*models.Bot, error)
+	GetBot(ctx context.Context, botID uuid.UUID) (*models.Bot, error)
+	SetWebhookURL(ctx context.Context, botID uuid.UUID, webhookURL string) (*models.Bot, error)
+	RegenerateToken(ctx context.Context, botID uuid.UUID) (*models.BotCredentials, error)
+	RegenerateWebhookSecret(ctx context.Context, botID uuid.UUID) (*models.BotCredentials, error)
+	DeleteBot(ctx context.Context, botID uuid.UUID) error
+
+	// Authenticate returns the bot a token belongs to, or common.ErrUnauthorized.
+	Authenticate(ctx context.Context, token string) (*models.Bot, error)
+}
@@ -0,0 +1,22 @@
+package service
+
+import (
+	"context"
+
+	"github.com/dk5761/go-serv/internal/domain/auth/models"
+	"github.com/google/uuid"
+)
+
+// LowPrekeyNotifier is told when a user's one-time prekeys are running low, so that their
+// clients can upload a new batch.
+type LowPrekeyNotifier func(userID uuid.UUID, remaining int)
+
+type KeyService interface {
+	SetIdentityKey(ctx context.Context, userID uuid.UUID, key *models.IdentityKey) error
+	SetSignedPrekey(ctx context.Context, userID uuid.UUID, prekey *models.SignedPrekey) error
+	// AddOneTimePrekeys stores a batch and returns how many one-time prekeys the user now has
+	AddOneTimePrekeys(ctx context.Context, userID uuid.UUID, prekeys []*models.OneTimePrekey) (int, error)
+	CountOneTimePrekeys(ctx context.Context, userID uuid.UUID) (int, error)
+	// GetPrekeyBundle hands out the bundle of userID to requesterID, consuming a one-time prekey
+	GetPrekeyBundle(ctx context.Context, requesterID, userID uuid.UUID) (*models.PrekeyBundle, error)
+}
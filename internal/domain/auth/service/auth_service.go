@@ -3,6 +3,7 @@ package service
 import (
 	"context"
 	"errors"
+	"fmt"
 	"time"
 
 	"github.com/dk5761/go-serv/internal/domain/auth/models"
@@ -14,6 +15,9 @@ import (
 	"go.uber.org/zap"
 )
 
+// ErrAccountSuspended is returned by Login for users a moderator has suspended.
+var ErrAccountSuspended = fmt.Errorf("%w: account is suspended", common.ErrForbidden)
+
 type authService struct {
 	userRepo   repository.UserRepository
 	jwtService JWTService
@@ -64,6 +68,10 @@ func (s *authService) Login(ctx context.Context, email, password string) (string
 		return "", errors.New("invalid credentials")
 	}
 
+	if user.IsSuspended(time.Now()) {
+		return "", ErrAccountSuspended
+	}
+
 	// Update last login and last login token timestamps
 	newLoginTime := time.Now()
 	err = s.userRepo.UpdateLastLogin(ctx, user.ID, newLoginTime, newLoginTime)
@@ -150,10 +158,10 @@ func (s *authService) UpdateUserProfile(ctx context.Context, userID uuid.UUID, u
 	return user, nil
 }
 
-func (s *authService) GetUsers(ctx context.Context, q string, limit, offset int) ([]*models.User, int, error) {
+func (s *authService) GetUsers(ctx context.Context, viewerID uuid.UUID, q string, limit, offset int) ([]*models.User, int, error) {
 
 	// Query the repository to get the user by username
-	user, totalItems, err := s.userRepo.GetUsers(ctx, q, limit, offset)
+	user, totalItems, err := s.userRepo.GetUsers(ctx, viewerID, q, limit, offset)
 	if err != nil {
 		if errors.Is(err, common.ErrNotFound) {
 			return nil, 0, errors.New("user not found")
@@ -0,0 +1,44 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/dk5761/go-serv/internal/domain/auth/models"
+	"github.com/dk5761/go-serv/internal/domain/auth/repository"
+	"github.com/dk5761/go-serv/internal/domain/common"
+	"github.com/google/uuid"
+)
+
+type blockService struct {
+	blockRepo repository.BlockRepository
+	userRepo  repository.UserRepository
+}
+
+func NewBlockService(blockRepo repository.BlockRepository, userRepo repository.UserRepository) BlockService {
+	return &blockService{blockRepo: blockRepo, userRepo: userRepo}
+}
+
+// BlockUser adds a user to the caller's block list
+func (s *blockService) BlockUser(ctx context.Context, blockerID, blockedID uuid.UUID) error {
+	if blockerID == blockedID {
+		return fmt.Errorf("%w: you cannot block yourself", common.ErrInvalidInput)
+	}
+
+	// Make sure the user exists so the block list only holds real accounts
+	if _, err := s.userRepo.GetUserByID(ctx, blockedID); err != nil {
+		return err
+	}
+
+	return s.blockRepo.BlockUser(ctx, blockerID, blockedID)
+}
+
+// UnblockUser removes a user from the caller's block list
+func (s *blockService) UnblockUser(ctx context.Context, blockerID, blockedID uuid.UUID) error {
+	return s.blockRepo.UnblockUser(ctx, blockerID, blockedID)
+}
+
+// GetBlockedUsers lists the caller's block list
+func (s *blockService) GetBlockedUsers(ctx context.Context, blockerID uuid.UUID) ([]*models.BlockedUser, error) {
+	return s.blockRepo.GetBlockedUsers(ctx, blockerID)
+}
@@ -0,0 +1,164 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/dk5761/go-serv/internal/domain/auth/models"
+	"github.com/dk5761/go-serv/internal/domain/auth/repository"
+	"github.com/dk5761/go-serv/internal/domain/common"
+	"github.com/google/uuid"
+)
+
+// BotTokenPrefix starts every bot token, so they are easy to tell apart from JWTs and to
+// spot in leaked text.
+const BotTokenPrefix = "bot_"
+
+// botEmailDomain gives bot accounts a unique address that never receives mail
+const botEmailDomain = "bots.invalid"
+
+const maxBotUsernameLength = 64
+
+type botService struct {
+	botRepo  repository.BotRepository
+	userRepo repository.UserRepository
+}
+
+func NewBotService(botRepo repository.BotRepository, userRepo repository.UserRepository) BotService {
+	return &botService{botRepo: botRepo, userRepo: userRepo}
+}
+
+// CreateBot creates the bot's user account and generates its token and webhook secret
+func (s *botService) CreateBot(ctx context.Context, creatorID uuid.UUID, username, webhookURL string) (*models.Bot, *models.BotCredentials, error) {
+	username = strings.TrimSpace(username)
+	if username == "" || len(username) > maxBotUsernameLength || strings.ContainsAny(username, " @") {
+		return nil, nil, fmt.Errorf("%w: username must be 1 to %d characters without spaces or @", common.ErrInvalidInput, maxBotUsernameLength)
+	}
+	if err := validateWebhookURL(webhookURL); err != nil {
+		return nil, nil, err
+	}
+	if _, err := s.userRepo.GetUserByUsername(ctx, username); err == nil {
+		return nil, nil, fmt.Errorf("%w: username is already in use", common.ErrConflict)
+	}
+
+	credentials := &models.BotCredentials{Token: newBotToken(), WebhookSecret: newSecret()}
+	now := time.Now()
+	user := &models.User{
+		ID:        uuid.New(),
+		Username:  username,
+		Role:      models.RoleUser,
+		IsBot:     true,
+		CreatedAt: now,
+	}
+	user.Email = user.ID.String() + "@" + botEmailDomain
+	bot := &models.Bot{
+		UserID:        user.ID,
+		Username:      username,
+		WebhookURL:    webhookURL,
+		WebhookSecret: credentials.WebhookSecret,
+		CreatedBy:     &creatorID,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+
+	if err := s.botRepo.CreateBot(ctx, user, bot, HashBotToken(credentials.Token)); err != nil {
+		return nil, nil, err
+	}
+	return bot, credentials, nil
+}
+
+func (s *botService) ListBots(ctx context.Context) ([]*models.Bot, error) {
+	return s.botRepo.ListBots(ctx)
+}
+
+func (s *botService) GetBot(ctx context.Context, botID uuid.UUID) (*models.Bot, error) {
+	return s.botRepo.GetBot(ctx, botID)
+}
+
+// SetWebhookURL moves the bot's webhook, keeping its secret
+func (s *botService) SetWebhookURL(ctx context.Context, botID uuid.UUID, webhookURL string) (*models.Bot, error) {
+	if err := validateWebhookURL(webhookURL); err != nil {
+		return nil, err
+	}
+	bot, err := s.botRepo.GetBot(ctx, botID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.botRepo.SetWebhook(ctx, botID, webhookURL, bot.WebhookSecret); err != nil {
+		return nil, err
+	}
+	return s.botRepo.GetBot(ctx, botID)
+}
+
+// RegenerateToken replaces the bot's token; the old one stops working right away
+func (s *botService) RegenerateToken(ctx context.Context, botID uuid.UUID) (*models.BotCredentials, error) {
+	token := newBotToken()
+	if err := s.botRepo.SetTokenHash(ctx, botID, HashBotToken(token)); err != nil {
+		return nil, err
+	}
+	return &models.BotCredentials{Token: token}, nil
+}
+
+// RegenerateWebhookSecret replaces the secret the bot's events are signed with
+func (s *botService) RegenerateWebhookSecret(ctx context.Context, botID uuid.UUID) (*models.BotCredentials, error) {
+	bot, err := s.botRepo.GetBot(ctx, botID)
+	if err != nil {
+		return nil, err
+	}
+	secret := newSecret()
+	if err := s.botRepo.SetWebhook(ctx, botID, bot.WebhookURL, secret); err != nil {
+		return nil, err
+	}
+	return &models.BotCredentials{WebhookSecret: secret}, nil
+}
+
+func (s *botService) DeleteBot(ctx context.Context, botID uuid.UUID) error {
+	return s.botRepo.DeleteBot(ctx, botID)
+}
+
+// Authenticate looks the bot up by the hash of its token
+func (s *botService) Authenticate(ctx context.Context, token string) (*models.Bot, error) {
+	if !strings.HasPrefix(token, BotTokenPrefix) {
+		return nil, common.ErrUnauthorized
+	}
+	bot, err := s.botRepo.GetBotByTokenHash(ctx, HashBotToken(token))
+	if errors.Is(err, common.ErrNotFound) {
+		return nil, common.ErrUnauthorized
+	}
+	return bot, err
+}
+
+// HashBotToken returns the form a bot token is stored in. Tokens are random, so a plain
+// SHA-256 is enough.
+func HashBotToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func newBotToken() string {
+	token := make([]byte, 32)
+	_, _ = rand.Read(token)
+	return BotTokenPrefix + base64.RawURLEncoding.EncodeToString(token)
+}
+
+func newSecret() string {
+	secret := make([]byte, 32)
+	_, _ = rand.Read(secret)
+	return hex.EncodeToString(secret)
+}
+
+func validateWebhookURL(webhookURL string) error {
+	u, err := url.Parse(webhookURL)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		return fmt.Errorf("%w: webhook_url must be an absolute http or https URL", common.ErrInvalidInput)
+	}
+	return nil
+}
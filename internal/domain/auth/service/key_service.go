@@ -0,0 +1,154 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/dk5761/go-serv/internal/domain/auth/models"
+	"github.com/dk5761/go-serv/internal/domain/auth/repository"
+	"github.com/dk5761/go-serv/internal/domain/common"
+	"github.com/google/uuid"
+)
+
+const (
+	// LowPrekeyThreshold is the number of one-time prekeys below which the owner is warned
+	LowPrekeyThreshold = 10
+
+	maxPrekeyBatch    = 100
+	maxOneTimePrekeys = 1000
+
+	// Curve25519 public keys are 32 bytes, or 33 with a leading key type byte
+	minPublicKeyLength = 32
+	maxPublicKeyLength = 33
+	signatureLength    = 64
+	maxKeyID           = 1<<24 - 1
+	maxRegistrationID  = 16380
+)
+
+type keyService struct {
+	keyRepo   repository.KeyRepository
+	blockRepo repository.BlockRepository
+	notify    LowPrekeyNotifier
+}
+
+func NewKeyService(keyRepo repository.KeyRepository, blockRepo repository.BlockRepository, notify LowPrekeyNotifier) KeyService {
+	return &keyService{keyRepo: keyRepo, blockRepo: blockRepo, notify: notify}
+}
+
+// SetIdentityKey publishes the user's identity key
+func (s *keyService) SetIdentityKey(ctx context.Context, userID uuid.UUID, key *models.IdentityKey) error {
+	if key.RegistrationID < 1 || key.RegistrationID > maxRegistrationID {
+		return fmt.Errorf("%w: registration_id must be between 1 and %d", common.ErrInvalidInput, maxRegistrationID)
+	}
+	if err := validatePublicKey("identity key", key.PublicKey); err != nil {
+		return err
+	}
+	return s.keyRepo.SetIdentityKey(ctx, userID, key)
+}
+
+// SetSignedPrekey publishes the user's signed prekey. The signature is opaque to the
+// server; peers verify it against the identity key.
+func (s *keyService) SetSignedPrekey(ctx context.Context, userID uuid.UUID, prekey *models.SignedPrekey) error {
+	if err := validateKeyID(prekey.KeyID); err != nil {
+		return err
+	}
+	if err := validatePublicKey("signed prekey", prekey.PublicKey); err != nil {
+		return err
+	}
+	if len(prekey.Signature) != signatureLength {
+		return fmt.Errorf("%w: signature must be %d bytes", common.ErrInvalidInput, signatureLength)
+	}
+
+	if _, err := s.keyRepo.GetIdentityKey(ctx, userID); err != nil {
+		return identityRequired(err)
+	}
+	return s.keyRepo.SetSignedPrekey(ctx, userID, prekey)
+}
+
+// AddOneTimePrekeys stores a batch of one-time prekeys up to the per-user limit
+func (s *keyService) AddOneTimePrekeys(ctx context.Context, userID uuid.UUID, prekeys []*models.OneTimePrekey) (int, error) {
+	if len(prekeys) == 0 || len(prekeys) > maxPrekeyBatch {
+		return 0, fmt.Errorf("%w: upload between 1 and %d prekeys at a time", common.ErrInvalidInput, maxPrekeyBatch)
+	}
+	for _, prekey := range prekeys {
+		if err := validateKeyID(prekey.KeyID); err != nil {
+			return 0, err
+		}
+		if err := validatePublicKey("one-time prekey", prekey.PublicKey); err != nil {
+			return 0, err
+		}
+	}
+
+	if _, err := s.keyRepo.GetIdentityKey(ctx, userID); err != nil {
+		return 0, identityRequired(err)
+	}
+
+	count, err := s.keyRepo.CountOneTimePrekeys(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+	if count+len(prekeys) > maxOneTimePrekeys {
+		return 0, fmt.Errorf("%w: at most %d one-time prekeys can be stored, %d are left", common.ErrInvalidInput, maxOneTimePrekeys, count)
+	}
+
+	added, err := s.keyRepo.AddOneTimePrekeys(ctx, userID, prekeys)
+	if err != nil {
+		return 0, err
+	}
+	return count + added, nil
+}
+
+func (s *keyService) CountOneTimePrekeys(ctx context.Context, userID uuid.UUID) (int, error) {
+	return s.keyRepo.CountOneTimePrekeys(ctx, userID)
+}
+
+// GetPrekeyBundle returns the user's bundle and warns them when their one-time prekeys run low.
+// Users who blocked the requester look as if they had not published any keys.
+func (s *keyService) GetPrekeyBundle(ctx context.Context, requesterID, userID uuid.UUID) (*models.PrekeyBundle, error) {
+	if requesterID == userID {
+		return nil, fmt.Errorf("%w: you cannot start a session with yourself", common.ErrInvalidInput)
+	}
+
+	blocked, err := s.blockRepo.IsBlocked(ctx, userID, requesterID)
+	if err != nil {
+		return nil, err
+	}
+	if blocked {
+		return nil, fmt.Errorf("%w: user has not published keys", common.ErrNotFound)
+	}
+
+	bundle, err := s.keyRepo.FetchPrekeyBundle(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	remaining, err := s.keyRepo.CountOneTimePrekeys(ctx, userID)
+	if err == nil && remaining < LowPrekeyThreshold && s.notify != nil {
+		s.notify(userID, remaining)
+	}
+
+	return bundle, nil
+}
+
+func validatePublicKey(name string, key []byte) error {
+	if len(key) < minPublicKeyLength || len(key) > maxPublicKeyLength {
+		return fmt.Errorf("%w: %s must be %d or %d bytes", common.ErrInvalidInput, name, minPublicKeyLength, maxPublicKeyLength)
+	}
+	return nil
+}
+
+func validateKeyID(keyID int) error {
+	if keyID < 0 || keyID > maxKeyID {
+		return fmt.Errorf("%w: key_id must be between 0 and %d", common.ErrInvalidInput, maxKeyID)
+	}
+	return nil
+}
+
+// identityRequired explains a missing identity key to the caller
+func identityRequired(err error) error {
+	if errors.Is(err, common.ErrNotFound) {
+		return fmt.Errorf("%w: upload an identity key first", common.ErrInvalidInput)
+	}
+	return err
+}
@@ -0,0 +1,68 @@
+// Package audio inspects uploaded voice notes without decoding them: it checks the container
+// and codec, reads the duration from the container, and estimates a waveform from the sizes
+// of the encoded packets.
+package audio
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+var (
+	// ErrUnsupported reports a container or codec voice notes cannot use
+	ErrUnsupported = errors.New("unsupported audio format, voice notes must be Ogg/Opus or M4A/AAC")
+	// ErrMalformed reports a file that claims a supported format but cannot be parsed
+	ErrMalformed = errors.New("malformed audio file")
+)
+
+// Codecs of the formats Probe accepts
+const (
+	CodecOpus = "opus"
+	CodecAAC  = "aac"
+)
+
+// Info describes a voice note.
+type Info struct {
+	MimeType string
+	Codec    string
+	Duration float64 // in seconds
+	// Waveform holds one level per equal slice of the recording, from 0 to MaxLevel
+	Waveform []int
+}
+
+// MaxLevel is the level of the loudest slice of a waveform.
+const MaxLevel = 100
+
+// packet is an encoded audio packet: its size in bytes and how long it plays, in units of
+// its track's timescale
+type packet struct {
+	size     int
+	duration int64
+}
+
+// Probe identifies the audio in the first size bytes of r and computes a waveform of the
+// given number of samples. Errors wrap ErrUnsupported or ErrMalformed.
+func Probe(r io.ReaderAt, size int64, samples int) (*Info, error) {
+	var magic [8]byte
+	if _, err := r.ReadAt(magic[:], 0); err != nil {
+		return nil, ErrUnsupported
+	}
+
+	switch {
+	case string(magic[:4]) == "OggS":
+		return probeOgg(io.NewSectionReader(r, 0, size), samples)
+	case string(magic[4:]) == "ftyp":
+		return probeMP4(r, size, samples)
+	default:
+		return nil, ErrUnsupported
+	}
+}
+
+func malformed(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s", ErrMalformed, fmt.Sprintf(format, args...))
+}
+
+func unsupported(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s", ErrUnsupported, fmt.Sprintf(format, args...))
+}
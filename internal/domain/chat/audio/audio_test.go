@@ -0,0 +1,470 @@
+package audio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"math"
+	"os"
+	"testing"
+)
+
+// The MP4 fixtures keep the ftyp and moov boxes of encoder output, with the media data
+// dropped since Probe never reads it: aac.mp4 holds a video track and an AAC sound track,
+// alac.m4a an Apple Lossless one. vorbis.ogg is the first page of a Vorbis stream. The
+// Opus streams are built below, one 20 ms CELT frame per packet.
+
+const opusFrame = 960 // Samples of a 20 ms frame
+
+// oggWriter builds an Ogg stream page by page. Checksums are left zero, as Probe does not
+// check them.
+type oggWriter struct {
+	serial   uint32
+	sequence uint32
+	data     []byte
+	last     int // Offset of the last page
+}
+
+// page appends a page holding packets. With open set, the last packet continues on the
+// next page and must be a multiple of 255 bytes long.
+func (w *oggWriter) page(flags byte, granule int64, open bool, packets ...[]byte) {
+	var segments, body []byte
+	for i, p := range packets {
+		n := len(p)
+		for ; n >= 255; n -= 255 {
+			segments = append(segments, 255)
+		}
+		if !open || i < len(packets)-1 {
+			segments = append(segments, byte(n))
+		}
+		body = append(body, p...)
+	}
+
+	header := make([]byte, oggHeaderSize)
+	copy(header, "OggS")
+	header[5] = flags
+	binary.LittleEndian.PutUint64(header[6:14], uint64(granule))
+	binary.LittleEndian.PutUint32(header[14:18], w.serial)
+	binary.LittleEndian.PutUint32(header[18:22], w.sequence)
+	header[26] = byte(len(segments))
+	w.sequence++
+
+	w.last = len(w.data)
+	w.data = append(w.data, header...)
+	w.data = append(w.data, segments...)
+	w.data = append(w.data, body...)
+}
+
+// setLastGranule rewrites the granule position of the last page
+func (w *oggWriter) setLastGranule(granule int64) {
+	binary.LittleEndian.PutUint64(w.data[w.last+6:w.last+14], uint64(granule))
+}
+
+func opusHead(preSkip uint16) []byte {
+	head := make([]byte, 19)
+	copy(head, "OpusHead")
+	head[8] = 1 // Version
+	head[9] = 1 // Channels
+	binary.LittleEndian.PutUint16(head[10:12], preSkip)
+	binary.LittleEndian.PutUint32(head[12:16], 48000)
+	return head
+}
+
+func opusTags() []byte {
+	return append([]byte("OpusTags"), make([]byte, 8)...)
+}
+
+// opusPacket returns a packet of size bytes holding one 20 ms CELT frame
+func opusPacket(size int) []byte {
+	p := bytes.Repeat([]byte{0x55}, size)
+	p[0] = 31 << 3 // Config 31: CELT fullband, 20 ms; code 0: one frame
+	return p
+}
+
+// opusStream builds an Opus stream with one page per entry of pages, holding packets of
+// the given sizes. Granule positions count from offset.
+func opusStream(preSkip uint16, offset int64, pages ...[]int) *oggWriter {
+	w := &oggWriter{serial: 0x1234}
+	w.page(0x02, 0, false, opusHead(preSkip))
+	w.page(0, 0, false, opusTags())
+
+	granule := offset
+	for i, sizes := range pages {
+		var packets [][]byte
+		for _, size := range sizes {
+			packets = append(packets, opusPacket(size))
+			granule += opusFrame
+		}
+		var flags byte
+		if i == len(pages)-1 {
+			flags = 0x04
+		}
+		w.page(flags, granule, false, packets...)
+	}
+	return w
+}
+
+// sizes returns n packet sizes
+func sizes(n, size int) []int {
+	result := make([]int, n)
+	for i := range result {
+		result[i] = size
+	}
+	return result
+}
+
+func readFixture(t *testing.T, name string) []byte {
+	t.Helper()
+	data, err := os.ReadFile("testdata/" + name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func probeBytes(data []byte, samples int) (*Info, error) {
+	return Probe(bytes.NewReader(data), int64(len(data)), samples)
+}
+
+func TestProbeOgg(t *testing.T) {
+	oneSecond := func() *oggWriter { return opusStream(312, 0, sizes(25, 40), sizes(25, 40)) }
+
+	spanning := &oggWriter{serial: 7}
+	spanning.page(0x02, 0, false, opusHead(0))
+	spanning.page(0, 0, false, opusTags())
+	spanning.page(0, -1, true, opusPacket(510)) // No packet ends on this page
+	spanning.page(0x05, 2*opusFrame, false, bytes.Repeat([]byte{0x55}, 90), opusPacket(40))
+
+	tests := []struct {
+		name     string
+		data     func() []byte
+		duration float64
+		err      error
+	}{
+		{
+			name:     "one second",
+			data:     func() []byte { return oneSecond().data },
+			duration: float64(50*opusFrame-312) / opusRate,
+		},
+		{
+			name: "end trimmed within the last page",
+			data: func() []byte {
+				w := oneSecond()
+				w.setLastGranule(50*opusFrame - 500)
+				return w.data
+			},
+			duration: float64(50*opusFrame-500-312) / opusRate,
+		},
+		{
+			name:     "starts at a later position",
+			data:     func() []byte { return opusStream(312, 10*opusRate, sizes(25, 40), sizes(25, 40)).data },
+			duration: float64(50*opusFrame-312) / opusRate,
+		},
+		{
+			name:     "packet across pages",
+			data:     func() []byte { return spanning.data },
+			duration: float64(2*opusFrame) / opusRate,
+		},
+		{
+			name:     "shorter than the pre-skip",
+			data:     func() []byte { return opusStream(3840, 0, sizes(2, 40)).data },
+			duration: 0,
+		},
+		{
+			name: "last granule beyond the audio",
+			data: func() []byte {
+				w := oneSecond()
+				w.setLastGranule(3600 * opusRate) // An hour from a second of packets
+				return w.data
+			},
+			err: ErrMalformed,
+		},
+		{
+			name: "last granule trims more than its page",
+			data: func() []byte {
+				w := oneSecond()
+				w.setLastGranule(10 * opusFrame)
+				return w.data
+			},
+			err: ErrMalformed,
+		},
+		{
+			name: "middle granule does not match",
+			data: func() []byte {
+				w := &oggWriter{serial: 1}
+				w.page(0x02, 0, false, opusHead(0))
+				w.page(0, 0, false, opusTags())
+				w.page(0, 2*opusFrame, false, opusPacket(40), opusPacket(40))
+				w.page(0, 5*opusFrame, false, opusPacket(40), opusPacket(40)) // Claims 3 frames for 2
+				w.page(0x04, 6*opusFrame, false, opusPacket(40))
+				return w.data
+			},
+			err: ErrMalformed,
+		},
+		{
+			name: "truncated page",
+			data: func() []byte {
+				data := oneSecond().data
+				return data[:len(data)-100]
+			},
+			err: ErrMalformed,
+		},
+		{
+			name: "truncated header",
+			data: func() []byte { return oneSecond().data[:oggHeaderSize+10] },
+			err:  ErrMalformed,
+		},
+		{
+			name: "headers only",
+			data: func() []byte { return opusStream(312, 0).data },
+			err:  ErrMalformed,
+		},
+		{
+			name: "missing OpusTags",
+			data: func() []byte {
+				w := &oggWriter{}
+				w.page(0x02, 0, false, opusHead(0))
+				w.page(0x04, opusFrame, false, opusPacket(40))
+				return w.data
+			},
+			err: ErrMalformed,
+		},
+		{
+			name: "second stream",
+			data: func() []byte {
+				w := oneSecond()
+				w.serial++
+				w.page(0x02, 0, false, opusHead(0))
+				return w.data
+			},
+			err: ErrUnsupported,
+		},
+		{
+			name: "Vorbis",
+			data: func() []byte { return readFixture(t, "vorbis.ogg") },
+			err:  ErrUnsupported,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info, err := probeBytes(tt.data(), 10)
+			if tt.err != nil {
+				if !errors.Is(err, tt.err) {
+					t.Fatalf("got error %v, want %v", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if info.MimeType != "audio/ogg" || info.Codec != CodecOpus {
+				t.Errorf("got %s %s, want audio/ogg opus", info.MimeType, info.Codec)
+			}
+			if math.Abs(info.Duration-tt.duration) > 1e-9 {
+				t.Errorf("got duration %v, want %v", info.Duration, tt.duration)
+			}
+			if len(info.Waveform) != 10 {
+				t.Errorf("got %d waveform samples, want 10", len(info.Waveform))
+			}
+		})
+	}
+}
+
+func TestProbeMP4(t *testing.T) {
+	aac := readFixture(t, "aac.mp4")
+
+	tests := []struct {
+		name     string
+		data     func() []byte
+		duration float64
+		err      error
+	}{
+		{
+			name:     "AAC after a video track, moov at the end",
+			data:     func() []byte { return aac },
+			duration: 5.568,
+		},
+		{
+			name:     "moov before the media data",
+			data:     func() []byte { return moovFirst(t, aac) },
+			duration: 5.568,
+		},
+		{
+			name: "Apple Lossless",
+			data: func() []byte { return readFixture(t, "alac.m4a") },
+			err:  ErrUnsupported,
+		},
+		{
+			name: "truncated moov",
+			data: func() []byte { return aac[:len(aac)-200] },
+			err:  ErrMalformed,
+		},
+		{
+			name: "no moov",
+			data: func() []byte { return aac[:binary.BigEndian.Uint32(aac)] },
+			err:  ErrMalformed,
+		},
+		{
+			name: "box size beyond the file",
+			data: func() []byte {
+				data := append([]byte(nil), aac...)
+				ftyp := binary.BigEndian.Uint32(data)
+				binary.BigEndian.PutUint32(data[ftyp:], 1<<30)
+				return data
+			},
+			err: ErrMalformed,
+		},
+		{
+			name: "box size below its header",
+			data: func() []byte {
+				data := append([]byte(nil), aac...)
+				ftyp := binary.BigEndian.Uint32(data)
+				binary.BigEndian.PutUint32(data[ftyp:], 4)
+				return data
+			},
+			err: ErrMalformed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info, err := probeBytes(tt.data(), 20)
+			if tt.err != nil {
+				if !errors.Is(err, tt.err) {
+					t.Fatalf("got error %v, want %v", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if info.MimeType != "audio/mp4" || info.Codec != CodecAAC {
+				t.Errorf("got %s %s, want audio/mp4 aac", info.MimeType, info.Codec)
+			}
+			if math.Abs(info.Duration-tt.duration) > 1e-9 {
+				t.Errorf("got duration %v, want %v", info.Duration, tt.duration)
+			}
+			if len(info.Waveform) != 20 {
+				t.Errorf("got %d waveform samples, want 20", len(info.Waveform))
+			}
+		})
+	}
+}
+
+// moovFirst moves the moov box of an MP4 file in front of the boxes before it
+func moovFirst(t *testing.T, data []byte) []byte {
+	t.Helper()
+	var moov, rest []byte
+	for offset := 0; offset < len(data); {
+		size := int(binary.BigEndian.Uint32(data[offset:]))
+		if string(data[offset+4:offset+8]) == "moov" {
+			moov = data[offset : offset+size]
+		} else {
+			rest = append(rest, data[offset:offset+size]...)
+		}
+		offset += size
+	}
+	if moov == nil {
+		t.Fatal("fixture has no moov box")
+	}
+	ftyp := binary.BigEndian.Uint32(rest)
+	result := append([]byte(nil), rest[:ftyp]...)
+	result = append(result, moov...)
+	return append(result, rest[ftyp:]...)
+}
+
+func TestProbeUnknown(t *testing.T) {
+	for name, data := range map[string][]byte{
+		"empty":    {},
+		"short":    []byte("Og"),
+		"RIFF":     []byte("RIFF\x24\x00\x00\x00WAVEfmt "),
+		"text":     []byte("hello, this is not audio"),
+		"bad page": append([]byte("OggS\x01"), make([]byte, 40)...),
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := probeBytes(data, 10)
+			if err == nil {
+				t.Fatal("got no error")
+			}
+			if !errors.Is(err, ErrUnsupported) && !errors.Is(err, ErrMalformed) {
+				t.Fatalf("got error %v, want ErrUnsupported or ErrMalformed", err)
+			}
+		})
+	}
+}
+
+func TestOpusPacketDuration(t *testing.T) {
+	tests := []struct {
+		name   string
+		packet []byte
+		want   int64
+	}{
+		{"empty", nil, 0},
+		{"SILK 10 ms", []byte{0 << 3}, 480},
+		{"SILK 60 ms", []byte{3 << 3}, 2880},
+		{"hybrid 20 ms", []byte{13 << 3}, 960},
+		{"CELT 2.5 ms", []byte{16 << 3}, 120},
+		{"CELT 20 ms, two frames", []byte{31<<3 | 1}, 1920},
+		{"CELT 20 ms, two frames of different sizes", []byte{31<<3 | 2}, 1920},
+		{"CELT 10 ms, code 3 with five frames", []byte{18<<3 | 3, 5}, 2400},
+		{"code 3 without a frame count", []byte{18<<3 | 3}, 0},
+	}
+	for _, tt := range tests {
+		if got := opusPacketDuration(tt.packet); got != tt.want {
+			t.Errorf("%s: got %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestWaveform(t *testing.T) {
+	t.Run("levels follow the bitrate", func(t *testing.T) {
+		packets := []packet{{size: 10, duration: 1}, {size: 40, duration: 1}, {size: 20, duration: 1}, {size: 0, duration: 1}}
+		got := waveform(packets, 4)
+		want := []int{25, 100, 50, 0}
+		if !equalInts(got, want) {
+			t.Errorf("got %v, want %v", got, want)
+		}
+	})
+
+	t.Run("constant bitrate is flat", func(t *testing.T) {
+		packets := make([]packet, 100)
+		for i := range packets {
+			packets[i] = packet{size: 50, duration: 960}
+		}
+		for _, level := range waveform(packets, 7) {
+			if level != MaxLevel {
+				t.Fatalf("got level %d, want %d", level, MaxLevel)
+			}
+		}
+	})
+
+	t.Run("long packets fill the slices after them", func(t *testing.T) {
+		got := waveform([]packet{{size: 30, duration: 3}, {size: 10, duration: 1}}, 4)
+		want := []int{100, 100, 100, 100}
+		if !equalInts(got, want) {
+			t.Errorf("got %v, want %v", got, want)
+		}
+	})
+
+	t.Run("no audio", func(t *testing.T) {
+		if got := waveform(nil, 10); got != nil {
+			t.Errorf("got %v, want nil", got)
+		}
+		if got := waveform([]packet{{size: 10, duration: 1}}, 0); got != nil {
+			t.Errorf("got %v, want nil", got)
+		}
+	})
+}
+
+func equalInts(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
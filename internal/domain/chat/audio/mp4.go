@@ -0,0 +1,318 @@
+package audio
+
+import (
+	"encoding/binary"
+	"io"
+)
+
+const maxMoovSize = 16 << 20 // 16 MB; a voice note's sample tables are far smaller
+
+// box is an ISO base media file format box (ISO/IEC 14496-12)
+type box struct {
+	kind string
+	data []byte // Payload after the header
+}
+
+// probeMP4 reads an MP4/M4A file holding an AAC audio track. It loads the moov box, which
+// may sit before or after the media data, and reads the first sound track's sample tables.
+func probeMP4(r io.ReaderAt, size int64, samples int) (*Info, error) {
+	moov, err := findMoov(r, size)
+	if err != nil {
+		return nil, err
+	}
+
+	var track []byte
+	for _, trak := range children(moov, "trak") {
+		mdia := child(trak, "mdia")
+		if hdlr := child(mdia, "hdlr"); len(hdlr) >= 12 && string(hdlr[8:12]) == "soun" {
+			track = mdia
+			break
+		}
+	}
+	if track == nil {
+		return nil, unsupported("MP4 file has no audio track")
+	}
+
+	timescale, duration, err := parseMdhd(child(track, "mdhd"))
+	if err != nil {
+		return nil, err
+	}
+
+	stbl := child(child(track, "minf"), "stbl")
+	if err := checkAAC(child(stbl, "stsd")); err != nil {
+		return nil, err
+	}
+	packets, err := parseSampleTables(child(stbl, "stts"), child(stbl, "stsz"))
+	if err != nil {
+		return nil, err
+	}
+	if len(packets) == 0 {
+		return nil, unsupported("fragmented MP4 files are not supported")
+	}
+	if duration == 0 {
+		for _, p := range packets {
+			duration += p.duration
+		}
+	}
+
+	return &Info{
+		MimeType: "audio/mp4",
+		Codec:    CodecAAC,
+		Duration: float64(duration) / float64(timescale),
+		Waveform: waveform(packets, samples),
+	}, nil
+}
+
+// findMoov walks the top-level boxes and returns the payload of moov
+func findMoov(r io.ReaderAt, size int64) ([]byte, error) {
+	for offset := int64(0); offset+8 <= size; {
+		var header [16]byte
+		if _, err := r.ReadAt(header[:8], offset); err != nil {
+			return nil, malformed("truncated MP4 box")
+		}
+		boxSize := int64(binary.BigEndian.Uint32(header[:4]))
+		kind := string(header[4:8])
+		headerSize := int64(8)
+		switch boxSize {
+		case 0: // Extends to the end of the file
+			boxSize = size - offset
+		case 1: // 64-bit size follows the type
+			if _, err := r.ReadAt(header[8:16], offset+8); err != nil {
+				return nil, malformed("truncated MP4 box")
+			}
+			boxSize = int64(binary.BigEndian.Uint64(header[8:16]))
+			headerSize = 16
+		}
+		if boxSize < headerSize || offset+boxSize > size {
+			return nil, malformed("invalid size of MP4 box %q", kind)
+		}
+
+		if kind == "moov" {
+			if boxSize-headerSize > maxMoovSize {
+				return nil, malformed("MP4 moov box is too large")
+			}
+			moov := make([]byte, boxSize-headerSize)
+			if _, err := r.ReadAt(moov, offset+headerSize); err != nil {
+				return nil, malformed("truncated MP4 moov box")
+			}
+			return moov, nil
+		}
+		offset += boxSize
+	}
+	return nil, malformed("MP4 file has no moov box")
+}
+
+// boxes splits a payload into the boxes it contains, stopping at the first invalid one
+func boxes(data []byte) []box {
+	var result []box
+	for len(data) >= 8 {
+		size := uint64(binary.BigEndian.Uint32(data[:4]))
+		kind := string(data[4:8])
+		headerSize := uint64(8)
+		switch size {
+		case 0:
+			size = uint64(len(data))
+		case 1:
+			if len(data) < 16 {
+				return result
+			}
+			size = binary.BigEndian.Uint64(data[8:16])
+			headerSize = 16
+		}
+		if size < headerSize || size > uint64(len(data)) {
+			return result
+		}
+		result = append(result, box{kind: kind, data: data[headerSize:size]})
+		data = data[size:]
+	}
+	return result
+}
+
+// children returns the payloads of the boxes of a kind inside data
+func children(data []byte, kind string) [][]byte {
+	var result [][]byte
+	for _, b := range boxes(data) {
+		if b.kind == kind {
+			result = append(result, b.data)
+		}
+	}
+	return result
+}
+
+// child returns the payload of the first box of a kind inside data, or nil
+func child(data []byte, kind string) []byte {
+	if found := children(data, kind); len(found) > 0 {
+		return found[0]
+	}
+	return nil
+}
+
+// parseMdhd reads the timescale and duration of a media header box
+func parseMdhd(mdhd []byte) (uint32, int64, error) {
+	if len(mdhd) < 4 {
+		return 0, 0, malformed("missing MP4 media header")
+	}
+	var timescale uint32
+	var duration int64
+	if mdhd[0] == 1 {
+		if len(mdhd) < 32 {
+			return 0, 0, malformed("truncated MP4 media header")
+		}
+		timescale = binary.BigEndian.Uint32(mdhd[20:24])
+		duration = int64(binary.BigEndian.Uint64(mdhd[24:32]))
+	} else {
+		if len(mdhd) < 20 {
+			return 0, 0, malformed("truncated MP4 media header")
+		}
+		timescale = binary.BigEndian.Uint32(mdhd[12:16])
+		duration = int64(binary.BigEndian.Uint32(mdhd[16:20]))
+	}
+	if timescale == 0 {
+		return 0, 0, malformed("MP4 media header has no timescale")
+	}
+	if duration < 0 || uint32(duration) == 0xffffffff {
+		duration = 0 // Unknown; summed from the sample durations instead
+	}
+	return timescale, duration, nil
+}
+
+// checkAAC checks that the sample description is an mp4a entry carrying MPEG-4 or MPEG-2 AAC
+func checkAAC(stsd []byte) error {
+	if len(stsd) < 8 {
+		return malformed("missing MP4 sample description")
+	}
+	entries := boxes(stsd[8:])
+	if len(entries) == 0 {
+		return malformed("empty MP4 sample description")
+	}
+	entry := entries[0]
+	if entry.kind != "mp4a" {
+		return unsupported("MP4 audio codec %q is not AAC", entry.kind)
+	}
+
+	// AudioSampleEntry fields precede the child boxes; QuickTime sound description
+	// versions 1 and 2 extend them
+	fields := 28
+	if len(entry.data) >= 10 {
+		switch binary.BigEndian.Uint16(entry.data[8:10]) {
+		case 1:
+			fields += 16
+		case 2:
+			fields += 36
+		}
+	}
+	if len(entry.data) < fields {
+		return malformed("truncated MP4 audio sample entry")
+	}
+	esds := child(entry.data[fields:], "esds")
+	if esds == nil {
+		// QuickTime files may nest it in a wave box
+		esds = child(child(entry.data[fields:], "wave"), "esds")
+	}
+	objectType, ok := decoderObjectType(esds)
+	if !ok {
+		return malformed("missing MP4 elementary stream descriptor")
+	}
+	switch objectType {
+	case 0x40, 0x66, 0x67, 0x68: // MPEG-4 Audio, MPEG-2 AAC Main, LC and SSR
+		return nil
+	default:
+		return unsupported("MP4 audio object type 0x%02x is not AAC", objectType)
+	}
+}
+
+// decoderObjectType reads the objectTypeIndication of the DecoderConfigDescriptor in an
+// esds box (ISO/IEC 14496-1)
+func decoderObjectType(esds []byte) (byte, bool) {
+	if len(esds) < 4 {
+		return 0, false
+	}
+	data := esds[4:] // version and flags
+
+	tag, body, ok := descriptor(data)
+	if !ok || tag != 0x03 || len(body) < 3 {
+		return 0, false
+	}
+	flags := body[2]
+	body = body[3:] // ES_ID and flags
+	if flags&0x80 != 0 {
+		body = skip(body, 2) // dependsOn_ES_ID
+	}
+	if flags&0x40 != 0 && len(body) > 0 {
+		body = skip(body, 1+int(body[0])) // URL
+	}
+	if flags&0x20 != 0 {
+		body = skip(body, 2) // OCR_ES_Id
+	}
+
+	tag, body, ok = descriptor(body)
+	if !ok || tag != 0x04 || len(body) < 1 {
+		return 0, false
+	}
+	return body[0], true
+}
+
+// descriptor reads a descriptor's tag and body; its length is coded on up to four bytes
+func descriptor(data []byte) (byte, []byte, bool) {
+	if len(data) < 2 {
+		return 0, nil, false
+	}
+	tag := data[0]
+	length, start := 0, 1
+	for start < len(data) && start <= 4 {
+		b := data[start]
+		length = length<<7 | int(b&0x7f)
+		start++
+		if b&0x80 == 0 {
+			break
+		}
+	}
+	if start+length > len(data) {
+		return 0, nil, false
+	}
+	return tag, data[start : start+length], true
+}
+
+func skip(data []byte, n int) []byte {
+	if n > len(data) {
+		return nil
+	}
+	return data[n:]
+}
+
+// parseSampleTables pairs the sample sizes of stsz with the sample durations of stts
+func parseSampleTables(stts, stsz []byte) ([]packet, error) {
+	if len(stsz) < 12 || len(stts) < 8 {
+		return nil, malformed("missing MP4 sample tables")
+	}
+
+	sampleSize := binary.BigEndian.Uint32(stsz[4:8])
+	count := int(binary.BigEndian.Uint32(stsz[8:12]))
+	if sampleSize == 0 && len(stsz) < 12+4*count {
+		return nil, malformed("truncated MP4 sample size table")
+	}
+	if count > maxMoovSize/4 {
+		return nil, malformed("MP4 sample count is too large")
+	}
+
+	entries := int(binary.BigEndian.Uint32(stts[4:8]))
+	if len(stts) < 8+8*entries {
+		return nil, malformed("truncated MP4 sample duration table")
+	}
+
+	packets := make([]packet, 0, count)
+	for i := 0; i < entries && len(packets) < count; i++ {
+		entry := stts[8+8*i:]
+		run := int(binary.BigEndian.Uint32(entry[:4]))
+		delta := int64(binary.BigEndian.Uint32(entry[4:8]))
+		for j := 0; j < run && len(packets) < count; j++ {
+			size := int(sampleSize)
+			if sampleSize == 0 {
+				index := len(packets)
+				size = int(binary.BigEndian.Uint32(stsz[12+4*index:]))
+			}
+			packets = append(packets, packet{size: size, duration: delta})
+		}
+	}
+	return packets, nil
+}
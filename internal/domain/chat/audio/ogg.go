@@ -0,0 +1,165 @@
+package audio
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+const (
+	oggHeaderSize = 27
+	opusRate      = 48000 // Opus granule positions always count 48 kHz samples
+)
+
+// probeOgg reads an Ogg file holding a single Opus stream (RFC 7845). The duration comes
+// from the granule position of the last page, which is checked against the packets: every
+// other page's position counts the samples up to its end, and the last one may only trim
+// samples of its own packets.
+func probeOgg(r io.Reader, samples int) (*Info, error) {
+	br := bufio.NewReader(r)
+
+	var (
+		serial      uint32
+		preSkip     int64
+		start       int64 = -1 // Granule position the audio starts at
+		granule     int64 = -1 // Granule position of the last page that ends a packet
+		total       int64      // Samples of all audio packets
+		lastSamples int64      // Samples of the audio packets ending on the last such page
+		packets     []packet
+		partial     []byte // Packet continued on the next page
+		headers     int    // Header packets seen: OpusHead, then OpusTags
+		firstPage   = true
+	)
+	for {
+		header := make([]byte, oggHeaderSize)
+		if _, err := io.ReadFull(br, header); err != nil {
+			if errors.Is(err, io.EOF) && !firstPage {
+				break
+			}
+			return nil, malformed("truncated Ogg page")
+		}
+		if string(header[:4]) != "OggS" || header[4] != 0 {
+			return nil, malformed("invalid Ogg page")
+		}
+
+		segments := make([]byte, header[26])
+		if _, err := io.ReadFull(br, segments); err != nil {
+			return nil, malformed("truncated Ogg page")
+		}
+		dataSize := 0
+		for _, length := range segments {
+			dataSize += int(length)
+		}
+		data := make([]byte, dataSize)
+		if _, err := io.ReadFull(br, data); err != nil {
+			return nil, malformed("truncated Ogg page")
+		}
+
+		pageSerial := binary.LittleEndian.Uint32(header[14:18])
+		if firstPage {
+			serial = pageSerial
+			firstPage = false
+		} else if pageSerial != serial {
+			return nil, unsupported("Ogg file holds more than one stream")
+		}
+		position := int64(binary.LittleEndian.Uint64(header[6:14]))
+
+		// Segments shorter than 255 bytes end a packet
+		var pageSamples int64
+		offset := 0
+		for _, length := range segments {
+			partial = append(partial, data[offset:offset+int(length)]...)
+			offset += int(length)
+			if length == 255 {
+				continue
+			}
+
+			switch headers {
+			case 0:
+				if len(partial) < 19 || !bytes.HasPrefix(partial, []byte("OpusHead")) {
+					return nil, unsupported("Ogg stream is not Opus")
+				}
+				preSkip = int64(binary.LittleEndian.Uint16(partial[10:12]))
+				headers++
+			case 1:
+				if !bytes.HasPrefix(partial, []byte("OpusTags")) {
+					return nil, malformed("missing OpusTags header")
+				}
+				headers++
+			default:
+				if duration := opusPacketDuration(partial); duration > 0 {
+					packets = append(packets, packet{size: len(partial), duration: duration})
+					pageSamples += duration
+				}
+			}
+			partial = partial[:0]
+		}
+		total += pageSamples
+
+		if position == -1 || pageSamples == 0 {
+			continue // Header pages, and pages that end no audio packet
+		}
+		if start < 0 {
+			// A stream may start at a later position, which the first audio page tells
+			start = position - total
+			if start < 0 {
+				if header[5]&0x04 == 0 {
+					return nil, malformed("Ogg granule position does not match the audio")
+				}
+				start = 0 // A single page, trimmed at the end
+			}
+		} else if granule-start != total-pageSamples {
+			return nil, malformed("Ogg granule position does not match the audio")
+		}
+		granule, lastSamples = position, pageSamples
+	}
+
+	if headers < 2 || granule < 0 {
+		return nil, malformed("Ogg stream has no audio")
+	}
+	played := granule - start
+	if played > total {
+		return nil, malformed("Ogg granule position is beyond the end of the audio")
+	}
+	if total-played > lastSamples {
+		return nil, malformed("Ogg granule position ends before the last page")
+	}
+	return &Info{
+		MimeType: "audio/ogg",
+		Codec:    CodecOpus,
+		Duration: float64(max(played-preSkip, 0)) / opusRate,
+		Waveform: waveform(packets, samples),
+	}, nil
+}
+
+// opusPacketDuration returns how many 48 kHz samples an Opus packet decodes to, from its
+// TOC byte (RFC 6716, section 3.1), or 0 for a packet too short to have one.
+func opusPacketDuration(p []byte) int64 {
+	if len(p) == 0 {
+		return 0
+	}
+	config := p[0] >> 3
+	var frameSize int64
+	switch {
+	case config < 12: // SILK: 10, 20, 40 or 60 ms
+		frameSize = []int64{480, 960, 1920, 2880}[config%4]
+	case config < 16: // Hybrid: 10 or 20 ms
+		frameSize = []int64{480, 960}[config%2]
+	default: // CELT: 2.5, 5, 10 or 20 ms
+		frameSize = []int64{120, 240, 480, 960}[config%4]
+	}
+
+	frames := int64(1)
+	switch p[0] & 3 {
+	case 1, 2:
+		frames = 2
+	case 3:
+		if len(p) < 2 {
+			return 0
+		}
+		frames = int64(p[1] & 0x3f)
+	}
+	return frameSize * frames
+}
@@ -0,0 +1,53 @@
+package audio
+
+import "math"
+
+// waveform splits the packets into samples slices of equal duration and levels each slice
+// by its bitrate. Opus and AAC encode at a variable bitrate that rises with loudness and
+// drops to a few bytes per packet in silence, so the bitrate traces the shape of the
+// recording closely enough for a voice note's UI; a recording encoded at a constant
+// bitrate comes out flat.
+func waveform(packets []packet, samples int) []int {
+	var total int64
+	for _, p := range packets {
+		total += p.duration
+	}
+	if samples <= 0 || total <= 0 {
+		return nil
+	}
+
+	bytes := make([]float64, samples)
+	durations := make([]float64, samples)
+	var position int64
+	for _, p := range packets {
+		// A packet counts toward the slice it starts in
+		slice := int(position * int64(samples) / total)
+		if slice >= samples {
+			slice = samples - 1
+		}
+		bytes[slice] += float64(p.size)
+		durations[slice] += float64(p.duration)
+		position += p.duration
+	}
+
+	rates := make([]float64, samples)
+	peak := 0.0
+	for i := range rates {
+		if durations[i] > 0 {
+			rates[i] = bytes[i] / durations[i]
+		} else if i > 0 {
+			// Packets longer than a slice leave the slices after them empty
+			rates[i] = rates[i-1]
+		}
+		peak = math.Max(peak, rates[i])
+	}
+
+	levels := make([]int, samples)
+	if peak == 0 {
+		return levels
+	}
+	for i, rate := range rates {
+		levels[i] = int(math.Round(rate / peak * MaxLevel))
+	}
+	return levels
+}
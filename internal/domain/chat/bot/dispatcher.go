@@ -0,0 +1,281 @@
+// Package bot delivers the messages addressed to bot accounts to the bots' webhooks.
+package bot
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"github.com/dk5761/go-serv/configs"
+	authModels "github.com/dk5761/go-serv/internal/domain/auth/models"
+	authRepo "github.com/dk5761/go-serv/internal/domain/auth/repository"
+	"github.com/dk5761/go-serv/internal/domain/chat/command"
+	"github.com/dk5761/go-serv/internal/domain/chat/models"
+	"github.com/dk5761/go-serv/internal/domain/common"
+	"github.com/dk5761/go-serv/internal/infrastructure/logging"
+)
+
+const (
+	defaultWebhookWorkers     = 4
+	defaultWebhookQueueSize   = 1024
+	defaultWebhookMaxAttempts = 5
+	defaultWebhookTimeout     = 10 * time.Second
+
+	webhookRetryBackoff = time.Second
+)
+
+// Event types posted to webhooks
+const (
+	EventMessage = "message" // A message addressed to the bot
+	EventCommand = "command" // One of the bot's slash commands; Message is not stored
+)
+
+// Headers of a webhook request. The signature is "sha256=" followed by the hex HMAC-SHA256,
+// keyed with the bot's webhook secret, of the timestamp, a dot and the body.
+const (
+	HeaderEvent     = "X-Bot-Event"
+	HeaderTimestamp = "X-Bot-Timestamp"
+	HeaderSignature = "X-Bot-Signature"
+)
+
+// Event is the JSON body posted to a bot's webhook.
+type Event struct {
+	Type      string          `json:"type"`
+	BotID     string          `json:"bot_id"`
+	Timestamp int64           `json:"timestamp"` // Unix seconds
+	Message   *models.Message `json:"message"`
+	Command   *CommandData    `json:"command,omitempty"`
+}
+
+// CommandData names the command of a command event and its raw arguments.
+type CommandData struct {
+	Name string `json:"name"`
+	Args string `json:"args"`
+}
+
+// Dispatcher posts messages addressed to bots to their webhooks. Deliver only queues the
+// event; Run's workers post it, retrying network errors and 429 and 5xx responses with
+// exponential backoff.
+type Dispatcher struct {
+	botRepo     authRepo.BotRepository
+	client      *http.Client
+	workers     int
+	maxAttempts int
+	queue       chan *job
+}
+
+type job struct {
+	bot         *authModels.Bot
+	eventType   string
+	message     *models.Message
+	command     *CommandData
+	onDelivered func()
+}
+
+func NewDispatcher(botRepo authRepo.BotRepository, cfg configs.BotConfig) *Dispatcher {
+	d := &Dispatcher{
+		botRepo:     botRepo,
+		workers:     cfg.Workers,
+		maxAttempts: cfg.MaxAttempts,
+	}
+	timeout := time.Duration(cfg.Timeout) * time.Second
+	if timeout <= 0 {
+		timeout = defaultWebhookTimeout
+	}
+	d.client = &http.Client{Timeout: timeout}
+	if d.workers <= 0 {
+		d.workers = defaultWebhookWorkers
+	}
+	if d.maxAttempts <= 0 {
+		d.maxAttempts = defaultWebhookMaxAttempts
+	}
+	queueSize := cfg.QueueSize
+	if queueSize <= 0 {
+		queueSize = defaultWebhookQueueSize
+	}
+	d.queue = make(chan *job, queueSize)
+	return d
+}
+
+// Deliver queues a message for its receiver's webhook if the receiver is a bot, and
+// reports whether it is. onDelivered is called once the webhook accepted the message. A
+// nil Dispatcher delivers nothing.
+func (d *Dispatcher) Deliver(ctx context.Context, message *models.Message, onDelivered func()) bool {
+	if d == nil {
+		return false
+	}
+	bot := d.receivingBot(ctx, message)
+	if bot == nil {
+		return false
+	}
+
+	d.enqueue(&job{bot: bot, eventType: EventMessage, message: message, onDelivered: onDelivered})
+	return true
+}
+
+// DeliverCommand queues a slash command for its receiver's webhook if the receiver is a
+// bot that registered the command, and reports whether it did.
+func (d *Dispatcher) DeliverCommand(ctx context.Context, inv *command.Invocation) bool {
+	if d == nil {
+		return false
+	}
+	bot := d.receivingBot(ctx, inv.Message)
+	if bot == nil {
+		return false
+	}
+
+	commands, err := d.botRepo.ListCommands(ctx, bot.UserID)
+	if err != nil {
+		logging.Logger.Error("Failed to look up bot commands", zap.String("bot_id", bot.UserID.String()), zap.Error(err))
+		return false
+	}
+	for _, cmd := range commands {
+		if cmd.Name == inv.Name {
+			d.enqueue(&job{
+				bot:       bot,
+				eventType: EventCommand,
+				message:   inv.Message,
+				command:   &CommandData{Name: inv.Name, Args: inv.Args},
+			})
+			return true
+		}
+	}
+	return false
+}
+
+// receivingBot returns the bot a message is addressed to, or nil if the receiver is not a bot
+func (d *Dispatcher) receivingBot(ctx context.Context, message *models.Message) *authModels.Bot {
+	receiverID, err := uuid.Parse(message.ReceiverID)
+	if err != nil {
+		return nil
+	}
+
+	bot, err := d.botRepo.GetBot(ctx, receiverID)
+	if err != nil {
+		if !errors.Is(err, common.ErrNotFound) {
+			logging.Logger.Error("Failed to look up bot", zap.String("user_id", message.ReceiverID), zap.Error(err))
+		}
+		return nil
+	}
+	return bot
+}
+
+func (d *Dispatcher) enqueue(j *job) {
+	select {
+	case d.queue <- j:
+	default:
+		logging.Logger.Warn("Bot webhook queue is full, dropping event", zap.String("bot_id", j.bot.UserID.String()))
+	}
+}
+
+// Run posts queued events until ctx is cancelled
+func (d *Dispatcher) Run(ctx context.Context) {
+	done := make(chan struct{})
+	for i := 0; i < d.workers; i++ {
+		go func() {
+			defer func() { done <- struct{}{} }()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case j := <-d.queue:
+					d.post(ctx, j)
+				}
+			}
+		}()
+	}
+	for i := 0; i < d.workers; i++ {
+		<-done
+	}
+}
+
+func (d *Dispatcher) post(ctx context.Context, j *job) {
+	body, err := json.Marshal(&Event{
+		Type:      j.eventType,
+		BotID:     j.bot.UserID.String(),
+		Timestamp: time.Now().Unix(),
+		Message:   j.message,
+		Command:   j.command,
+	})
+	if err != nil {
+		logging.Logger.Error("Failed to encode bot event", zap.Error(err))
+		return
+	}
+
+	backoff := webhookRetryBackoff
+	for attempt := 1; ; attempt++ {
+		retry, err := d.send(ctx, j.bot, j.eventType, body)
+		if err == nil {
+			if j.onDelivered != nil {
+				j.onDelivered()
+			}
+			return
+		}
+		if !retry || attempt >= d.maxAttempts {
+			logging.Logger.Error("Failed to post bot event",
+				zap.String("bot_id", j.bot.UserID.String()),
+				zap.String("message_id", j.message.ID.Hex()),
+				zap.Int("attempts", attempt),
+				zap.Error(err),
+			)
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// send posts one signed event and reports whether a failure is worth retrying
+func (d *Dispatcher) send(ctx context.Context, bot *authModels.Bot, eventType string, body []byte) (bool, error) {
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, bot.WebhookURL, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderEvent, eventType)
+	req.Header.Set(HeaderTimestamp, timestamp)
+	req.Header.Set(HeaderSignature, Sign(bot.WebhookSecret, timestamp, body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	resp.Body.Close()
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return false, nil
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return true, fmt.Errorf("webhook responded %s", resp.Status)
+	default:
+		return false, fmt.Errorf("webhook responded %s", resp.Status)
+	}
+}
+
+// Sign returns the signature header value of a webhook request, for bots to compare
+// against.
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,20 @@
+package bot
+
+import "testing"
+
+func TestSign(t *testing.T) {
+	body := []byte(`{"event":"message"}`)
+	// openssl dgst -sha256 -hmac bot-secret over "1700000000.{body}"
+	want := "sha256=fe34d34a6956cf8bcc4bf69cd2f95b11ed8e70b713d1d044fdb67203f54dbcb3"
+	if got := Sign("bot-secret", "1700000000", body); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	// The timestamp is signed, so a captured request cannot be replayed with a new one
+	if Sign("bot-secret", "1700000001", body) == want {
+		t.Error("signature does not depend on the timestamp")
+	}
+	if Sign("other-secret", "1700000000", body) == want {
+		t.Error("signature does not depend on the secret")
+	}
+}
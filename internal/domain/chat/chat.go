@@ -7,20 +7,45 @@ import (
 	"github.com/dk5761/go-serv/internal/domain/chat/service"
 	"github.com/dk5761/go-serv/internal/domain/chat/websocket"
 	"github.com/dk5761/go-serv/internal/infrastructure/storage"
-	"go.mongodb.org/mongo-driver/mongo"
 )
 
-// NewChatHandler initializes and returns a ChatHandler with all dependencies injected.
-func NewChatHandler(db *mongo.Database, config *configs.Config, wsManager *websocket.WebSocketManager) *handler.ChatHandler {
-	// Initialize repository with the provided database connection
-	chatRepo := repository.NewMongoMessageRepository(db)
-
+// NewChatHandler initializes and returns a ChatHandler backed by the given message and attachment repositories.
+func NewChatHandler(chatRepo repository.MessageRepository, attachmentRepo repository.AttachmentRepository, config *configs.Config, wsManager *websocket.WebSocketManager) *handler.ChatHandler {
 	// Initialize storage service with S3 configuration
 	storageService := storage.NewS3StorageService(config.Storage.S3Config)
 
 	// Initialize chat service with the repository, storage, and WebSocket manager
-	chatService := service.NewChatService(chatRepo, storageService, wsManager)
+	chatService := service.NewChatService(chatRepo, attachmentRepo, storageService, wsManager, config.Voice)
 
 	// Return a new handler with all dependencies set up
 	return handler.NewChatHandler(chatService, wsManager)
 }
+
+// NewScheduledHandler initializes and returns a ScheduledHandler backed by the given repository.
+func NewScheduledHandler(scheduledRepo repository.ScheduledMessageRepository, storageService storage.StorageService) *handler.ScheduledHandler {
+	scheduledService := service.NewScheduledService(scheduledRepo, storageService)
+	return handler.NewScheduledHandler(scheduledService)
+}
+
+// NewConversationHandler initializes and returns a ConversationHandler backed by the given repository.
+func NewConversationHandler(conversationRepo repository.ConversationRepository, wsManager *websocket.WebSocketManager) *handler.ConversationHandler {
+	conversationService := service.NewConversationService(conversationRepo, wsManager)
+	return handler.NewConversationHandler(conversationService)
+}
+
+// NewDraftHandler initializes and returns a DraftHandler backed by the given repository.
+func NewDraftHandler(draftRepo repository.DraftRepository, wsManager *websocket.WebSocketManager) *handler.DraftHandler {
+	draftService := service.NewDraftService(draftRepo, wsManager)
+	return handler.NewDraftHandler(draftService)
+}
+
+// NewDeviceHandler initializes and returns a DeviceHandler backed by the given repository.
+func NewDeviceHandler(deviceRepo repository.DeviceRepository) *handler.DeviceHandler {
+	return handler.NewDeviceHandler(service.NewDeviceService(deviceRepo))
+}
+
+// NewInboxHandler initializes and returns an InboxHandler backed by the given repositories.
+func NewInboxHandler(settingsRepo repository.ConversationSettingsRepository, preferencesRepo repository.UserPreferencesRepository, wsManager *websocket.WebSocketManager) *handler.InboxHandler {
+	inboxService := service.NewInboxService(settingsRepo, preferencesRepo, wsManager)
+	return handler.NewInboxHandler(inboxService)
+}
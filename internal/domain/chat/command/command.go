@@ -0,0 +1,158 @@
+// Package command routes slash commands, messages starting with "/", to their handlers
+// instead of sending them. Commands are registered with a Registry; bots register theirs
+// through the bot API and get them at their webhook.
+package command
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/dk5761/go-serv/internal/domain/chat/models"
+	"github.com/dk5761/go-serv/internal/domain/common"
+)
+
+// ErrUnknownCommand is returned for a command nobody registered.
+var ErrUnknownCommand = fmt.Errorf("%w: unknown command", common.ErrInvalidInput)
+
+const maxNameLength = 32
+
+// Invocation is one run of a command. Message is the message the command was typed in,
+// with its conversation already resolved; it is never stored.
+type Invocation struct {
+	Name    string
+	Args    string
+	Message *models.Message
+}
+
+// Result is what a command produced. Reply is shown only to the sender, and Message is
+// sent to the conversation like any other message. Either may be empty.
+type Result struct {
+	Reply   string
+	Message *models.Message
+}
+
+type Handler func(ctx context.Context, inv *Invocation) (*Result, error)
+
+// Command describes a command for autocompletion. BotID is set for commands a bot
+// registered, which only work in conversations with that bot.
+type Command struct {
+	Name        string `json:"name"`
+	Usage       string `json:"usage"`
+	Description string `json:"description"`
+	BotID       string `json:"bot_id,omitempty"`
+
+	handler Handler
+}
+
+// Registry holds the built-in commands.
+type Registry struct {
+	mu       sync.RWMutex
+	commands map[string]*Command
+}
+
+func NewRegistry() *Registry {
+	return &Registry{commands: make(map[string]*Command)}
+}
+
+// Register adds a built-in command. It must be called before clients connect.
+func (r *Registry) Register(name, usage, description string, handler Handler) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.commands[name] = &Command{Name: name, Usage: usage, Description: description, handler: handler}
+}
+
+// Run runs the built-in command of an invocation. It reports false when there is no such
+// command, e.g. because a bot may handle it.
+func (r *Registry) Run(ctx context.Context, inv *Invocation) (*Result, bool, error) {
+	if r == nil {
+		return nil, false, nil
+	}
+	r.mu.RLock()
+	cmd, ok := r.commands[inv.Name]
+	r.mu.RUnlock()
+	if !ok {
+		return nil, false, nil
+	}
+
+	result, err := cmd.handler(ctx, inv)
+	return result, true, err
+}
+
+// List returns the built-in commands sorted by name.
+func (r *Registry) List() []*Command {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	commands := make([]*Command, 0, len(r.commands))
+	for _, cmd := range r.commands {
+		commands = append(commands, cmd)
+	}
+	sort.Slice(commands, func(i, j int) bool { return commands[i].Name < commands[j].Name })
+	return commands
+}
+
+// Parse splits a text message of the form "/name args" into the command name and its
+// arguments. A leading "//" escapes the slash: the message is sent with one slash removed.
+func Parse(message *models.Message) (name, args string, ok bool) {
+	if (message.Type != "" && message.Type != models.TextMessage) || !strings.HasPrefix(message.Content, "/") {
+		return "", "", false
+	}
+	if strings.HasPrefix(message.Content, "//") {
+		message.Content = message.Content[1:]
+		return "", "", false
+	}
+
+	name, args, _ = strings.Cut(message.Content[1:], " ")
+	if !ValidName(name) {
+		return "", "", false
+	}
+	return strings.ToLower(name), strings.TrimSpace(args), true
+}
+
+// ValidName reports whether name can be a command: letters, digits, dashes and
+// underscores, starting with a letter.
+func ValidName(name string) bool {
+	if name == "" || len(name) > maxNameLength {
+		return false
+	}
+	for i, c := range name {
+		switch {
+		case unicode.IsLetter(c):
+		case i > 0 && (unicode.IsDigit(c) || c == '-' || c == '_'):
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// SplitArgs splits arguments on spaces, keeping double-quoted arguments together.
+func SplitArgs(args string) []string {
+	var fields []string
+	var current strings.Builder
+	quoted, started := false, false
+	for _, c := range args {
+		switch {
+		case c == '"':
+			quoted = !quoted
+			started = true
+		case c == ' ' && !quoted:
+			if started {
+				fields = append(fields, current.String())
+				current.Reset()
+				started = false
+			}
+		default:
+			current.WriteRune(c)
+			started = true
+		}
+	}
+	if started {
+		fields = append(fields, current.String())
+	}
+	return fields
+}
@@ -0,0 +1,128 @@
+package command
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/dk5761/go-serv/internal/domain/chat/models"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name        string
+		message     models.Message
+		wantName    string
+		wantArgs    string
+		wantOK      bool
+		wantContent string // Content after parsing
+	}{
+		{
+			name:        "command with arguments",
+			message:     models.Message{Type: models.TextMessage, Content: "/remind  me in 5m "},
+			wantName:    "remind",
+			wantArgs:    "me in 5m",
+			wantOK:      true,
+			wantContent: "/remind  me in 5m ",
+		},
+		{
+			name:        "untyped message",
+			message:     models.Message{Content: "/help"},
+			wantName:    "help",
+			wantOK:      true,
+			wantContent: "/help",
+		},
+		{
+			name:        "name is lowercased",
+			message:     models.Message{Content: "/Poll Lunch?"},
+			wantName:    "poll",
+			wantArgs:    "Lunch?",
+			wantOK:      true,
+			wantContent: "/Poll Lunch?",
+		},
+		{
+			name:        "plain text",
+			message:     models.Message{Content: "hello /help"},
+			wantContent: "hello /help",
+		},
+		{
+			name:        "escaped slash",
+			message:     models.Message{Content: "//help is not a command"},
+			wantContent: "/help is not a command",
+		},
+		{
+			name:        "invalid name",
+			message:     models.Message{Content: "/1up"},
+			wantContent: "/1up",
+		},
+		{
+			name:        "bare slash",
+			message:     models.Message{Content: "/"},
+			wantContent: "/",
+		},
+		{
+			name:        "not a text message",
+			message:     models.Message{Type: models.ImageMessage, Content: "/help"},
+			wantContent: "/help",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			message := tt.message
+			name, args, ok := Parse(&message)
+			if name != tt.wantName || args != tt.wantArgs || ok != tt.wantOK {
+				t.Errorf("got (%q, %q, %v), want (%q, %q, %v)", name, args, ok, tt.wantName, tt.wantArgs, tt.wantOK)
+			}
+			if message.Content != tt.wantContent {
+				t.Errorf("got content %q, want %q", message.Content, tt.wantContent)
+			}
+		})
+	}
+}
+
+func TestValidName(t *testing.T) {
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"help", true},
+		{"set_topic", true},
+		{"dice-2", true},
+		{"é", true},
+		{"", false},
+		{"2dice", false},
+		{"-help", false},
+		{"help!", false},
+		{"with space", false},
+		{strings.Repeat("a", maxNameLength), true},
+		{strings.Repeat("a", maxNameLength+1), false},
+	}
+
+	for _, tt := range tests {
+		if got := ValidName(tt.name); got != tt.want {
+			t.Errorf("ValidName(%q): got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestSplitArgs(t *testing.T) {
+	tests := []struct {
+		args string
+		want []string
+	}{
+		{"", nil},
+		{"   ", nil},
+		{"a b  c", []string{"a", "b", "c"}},
+		{`"Where to eat?" pizza "dim sum"`, []string{"Where to eat?", "pizza", "dim sum"}},
+		{`say ""`, []string{"say", ""}},
+		{`a"b c"d`, []string{"ab cd"}},
+		{`"unterminated quote`, []string{"unterminated quote"}},
+	}
+
+	for _, tt := range tests {
+		if got := SplitArgs(tt.args); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("SplitArgs(%q): got %q, want %q", tt.args, got, tt.want)
+		}
+	}
+}
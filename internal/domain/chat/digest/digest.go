@@ -0,0 +1,149 @@
+// Package digest builds the emails summarizing a user's unread conversations.
+package digest
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	authModels "github.com/dk5761/go-serv/internal/domain/auth/models"
+	authRepo "github.com/dk5761/go-serv/internal/domain/auth/repository"
+	"github.com/dk5761/go-serv/internal/domain/chat/models"
+	"github.com/dk5761/go-serv/internal/domain/chat/repository"
+	"github.com/dk5761/go-serv/internal/infrastructure/mail"
+)
+
+const (
+	defaultMaxConversations = 10
+	previewsPerConversation = 3
+	maxPreviewLength        = 200
+)
+
+// Digest is the content of one digest email.
+type Digest struct {
+	Username       string
+	Conversations  []*Conversation
+	TotalUnread    int
+	UnsubscribeURL string
+}
+
+// Conversation is a conversation listed in a digest, with previews of its latest unread
+// messages, oldest first.
+type Conversation struct {
+	PeerName      string
+	UnreadCount   int
+	LastMessageAt time.Time
+	Previews      []string
+}
+
+// Composer gathers the unread conversations of a user into a Digest.
+type Composer struct {
+	msgRepo          repository.MessageRepository
+	settingsRepo     repository.ConversationSettingsRepository
+	userRepo         authRepo.UserRepository
+	signer           *Signer
+	maxConversations int
+}
+
+func NewComposer(
+	msgRepo repository.MessageRepository,
+	settingsRepo repository.ConversationSettingsRepository,
+	userRepo authRepo.UserRepository,
+	signer *Signer,
+	maxConversations int,
+) *Composer {
+	if maxConversations <= 0 {
+		maxConversations = defaultMaxConversations
+	}
+	return &Composer{
+		msgRepo:          msgRepo,
+		settingsRepo:     settingsRepo,
+		userRepo:         userRepo,
+		signer:           signer,
+		maxConversations: maxConversations,
+	}
+}
+
+// Compose builds a digest of the user's unmuted conversations with unread messages whose
+// last message arrived in (since, before]. It returns nil when there are none, so users
+// are not emailed about conversations a previous digest already covered.
+func (c *Composer) Compose(ctx context.Context, user *authModels.User, since, before, now time.Time) (*Digest, error) {
+	userID := user.ID.String()
+	unread, err := c.settingsRepo.ListUnread(ctx, userID, since, before, now, c.maxConversations)
+	if err != nil {
+		return nil, err
+	}
+	if len(unread) == 0 {
+		return nil, nil
+	}
+
+	digest := &Digest{
+		Username:       user.Username,
+		Conversations:  make([]*Conversation, 0, len(unread)),
+		UnsubscribeURL: c.signer.UnsubscribeURL(userID),
+	}
+	for _, settings := range unread {
+		conversation := &Conversation{
+			PeerName:      c.peerName(ctx, settings.ConversationID, userID),
+			UnreadCount:   settings.UnreadCount,
+			LastMessageAt: settings.LastMessageAt,
+		}
+
+		messages, err := c.msgRepo.GetMessagesBeforeSeq(ctx, settings.ConversationID, settings.LastMessageSeq+1, previewsPerConversation)
+		if err != nil {
+			return nil, err
+		}
+		for _, message := range messages {
+			if message.ReceiverID == userID && message.Type != models.SystemMessage {
+				conversation.Previews = append(conversation.Previews, message.Preview(maxPreviewLength))
+			}
+		}
+
+		digest.Conversations = append(digest.Conversations, conversation)
+		digest.TotalUnread += settings.UnreadCount
+	}
+	return digest, nil
+}
+
+// peerName returns the username of the other participant, or a placeholder if they are gone
+func (c *Composer) peerName(ctx context.Context, conversationID, userID string) string {
+	peerID, ok := models.ConversationPeer(conversationID, userID)
+	if !ok {
+		return "Someone"
+	}
+	id, err := uuid.Parse(peerID)
+	if err != nil {
+		return "Someone"
+	}
+	peer, err := c.userRepo.GetUserByID(ctx, id)
+	if err != nil || peer == nil {
+		return "Someone"
+	}
+	return peer.Username
+}
+
+// Message renders the digest as an email to the given address.
+func (d *Digest) Message(to string) (*mail.Message, error) {
+	text, html, err := render(d)
+	if err != nil {
+		return nil, err
+	}
+
+	subject := fmt.Sprintf("You have %d unread messages", d.TotalUnread)
+	if d.TotalUnread == 1 {
+		subject = "You have an unread message"
+	}
+	return &mail.Message{
+		To:      to,
+		Subject: subject,
+		Text:    text,
+		HTML:    html,
+		Headers: map[string]string{
+			// One-click unsubscribe from the mail client, as in RFC 8058
+			"List-Unsubscribe":      "<" + d.UnsubscribeURL + ">",
+			"List-Unsubscribe-Post": "List-Unsubscribe=One-Click",
+		},
+	}, nil
+}
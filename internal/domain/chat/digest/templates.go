@@ -0,0 +1,56 @@
+package digest
+
+import (
+	htmltemplate "html/template"
+	"strings"
+	texttemplate "text/template"
+	"time"
+)
+
+var (
+	templateFuncs = map[string]interface{}{
+		"time": func(t time.Time) string { return t.UTC().Format("Jan 2, 15:04 MST") },
+	}
+
+	textDigest = texttemplate.Must(texttemplate.New("digest").Funcs(templateFuncs).Parse(`Hi {{.Username}},
+
+You have {{.TotalUnread}} unread {{if eq .TotalUnread 1}}message{{else}}messages{{end}}.
+{{range .Conversations}}
+{{.PeerName}} ({{.UnreadCount}} unread, last at {{time .LastMessageAt}})
+{{- range .Previews}}
+  > {{.}}
+{{- end}}
+{{end}}
+Open the app to reply.
+
+To stop these emails, visit {{.UnsubscribeURL}}
+`))
+
+	htmlDigest = htmltemplate.Must(htmltemplate.New("digest").Funcs(templateFuncs).Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>Unread messages</title></head>
+<body style="font-family: sans-serif; color: #222;">
+<p>Hi {{.Username}},</p>
+<p>You have {{.TotalUnread}} unread {{if eq .TotalUnread 1}}message{{else}}messages{{end}}.</p>
+{{range .Conversations}}<div style="margin: 16px 0;">
+<strong>{{.PeerName}}</strong> <span style="color: #888;">{{.UnreadCount}} unread, last at {{time .LastMessageAt}}</span>
+{{range .Previews}}<div style="margin: 4px 0 0 12px; color: #555;">{{.}}</div>
+{{end}}</div>
+{{end}}<p>Open the app to reply.</p>
+<p style="font-size: 12px; color: #888;"><a href="{{.UnsubscribeURL}}">Unsubscribe</a> from these emails.</p>
+</body>
+</html>
+`))
+)
+
+// render returns the text and HTML bodies of a digest
+func render(d *Digest) (string, string, error) {
+	var text, html strings.Builder
+	if err := textDigest.Execute(&text, d); err != nil {
+		return "", "", err
+	}
+	if err := htmlDigest.Execute(&html, d); err != nil {
+		return "", "", err
+	}
+	return text.String(), html.String(), nil
+}
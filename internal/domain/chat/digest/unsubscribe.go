@@ -0,0 +1,41 @@
+package digest
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/url"
+	"strings"
+)
+
+// UnsubscribePath is where unsubscribe links point, relative to the server's base URL.
+const UnsubscribePath = "/api/chat/digest/unsubscribe"
+
+// Signer signs unsubscribe links, so one click turns digests off without logging in while
+// nobody can unsubscribe someone else.
+type Signer struct {
+	secret  []byte
+	baseURL string
+}
+
+func NewSigner(secret, baseURL string) *Signer {
+	return &Signer{secret: []byte(secret), baseURL: strings.TrimRight(baseURL, "/")}
+}
+
+// Sign returns the signature of an unsubscribe link for userID.
+func (s *Signer) Sign(userID string) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte("digest-unsubscribe:" + userID))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// Verify reports whether signature is the unsubscribe signature for userID.
+func (s *Signer) Verify(userID, signature string) bool {
+	return hmac.Equal([]byte(s.Sign(userID)), []byte(signature))
+}
+
+// UnsubscribeURL returns the signed one-click unsubscribe link for userID.
+func (s *Signer) UnsubscribeURL(userID string) string {
+	query := url.Values{"user": {userID}, "sig": {s.Sign(userID)}}
+	return s.baseURL + UnsubscribePath + "?" + query.Encode()
+}
@@ -0,0 +1,54 @@
+package digest
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestSignerVerify(t *testing.T) {
+	signer := NewSigner("secret", "https://chat.example")
+	signature := signer.Sign("user-1")
+
+	tests := []struct {
+		name      string
+		signer    *Signer
+		userID    string
+		signature string
+		want      bool
+	}{
+		{"valid", signer, "user-1", signature, true},
+		{"other user", signer, "user-2", signature, false},
+		{"other secret", NewSigner("other", "https://chat.example"), "user-1", signature, false},
+		{"truncated", signer, "user-1", signature[:len(signature)-1], false},
+		{"empty", signer, "user-1", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.signer.Verify(tt.userID, tt.signature); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSignerUnsubscribeURL(t *testing.T) {
+	signer := NewSigner("secret", "https://chat.example/")
+	link := signer.UnsubscribeURL("user 1&2")
+
+	if !strings.HasPrefix(link, "https://chat.example"+UnsubscribePath+"?") {
+		t.Fatalf("got %q", link)
+	}
+	parsed, err := url.Parse(link)
+	if err != nil {
+		t.Fatal(err)
+	}
+	query := parsed.Query()
+	if query.Get("user") != "user 1&2" {
+		t.Errorf("got user %q, want %q", query.Get("user"), "user 1&2")
+	}
+	if !signer.Verify(query.Get("user"), query.Get("sig")) {
+		t.Error("link signature does not verify")
+	}
+}
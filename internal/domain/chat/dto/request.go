@@ -0,0 +1,132 @@
+package dto
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/dk5761/go-serv/internal/domain/chat/models"
+)
+
+// ScheduleMessageRequest represents the request body for scheduling a message.
+type ScheduleMessageRequest struct {
+	ReceiverID string    `json:"receiver_id" binding:"required"`
+	Content    string    `json:"content"`
+	FileURL    string    `json:"file_url"`
+	SendAt     time.Time `json:"send_at" binding:"required"`
+}
+
+// UpdateScheduledMessageRequest represents the request body for editing a scheduled message.
+type UpdateScheduledMessageRequest struct {
+	Content string    `json:"content"`
+	SendAt  time.Time `json:"send_at" binding:"required"`
+}
+
+// SetMessageTimerRequest represents the request body for changing a conversation's disappearing message timer.
+type SetMessageTimerRequest struct {
+	Timer string `json:"timer" binding:"required"` // off, 1h, 1d or 7d
+}
+
+// SaveDraftRequest represents the request body for saving a conversation draft.
+type SaveDraftRequest struct {
+	Content   string    `json:"content"`
+	DeviceID  string    `json:"device_id"`
+	UpdatedAt time.Time `json:"updated_at"` // When the device last edited the draft; defaults to now and is capped at it
+}
+
+// PollVoteRequest represents a vote cast over REST or as a poll_vote WebSocket frame.
+// An empty option_ids retracts the vote.
+type PollVoteRequest struct {
+	PollID    string   `json:"poll_id"`
+	OptionIDs []string `json:"option_ids"`
+}
+
+// MuteConversationRequest represents the request body for muting a conversation. Either
+// Until or Forever must be set.
+type MuteConversationRequest struct {
+	Until   *time.Time `json:"until"`
+	Forever bool       `json:"forever"`
+}
+
+// SetFoldersRequest represents the request body for filing a conversation under folders.
+type SetFoldersRequest struct {
+	Folders []string `json:"folders"`
+}
+
+// SavePreferencesRequest represents the request body for updating a user's chat preferences.
+type SavePreferencesRequest struct {
+	KeepArchived    bool                   `json:"keep_archived"`
+	DigestFrequency models.DigestFrequency `json:"digest_frequency"` // off, hourly or daily; defaults to daily
+}
+
+// SetRetentionRequest represents the request body for a conversation's retention policy.
+// Zero for both fields removes the conversation's own policy.
+type SetRetentionRequest struct {
+	MaxAgeDays int `json:"max_age_days"`
+	KeepLast   int `json:"keep_last"`
+}
+
+// CreateReportRequest represents the request body for reporting a message or a user.
+// Exactly one of MessageID and UserID must be set.
+type CreateReportRequest struct {
+	MessageID string              `json:"message_id"`
+	UserID    string              `json:"user_id"`
+	Reason    models.ReportReason `json:"reason" binding:"required"`
+	Details   string              `json:"details"`
+}
+
+// ResolveReportRequest represents the request body for resolving a claimed report.
+// SuspendDays is required by the suspend_user action.
+type ResolveReportRequest struct {
+	Action      models.ModeratorAction `json:"action" binding:"required"`
+	Note        string                 `json:"note"`
+	SuspendDays int                    `json:"suspend_days"`
+}
+
+// RegisterDeviceRequest represents the request body for registering a device for push notifications.
+type RegisterDeviceRequest struct {
+	Token    string                `json:"token" binding:"required"`
+	Platform models.DevicePlatform `json:"platform" binding:"required"` // fcm or apns
+}
+
+// BotCommandRequest is one command of a SetBotCommandsRequest.
+type BotCommandRequest struct {
+	Name        string `json:"name" binding:"required"`
+	Usage       string `json:"usage"`
+	Description string `json:"description"`
+}
+
+// SetBotCommandsRequest represents the request body for a bot registering its slash commands.
+type SetBotCommandsRequest struct {
+	Commands []BotCommandRequest `json:"commands" binding:"dive"`
+}
+
+// CommandReplyRequest represents the request body for a bot's ephemeral reply to a command.
+type CommandReplyRequest struct {
+	UserID  string `json:"user_id" binding:"required"`
+	Command string `json:"command"`
+	Content string `json:"content" binding:"required"`
+}
+
+// CallSignalRequest is a call signaling WebSocket frame: call_invite, call_answer,
+// ice_candidate, call_hangup or call_reject. A call_invite names the callee in ReceiverID;
+// every other frame names the call in CallID.
+type CallSignalRequest struct {
+	CallID     string           `json:"call_id"`
+	TempID     string           `json:"temp_id"`
+	ReceiverID string           `json:"receiver_id"`
+	Media      models.CallMedia `json:"media"`
+	SDP        json.RawMessage  `json:"sdp"`
+	Candidate  json.RawMessage  `json:"candidate"`
+}
+
+// CreateWebhookRequest represents the request body for adding an incoming webhook to a conversation.
+type CreateWebhookRequest struct {
+	Name string `json:"name" binding:"required"`
+}
+
+// IncomingWebhookRequest is the JSON an external service posts to an incoming webhook.
+// Text is accepted in place of Content for senders built for Slack-style webhooks.
+type IncomingWebhookRequest struct {
+	Content string `json:"content"`
+	Text    string `json:"text"`
+}
@@ -0,0 +1,226 @@
+package export
+
+import (
+	"archive/zip"
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"path"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	authRepo "github.com/dk5761/go-serv/internal/domain/auth/repository"
+	"github.com/dk5761/go-serv/internal/domain/chat/models"
+	"github.com/dk5761/go-serv/internal/domain/chat/repository"
+	"github.com/dk5761/go-serv/internal/infrastructure/logging"
+	"github.com/dk5761/go-serv/internal/infrastructure/storage"
+)
+
+// Header describes the conversation at the top of a transcript.
+type Header struct {
+	ConversationID string            `json:"conversation_id"`
+	Participants   map[string]string `json:"participants"` // User ID to username
+	ExportedAt     time.Time         `json:"exported_at"`
+}
+
+// Record is a message as it appears in a transcript. Attachment is the stable URL of the
+// message's file, or its path inside the zip bundle. AttachmentNote explains why a bundle
+// left the file out.
+type Record struct {
+	*models.Message
+	SenderName     string `json:"sender_name,omitempty"`
+	Attachment     string `json:"attachment,omitempty"`
+	AttachmentNote string `json:"attachment_note,omitempty"`
+}
+
+// notStoredNote marks attachments a bundle leaves out because they are not stored by the
+// server; their URLs came from clients and are never fetched
+const notStoredNote = "not included, the file is not stored on this server"
+
+// Writer writes a transcript in one format, one record at a time.
+type Writer interface {
+	Begin(header *Header) error
+	Write(record *Record) error
+	End() error
+}
+
+// NewWriter returns the transcript writer for format.
+func NewWriter(format models.ExportFormat, w io.Writer) (Writer, error) {
+	switch format {
+	case models.ExportJSON:
+		return &jsonWriter{w: w}, nil
+	case models.ExportHTML:
+		return &htmlWriter{w: w}, nil
+	case models.ExportText:
+		return &textWriter{w: w}, nil
+	default:
+		return nil, fmt.Errorf("unknown export format %q", format)
+	}
+}
+
+// ContentType returns the MIME type of an export.
+func ContentType(opts models.ExportOptions) string {
+	if opts.Bundle {
+		return "application/zip"
+	}
+	switch opts.Format {
+	case models.ExportJSON:
+		return "application/json"
+	case models.ExportHTML:
+		return "text/html; charset=utf-8"
+	default:
+		return "text/plain; charset=utf-8"
+	}
+}
+
+// FileName returns the download name of an export.
+func FileName(opts models.ExportOptions) string {
+	if opts.Bundle {
+		return "conversation-" + opts.ConversationID + ".zip"
+	}
+	return "conversation-" + opts.ConversationID + "." + string(opts.Format)
+}
+
+// attachment is a file to add to a zip bundle after the transcript
+type attachment struct {
+	path string
+	url  string
+}
+
+// Exporter streams conversation transcripts from the message store.
+type Exporter struct {
+	msgRepo        repository.MessageRepository
+	userRepo       authRepo.UserRepository
+	storageService storage.StorageService
+}
+
+func NewExporter(msgRepo repository.MessageRepository, userRepo authRepo.UserRepository, storageService storage.StorageService) *Exporter {
+	return &Exporter{
+		msgRepo:        msgRepo,
+		userRepo:       userRepo,
+		storageService: storageService,
+	}
+}
+
+// Export writes the transcript described by opts to w. Messages are read through a cursor
+// and written as they arrive, so memory use does not grow with the conversation. A bundle
+// is a zip archive holding the transcript followed by the attachments read from storage.
+func (e *Exporter) Export(ctx context.Context, w io.Writer, opts models.ExportOptions) error {
+	if !opts.Bundle {
+		_, err := e.writeTranscript(ctx, w, opts)
+		return err
+	}
+
+	archive := zip.NewWriter(w)
+	transcript, err := archive.Create("transcript." + string(opts.Format))
+	if err != nil {
+		return err
+	}
+	attachments, err := e.writeTranscript(ctx, transcript, opts)
+	if err != nil {
+		return err
+	}
+
+	for _, file := range attachments {
+		if err := e.addAttachment(ctx, archive, file); err != nil {
+			return err
+		}
+	}
+	return archive.Close()
+}
+
+// writeTranscript streams the conversation into a transcript writer. In bundle mode it
+// returns the attachments the transcript refers to by their path in the archive.
+func (e *Exporter) writeTranscript(ctx context.Context, w io.Writer, opts models.ExportOptions) ([]attachment, error) {
+	writer, err := NewWriter(opts.Format, w)
+	if err != nil {
+		return nil, err
+	}
+
+	header := &Header{
+		ConversationID: opts.ConversationID,
+		Participants:   e.participantNames(ctx, opts.ConversationID),
+		ExportedAt:     time.Now().UTC(),
+	}
+	if err := writer.Begin(header); err != nil {
+		return nil, err
+	}
+
+	var attachments []attachment
+	err = e.msgRepo.StreamConversation(ctx, opts.ConversationID, func(message *models.Message) error {
+		record := &Record{
+			Message:    message,
+			SenderName: header.Participants[message.SenderID],
+			Attachment: message.FileURL,
+		}
+		if opts.Bundle && message.FileURL != "" {
+			if e.storageService.Stores(message.FileURL) {
+				record.Attachment = attachmentPath(message)
+				attachments = append(attachments, attachment{path: record.Attachment, url: message.FileURL})
+			} else {
+				record.AttachmentNote = notStoredNote
+			}
+		}
+		return writer.Write(record)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return attachments, writer.End()
+}
+
+// participantNames resolves the usernames of both participants, leaving out unknown users
+func (e *Exporter) participantNames(ctx context.Context, conversationID string) map[string]string {
+	names := make(map[string]string, 2)
+	userID1, userID2, _ := models.ConversationParticipants(conversationID)
+	for _, userID := range []string{userID1, userID2} {
+		id, err := uuid.Parse(userID)
+		if err != nil {
+			continue
+		}
+		user, err := e.userRepo.GetUserByID(ctx, id)
+		if err != nil {
+			logging.Logger.Warn("Failed to resolve export participant", zap.String("user_id", userID), zap.Error(err))
+			continue
+		}
+		names[userID] = user.Username
+	}
+	return names
+}
+
+// addAttachment copies a file from storage into the archive. A file that cannot be read is
+// replaced by a note, so that one missing attachment does not fail the whole export.
+func (e *Exporter) addAttachment(ctx context.Context, archive *zip.Writer, file attachment) error {
+	body, err := e.storageService.OpenFile(ctx, file.url)
+	if err != nil {
+		logging.Logger.Warn("Failed to read attachment for export", zap.String("file_url", file.url), zap.Error(err))
+		note, createErr := archive.Create(file.path + ".missing.txt")
+		if createErr != nil {
+			return createErr
+		}
+		_, err = fmt.Fprintf(note, "The attachment could not be read from %s: %v\n", file.url, err)
+		return err
+	}
+	defer body.Close()
+
+	entry, err := archive.Create(file.path)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(entry, body)
+	return err
+}
+
+// attachmentPath names a message's file inside the bundle, prefixed by the message ID so
+// that files with the same name do not collide
+func attachmentPath(message *models.Message) string {
+	name := "file"
+	if parsed, err := url.Parse(message.FileURL); err == nil && path.Base(parsed.Path) != "/" && path.Base(parsed.Path) != "." {
+		name = path.Base(parsed.Path)
+	}
+	return "attachments/" + message.ID.Hex() + "_" + name
+}
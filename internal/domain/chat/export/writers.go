@@ -0,0 +1,157 @@
+package export
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"sort"
+	"time"
+)
+
+const timeLayout = "2006-01-02 15:04:05 MST"
+
+// jsonWriter writes the header fields followed by a messages array, one element per record
+type jsonWriter struct {
+	w     io.Writer
+	count int
+}
+
+func (j *jsonWriter) Begin(header *Header) error {
+	data, err := json.Marshal(header)
+	if err != nil {
+		return err
+	}
+	// Reopen the header object to append the messages array to it
+	_, err = fmt.Fprintf(j.w, "%s,\"messages\":[\n", data[:len(data)-1])
+	return err
+}
+
+func (j *jsonWriter) Write(record *Record) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	if j.count > 0 {
+		if _, err := io.WriteString(j.w, ",\n"); err != nil {
+			return err
+		}
+	}
+	j.count++
+	_, err = j.w.Write(data)
+	return err
+}
+
+func (j *jsonWriter) End() error {
+	_, err := io.WriteString(j.w, "\n]}\n")
+	return err
+}
+
+// textWriter writes one line per message
+type textWriter struct {
+	w io.Writer
+}
+
+func (t *textWriter) Begin(header *Header) error {
+	if _, err := fmt.Fprintf(t.w, "Conversation %s\n", header.ConversationID); err != nil {
+		return err
+	}
+	for _, id := range sortedIDs(header.Participants) {
+		if _, err := fmt.Fprintf(t.w, "Participant: %s (%s)\n", header.Participants[id], id); err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintf(t.w, "Exported at %s\n\n", header.ExportedAt.Format(timeLayout))
+	return err
+}
+
+func (t *textWriter) Write(record *Record) error {
+	if _, err := fmt.Fprintf(t.w, "[%s] %s: %s\n", record.CreatedAt.UTC().Format(timeLayout), senderLabel(record), record.Content); err != nil {
+		return err
+	}
+	if record.Attachment != "" {
+		if _, err := fmt.Fprintf(t.w, "    Attachment: %s\n", record.Attachment); err != nil {
+			return err
+		}
+	}
+	if record.AttachmentNote != "" {
+		if _, err := fmt.Fprintf(t.w, "    Attachment %s\n", record.AttachmentNote); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t *textWriter) End() error {
+	return nil
+}
+
+var (
+	htmlHeader = template.Must(template.New("header").Funcs(templateFuncs).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Conversation {{.ConversationID}}</title>
+<style>
+body { font-family: sans-serif; max-width: 48em; margin: 2em auto; }
+.message { margin: 0.5em 0; }
+.meta { color: #666; font-size: 0.85em; }
+.system { font-style: italic; color: #666; }
+</style>
+</head>
+<body>
+<h1>Conversation {{.ConversationID}}</h1>
+<ul>
+{{range $id, $name := .Participants}}<li>{{$name}} ({{$id}})</li>
+{{end}}</ul>
+<p class="meta">Exported at {{formatTime .ExportedAt}}</p>
+`))
+
+	htmlMessage = template.Must(template.New("message").Funcs(templateFuncs).Parse(`<div class="message{{if eq .Type "system"}} system{{end}}">
+<div class="meta">{{formatTime .CreatedAt}} &middot; {{sender .}}</div>
+<div>{{.Content}}</div>
+{{if .Attachment}}<div><a href="{{.Attachment}}">Attachment</a></div>
+{{end}}{{if .AttachmentNote}}<div class="meta">Attachment {{.AttachmentNote}}</div>
+{{end}}</div>
+`))
+
+	templateFuncs = template.FuncMap{
+		"formatTime": func(t time.Time) string { return t.UTC().Format(timeLayout) },
+		"sender":     senderLabel,
+	}
+)
+
+// htmlWriter writes a standalone page. Templates escape all message content.
+type htmlWriter struct {
+	w io.Writer
+}
+
+func (h *htmlWriter) Begin(header *Header) error {
+	return htmlHeader.Execute(h.w, header)
+}
+
+func (h *htmlWriter) Write(record *Record) error {
+	return htmlMessage.Execute(h.w, record)
+}
+
+func (h *htmlWriter) End() error {
+	_, err := io.WriteString(h.w, "</body>\n</html>\n")
+	return err
+}
+
+// senderLabel names the sender by username, falling back to their ID
+func senderLabel(record *Record) string {
+	if record.SenderName != "" {
+		return record.SenderName
+	}
+	return record.SenderID
+}
+
+func sortedIDs(names map[string]string) []string {
+	ids := make([]string, 0, len(names))
+	for id := range names {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
@@ -0,0 +1,122 @@
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dk5761/go-serv/internal/domain/chat/models"
+)
+
+var (
+	testHeader = &Header{
+		ConversationID: "alice:bob",
+		Participants:   map[string]string{"bob": "Bob", "alice": "Alice"},
+		ExportedAt:     time.Date(2024, time.May, 1, 9, 30, 0, 0, time.UTC),
+	}
+	testRecords = []*Record{
+		{
+			Message:    &models.Message{SenderID: "alice", Content: "Hi <b>Bob</b>", CreatedAt: time.Date(2024, time.April, 30, 8, 0, 0, 0, time.UTC)},
+			SenderName: "Alice",
+		},
+		{
+			Message:        &models.Message{SenderID: "bob", Type: models.ImageMessage, Content: "photo", CreatedAt: time.Date(2024, time.April, 30, 10, 15, 0, 0, time.FixedZone("CEST", 2*3600))},
+			Attachment:     "attachments/photo.jpg",
+			AttachmentNote: notStoredNote,
+		},
+	}
+)
+
+// writeTranscript writes the test transcript in format
+func writeTranscript(t *testing.T, format models.ExportFormat, records []*Record) string {
+	t.Helper()
+	var buf bytes.Buffer
+	w, err := NewWriter(format, &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Begin(testHeader); err != nil {
+		t.Fatal(err)
+	}
+	for _, record := range records {
+		if err := w.Write(record); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.End(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.String()
+}
+
+func TestJSONWriter(t *testing.T) {
+	for _, records := range [][]*Record{nil, testRecords} {
+		var transcript struct {
+			ConversationID string            `json:"conversation_id"`
+			Participants   map[string]string `json:"participants"`
+			Messages       []struct {
+				SenderID   string `json:"sender_id"`
+				SenderName string `json:"sender_name"`
+				Content    string `json:"content"`
+				Attachment string `json:"attachment"`
+			} `json:"messages"`
+		}
+		output := writeTranscript(t, models.ExportJSON, records)
+		if err := json.Unmarshal([]byte(output), &transcript); err != nil {
+			t.Fatalf("invalid JSON with %d records: %v\n%s", len(records), err, output)
+		}
+
+		if transcript.ConversationID != "alice:bob" || len(transcript.Participants) != 2 {
+			t.Errorf("got header %q %v", transcript.ConversationID, transcript.Participants)
+		}
+		if len(transcript.Messages) != len(records) {
+			t.Fatalf("got %d messages, want %d", len(transcript.Messages), len(records))
+		}
+		for i, message := range transcript.Messages {
+			if message.SenderID != records[i].SenderID || message.Content != records[i].Content || message.Attachment != records[i].Attachment {
+				t.Errorf("message %d: got %+v", i, message)
+			}
+		}
+	}
+}
+
+func TestTextWriter(t *testing.T) {
+	want := `Conversation alice:bob
+Participant: Alice (alice)
+Participant: Bob (bob)
+Exported at 2024-05-01 09:30:00 UTC
+
+[2024-04-30 08:00:00 UTC] Alice: Hi <b>Bob</b>
+[2024-04-30 08:15:00 UTC] bob: photo
+    Attachment: attachments/photo.jpg
+    Attachment not included, the file is not stored on this server
+`
+	if got := writeTranscript(t, models.ExportText, testRecords); got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestHTMLWriter(t *testing.T) {
+	got := writeTranscript(t, models.ExportHTML, testRecords)
+
+	for _, want := range []string{
+		"<title>Conversation alice:bob</title>",
+		"<li>Alice (alice)</li>",
+		"2024-04-30 08:00:00 UTC &middot; Alice",
+		"Hi &lt;b&gt;Bob&lt;/b&gt;",
+		"2024-04-30 08:15:00 UTC &middot; bob",
+		`<a href="attachments/photo.jpg">Attachment</a>`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("output does not contain %q:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "<b>Bob</b>") {
+		t.Error("message content was not escaped")
+	}
+	if !strings.HasSuffix(got, "</body>\n</html>\n") {
+		t.Error("page is not closed")
+	}
+}
@@ -0,0 +1,99 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/dk5761/go-serv/internal/domain/chat/dto"
+	"github.com/dk5761/go-serv/internal/domain/chat/models"
+	"github.com/dk5761/go-serv/internal/domain/chat/service"
+	ws "github.com/dk5761/go-serv/internal/domain/chat/websocket"
+	"github.com/dk5761/go-serv/internal/domain/common"
+	"github.com/gin-gonic/gin"
+)
+
+type CallHandler struct {
+	callService service.CallService
+}
+
+// NewCallHandler creates a CallHandler and registers it for call signaling WebSocket frames
+// and for disconnects, which end the calls of the device that dropped.
+func NewCallHandler(callService service.CallService, wsManager *ws.WebSocketManager) *CallHandler {
+	h := &CallHandler{callService}
+	wsManager.RegisterEventHandler(models.EventCallInvite, h.HandleInviteEvent)
+	wsManager.RegisterEventHandler(models.EventCallAnswer, h.HandleAnswerEvent)
+	wsManager.RegisterEventHandler(models.EventICECandidate, h.HandleCandidateEvent)
+	wsManager.RegisterEventHandler(models.EventCallHangup, h.HandleHangupEvent)
+	wsManager.RegisterEventHandler(models.EventCallReject, h.HandleRejectEvent)
+	wsManager.RegisterDisconnectHandler(callService.Disconnected)
+	return h
+}
+
+// GetICEServers lists the STUN and TURN servers clients should use to establish calls
+func (h *CallHandler) GetICEServers(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"ice_servers": h.callService.ICEServers(userID.String())})
+}
+
+// HandleInviteEvent starts a call received as a call_invite WebSocket frame
+func (h *CallHandler) HandleInviteEvent(ctx context.Context, client *models.Client, frame []byte) error {
+	req, err := parseCallSignal(models.EventCallInvite, frame, false)
+	if err != nil {
+		return err
+	}
+	return h.callService.Invite(ctx, client, req.ReceiverID, req.Media, req.TempID, req.SDP)
+}
+
+// HandleAnswerEvent answers a call received as a call_answer WebSocket frame
+func (h *CallHandler) HandleAnswerEvent(ctx context.Context, client *models.Client, frame []byte) error {
+	req, err := parseCallSignal(models.EventCallAnswer, frame, true)
+	if err != nil {
+		return err
+	}
+	return h.callService.Answer(ctx, client, req.CallID, req.SDP)
+}
+
+// HandleCandidateEvent relays an ICE candidate received as an ice_candidate WebSocket frame
+func (h *CallHandler) HandleCandidateEvent(ctx context.Context, client *models.Client, frame []byte) error {
+	req, err := parseCallSignal(models.EventICECandidate, frame, true)
+	if err != nil {
+		return err
+	}
+	return h.callService.RelayCandidate(ctx, client, req.CallID, req.Candidate)
+}
+
+// HandleHangupEvent ends a call received as a call_hangup WebSocket frame
+func (h *CallHandler) HandleHangupEvent(ctx context.Context, client *models.Client, frame []byte) error {
+	req, err := parseCallSignal(models.EventCallHangup, frame, true)
+	if err != nil {
+		return err
+	}
+	return h.callService.Hangup(ctx, client, req.CallID)
+}
+
+// HandleRejectEvent declines a call received as a call_reject WebSocket frame
+func (h *CallHandler) HandleRejectEvent(ctx context.Context, client *models.Client, frame []byte) error {
+	req, err := parseCallSignal(models.EventCallReject, frame, true)
+	if err != nil {
+		return err
+	}
+	return h.callService.Reject(ctx, client, req.CallID)
+}
+
+// parseCallSignal decodes a call signaling frame, requiring a call_id unless it starts a call
+func parseCallSignal(eventType string, frame []byte, needsCallID bool) (*dto.CallSignalRequest, error) {
+	var req dto.CallSignalRequest
+	if err := json.Unmarshal(frame, &req); err != nil {
+		return nil, fmt.Errorf("%w: malformed %s frame", common.ErrInvalidInput, eventType)
+	}
+	if needsCallID && req.CallID == "" {
+		return nil, fmt.Errorf("%w: call_id is required", common.ErrInvalidInput)
+	}
+	return &req, nil
+}
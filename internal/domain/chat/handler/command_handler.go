@@ -0,0 +1,97 @@
+package handler
+
+import (
+	"net/http"
+
+	authModels "github.com/dk5761/go-serv/internal/domain/auth/models"
+	"github.com/dk5761/go-serv/internal/domain/chat/dto"
+	"github.com/dk5761/go-serv/internal/domain/chat/service"
+	"github.com/gin-gonic/gin"
+)
+
+type CommandHandler struct {
+	commandService service.CommandService
+}
+
+func NewCommandHandler(commandService service.CommandService) *CommandHandler {
+	return &CommandHandler{commandService}
+}
+
+// ListCommands returns the slash commands available in the conversation given by the
+// conversation_id query parameter, for autocompletion
+func (h *CommandHandler) ListCommands(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	commands, err := h.commandService.ListCommands(c.Request.Context(), userID.String(), c.Query("conversation_id"))
+	if err != nil {
+		respondError(c, err, "Failed to retrieve commands")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"commands": commands})
+}
+
+// SetBotCommands replaces the slash commands of the authenticated bot
+func (h *CommandHandler) SetBotCommands(c *gin.Context) {
+	botID, ok := currentBotID(c)
+	if !ok {
+		return
+	}
+
+	var req dto.SetBotCommandsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request parameters"})
+		return
+	}
+
+	commands := make([]*authModels.BotCommand, len(req.Commands))
+	for i, cmd := range req.Commands {
+		commands[i] = &authModels.BotCommand{Name: cmd.Name, Usage: cmd.Usage, Description: cmd.Description}
+	}
+
+	saved, err := h.commandService.SetBotCommands(c.Request.Context(), botID, commands)
+	if err != nil {
+		respondError(c, err, "Failed to save commands")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"commands": saved})
+}
+
+// Reply sends the authenticated bot's reply to a command, visible only to the user who ran it
+func (h *CommandHandler) Reply(c *gin.Context) {
+	botID, ok := currentBotID(c)
+	if !ok {
+		return
+	}
+
+	var req dto.CommandReplyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request parameters"})
+		return
+	}
+
+	if err := h.commandService.Reply(c.Request.Context(), botID, req.UserID, req.Command, req.Content); err != nil {
+		respondError(c, err, "Failed to send reply")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "reply sent"})
+}
+
+// currentBotID returns the ID of the bot authenticated with a bot token, writing a
+// Forbidden response for other users
+func currentBotID(c *gin.Context) (string, bool) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return "", false
+	}
+	if !c.GetBool("isBot") {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only bots can use this endpoint"})
+		return "", false
+	}
+	return userID.String(), true
+}
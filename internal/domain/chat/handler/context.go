@@ -0,0 +1,55 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/dk5761/go-serv/internal/domain/common"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// currentUserID returns the authenticated user's ID set by the JWT middleware,
+// writing an Unauthorized response when it is missing.
+func currentUserID(c *gin.Context) (uuid.UUID, bool) {
+	userIDValue, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return uuid.Nil, false
+	}
+	userID, ok := userIDValue.(uuid.UUID)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid user ID"})
+		return uuid.Nil, false
+	}
+	return userID, true
+}
+
+// errorStatus maps domain errors to HTTP status codes.
+func errorStatus(err error) int {
+	switch {
+	case errors.Is(err, common.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, common.ErrUnauthorized):
+		return http.StatusUnauthorized
+	case errors.Is(err, common.ErrForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, common.ErrInvalidInput):
+		return http.StatusBadRequest
+	case errors.Is(err, common.ErrConflict):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// respondError writes err as a JSON error response. Internal errors are replaced by fallback
+// so that storage details never reach the client.
+func respondError(c *gin.Context, err error, fallback string) {
+	status := errorStatus(err)
+	if status == http.StatusInternalServerError {
+		c.JSON(status, gin.H{"error": fallback})
+		return
+	}
+	c.JSON(status, gin.H{"error": err.Error()})
+}
@@ -0,0 +1,55 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/dk5761/go-serv/internal/domain/chat/dto"
+	"github.com/dk5761/go-serv/internal/domain/chat/service"
+	"github.com/gin-gonic/gin"
+)
+
+type ConversationHandler struct {
+	conversationService service.ConversationService
+}
+
+func NewConversationHandler(conversationService service.ConversationService) *ConversationHandler {
+	return &ConversationHandler{conversationService}
+}
+
+// GetConversation returns the settings of a conversation the user takes part in
+func (h *ConversationHandler) GetConversation(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	conversation, err := h.conversationService.GetConversation(c.Request.Context(), c.Param("id"), userID.String())
+	if err != nil {
+		respondError(c, err, "Failed to retrieve conversation")
+		return
+	}
+
+	c.JSON(http.StatusOK, conversation)
+}
+
+// SetMessageTimer changes the disappearing message timer of a conversation
+func (h *ConversationHandler) SetMessageTimer(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	var req dto.SetMessageTimerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request parameters"})
+		return
+	}
+
+	conversation, err := h.conversationService.SetMessageTimer(c.Request.Context(), c.Param("id"), userID.String(), req.Timer)
+	if err != nil {
+		respondError(c, err, "Failed to update message timer")
+		return
+	}
+
+	c.JSON(http.StatusOK, conversation)
+}
@@ -0,0 +1,70 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/dk5761/go-serv/internal/domain/chat/dto"
+	"github.com/dk5761/go-serv/internal/domain/chat/service"
+	"github.com/gin-gonic/gin"
+)
+
+type DeviceHandler struct {
+	deviceService service.DeviceService
+}
+
+func NewDeviceHandler(deviceService service.DeviceService) *DeviceHandler {
+	return &DeviceHandler{deviceService}
+}
+
+// RegisterDevice registers a push token for the user's device
+func (h *DeviceHandler) RegisterDevice(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	var req dto.RegisterDeviceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request parameters"})
+		return
+	}
+
+	device, err := h.deviceService.RegisterDevice(c.Request.Context(), userID.String(), req.Token, req.Platform)
+	if err != nil {
+		respondError(c, err, "Failed to register device")
+		return
+	}
+
+	c.JSON(http.StatusOK, device)
+}
+
+// ListDevices returns the user's registered devices
+func (h *DeviceHandler) ListDevices(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	devices, err := h.deviceService.ListDevices(c.Request.Context(), userID.String())
+	if err != nil {
+		respondError(c, err, "Failed to retrieve devices")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"devices": devices})
+}
+
+// UnregisterDevice removes one of the user's push tokens, e.g. on logout
+func (h *DeviceHandler) UnregisterDevice(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.deviceService.UnregisterDevice(c.Request.Context(), userID.String(), c.Param("token")); err != nil {
+		respondError(c, err, "Failed to unregister device")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "device unregistered"})
+}
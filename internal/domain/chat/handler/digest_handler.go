@@ -0,0 +1,27 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/dk5761/go-serv/internal/domain/chat/service"
+	"github.com/gin-gonic/gin"
+)
+
+type DigestHandler struct {
+	digestService service.DigestService
+}
+
+func NewDigestHandler(digestService service.DigestService) *DigestHandler {
+	return &DigestHandler{digestService}
+}
+
+// Unsubscribe turns email digests off through the signed link in a digest. It is public,
+// and answers both the link itself and mail clients' one-click POST.
+func (h *DigestHandler) Unsubscribe(c *gin.Context) {
+	if err := h.digestService.Unsubscribe(c.Request.Context(), c.Query("user"), c.Query("sig")); err != nil {
+		respondError(c, err, "Failed to unsubscribe")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "unsubscribed from email digests"})
+}
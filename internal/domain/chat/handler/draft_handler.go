@@ -0,0 +1,55 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/dk5761/go-serv/internal/domain/chat/dto"
+	"github.com/dk5761/go-serv/internal/domain/chat/service"
+	"github.com/gin-gonic/gin"
+)
+
+type DraftHandler struct {
+	draftService service.DraftService
+}
+
+func NewDraftHandler(draftService service.DraftService) *DraftHandler {
+	return &DraftHandler{draftService}
+}
+
+// SaveDraft stores the user's draft for a conversation and syncs it to their other devices
+func (h *DraftHandler) SaveDraft(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	var req dto.SaveDraftRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request parameters"})
+		return
+	}
+
+	draft, applied, err := h.draftService.SaveDraft(c.Request.Context(), c.Param("id"), userID.String(), req.Content, req.DeviceID, req.UpdatedAt)
+	if err != nil {
+		respondError(c, err, "Failed to save draft")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"draft": draft, "applied": applied})
+}
+
+// GetDraft returns the user's draft for a conversation
+func (h *DraftHandler) GetDraft(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	draft, err := h.draftService.GetDraft(c.Request.Context(), c.Param("id"), userID.String())
+	if err != nil {
+		respondError(c, err, "Failed to retrieve draft")
+		return
+	}
+
+	c.JSON(http.StatusOK, draft)
+}
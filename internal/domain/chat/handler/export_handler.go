@@ -0,0 +1,82 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/dk5761/go-serv/internal/domain/chat/export"
+	"github.com/dk5761/go-serv/internal/domain/chat/models"
+	"github.com/dk5761/go-serv/internal/domain/chat/service"
+	"github.com/dk5761/go-serv/internal/infrastructure/logging"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+type ExportHandler struct {
+	exportService service.ExportService
+}
+
+func NewExportHandler(exportService service.ExportService) *ExportHandler {
+	return &ExportHandler{exportService}
+}
+
+// ExportConversation streams a transcript of the conversation, or queues a background
+// export for large conversations and when async=true is passed. bundle=zip packs the
+// attachments into a zip archive next to the transcript.
+func (h *ExportHandler) ExportConversation(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	format := c.DefaultQuery("format", string(models.ExportJSON))
+	opts := models.ExportOptions{
+		ConversationID: c.Param("id"),
+		Format:         models.ExportFormat(format),
+		Bundle:         c.Query("bundle") == "zip",
+	}
+
+	job, err := h.exportService.StartExport(c.Request.Context(), userID.String(), opts, c.Query("async") == "true")
+	if err != nil {
+		respondError(c, err, "Failed to export conversation")
+		return
+	}
+	if job != nil {
+		c.JSON(http.StatusAccepted, job)
+		return
+	}
+
+	c.Header("Content-Type", export.ContentType(opts))
+	c.Header("Content-Disposition", `attachment; filename="`+export.FileName(opts)+`"`)
+	c.Status(http.StatusOK)
+
+	if err := h.exportService.StreamExport(c.Request.Context(), c.Writer, opts); err != nil {
+		// The response has started, so the truncated download is all the client gets
+		logging.Logger.Error("Failed to stream conversation export",
+			zap.String("conversation_id", opts.ConversationID),
+			zap.Error(err),
+		)
+	}
+}
+
+// GetExportJob returns the state of a background export
+func (h *ExportHandler) GetExportJob(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid export ID"})
+		return
+	}
+
+	job, err := h.exportService.GetExportJob(c.Request.Context(), id, userID.String())
+	if err != nil {
+		respondError(c, err, "Failed to retrieve export")
+		return
+	}
+
+	c.JSON(http.StatusOK, job)
+}
@@ -25,7 +25,8 @@ var upgrader = websocket.Upgrader{
 	CheckOrigin: func(r *http.Request) bool { return true },
 }
 
-// UploadFile handles file uploads through the ChatService
+// UploadFile handles file uploads through the ChatService. Uploads with the form field
+// type=voice are checked as voice notes and answered with their extracted metadata.
 func (h *ChatHandler) UploadFile(c *gin.Context) {
 	file, header, err := c.Request.FormFile("file")
 	if err != nil {
@@ -34,6 +35,20 @@ func (h *ChatHandler) UploadFile(c *gin.Context) {
 	}
 	defer file.Close()
 
+	if c.PostForm("type") == "voice" {
+		userID, ok := currentUserID(c)
+		if !ok {
+			return
+		}
+		attachment, err := h.chatService.UploadVoice(c.Request.Context(), userID, file, header.Filename, header.Size)
+		if err != nil {
+			respondError(c, err, "Failed to upload voice note")
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"file_url": attachment.FileURL, "voice": attachment.Voice})
+		return
+	}
+
 	// Generate a unique file name or use the original
 	fileName := header.Filename
 
@@ -99,6 +114,12 @@ func (h *ChatHandler) UploadFile(c *gin.Context) {
 //}
 
 func (h *ChatHandler) HandleWebSocket(c *gin.Context) {
+	// The auth middleware authenticated the upgrade and refused suspended users
+	authenticatedID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
 	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to upgrade WebSocket"})
@@ -107,11 +128,12 @@ func (h *ChatHandler) HandleWebSocket(c *gin.Context) {
 
 	fmt.Println("inside HandleWebSocket")
 
-	userID := c.Query("userID")
+	userID := authenticatedID.String()
 	client := &models.Client{
-		ID:     userID,
-		Conn:   conn,
-		SendCh: make(chan *models.Message, 10),
+		ID:       userID,
+		DeviceID: c.Query("deviceID"),
+		Conn:     conn,
+		SendCh:   make(chan interface{}, 10),
 	}
 
 	h.wsManager.AddClient(client)
@@ -137,10 +159,11 @@ func (h *ChatHandler) SendMessage(c *gin.Context) {
 		return
 	}
 	msg.SenderID = senderID.String()
+	msg.ClearServerFields()
 
 	// Call the service to send the message (without file)
 	if err := h.chatService.SendMessage(c.Request.Context(), &msg, nil, ""); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to send message"})
+		respondError(c, err, "Failed to send message")
 		return
 	}
 
@@ -189,3 +212,73 @@ func (h *ChatHandler) GetChatHistory(c *gin.Context) {
 
 	c.JSON(http.StatusOK, messages)
 }
+
+// GetMentions retrieves the messages in which the authenticated user was mentioned
+func (h *ChatHandler) GetMentions(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	// Pagination parameters
+	limit, offset := 20, 0
+	if l := c.Query("limit"); l != "" {
+		fmt.Sscanf(l, "%d", &limit)
+	}
+	if o := c.Query("offset"); o != "" {
+		fmt.Sscanf(o, "%d", &offset)
+	}
+
+	messages, err := h.chatService.GetMentions(c.Request.Context(), userID, limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve mentions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"mentions": messages})
+}
+
+// GetConversationMessages pages through a conversation's history by sequence number.
+//
+// With after_seq, it returns the messages following it in order, which resumes after the
+// last message a client has seen. A gap from seq a to b is filled with after_seq=a-1 and
+// before_seq=b+1. Without after_seq, it returns the newest messages preceding before_seq,
+// which scrolls back through history. Imported history older than the conversation is
+// numbered below 1, so it is reached by scrolling back and may have negative numbers; 0 is
+// never used. Numbers missing from a complete range belong to messages that were deleted,
+// have expired or were never stored.
+func (h *ChatHandler) GetConversationMessages(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	var afterSeq *int64
+	if a := c.Query("after_seq"); a != "" {
+		var after int64
+		if _, err := fmt.Sscanf(a, "%d", &after); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid after_seq"})
+			return
+		}
+		afterSeq = &after
+	}
+	var beforeSeq int64
+	if b := c.Query("before_seq"); b != "" {
+		if _, err := fmt.Sscanf(b, "%d", &beforeSeq); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid before_seq"})
+			return
+		}
+	}
+	limit := 0
+	if l := c.Query("limit"); l != "" {
+		fmt.Sscanf(l, "%d", &limit)
+	}
+
+	page, err := h.chatService.GetConversationMessages(c.Request.Context(), c.Param("id"), userID, afterSeq, beforeSeq, limit)
+	if err != nil {
+		respondError(c, err, "Failed to retrieve messages")
+		return
+	}
+
+	c.JSON(http.StatusOK, page)
+}
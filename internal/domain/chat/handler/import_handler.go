@@ -0,0 +1,50 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/dk5761/go-serv/internal/domain/chat/importer"
+	"github.com/gin-gonic/gin"
+)
+
+type ImportHandler struct {
+	importer *importer.Importer
+}
+
+func NewImportHandler(transcriptImporter *importer.Importer) *ImportHandler {
+	return &ImportHandler{transcriptImporter}
+}
+
+// ImportTranscript imports a JSONL transcript uploaded as the multipart field "transcript".
+// The form also takes the "source" tool name and an optional "handles" JSON object mapping
+// source handles to usernames.
+func (h *ImportHandler) ImportTranscript(c *gin.Context) {
+	file, _, err := c.Request.FormFile("transcript")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to get transcript"})
+		return
+	}
+	defer file.Close()
+
+	opts := importer.Options{Source: c.PostForm("source")}
+	if handles := c.PostForm("handles"); handles != "" {
+		if err := json.Unmarshal([]byte(handles), &opts.Handles); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "handles must be a JSON object of handle to username"})
+			return
+		}
+	}
+
+	result, err := h.importer.Import(c.Request.Context(), file, opts)
+	if err != nil {
+		if result != nil {
+			// Report the progress so far; the import is safe to re-run
+			c.JSON(errorStatus(err), gin.H{"error": "Import stopped before the end of the transcript", "result": result})
+			return
+		}
+		respondError(c, err, "Failed to import transcript")
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,208 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/dk5761/go-serv/internal/domain/chat/dto"
+	"github.com/dk5761/go-serv/internal/domain/chat/models"
+	"github.com/dk5761/go-serv/internal/domain/chat/service"
+	"github.com/gin-gonic/gin"
+)
+
+type InboxHandler struct {
+	inboxService service.InboxService
+}
+
+func NewInboxHandler(inboxService service.InboxService) *InboxHandler {
+	return &InboxHandler{inboxService}
+}
+
+// ListConversations lists the user's conversations, optionally filtered by folder or archive state
+func (h *InboxHandler) ListConversations(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	filter := models.InboxFilter{
+		Folder:   c.Query("folder"),
+		Archived: c.Query("archived") == "true",
+	}
+
+	// Pagination parameters
+	limit, offset := 20, 0
+	if l := c.Query("limit"); l != "" {
+		fmt.Sscanf(l, "%d", &limit)
+	}
+	if o := c.Query("offset"); o != "" {
+		fmt.Sscanf(o, "%d", &offset)
+	}
+
+	conversations, err := h.inboxService.ListConversations(c.Request.Context(), userID.String(), filter, limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve conversations"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"conversations": conversations})
+}
+
+// GetSettings returns the user's settings for a conversation
+func (h *InboxHandler) GetSettings(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	settings, err := h.inboxService.GetSettings(c.Request.Context(), c.Param("id"), userID.String())
+	if err != nil {
+		respondError(c, err, "Failed to retrieve conversation settings")
+		return
+	}
+
+	c.JSON(http.StatusOK, settings)
+}
+
+// Mute silences a conversation until a given time or forever
+func (h *InboxHandler) Mute(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	var req dto.MuteConversationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request parameters"})
+		return
+	}
+
+	settings, err := h.inboxService.Mute(c.Request.Context(), c.Param("id"), userID.String(), req.Until, req.Forever)
+	if err != nil {
+		respondError(c, err, "Failed to mute conversation")
+		return
+	}
+
+	c.JSON(http.StatusOK, settings)
+}
+
+// Unmute restores notifications for a conversation
+func (h *InboxHandler) Unmute(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	settings, err := h.inboxService.Unmute(c.Request.Context(), c.Param("id"), userID.String())
+	if err != nil {
+		respondError(c, err, "Failed to unmute conversation")
+		return
+	}
+
+	c.JSON(http.StatusOK, settings)
+}
+
+// Archive moves a conversation to the user's archive
+func (h *InboxHandler) Archive(c *gin.Context) {
+	h.setArchived(c, true)
+}
+
+// Unarchive moves a conversation back to the user's inbox
+func (h *InboxHandler) Unarchive(c *gin.Context) {
+	h.setArchived(c, false)
+}
+
+func (h *InboxHandler) setArchived(c *gin.Context, archived bool) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	settings, err := h.inboxService.SetArchived(c.Request.Context(), c.Param("id"), userID.String(), archived)
+	if err != nil {
+		respondError(c, err, "Failed to update conversation")
+		return
+	}
+
+	c.JSON(http.StatusOK, settings)
+}
+
+// SetFolders files a conversation under the user's folders
+func (h *InboxHandler) SetFolders(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	var req dto.SetFoldersRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request parameters"})
+		return
+	}
+
+	settings, err := h.inboxService.SetFolders(c.Request.Context(), c.Param("id"), userID.String(), req.Folders)
+	if err != nil {
+		respondError(c, err, "Failed to update folders")
+		return
+	}
+
+	c.JSON(http.StatusOK, settings)
+}
+
+// MarkRead clears the unread count of a conversation
+func (h *InboxHandler) MarkRead(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	settings, err := h.inboxService.MarkRead(c.Request.Context(), c.Param("id"), userID.String())
+	if err != nil {
+		respondError(c, err, "Failed to mark conversation as read")
+		return
+	}
+
+	c.JSON(http.StatusOK, settings)
+}
+
+// GetPreferences returns the user's chat preferences
+func (h *InboxHandler) GetPreferences(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	preferences, err := h.inboxService.GetPreferences(c.Request.Context(), userID.String())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve preferences"})
+		return
+	}
+
+	c.JSON(http.StatusOK, preferences)
+}
+
+// SavePreferences updates the user's chat preferences
+func (h *InboxHandler) SavePreferences(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	var req dto.SavePreferencesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request parameters"})
+		return
+	}
+
+	preferences := &models.UserPreferences{
+		UserID:          userID.String(),
+		KeepArchived:    req.KeepArchived,
+		DigestFrequency: req.DigestFrequency,
+	}
+	if err := h.inboxService.SavePreferences(c.Request.Context(), preferences); err != nil {
+		respondError(c, err, "Failed to save preferences")
+		return
+	}
+
+	c.JSON(http.StatusOK, preferences)
+}
@@ -0,0 +1,38 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/dk5761/go-serv/internal/domain/chat/service"
+	"github.com/gin-gonic/gin"
+)
+
+type ModerationHandler struct {
+	moderationService service.ModerationService
+}
+
+func NewModerationHandler(moderationService service.ModerationService) *ModerationHandler {
+	return &ModerationHandler{moderationService}
+}
+
+// ListRejections lists the messages the moderation pipeline rejected, optionally only
+// those rejected by the stage given in ?stage=
+func (h *ModerationHandler) ListRejections(c *gin.Context) {
+	// Pagination parameters
+	limit, offset := 50, 0
+	if l := c.Query("limit"); l != "" {
+		fmt.Sscanf(l, "%d", &limit)
+	}
+	if o := c.Query("offset"); o != "" {
+		fmt.Sscanf(o, "%d", &offset)
+	}
+
+	records, err := h.moderationService.ListRejections(c.Request.Context(), c.Query("stage"), limit, offset)
+	if err != nil {
+		respondError(c, err, "Failed to retrieve rejected messages")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"rejections": records})
+}
@@ -0,0 +1,116 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/dk5761/go-serv/internal/domain/chat/dto"
+	"github.com/dk5761/go-serv/internal/domain/chat/models"
+	"github.com/dk5761/go-serv/internal/domain/chat/service"
+	ws "github.com/dk5761/go-serv/internal/domain/chat/websocket"
+	"github.com/dk5761/go-serv/internal/domain/common"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type PollHandler struct {
+	pollService service.PollService
+}
+
+// NewPollHandler creates a PollHandler and registers it for poll_vote WebSocket frames.
+func NewPollHandler(pollService service.PollService, wsManager *ws.WebSocketManager) *PollHandler {
+	h := &PollHandler{pollService}
+	wsManager.RegisterEventHandler("poll_vote", h.HandleVoteEvent)
+	return h
+}
+
+// GetPoll returns a poll with its current tally
+func (h *PollHandler) GetPoll(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	pollID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid poll ID"})
+		return
+	}
+
+	poll, err := h.pollService.GetPoll(c.Request.Context(), pollID, userID.String())
+	if err != nil {
+		respondError(c, err, "Failed to retrieve poll")
+		return
+	}
+
+	c.JSON(http.StatusOK, poll)
+}
+
+// Vote casts or replaces the user's vote in a poll
+func (h *PollHandler) Vote(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	pollID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid poll ID"})
+		return
+	}
+
+	var req dto.PollVoteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request parameters"})
+		return
+	}
+
+	results, err := h.pollService.Vote(c.Request.Context(), pollID, userID.String(), req.OptionIDs)
+	if err != nil {
+		respondError(c, err, "Failed to record vote")
+		return
+	}
+
+	c.JSON(http.StatusOK, results)
+}
+
+// ClosePoll closes a poll and freezes its results
+func (h *PollHandler) ClosePoll(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	pollID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid poll ID"})
+		return
+	}
+
+	results, err := h.pollService.ClosePoll(c.Request.Context(), pollID, userID.String())
+	if err != nil {
+		respondError(c, err, "Failed to close poll")
+		return
+	}
+
+	c.JSON(http.StatusOK, results)
+}
+
+// HandleVoteEvent casts a vote received as a poll_vote WebSocket frame. The updated tally
+// reaches the voter through the poll_updated broadcast.
+func (h *PollHandler) HandleVoteEvent(ctx context.Context, client *models.Client, frame []byte) error {
+	var req dto.PollVoteRequest
+	if err := json.Unmarshal(frame, &req); err != nil {
+		return fmt.Errorf("%w: malformed poll_vote frame", common.ErrInvalidInput)
+	}
+
+	pollID, err := primitive.ObjectIDFromHex(req.PollID)
+	if err != nil {
+		return fmt.Errorf("%w: invalid poll_id", common.ErrInvalidInput)
+	}
+
+	_, err = h.pollService.Vote(ctx, pollID, client.ID, req.OptionIDs)
+	return err
+}
@@ -0,0 +1,168 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/dk5761/go-serv/internal/domain/chat/dto"
+	"github.com/dk5761/go-serv/internal/domain/chat/models"
+	"github.com/dk5761/go-serv/internal/domain/chat/service"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type ReportHandler struct {
+	reportService service.ReportService
+}
+
+func NewReportHandler(reportService service.ReportService) *ReportHandler {
+	return &ReportHandler{reportService}
+}
+
+// CreateReport reports a message or a user to the moderators
+func (h *ReportHandler) CreateReport(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	var req dto.CreateReportRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request parameters"})
+		return
+	}
+	if (req.MessageID == "") == (req.UserID == "") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Either message_id or user_id is required"})
+		return
+	}
+
+	var report *models.Report
+	var err error
+	if req.MessageID != "" {
+		messageID, parseErr := primitive.ObjectIDFromHex(req.MessageID)
+		if parseErr != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid message ID"})
+			return
+		}
+		report, err = h.reportService.ReportMessage(c.Request.Context(), userID.String(), messageID, req.Reason, req.Details)
+	} else {
+		report, err = h.reportService.ReportUser(c.Request.Context(), userID.String(), req.UserID, req.Reason, req.Details)
+	}
+	if err != nil {
+		respondError(c, err, "Failed to file report")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"id": report.ID, "status": report.Status})
+}
+
+// ListOwnReports lists the reports the user filed and their outcome
+func (h *ReportHandler) ListOwnReports(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	limit, offset := reportPagination(c)
+	reports, err := h.reportService.ListOwnReports(c.Request.Context(), userID.String(), limit, offset)
+	if err != nil {
+		respondError(c, err, "Failed to retrieve reports")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"reports": reports})
+}
+
+// ListReports lists the moderator queue, optionally filtered by ?status=
+func (h *ReportHandler) ListReports(c *gin.Context) {
+	limit, offset := reportPagination(c)
+	reports, err := h.reportService.ListReports(c.Request.Context(), models.ReportStatus(c.Query("status")), limit, offset)
+	if err != nil {
+		respondError(c, err, "Failed to retrieve reports")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"reports": reports})
+}
+
+// GetReport returns a report with its snapshot
+func (h *ReportHandler) GetReport(c *gin.Context) {
+	reportID, ok := reportIDParam(c)
+	if !ok {
+		return
+	}
+
+	report, err := h.reportService.GetReport(c.Request.Context(), reportID)
+	if err != nil {
+		respondError(c, err, "Failed to retrieve report")
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// ClaimReport assigns a report to the calling moderator
+func (h *ReportHandler) ClaimReport(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	reportID, ok := reportIDParam(c)
+	if !ok {
+		return
+	}
+
+	report, err := h.reportService.ClaimReport(c.Request.Context(), reportID, userID.String())
+	if err != nil {
+		respondError(c, err, "Failed to claim report")
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// ResolveReport resolves a report the calling moderator claimed
+func (h *ReportHandler) ResolveReport(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	reportID, ok := reportIDParam(c)
+	if !ok {
+		return
+	}
+
+	var req dto.ResolveReportRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request parameters"})
+		return
+	}
+
+	report, err := h.reportService.ResolveReport(c.Request.Context(), reportID, userID.String(), req.Action, req.Note, req.SuspendDays)
+	if err != nil {
+		respondError(c, err, "Failed to resolve report")
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+func reportIDParam(c *gin.Context) (primitive.ObjectID, bool) {
+	reportID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid report ID"})
+		return primitive.NilObjectID, false
+	}
+	return reportID, true
+}
+
+func reportPagination(c *gin.Context) (int, int) {
+	limit, offset := 20, 0
+	if l := c.Query("limit"); l != "" {
+		fmt.Sscanf(l, "%d", &limit)
+	}
+	if o := c.Query("offset"); o != "" {
+		fmt.Sscanf(o, "%d", &offset)
+	}
+	return limit, offset
+}
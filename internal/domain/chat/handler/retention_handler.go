@@ -0,0 +1,53 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/dk5761/go-serv/internal/domain/chat/dto"
+	"github.com/dk5761/go-serv/internal/domain/chat/models"
+	"github.com/dk5761/go-serv/internal/domain/chat/service"
+	"github.com/gin-gonic/gin"
+)
+
+type RetentionHandler struct {
+	retentionService service.RetentionService
+}
+
+func NewRetentionHandler(retentionService service.RetentionService) *RetentionHandler {
+	return &RetentionHandler{retentionService}
+}
+
+// SetRetention changes the retention policy of a conversation. The global policy still
+// applies where it is stricter.
+func (h *RetentionHandler) SetRetention(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	var req dto.SetRetentionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request parameters"})
+		return
+	}
+
+	policy := models.RetentionPolicy{MaxAgeDays: req.MaxAgeDays, KeepLast: req.KeepLast}
+	conversation, err := h.retentionService.SetConversationRetention(c.Request.Context(), c.Param("id"), userID.String(), policy)
+	if err != nil {
+		respondError(c, err, "Failed to update retention policy")
+		return
+	}
+
+	c.JSON(http.StatusOK, conversation)
+}
+
+// PreviewPurge reports what the next purge would delete without deleting anything
+func (h *RetentionHandler) PreviewPurge(c *gin.Context) {
+	report, err := h.retentionService.Purge(c.Request.Context(), true)
+	if err != nil {
+		respondError(c, err, "Failed to preview purge")
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
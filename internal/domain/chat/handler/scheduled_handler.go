@@ -0,0 +1,112 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/dk5761/go-serv/internal/domain/chat/dto"
+	"github.com/dk5761/go-serv/internal/domain/chat/models"
+	"github.com/dk5761/go-serv/internal/domain/chat/service"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type ScheduledHandler struct {
+	scheduledService service.ScheduledService
+}
+
+func NewScheduledHandler(scheduledService service.ScheduledService) *ScheduledHandler {
+	return &ScheduledHandler{scheduledService}
+}
+
+// ScheduleMessage stores a message to be sent at a future time
+func (h *ScheduledHandler) ScheduleMessage(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	var req dto.ScheduleMessageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request parameters"})
+		return
+	}
+
+	msg := &models.ScheduledMessage{
+		SenderID:   userID.String(),
+		ReceiverID: req.ReceiverID,
+		Content:    req.Content,
+		FileURL:    req.FileURL,
+		SendAt:     req.SendAt,
+	}
+	if err := h.scheduledService.ScheduleMessage(c.Request.Context(), msg); err != nil {
+		respondError(c, err, "Failed to schedule message")
+		return
+	}
+
+	c.JSON(http.StatusCreated, msg)
+}
+
+// ListScheduledMessages returns the user's pending scheduled messages
+func (h *ScheduledHandler) ListScheduledMessages(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	messages, err := h.scheduledService.ListScheduledMessages(c.Request.Context(), userID.String())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve scheduled messages"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"scheduled_messages": messages})
+}
+
+// UpdateScheduledMessage edits the content or send time of a pending scheduled message
+func (h *ScheduledHandler) UpdateScheduledMessage(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid scheduled message ID"})
+		return
+	}
+
+	var req dto.UpdateScheduledMessageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request parameters"})
+		return
+	}
+
+	msg, err := h.scheduledService.UpdateScheduledMessage(c.Request.Context(), id, userID.String(), req.Content, req.SendAt)
+	if err != nil {
+		respondError(c, err, "Failed to update scheduled message")
+		return
+	}
+
+	c.JSON(http.StatusOK, msg)
+}
+
+// CancelScheduledMessage cancels a pending scheduled message
+func (h *ScheduledHandler) CancelScheduledMessage(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	id, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid scheduled message ID"})
+		return
+	}
+
+	if err := h.scheduledService.CancelScheduledMessage(c.Request.Context(), id, userID.String()); err != nil {
+		respondError(c, err, "Failed to cancel scheduled message")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "scheduled message canceled"})
+}
@@ -0,0 +1,162 @@
+package handler
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/dk5761/go-serv/internal/domain/chat/dto"
+	"github.com/dk5761/go-serv/internal/domain/chat/service"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const maxWebhookBodySize = 64 << 10 // 64 KB
+
+type WebhookHandler struct {
+	webhookService service.WebhookService
+}
+
+func NewWebhookHandler(webhookService service.WebhookService) *WebhookHandler {
+	return &WebhookHandler{webhookService}
+}
+
+// CreateWebhook adds an incoming webhook to the conversation in the path. The token is
+// only ever returned here and when it is regenerated.
+func (h *WebhookHandler) CreateWebhook(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	var req dto.CreateWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request parameters"})
+		return
+	}
+
+	credentials, err := h.webhookService.CreateWebhook(c.Request.Context(), c.Param("id"), userID, req.Name)
+	if err != nil {
+		respondError(c, err, "Failed to create webhook")
+		return
+	}
+
+	c.JSON(http.StatusCreated, credentials)
+}
+
+// ListWebhooks lists the incoming webhooks of the conversation in the path with their
+// usage and error counters
+func (h *WebhookHandler) ListWebhooks(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	webhooks, err := h.webhookService.ListWebhooks(c.Request.Context(), c.Param("id"), userID)
+	if err != nil {
+		respondError(c, err, "Failed to retrieve webhooks")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"webhooks": webhooks})
+}
+
+// RegenerateToken issues a new token for a webhook, invalidating the previous one
+func (h *WebhookHandler) RegenerateToken(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	webhookID, ok := webhookParam(c)
+	if !ok {
+		return
+	}
+
+	credentials, err := h.webhookService.RegenerateToken(c.Request.Context(), webhookID, userID)
+	if err != nil {
+		respondError(c, err, "Failed to regenerate webhook token")
+		return
+	}
+
+	c.JSON(http.StatusOK, credentials)
+}
+
+// RevokeToken disables a webhook until its token is regenerated
+func (h *WebhookHandler) RevokeToken(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	webhookID, ok := webhookParam(c)
+	if !ok {
+		return
+	}
+
+	webhook, err := h.webhookService.RevokeToken(c.Request.Context(), webhookID, userID)
+	if err != nil {
+		respondError(c, err, "Failed to revoke webhook token")
+		return
+	}
+
+	c.JSON(http.StatusOK, webhook)
+}
+
+// DeleteWebhook removes a webhook
+func (h *WebhookHandler) DeleteWebhook(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	webhookID, ok := webhookParam(c)
+	if !ok {
+		return
+	}
+
+	if err := h.webhookService.DeleteWebhook(c.Request.Context(), webhookID, userID); err != nil {
+		respondError(c, err, "Failed to delete webhook")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "webhook deleted"})
+}
+
+// Post creates a message from the JSON posted to the webhook whose token is in the path.
+// It is public; the token authenticates the request.
+func (h *WebhookHandler) Post(c *gin.Context) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxWebhookBodySize)
+
+	var req dto.IncomingWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+	content := req.Content
+	if content == "" {
+		content = req.Text
+	}
+
+	message, err := h.webhookService.Post(c.Request.Context(), c.Param("token"), content)
+	var rateLimited *service.RateLimitedError
+	if errors.As(err, &rateLimited) {
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(rateLimited.RetryAfter.Seconds()))))
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		respondError(c, err, "Failed to post message")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message_id": message.ID.Hex()})
+}
+
+// webhookParam parses the webhook ID in the path
+func webhookParam(c *gin.Context) (primitive.ObjectID, bool) {
+	webhookID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid webhook ID"})
+		return primitive.NilObjectID, false
+	}
+	return webhookID, true
+}
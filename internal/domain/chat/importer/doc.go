@@ -0,0 +1,34 @@
+// Package importer loads chat history exported from other tools into the messages collection.
+//
+// # Transcript format
+//
+// A transcript is a JSONL file: one JSON object per line, each describing one message.
+// Blank lines are ignored.
+//
+//	{"id": "1699999999.000100", "from": "U024BE7LH", "to": "U0G9QF9C6", "text": "Hi!", "timestamp": "2023-11-14T22:13:20Z"}
+//	{"id": "1699999999.000200", "from": "U0G9QF9C6", "to": "U024BE7LH", "text": "", "file_url": "https://files.example.com/report.pdf", "timestamp": "2023-11-14T22:14:02Z", "read": true}
+//
+// Fields:
+//
+//	id         required  Identifier of the message in the source tool, unique within the source.
+//	from       required  Handle of the sender in the source tool.
+//	to         required  Handle of the receiver in the source tool.
+//	text       optional  Message text. Either text or file_url must be set.
+//	file_url   optional  Stable URL of an attachment.
+//	timestamp  required  When the message was sent, in RFC 3339 format. It is kept as the message's created_at.
+//	read       optional  Whether the receiver read the message. Defaults to false.
+//
+// Every import names its source, e.g. "slack". The source and id together identify an
+// imported message, so running the same import again skips the messages that are already
+// there and only inserts the ones a previous, partially failed run missed.
+//
+// # Handles
+//
+// Handles are mapped to existing users through an optional handle map from handle to
+// username. Handles missing from the map are looked up as usernames directly. Lines whose
+// handles do not resolve to a user are reported and skipped; they are never imported
+// under a placeholder user.
+//
+// Imported messages are historical: they are stored as delivered at their timestamp and are
+// never pushed to connected clients.
+package importer
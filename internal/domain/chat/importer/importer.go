@@ -0,0 +1,249 @@
+package importer
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	authRepo "github.com/dk5761/go-serv/internal/domain/auth/repository"
+	"github.com/dk5761/go-serv/internal/domain/chat/models"
+	"github.com/dk5761/go-serv/internal/domain/chat/repository"
+	"github.com/dk5761/go-serv/internal/domain/common"
+	"github.com/dk5761/go-serv/internal/infrastructure/logging"
+)
+
+const (
+	batchSize = 500
+
+	// maxLineSize bounds a single transcript line
+	maxLineSize = 1 << 20
+
+	// maxReportedErrors bounds the line errors kept in a Result
+	maxReportedErrors = 100
+)
+
+// Line is one message of a transcript.
+type Line struct {
+	ID        string    `json:"id"`
+	From      string    `json:"from"`
+	To        string    `json:"to"`
+	Text      string    `json:"text"`
+	FileURL   string    `json:"file_url"`
+	Timestamp time.Time `json:"timestamp"`
+	Read      bool      `json:"read"`
+}
+
+// Options configures an import.
+type Options struct {
+	Source  string            // Name of the tool the transcript comes from
+	Handles map[string]string // Handle in the source tool to username
+}
+
+// LineError reports a transcript line that was not imported.
+type LineError struct {
+	Line  int    `json:"line"`
+	Error string `json:"error"`
+}
+
+// Result summarizes an import.
+type Result struct {
+	Inserted int         `json:"inserted"`
+	Skipped  int         `json:"skipped"` // Already imported by an earlier run
+	Failed   int         `json:"failed"`
+	Errors   []LineError `json:"errors,omitempty"` // The first failed lines
+}
+
+func (r *Result) fail(line int, err error) {
+	r.Failed++
+	if len(r.Errors) < maxReportedErrors {
+		r.Errors = append(r.Errors, LineError{Line: line, Error: err.Error()})
+	}
+}
+
+// Importer imports JSONL transcripts.
+type Importer struct {
+	msgRepo      repository.MessageRepository
+	settingsRepo repository.ConversationSettingsRepository
+	userRepo     authRepo.UserRepository
+}
+
+func NewImporter(msgRepo repository.MessageRepository, settingsRepo repository.ConversationSettingsRepository, userRepo authRepo.UserRepository) *Importer {
+	return &Importer{msgRepo: msgRepo, settingsRepo: settingsRepo, userRepo: userRepo}
+}
+
+// Import reads a transcript from r and inserts its messages in batches. Invalid lines are
+// reported in the result and do not stop the import; a database error does, and the import
+// can then be re-run from the start.
+func (i *Importer) Import(ctx context.Context, r io.Reader, opts Options) (*Result, error) {
+	opts.Source = strings.TrimSpace(opts.Source)
+	if opts.Source == "" {
+		return nil, fmt.Errorf("%w: source is required", common.ErrInvalidInput)
+	}
+
+	result := &Result{}
+	resolver := &handleResolver{userRepo: i.userRepo, handles: opts.Handles, cache: make(map[string]string)}
+	latest := make(map[string]*models.Message) // Newest inserted message per conversation
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxLineSize)
+
+	batch := make([]*models.Message, 0, batchSize)
+	flush := func() error {
+		inserted, err := i.msgRepo.InsertImportedMessages(ctx, batch)
+		if err != nil {
+			return err
+		}
+		result.Inserted += len(inserted)
+		result.Skipped += len(batch) - len(inserted)
+		for _, message := range inserted {
+			if newest := latest[message.ConversationID]; newest == nil || message.CreatedAt.After(newest.CreatedAt) {
+				latest[message.ConversationID] = message
+			}
+		}
+		batch = batch[:0]
+		return nil
+	}
+
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		raw := strings.TrimSpace(scanner.Text())
+		if raw == "" {
+			continue
+		}
+
+		message, err := i.parseLine(ctx, raw, opts.Source, resolver)
+		if err != nil {
+			if errors.Is(err, common.ErrInvalidInput) {
+				result.fail(lineNumber, err)
+				continue
+			}
+			return result, err
+		}
+
+		batch = append(batch, message)
+		if len(batch) == batchSize {
+			if err := flush(); err != nil {
+				return result, err
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return result, fmt.Errorf("%w: failed to read line %d: %v", common.ErrInvalidInput, lineNumber+1, err)
+	}
+	if err := flush(); err != nil {
+		return result, err
+	}
+
+	i.updateInboxes(ctx, latest)
+	return result, nil
+}
+
+// parseLine turns a transcript line into a message ready to insert
+func (i *Importer) parseLine(ctx context.Context, raw, source string, resolver *handleResolver) (*models.Message, error) {
+	var line Line
+	if err := json.Unmarshal([]byte(raw), &line); err != nil {
+		return nil, fmt.Errorf("%w: invalid JSON: %v", common.ErrInvalidInput, err)
+	}
+	switch {
+	case line.ID == "":
+		return nil, fmt.Errorf("%w: id is required", common.ErrInvalidInput)
+	case line.From == "" || line.To == "":
+		return nil, fmt.Errorf("%w: from and to are required", common.ErrInvalidInput)
+	case line.Timestamp.IsZero():
+		return nil, fmt.Errorf("%w: timestamp is required", common.ErrInvalidInput)
+	}
+
+	senderID, err := resolver.resolve(ctx, line.From)
+	if err != nil {
+		return nil, err
+	}
+	receiverID, err := resolver.resolve(ctx, line.To)
+	if err != nil {
+		return nil, err
+	}
+	if senderID == receiverID {
+		return nil, fmt.Errorf("%w: from and to resolve to the same user", common.ErrInvalidInput)
+	}
+
+	status, readAt := models.Received, time.Time{}
+	if line.Read {
+		status, readAt = models.Read, line.Timestamp
+	}
+	message := &models.Message{
+		EventType:      "receive_message",
+		ConversationID: models.ConversationID(senderID, receiverID),
+		Type:           models.TextMessage,
+		SenderID:       senderID,
+		ReceiverID:     receiverID,
+		Content:        line.Text,
+		FileURL:        line.FileURL,
+		CreatedAt:      line.Timestamp,
+		Delivered:      true,
+		DeliveredAt:    line.Timestamp,
+		Status:         status,
+		SentAt:         line.Timestamp,
+		ReceivedAt:     line.Timestamp,
+		ReadAt:         readAt,
+		ImportKey:      source + ":" + line.ID,
+	}
+	if err := message.Validate(); err != nil {
+		return nil, err
+	}
+	return message, nil
+}
+
+// updateInboxes lists the imported conversations in both participants' inboxes
+func (i *Importer) updateInboxes(ctx context.Context, latest map[string]*models.Message) {
+	for _, message := range latest {
+		for _, userID := range []string{message.SenderID, message.ReceiverID} {
+			if _, err := i.settingsRepo.RecordMessage(ctx, userID, message, false); err != nil {
+				logging.Logger.Error("Failed to update inbox after import",
+					zap.String("user_id", userID),
+					zap.String("conversation_id", message.ConversationID),
+					zap.Error(err),
+				)
+			}
+		}
+	}
+}
+
+// handleResolver maps source handles to user IDs, remembering earlier lookups
+type handleResolver struct {
+	userRepo authRepo.UserRepository
+	handles  map[string]string
+	cache    map[string]string
+}
+
+func (r *handleResolver) resolve(ctx context.Context, handle string) (string, error) {
+	if userID, ok := r.cache[handle]; ok {
+		if userID == "" {
+			return "", fmt.Errorf("%w: unknown handle %q", common.ErrInvalidInput, handle)
+		}
+		return userID, nil
+	}
+
+	username := handle
+	if mapped, ok := r.handles[handle]; ok {
+		username = mapped
+	}
+
+	user, err := r.userRepo.GetUserByUsername(ctx, username)
+	if errors.Is(err, common.ErrNotFound) {
+		r.cache[handle] = ""
+		return "", fmt.Errorf("%w: unknown handle %q", common.ErrInvalidInput, handle)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	r.cache[handle] = user.ID.String()
+	return user.ID.String(), nil
+}
@@ -0,0 +1,113 @@
+package mention
+
+import (
+	"context"
+	"unicode"
+	"unicode/utf8"
+
+	"go.uber.org/zap"
+
+	authRepo "github.com/dk5761/go-serv/internal/domain/auth/repository"
+	"github.com/dk5761/go-serv/internal/domain/chat/models"
+	"github.com/dk5761/go-serv/internal/infrastructure/logging"
+)
+
+// MaxUsernames caps how many distinct usernames are resolved per message
+const MaxUsernames = 50
+
+// Token is an @username occurrence in message content. Offset and Length count runes
+// and cover the leading @.
+type Token struct {
+	Username string
+	Offset   int
+	Length   int
+}
+
+// Parse finds @username tokens in content. A token starts at the beginning of the text or after
+// a character that cannot be part of a username, so e-mail addresses are not picked up.
+func Parse(content string) []Token {
+	var tokens []Token
+	runes := []rune(content)
+
+	for i := 0; i < len(runes); i++ {
+		if runes[i] != '@' || (i > 0 && isUsernameRune(runes[i-1])) {
+			continue
+		}
+
+		end := i + 1
+		for end < len(runes) && isUsernameRune(runes[end]) {
+			end++
+		}
+		// Trailing dots and dashes are punctuation, as in "thanks @bob."
+		for end > i+1 && (runes[end-1] == '.' || runes[end-1] == '-') {
+			end--
+		}
+		if end == i+1 {
+			continue
+		}
+
+		tokens = append(tokens, Token{
+			Username: string(runes[i+1 : end]),
+			Offset:   i,
+			Length:   end - i,
+		})
+		i = end - 1
+	}
+
+	return tokens
+}
+
+func isUsernameRune(r rune) bool {
+	return r == '_' || r == '.' || r == '-' || (r < utf8.RuneSelf && (unicode.IsLetter(r) || unicode.IsDigit(r)))
+}
+
+// Resolver turns @username tokens into mention entities for existing users.
+type Resolver struct {
+	userRepo authRepo.UserRepository
+}
+
+func NewResolver(userRepo authRepo.UserRepository) *Resolver {
+	return &Resolver{userRepo: userRepo}
+}
+
+// Resolve returns a mention entity for every token naming an existing user. Unknown
+// usernames are left as plain text, and so are usernames past the first MaxUsernames
+// distinct ones, which are all looked up in a single query.
+func (r *Resolver) Resolve(ctx context.Context, content string) []models.Mention {
+	tokens := Parse(content)
+	if len(tokens) == 0 {
+		return nil
+	}
+
+	var usernames []string
+	seen := make(map[string]bool)
+	for _, token := range tokens {
+		if !seen[token.Username] && len(usernames) < MaxUsernames {
+			seen[token.Username] = true
+			usernames = append(usernames, token.Username)
+		}
+	}
+
+	userIDs, err := r.userRepo.GetUserIDsByUsernames(ctx, usernames)
+	if err != nil {
+		logging.Logger.Error("Failed to resolve mentions", zap.Int("usernames", len(usernames)), zap.Error(err))
+		return nil
+	}
+
+	var mentions []models.Mention
+	for _, token := range tokens {
+		userID, ok := userIDs[token.Username]
+		if !ok {
+			continue
+		}
+
+		mentions = append(mentions, models.Mention{
+			UserID:   userID.String(),
+			Username: token.Username,
+			Offset:   token.Offset,
+			Length:   token.Length,
+		})
+	}
+
+	return mentions
+}
@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+// Attachment is a file a user uploaded together with the metadata the server extracted
+// from it. Messages that reference the file by FileURL get their payload from here rather
+// than from the client.
+type Attachment struct {
+	FileURL   string        `bson:"_id" json:"file_url"`
+	OwnerID   string        `bson:"owner_id" json:"owner_id"`
+	Voice     *VoicePayload `bson:"voice,omitempty" json:"voice,omitempty"`
+	CreatedAt time.Time     `bson:"created_at" json:"created_at"`
+}
@@ -0,0 +1,27 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ActorSystem is the actor of audit entries recorded by background jobs.
+const ActorSystem = "system"
+
+// Audit actions
+const (
+	AuditRetentionPurge = "retention_purge"
+	AuditReportClaimed  = "report_claimed"
+	AuditReportResolved = "report_resolved"
+)
+
+// AuditEntry records a destructive or administrative action for later review.
+type AuditEntry struct {
+	ID        primitive.ObjectID     `bson:"_id,omitempty" json:"id"`
+	Action    string                 `bson:"action" json:"action"`
+	ActorID   string                 `bson:"actor_id" json:"actor_id"`
+	TargetID  string                 `bson:"target_id,omitempty" json:"target_id,omitempty"`
+	Details   map[string]interface{} `bson:"details,omitempty" json:"details,omitempty"`
+	CreatedAt time.Time              `bson:"created_at" json:"created_at"`
+}
@@ -0,0 +1,78 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// CallMessage records a finished one-to-one call in the conversation's history. It is
+// created by the server when the call ends and cannot be sent by clients.
+const CallMessage MessageType = "call"
+
+// CallMedia is what a call carries besides audio.
+type CallMedia string
+
+const (
+	CallAudio CallMedia = "audio"
+	CallVideo CallMedia = "video"
+)
+
+// Valid reports whether m is a known call media type.
+func (m CallMedia) Valid() bool {
+	return m == CallAudio || m == CallVideo
+}
+
+// CallOutcome is how a call ended, as recorded in its call message.
+type CallOutcome string
+
+const (
+	CallCompleted CallOutcome = "completed" // Answered, then hung up by either side
+	CallMissed    CallOutcome = "missed"    // Rang until the timeout, or the callee was offline
+	CallRejected  CallOutcome = "rejected"  // Declined by the callee
+	CallCancelled CallOutcome = "cancelled" // Hung up by the caller before it was answered
+	CallBusy      CallOutcome = "busy"      // The callee was already in another call
+)
+
+// Reasons carried by call_hangup events
+const (
+	CallReasonHangup            = "hangup"             // The other participant hung up
+	CallReasonTimeout           = "timeout"            // Nobody answered before the ring timeout
+	CallReasonBusy              = "busy"               // The callee is in another call
+	CallReasonUnavailable       = "unavailable"        // The callee has no connected device
+	CallReasonDisconnected      = "disconnected"       // The other participant's device lost its connection
+	CallReasonAnsweredElsewhere = "answered_elsewhere" // Another device of the callee answered
+)
+
+// CallPayload is the record of a call. Duration counts from the answer to the hangup and
+// is zero for calls that were never answered.
+type CallPayload struct {
+	CallID     string      `bson:"call_id" json:"call_id"`
+	Media      CallMedia   `bson:"media" json:"media"`
+	Outcome    CallOutcome `bson:"outcome" json:"outcome"`
+	StartedAt  time.Time   `bson:"started_at" json:"started_at"`
+	AnsweredAt time.Time   `bson:"answered_at,omitempty" json:"answered_at,omitempty"`
+	EndedAt    time.Time   `bson:"ended_at" json:"ended_at"`
+	Duration   int64       `bson:"duration" json:"duration"` // in seconds
+}
+
+// CallSignalData is the payload of the call signaling events relayed between the
+// participants of a call. SDP and Candidate are opaque to the server.
+type CallSignalData struct {
+	CallID         string          `json:"call_id"`
+	ConversationID string          `json:"conversation_id,omitempty"`
+	CallerID       string          `json:"caller_id,omitempty"`
+	CalleeID       string          `json:"callee_id,omitempty"`
+	Media          CallMedia       `json:"media,omitempty"`
+	TempID         string          `json:"temp_id,omitempty"` // Echoes the caller's ID for its call_invite
+	SDP            json.RawMessage `json:"sdp,omitempty"`
+	Candidate      json.RawMessage `json:"candidate,omitempty"`
+	Reason         string          `json:"reason,omitempty"`
+}
+
+// ICEServer is a STUN or TURN server handed to clients for establishing calls, in the
+// shape of WebRTC's RTCIceServer.
+type ICEServer struct {
+	URLs       []string `json:"urls"`
+	Username   string   `json:"username,omitempty"`
+	Credential string   `json:"credential,omitempty"`
+}
@@ -6,9 +6,10 @@ import (
 )
 
 type Client struct {
-	ID     string
-	Conn   *websocket.Conn
-	SendCh chan *Message
+	ID       string
+	DeviceID string // Optional, lets a device skip echoes of its own changes
+	Conn     *websocket.Conn
+	SendCh   chan interface{} // Carries *Message and *Event frames
 }
 
 func (c *Client) Listen() {
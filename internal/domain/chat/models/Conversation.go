@@ -0,0 +1,52 @@
+package models
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+const conversationIDSeparator = "_"
+
+// Conversation holds settings shared by both participants of a one-to-one chat.
+type Conversation struct {
+	ID           string           `bson:"_id" json:"id"`
+	Participants []string         `bson:"participants" json:"participants"`
+	MessageTTL   int64            `bson:"message_ttl" json:"message_ttl"`                 // in seconds, 0 disables disappearing messages
+	Retention    *RetentionPolicy `bson:"retention,omitempty" json:"retention,omitempty"` // Combined with the global policy, the stricter limit wins
+	UpdatedBy    string           `bson:"updated_by,omitempty" json:"updated_by,omitempty"`
+	UpdatedAt    time.Time        `bson:"updated_at" json:"updated_at"`
+}
+
+// ConversationID returns the stable ID of the conversation between two users,
+// independent of which one is the sender.
+func ConversationID(userID1, userID2 string) string {
+	ids := []string{userID1, userID2}
+	sort.Strings(ids)
+	return strings.Join(ids, conversationIDSeparator)
+}
+
+// ConversationParticipants splits a conversation ID back into its two user IDs.
+func ConversationParticipants(conversationID string) (string, string, bool) {
+	parts := strings.Split(conversationID, conversationIDSeparator)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", false
+	}
+	return parts[0], parts[1], true
+}
+
+// ConversationPeer returns the other participant of the conversation, or false
+// when userID is not one of its participants.
+func ConversationPeer(conversationID, userID string) (string, bool) {
+	a, b, ok := ConversationParticipants(conversationID)
+	switch {
+	case !ok:
+		return "", false
+	case a == userID:
+		return b, true
+	case b == userID:
+		return a, true
+	default:
+		return "", false
+	}
+}
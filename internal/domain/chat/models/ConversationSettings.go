@@ -0,0 +1,89 @@
+package models
+
+import "time"
+
+// ConversationSettings is one user's view of a conversation in their inbox: how it is
+// organized and muted, and what the user has not read yet. The peer has their own settings.
+type ConversationSettings struct {
+	ID             string     `bson:"_id" json:"-"`
+	UserID         string     `bson:"user_id" json:"user_id"`
+	ConversationID string     `bson:"conversation_id" json:"conversation_id"`
+	MutedUntil     *time.Time `bson:"muted_until,omitempty" json:"muted_until,omitempty"`
+	MutedForever   bool       `bson:"muted_forever" json:"muted_forever"`
+	Archived       bool       `bson:"archived" json:"archived"`
+	Folders        []string   `bson:"folders" json:"folders"`
+	UnreadCount    int        `bson:"unread_count" json:"unread_count"`
+	LastMessageID  string     `bson:"last_message_id,omitempty" json:"last_message_id,omitempty"`
+	LastMessageAt  time.Time  `bson:"last_message_at,omitempty" json:"last_message_at,omitempty"`
+	LastMessageSeq int64      `bson:"last_message_seq,omitempty" json:"last_message_seq,omitempty"`
+	UpdatedAt      time.Time  `bson:"updated_at" json:"updated_at"`
+
+	// Badge is the unread count shown to the user, which is hidden while the conversation is muted
+	Badge int `bson:"-" json:"badge"`
+}
+
+// ConversationSettingsID returns the ID of a user's settings for a conversation.
+func ConversationSettingsID(userID, conversationID string) string {
+	return userID + ":" + conversationID
+}
+
+// IsMuted reports whether notifications for the conversation are silenced at the given time.
+func (s *ConversationSettings) IsMuted(now time.Time) bool {
+	return s.MutedForever || (s.MutedUntil != nil && s.MutedUntil.After(now))
+}
+
+// InboxFilter selects the conversations listed in a user's inbox.
+type InboxFilter struct {
+	Folder   string // Only conversations in this folder when set
+	Archived bool   // Archived conversations instead of the main inbox
+}
+
+// UserPreferences holds a user's chat-wide settings.
+type UserPreferences struct {
+	UserID string `bson:"_id" json:"user_id"`
+
+	// KeepArchived stops new messages from moving archived conversations back to the inbox
+	KeepArchived bool `bson:"keep_archived" json:"keep_archived"`
+
+	// DigestFrequency is how often unread conversations are summarized by email
+	DigestFrequency DigestFrequency `bson:"digest_frequency,omitempty" json:"digest_frequency"`
+	LastDigestAt    *time.Time      `bson:"last_digest_at,omitempty" json:"-"`
+	UpdatedAt       time.Time       `bson:"updated_at" json:"updated_at"`
+}
+
+// DigestFrequency is how often a user gets an email digest of their unread conversations.
+// Users who never chose one get a daily digest.
+type DigestFrequency string
+
+const (
+	DigestOff    DigestFrequency = "off"
+	DigestHourly DigestFrequency = "hourly"
+	DigestDaily  DigestFrequency = "daily"
+)
+
+func (f DigestFrequency) Valid() bool {
+	switch f {
+	case DigestOff, DigestHourly, DigestDaily:
+		return true
+	}
+	return false
+}
+
+// Interval returns the time between two digests, or zero when digests are off.
+func (f DigestFrequency) Interval() time.Duration {
+	switch f {
+	case DigestHourly:
+		return time.Hour
+	case DigestDaily:
+		return 24 * time.Hour
+	}
+	return 0
+}
+
+// Digest returns the user's digest frequency, defaulting to daily.
+func (p *UserPreferences) Digest() DigestFrequency {
+	if p.DigestFrequency == "" {
+		return DigestDaily
+	}
+	return p.DigestFrequency
+}
@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// DevicePlatform names the push service that delivers notifications to a device.
+type DevicePlatform string
+
+const (
+	PlatformFCM  DevicePlatform = "fcm"  // Firebase Cloud Messaging, for Android and web
+	PlatformAPNs DevicePlatform = "apns" // Apple Push Notification service
+)
+
+func (p DevicePlatform) Valid() bool {
+	return p == PlatformFCM || p == PlatformAPNs
+}
+
+// Device is a push token a user's client registered to be notified while it is offline. A
+// token identifies one app install, so registering it again moves it to the new user.
+type Device struct {
+	Token     string         `bson:"_id" json:"token"`
+	UserID    string         `bson:"user_id" json:"user_id"`
+	Platform  DevicePlatform `bson:"platform" json:"platform"`
+	CreatedAt time.Time      `bson:"created_at" json:"created_at"`
+	UpdatedAt time.Time      `bson:"updated_at" json:"updated_at"`
+}
@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// Draft is an unsent message a user is composing in a conversation, synced between their devices.
+// Drafts belong to their author only and are never delivered to the peer.
+type Draft struct {
+	ID             string    `bson:"_id" json:"-"`
+	UserID         string    `bson:"user_id" json:"user_id"`
+	ConversationID string    `bson:"conversation_id" json:"conversation_id"`
+	Content        string    `bson:"content" json:"content"`
+	DeviceID       string    `bson:"device_id,omitempty" json:"device_id,omitempty"`
+	UpdatedAt      time.Time `bson:"updated_at" json:"updated_at"` // Set by the writing device; the latest write wins
+
+	Sealed *SealedFields `bson:"sealed,omitempty" json:"-"` // Content, when encrypted at rest
+}
+
+// DraftID returns the ID of a user's draft in a conversation.
+func DraftID(userID, conversationID string) string {
+	return userID + ":" + conversationID
+}
@@ -0,0 +1,131 @@
+package models
+
+// Event is a server-pushed WebSocket frame that is not itself a chat message.
+type Event struct {
+	EventType string      `json:"event_type"`
+	Data      interface{} `json:"data,omitempty"`
+}
+
+const EventMessageExpired = "message_expired"
+
+// MessageExpiredData is the payload of a message_expired event.
+type MessageExpiredData struct {
+	MessageID      string `json:"message_id"`
+	ConversationID string `json:"conversation_id"`
+}
+
+const EventDraftUpdated = "draft_updated"
+
+// EventConversationSettingsUpdated carries a user's ConversationSettings to their devices
+// when they change, including when a new message unarchives the conversation.
+const EventConversationSettingsUpdated = "conversation_settings_updated"
+
+// EventExportFinished carries an ExportJob to the user once its transcript is uploaded or the export failed.
+const EventExportFinished = "export_finished"
+
+// EventMessageRemoved tells both participants that a moderator deleted a message.
+const EventMessageRemoved = "message_removed"
+
+// EventReportResolved carries a resolved Report to the user who filed it.
+const EventReportResolved = "report_resolved"
+
+const EventModerationWarning = "moderation_warning"
+
+// ModerationWarningData is the payload of a moderation_warning event, sent to a user a
+// moderator warned after a report.
+type ModerationWarningData struct {
+	ReportID string       `json:"report_id"`
+	Reason   ReportReason `json:"reason"`
+	Note     string       `json:"note,omitempty"`
+}
+
+const EventPrekeysLow = "prekeys_low"
+
+// PrekeysLowData is the payload of a prekeys_low event, asking the user's clients to upload
+// more one-time prekeys.
+type PrekeysLowData struct {
+	Remaining int `json:"remaining"`
+}
+
+const EventDeliveryFailed = "delivery_failed"
+
+// DeliveryFailedData is the payload of a delivery_failed event, telling the sender that
+// redelivery of a message gave up.
+type DeliveryFailedData struct {
+	MessageID      string `json:"message_id"`
+	ConversationID string `json:"conversation_id"`
+	TempID         string `json:"temp_id,omitempty"`
+	Attempts       int    `json:"attempts"`
+	Error          string `json:"error"`
+}
+
+const EventCommandReply = "command_reply"
+
+// CommandReplyData is the payload of a command_reply event, a slash command's reply that
+// only the user who ran it sees.
+type CommandReplyData struct {
+	Command        string `json:"command"`
+	ConversationID string `json:"conversation_id"`
+	TempID         string `json:"temp_id,omitempty"`
+	Content        string `json:"content"`
+}
+
+// Call signaling events, relayed between the participants of a call with a CallSignalData
+// payload. call_ringing tells the caller the ID of the call its call_invite started.
+const (
+	EventCallInvite   = "call_invite"
+	EventCallRinging  = "call_ringing"
+	EventCallAnswer   = "call_answer"
+	EventICECandidate = "ice_candidate"
+	EventCallHangup   = "call_hangup"
+	EventCallReject   = "call_reject"
+)
+
+const EventMentioned = "mentioned"
+
+// MentionedData is the payload of a mentioned event.
+type MentionedData struct {
+	MessageID      string `json:"message_id"`
+	ConversationID string `json:"conversation_id"`
+	SenderID       string `json:"sender_id"`
+	Content        string `json:"content"`
+}
+
+const EventError = "error"
+
+// Error codes carried by error events
+const (
+	ErrCodeInvalidMessage = "invalid_message"
+	ErrCodeSendFailed     = "send_failed"
+	ErrCodeInvalidRequest = "invalid_request"
+	ErrCodeForbidden      = "forbidden"
+	ErrCodeNotFound       = "not_found"
+	ErrCodeRequestFailed  = "request_failed"
+	ErrCodeBlocked        = "blocked"
+
+	// ErrCodeContentRejected reports a message the moderation pipeline rejected; Stage
+	// names the stage that rejected it
+	ErrCodeContentRejected = "content_rejected"
+)
+
+// ErrorData is the payload of an error event. TempID echoes the client's ID for the
+// message that was rejected.
+type ErrorData struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	TempID  string `json:"temp_id,omitempty"`
+	Stage   string `json:"stage,omitempty"`
+}
+
+const (
+	EventPollUpdated = "poll_updated"
+	EventPollClosed  = "poll_closed"
+)
+
+// PollUpdatedData is the payload of poll_updated and poll_closed events.
+type PollUpdatedData struct {
+	PollID         string       `json:"poll_id"`
+	ConversationID string       `json:"conversation_id"`
+	Closed         bool         `json:"closed"`
+	Results        *PollResults `json:"results"`
+}
@@ -0,0 +1,48 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ExportFormat is the file format of a conversation transcript.
+type ExportFormat string
+
+const (
+	ExportJSON ExportFormat = "json"
+	ExportHTML ExportFormat = "html"
+	ExportText ExportFormat = "txt"
+)
+
+// ExportOptions describes a transcript to produce.
+type ExportOptions struct {
+	ConversationID string       `bson:"conversation_id" json:"conversation_id"`
+	Format         ExportFormat `bson:"format" json:"format"`
+	Bundle         bool         `bson:"bundle" json:"bundle"` // Zip the transcript together with its attachments
+}
+
+type ExportStatus string
+
+const (
+	ExportPending ExportStatus = "pending"
+	ExportRunning ExportStatus = "running"
+	ExportDone    ExportStatus = "done"
+	ExportFailed  ExportStatus = "failed"
+)
+
+// ExportJob is an export too large to stream in a request. The export worker writes the
+// transcript to storage and tells the user where to download it.
+type ExportJob struct {
+	ID            primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID        string             `bson:"user_id" json:"user_id"`
+	ExportOptions `bson:",inline"`
+	Status        ExportStatus `bson:"status" json:"status"`
+	FileURL       string       `bson:"file_url,omitempty" json:"file_url,omitempty"`
+	Error         string       `bson:"error,omitempty" json:"error,omitempty"`
+	CreatedAt     time.Time    `bson:"created_at" json:"created_at"`
+	CompletedAt   time.Time    `bson:"completed_at,omitempty" json:"completed_at,omitempty"`
+	Attempts      int          `bson:"attempts" json:"-"`
+	LeaseOwner    string       `bson:"lease_owner,omitempty" json:"-"` // Instance currently running the export
+	LeaseUntil    time.Time    `bson:"lease_until,omitempty" json:"-"`
+}
@@ -6,26 +6,125 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// MessageStatus is where a message is in its lifecycle. A message only ever moves forward
+// through the statuses below, possibly skipping some, e.g. when the receiver acknowledges
+// a message before the server recorded sending it.
 type MessageStatus string
 
 const (
-	Stored   MessageStatus = "stored"
-	Sent     MessageStatus = "sent"
-	Received MessageStatus = "received"
-	Pending  MessageStatus = "pending"
-	Read     MessageStatus = "read"
+	Stored   MessageStatus = "stored"   // Persisted by the server
+	Sent     MessageStatus = "sent"     // Pushed to a connected device of the receiver
+	Received MessageStatus = "received" // Acknowledged by a device of the receiver
+	Read     MessageStatus = "read"     // Read by the receiver
+)
+
+var statusLifecycle = []MessageStatus{Stored, Sent, Received, Read}
+
+// PriorStatuses returns the statuses a message can move to s from, in lifecycle order. It
+// is empty for Stored, which is only ever the initial status, and for unknown statuses.
+func (s MessageStatus) PriorStatuses() []MessageStatus {
+	for i, status := range statusLifecycle {
+		if status == s {
+			return statusLifecycle[:i:i]
+		}
+	}
+	return nil
+}
+
+// MessageType discriminates how a message is rendered. Documents stored before the field
+// existed are text messages. Rich types and their payloads are in MessagePayload.go.
+type MessageType string
+
+const (
+	TextMessage   MessageType = "text"
+	SystemMessage MessageType = "system" // Generated by the server, e.g. when a conversation setting changes
 )
 
 type Message struct {
-	EventType   string             `bson:"event_type" json:"event_type"`
-	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
-	TempID      string             `bson:"temp_id,omitempty" json:"temp_id,omitempty"`
-	SenderID    string             `bson:"sender_id" json:"sender_id"`
-	ReceiverID  string             `bson:"receiver_id" json:"receiver_id"`
-	Content     string             `bson:"content" json:"content"`
-	CreatedAt   time.Time          `bson:"created_at" json:"created_at"`
-	FileURL     string             `bson:"file_url,omitempty" json:"file_url"`
-	Delivered   bool               `bson:"delivered" json:"delivered"`
-	DeliveredAt time.Time          `bson:"delivered_at,omitempty" json:"delivered_at,omitempty"`
-	Status      MessageStatus      `bson:"status" json:"status"`
+	EventType      string             `bson:"event_type" json:"event_type"`
+	ID             primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	TempID         string             `bson:"temp_id,omitempty" json:"temp_id,omitempty"`
+	ConversationID string             `bson:"conversation_id,omitempty" json:"conversation_id,omitempty"`
+	Seq            int64              `bson:"seq,omitempty" json:"seq,omitempty"` // Position in the conversation, allocated when the message is stored; never 0
+	Type           MessageType        `bson:"type,omitempty" json:"type,omitempty"`
+	SenderID       string             `bson:"sender_id" json:"sender_id"`
+	ReceiverID     string             `bson:"receiver_id" json:"receiver_id"`
+	Content        string             `bson:"content" json:"content"`
+	CreatedAt      time.Time          `bson:"created_at" json:"created_at"`
+	FileURL        string             `bson:"file_url,omitempty" json:"file_url"`
+	Delivered      bool               `bson:"delivered" json:"delivered"`
+	DeliveredAt    time.Time          `bson:"delivered_at,omitempty" json:"delivered_at,omitempty"`
+	Status         MessageStatus      `bson:"status" json:"status"`
+	SentAt         time.Time          `bson:"sent_at,omitempty" json:"sent_at,omitempty"`
+	ReceivedAt     time.Time          `bson:"received_at,omitempty" json:"received_at,omitempty"`
+	ReadAt         time.Time          `bson:"read_at,omitempty" json:"read_at,omitempty"`
+	AckPending     bool               `bson:"ack_pending,omitempty" json:"-"` // The sender has not been told the current Status yet
+
+	// Redelivery state of a message stuck in Stored while its receiver is connected
+	DeliveryAttempts  int          `bson:"delivery_attempts,omitempty" json:"-"`
+	LastDeliveryError string       `bson:"last_delivery_error,omitempty" json:"-"`
+	NextAttemptAt     time.Time    `bson:"next_attempt_at,omitempty" json:"-"`
+	DeliveryFailedAt  time.Time    `bson:"delivery_failed_at,omitempty" json:"delivery_failed_at,omitempty"` // Set once redelivery gave up
+	ExpiresAt         time.Time    `bson:"expires_at,omitempty" json:"expires_at,omitempty"`
+	SystemEvent       *SystemEvent `bson:"system_event,omitempty" json:"system_event,omitempty"`
+	Mentions          []Mention    `bson:"mentions,omitempty" json:"mentions,omitempty"`
+	ImportKey         string       `bson:"import_key,omitempty" json:"-"`                    // Source and external ID of an imported message
+	WebhookID         string       `bson:"webhook_id,omitempty" json:"webhook_id,omitempty"` // Incoming webhook the message was posted through
+
+	// Typed payloads; only the one matching Type is set
+	Image    *ImagePayload    `bson:"image,omitempty" json:"image,omitempty"`
+	File     *FilePayload     `bson:"file,omitempty" json:"file,omitempty"`
+	Voice    *VoicePayload    `bson:"voice,omitempty" json:"voice,omitempty"`
+	Location *LocationPayload `bson:"location,omitempty" json:"location,omitempty"`
+	Contact  *ContactPayload  `bson:"contact,omitempty" json:"contact,omitempty"`
+	Poll     *PollPayload     `bson:"poll,omitempty" json:"poll,omitempty"`
+	Call     *CallPayload     `bson:"call,omitempty" json:"call,omitempty"`
+
+	Encrypted *EncryptedPayload `bson:"encrypted,omitempty" json:"encrypted,omitempty"`
+
+	// Sealed holds Content and FileURL encrypted at rest. The message repository fills it in
+	// when storing a message and decrypts it back into Content and FileURL when reading one.
+	Sealed *SealedFields `bson:"sealed,omitempty" json:"-"`
 }
+
+// SealedFields is the envelope-encrypted form of a document's Content and FileURL, or of a
+// report's Snapshot. They are encrypted with the document's own data key, which is stored
+// wrapped by the master key KeyID.
+type SealedFields struct {
+	KeyID      string `bson:"key_id"`
+	WrappedKey []byte `bson:"wrapped_key"`
+	Content    []byte `bson:"content,omitempty"`
+	FileURL    []byte `bson:"file_url,omitempty"`
+	Snapshot   []byte `bson:"snapshot,omitempty"` // BSON-encoded ReportSnapshot
+}
+
+// MessagePage is a page of a conversation's history in sequence order. LastSeq is the
+// highest sequence number allocated in the conversation so far, which tells a client how
+// far behind it is.
+type MessagePage struct {
+	Messages []*Message `json:"messages"`
+	LastSeq  int64      `json:"last_seq"`
+	HasMore  bool       `json:"has_more"`
+}
+
+// Mention is an @username in Content that resolved to a user. Offset and Length are
+// counted in runes and include the leading @.
+type Mention struct {
+	UserID   string `bson:"user_id" json:"user_id"`
+	Username string `bson:"username" json:"username"`
+	Offset   int    `bson:"offset" json:"offset"`
+	Length   int    `bson:"length" json:"length"`
+}
+
+// SystemEvent describes the change recorded by a system message.
+type SystemEvent struct {
+	Action     string           `bson:"action" json:"action"`
+	ActorID    string           `bson:"actor_id" json:"actor_id"`
+	TTLSeconds int64            `bson:"ttl_seconds,omitempty" json:"ttl_seconds,omitempty"`
+	Retention  *RetentionPolicy `bson:"retention,omitempty" json:"retention,omitempty"`
+}
+
+const (
+	SystemActionTimerChanged     = "timer_changed"
+	SystemActionRetentionChanged = "retention_changed"
+)
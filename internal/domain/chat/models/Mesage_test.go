@@ -0,0 +1,32 @@
+package models
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMessageStatusPriorStatuses(t *testing.T) {
+	tests := []struct {
+		status MessageStatus
+		want   []MessageStatus
+	}{
+		{Stored, []MessageStatus{}},
+		{Sent, []MessageStatus{Stored}},
+		{Received, []MessageStatus{Stored, Sent}},
+		{Read, []MessageStatus{Stored, Sent, Received}},
+		{"delivered", nil},
+	}
+
+	for _, tt := range tests {
+		got := tt.status.PriorStatuses()
+		if len(got) != len(tt.want) || (len(got) > 0 && !reflect.DeepEqual(got, tt.want)) {
+			t.Errorf("%q.PriorStatuses(): got %v, want %v", tt.status, got, tt.want)
+		}
+	}
+
+	// Appending to the result must not overwrite the lifecycle
+	_ = append(Sent.PriorStatuses(), Read)
+	if got := Received.PriorStatuses(); !reflect.DeepEqual(got, []MessageStatus{Stored, Sent}) {
+		t.Errorf("lifecycle was modified: got %v", got)
+	}
+}
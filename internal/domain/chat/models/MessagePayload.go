@@ -0,0 +1,249 @@
+package models
+
+import (
+	"fmt"
+	"mime"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/dk5761/go-serv/internal/domain/common"
+)
+
+const (
+	ImageMessage    MessageType = "image"
+	FileMessage     MessageType = "file"
+	VoiceMessage    MessageType = "voice"
+	LocationMessage MessageType = "location"
+	ContactMessage  MessageType = "contact"
+
+	// EncryptedMessage carries end-to-end encrypted content the server cannot read
+	EncryptedMessage MessageType = "encrypted"
+)
+
+const (
+	maxContentLength  = 10000
+	maxImageSide      = 20000
+	maxFileSize       = 100 << 20 // 100 MB
+	maxCiphertextSize = 64 << 10  // 64 KB
+)
+
+// ImagePayload describes an image attached through FileURL.
+type ImagePayload struct {
+	Width    int    `bson:"width" json:"width"`
+	Height   int    `bson:"height" json:"height"`
+	MimeType string `bson:"mime_type,omitempty" json:"mime_type,omitempty"`
+}
+
+// FilePayload describes a file attached through FileURL.
+type FilePayload struct {
+	Name     string `bson:"name" json:"name"`
+	Size     int64  `bson:"size" json:"size"` // in bytes
+	MimeType string `bson:"mime_type" json:"mime_type"`
+}
+
+// VoicePayload describes a voice note attached through FileURL. It is extracted by the
+// server when the note is uploaded; Waveform holds levels from 0 to 100 over equal slices
+// of the recording.
+type VoicePayload struct {
+	Duration float64 `bson:"duration" json:"duration"` // in seconds
+	MimeType string  `bson:"mime_type,omitempty" json:"mime_type,omitempty"`
+	Codec    string  `bson:"codec,omitempty" json:"codec,omitempty"`
+	Size     int64   `bson:"size,omitempty" json:"size,omitempty"` // in bytes
+	Waveform []int   `bson:"waveform,omitempty" json:"waveform,omitempty"`
+}
+
+// LocationPayload is a shared map location.
+type LocationPayload struct {
+	Latitude  float64 `bson:"latitude" json:"latitude"`
+	Longitude float64 `bson:"longitude" json:"longitude"`
+	Name      string  `bson:"name,omitempty" json:"name,omitempty"`
+	Address   string  `bson:"address,omitempty" json:"address,omitempty"`
+}
+
+// ContactPayload is a shared contact card. UserID is set when the contact is a user of this service.
+type ContactPayload struct {
+	Name   string   `bson:"name" json:"name"`
+	Phones []string `bson:"phones,omitempty" json:"phones,omitempty"`
+	Emails []string `bson:"emails,omitempty" json:"emails,omitempty"`
+	UserID string   `bson:"user_id,omitempty" json:"user_id,omitempty"`
+}
+
+// EncryptedPayload is an end-to-end encrypted message. The server stores and forwards the
+// ciphertext as is: moderation, mention parsing and content indexing all skip encrypted
+// messages, whose Content is always empty. An attachment in FileURL is encrypted by the
+// client as well.
+type EncryptedPayload struct {
+	Ciphertext    []byte `bson:"ciphertext" json:"ciphertext"`         // base64 in JSON
+	PrekeyMessage bool   `bson:"prekey_message" json:"prekey_message"` // Starts a session from the receiver's prekey bundle
+}
+
+// Validate checks a message sent by a client against the rules of its type. Messages without
+// a type are text messages. Server-generated types such as system messages are rejected.
+// Server-maintained payload fields, such as poll option IDs, are filled in along the way.
+func (m *Message) Validate() error {
+	if m.Type == "" {
+		m.Type = TextMessage
+	}
+	if len(m.Content) > maxContentLength {
+		return invalid("content exceeds %d characters", maxContentLength)
+	}
+	if err := m.checkPayloads(); err != nil {
+		return err
+	}
+
+	switch m.Type {
+	case TextMessage:
+		if strings.TrimSpace(m.Content) == "" && m.FileURL == "" {
+			return invalid("text message requires content")
+		}
+	case ImageMessage:
+		if m.FileURL == "" || m.Image == nil {
+			return invalid("image message requires file_url and image")
+		}
+		if m.Image.Width <= 0 || m.Image.Height <= 0 || m.Image.Width > maxImageSide || m.Image.Height > maxImageSide {
+			return invalid("image dimensions must be between 1 and %d", maxImageSide)
+		}
+		if m.Image.MimeType != "" && !hasMediaType(m.Image.MimeType, "image") {
+			return invalid("image mime_type must be an image type")
+		}
+	case FileMessage:
+		if m.FileURL == "" || m.File == nil {
+			return invalid("file message requires file_url and file")
+		}
+		if strings.TrimSpace(m.File.Name) == "" {
+			return invalid("file name is required")
+		}
+		if m.File.Size <= 0 || m.File.Size > maxFileSize {
+			return invalid("file size must be between 1 and %d bytes", maxFileSize)
+		}
+		if !hasMediaType(m.File.MimeType, "") {
+			return invalid("file mime_type is invalid")
+		}
+	case VoiceMessage:
+		// The voice payload is replaced by the metadata extracted when the file was uploaded
+		if m.FileURL == "" {
+			return invalid("voice message requires file_url")
+		}
+	case LocationMessage:
+		if m.Location == nil {
+			return invalid("location message requires location")
+		}
+		if m.Location.Latitude < -90 || m.Location.Latitude > 90 {
+			return invalid("latitude must be between -90 and 90")
+		}
+		if m.Location.Longitude < -180 || m.Location.Longitude > 180 {
+			return invalid("longitude must be between -180 and 180")
+		}
+	case PollMessage:
+		if err := m.validatePoll(); err != nil {
+			return err
+		}
+	case EncryptedMessage:
+		if m.Encrypted == nil || len(m.Encrypted.Ciphertext) == 0 {
+			return invalid("encrypted message requires ciphertext")
+		}
+		if len(m.Encrypted.Ciphertext) > maxCiphertextSize {
+			return invalid("ciphertext exceeds %d bytes", maxCiphertextSize)
+		}
+		if m.Content != "" {
+			return invalid("encrypted message must not have plaintext content")
+		}
+	case ContactMessage:
+		if m.Contact == nil || strings.TrimSpace(m.Contact.Name) == "" {
+			return invalid("contact message requires a contact name")
+		}
+		if len(m.Contact.Phones) == 0 && len(m.Contact.Emails) == 0 && m.Contact.UserID == "" {
+			return invalid("contact requires a phone, email or user_id")
+		}
+	case CallMessage:
+		return invalid("call records are created by the server")
+	default:
+		return invalid("unsupported message type %q", m.Type)
+	}
+
+	return nil
+}
+
+// ClearServerFields drops fields a client must not set on a message it sends.
+func (m *Message) ClearServerFields() {
+	m.ID = primitive.NilObjectID
+	m.Seq = 0
+	m.Status = ""
+	m.Delivered = false
+	m.DeliveredAt = time.Time{}
+	m.SentAt = time.Time{}
+	m.ReceivedAt = time.Time{}
+	m.ReadAt = time.Time{}
+	m.DeliveryFailedAt = time.Time{}
+	m.SystemEvent = nil
+	m.ExpiresAt = time.Time{}
+	m.Mentions = nil
+	m.WebhookID = ""
+}
+
+// Preview describes the message in one line of at most maxLength runes, for notifications
+// that cannot render it. Only text is quoted; end-to-end encrypted content stays hidden.
+func (m *Message) Preview(maxLength int) string {
+	switch m.Type {
+	case ImageMessage:
+		return "Sent a photo"
+	case FileMessage:
+		return "Sent a file"
+	case VoiceMessage:
+		return "Sent a voice message"
+	case LocationMessage:
+		return "Shared a location"
+	case ContactMessage:
+		return "Shared a contact"
+	case PollMessage:
+		return "Started a poll"
+	case EncryptedMessage:
+		return "New message"
+	case CallMessage:
+		if m.Call != nil && m.Call.Outcome != CallCompleted {
+			return "Missed call"
+		}
+		return "Call"
+	}
+
+	content := []rune(m.Content)
+	if len(content) > maxLength {
+		return string(content[:maxLength-1]) + "…"
+	}
+	return string(content)
+}
+
+// checkPayloads rejects payloads that do not belong to the message type
+func (m *Message) checkPayloads() error {
+	payloads := map[MessageType]bool{
+		ImageMessage:     m.Image != nil,
+		FileMessage:      m.File != nil,
+		VoiceMessage:     m.Voice != nil,
+		LocationMessage:  m.Location != nil,
+		ContactMessage:   m.Contact != nil,
+		PollMessage:      m.Poll != nil,
+		EncryptedMessage: m.Encrypted != nil,
+		CallMessage:      m.Call != nil,
+	}
+	for messageType, present := range payloads {
+		if present && messageType != m.Type {
+			return invalid("%s payload is not allowed on a %s message", messageType, m.Type)
+		}
+	}
+	return nil
+}
+
+// hasMediaType reports whether mimeType parses and, when top is set, belongs to that top-level type
+func hasMediaType(mimeType, top string) bool {
+	mediaType, _, err := mime.ParseMediaType(mimeType)
+	if err != nil || !strings.Contains(mediaType, "/") {
+		return false
+	}
+	return top == "" || strings.HasPrefix(mediaType, top+"/")
+}
+
+func invalid(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s", common.ErrInvalidInput, fmt.Sprintf(format, args...))
+}
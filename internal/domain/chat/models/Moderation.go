@@ -0,0 +1,30 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ModerationAction is what a moderation stage decided about a message's content.
+type ModerationAction string
+
+const (
+	ModerationAllow  ModerationAction = "allow"
+	ModerationRedact ModerationAction = "redact" // The content is stored with the offending parts masked
+	ModerationReject ModerationAction = "reject" // The message is not stored
+)
+
+// ModerationRecord is content a moderation stage rejected, kept for review.
+type ModerationRecord struct {
+	ID             primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	SenderID       string             `bson:"sender_id" json:"sender_id"`
+	ReceiverID     string             `bson:"receiver_id" json:"receiver_id"`
+	ConversationID string             `bson:"conversation_id" json:"conversation_id"`
+	Type           MessageType        `bson:"type" json:"type"`
+	Content        string             `bson:"content" json:"content"` // As sent, before any redaction
+	Stage          string             `bson:"stage" json:"stage"`
+	Reason         string             `bson:"reason" json:"reason"`
+	CreatedAt      time.Time          `bson:"created_at" json:"created_at"`
+	Sealed         *SealedFields      `bson:"sealed,omitempty" json:"-"` // Content, when encrypted at rest
+}
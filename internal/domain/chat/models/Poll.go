@@ -0,0 +1,98 @@
+package models
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+const PollMessage MessageType = "poll"
+
+const (
+	minPollOptions = 2
+	maxPollOptions = 10
+)
+
+// PollPayload is a poll posted as a message. Option IDs, Closed, ClosedAt, Results and Votes
+// are maintained by the server.
+type PollPayload struct {
+	Question  string       `bson:"question" json:"question"`
+	Options   []PollOption `bson:"options" json:"options"`
+	Multiple  bool         `bson:"multiple" json:"multiple"`   // Voters may pick more than one option
+	Anonymous bool         `bson:"anonymous" json:"anonymous"` // Tallies never reveal who voted for what
+	ClosesAt  time.Time    `bson:"closes_at,omitempty" json:"closes_at,omitempty"`
+	Closed    bool         `bson:"closed" json:"closed"`
+	ClosedAt  time.Time    `bson:"closed_at,omitempty" json:"closed_at,omitempty"`
+	Results   *PollResults `bson:"results,omitempty" json:"results,omitempty"` // Latest tally, frozen once Closed
+	Votes     []PollVote   `bson:"votes,omitempty" json:"-"`                   // Current vote of each voter; clients only see Results
+}
+
+type PollOption struct {
+	ID   string `bson:"id" json:"id"`
+	Text string `bson:"text" json:"text"`
+}
+
+// PollResults is the tally of a poll. Voters is only filled for polls that are not anonymous.
+type PollResults struct {
+	Counts      map[string]int      `bson:"counts" json:"counts"`
+	Voters      map[string][]string `bson:"voters,omitempty" json:"voters,omitempty"`
+	TotalVoters int                 `bson:"total_voters" json:"total_voters"`
+}
+
+// PollVote is one user's current choice in a poll. Voting again replaces it.
+type PollVote struct {
+	UserID    string    `bson:"user_id"`
+	OptionIDs []string  `bson:"option_ids"`
+	UpdatedAt time.Time `bson:"updated_at"`
+}
+
+// IsOpen reports whether the poll still accepts votes at now.
+func (p *PollPayload) IsOpen(now time.Time) bool {
+	return !p.Closed && (p.ClosesAt.IsZero() || now.Before(p.ClosesAt))
+}
+
+// HasOption reports whether optionID belongs to the poll.
+func (p *PollPayload) HasOption(optionID string) bool {
+	for _, option := range p.Options {
+		if option.ID == optionID {
+			return true
+		}
+	}
+	return false
+}
+
+// validatePoll checks a poll sent by a client and assigns its option IDs
+func (m *Message) validatePoll() error {
+	poll := m.Poll
+	if poll == nil || strings.TrimSpace(poll.Question) == "" {
+		return invalid("poll message requires a question")
+	}
+	if len(poll.Options) < minPollOptions || len(poll.Options) > maxPollOptions {
+		return invalid("poll requires between %d and %d options", minPollOptions, maxPollOptions)
+	}
+
+	seen := make(map[string]bool, len(poll.Options))
+	for i := range poll.Options {
+		text := strings.TrimSpace(poll.Options[i].Text)
+		if text == "" {
+			return invalid("poll options cannot be empty")
+		}
+		if seen[strings.ToLower(text)] {
+			return invalid("poll options must be unique")
+		}
+		seen[strings.ToLower(text)] = true
+
+		poll.Options[i].ID = strconv.Itoa(i + 1)
+		poll.Options[i].Text = text
+	}
+
+	if !poll.ClosesAt.IsZero() && !poll.ClosesAt.After(time.Now()) {
+		return invalid("poll closes_at must be in the future")
+	}
+
+	poll.Closed = false
+	poll.ClosedAt = time.Time{}
+	poll.Results = nil
+	poll.Votes = nil
+	return nil
+}
@@ -0,0 +1,100 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ReportKind tells whether a report is about a single message or a user as a whole.
+type ReportKind string
+
+const (
+	ReportMessage ReportKind = "message"
+	ReportUser    ReportKind = "user"
+)
+
+// ReportReason is the category a reporter picks.
+type ReportReason string
+
+const (
+	ReasonSpam       ReportReason = "spam"
+	ReasonHarassment ReportReason = "harassment"
+	ReasonHate       ReportReason = "hate"
+	ReasonSexual     ReportReason = "sexual"
+	ReasonViolence   ReportReason = "violence"
+	ReasonOther      ReportReason = "other"
+)
+
+// Valid reports whether r is one of the known reasons
+func (r ReportReason) Valid() bool {
+	switch r {
+	case ReasonSpam, ReasonHarassment, ReasonHate, ReasonSexual, ReasonViolence, ReasonOther:
+		return true
+	}
+	return false
+}
+
+type ReportStatus string
+
+const (
+	ReportOpen     ReportStatus = "open"
+	ReportClaimed  ReportStatus = "claimed" // A moderator is reviewing it
+	ReportResolved ReportStatus = "resolved"
+)
+
+// ModeratorAction is how a moderator resolves a report.
+type ModeratorAction string
+
+const (
+	ActionDismiss       ModeratorAction = "dismiss"
+	ActionWarn          ModeratorAction = "warn"
+	ActionDeleteMessage ModeratorAction = "delete_message"
+	ActionSuspendUser   ModeratorAction = "suspend_user"
+)
+
+// ReportSnapshot is a copy of the reported message and the messages around it, taken when
+// the report is filed so that later edits or deletions do not hide what was reported.
+type ReportSnapshot struct {
+	Message *Message   `bson:"message,omitempty" json:"message,omitempty"`
+	Context []*Message `bson:"context" json:"context"` // Oldest first
+	TakenAt time.Time  `bson:"taken_at" json:"taken_at"`
+}
+
+// ReportResolution records how a moderator resolved a report.
+type ReportResolution struct {
+	Action         ModeratorAction `bson:"action" json:"action"`
+	Note           string          `bson:"note,omitempty" json:"note,omitempty"`
+	SuspendedUntil *time.Time      `bson:"suspended_until,omitempty" json:"suspended_until,omitempty"`
+	ResolvedBy     string          `bson:"resolved_by" json:"resolved_by,omitempty"`
+	ResolvedAt     time.Time       `bson:"resolved_at" json:"resolved_at"`
+}
+
+// Report is a user's complaint about a message or another user, queued for moderators.
+type Report struct {
+	ID             primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Kind           ReportKind         `bson:"kind" json:"kind"`
+	ReporterID     string             `bson:"reporter_id" json:"reporter_id"`
+	ReportedUserID string             `bson:"reported_user_id" json:"reported_user_id"`
+	MessageID      primitive.ObjectID `bson:"message_id,omitempty" json:"message_id,omitempty"`
+	ConversationID string             `bson:"conversation_id" json:"conversation_id"`
+	Reason         ReportReason       `bson:"reason" json:"reason"`
+	Details        string             `bson:"details,omitempty" json:"details,omitempty"`
+	Snapshot       *ReportSnapshot    `bson:"snapshot,omitempty" json:"snapshot,omitempty"`
+	Status         ReportStatus       `bson:"status" json:"status"`
+	ClaimedBy      string             `bson:"claimed_by,omitempty" json:"claimed_by,omitempty"`
+	ClaimedAt      time.Time          `bson:"claimed_at,omitempty" json:"claimed_at,omitempty"`
+	Resolution     *ReportResolution  `bson:"resolution,omitempty" json:"resolution,omitempty"`
+	CreatedAt      time.Time          `bson:"created_at" json:"created_at"`
+
+	// Set while the report is unresolved, so that a reporter cannot file the same report twice
+	DedupeKey string `bson:"dedupe_key,omitempty" json:"-"`
+
+	Sealed *SealedFields `bson:"sealed,omitempty" json:"-"` // Snapshot, when encrypted at rest
+}
+
+// ReportFilter selects reports in the moderator queue.
+type ReportFilter struct {
+	Status     ReportStatus
+	ReporterID string
+}
@@ -0,0 +1,58 @@
+package models
+
+import "time"
+
+// RetentionPolicy limits how long messages are kept. A zero field places no limit.
+type RetentionPolicy struct {
+	MaxAgeDays int `bson:"max_age_days,omitempty" json:"max_age_days,omitempty"` // Delete messages older than this
+	KeepLast   int `bson:"keep_last,omitempty" json:"keep_last,omitempty"`       // Delete all but the newest messages
+}
+
+// IsZero reports whether the policy keeps every message.
+func (p RetentionPolicy) IsZero() bool {
+	return p.MaxAgeDays <= 0 && p.KeepLast <= 0
+}
+
+// Combine returns the stricter of both policies' limits, so that a conversation policy can
+// shorten the global retention but never extend it.
+func (p RetentionPolicy) Combine(other *RetentionPolicy) RetentionPolicy {
+	if other == nil {
+		return p
+	}
+	p.MaxAgeDays = stricterLimit(p.MaxAgeDays, other.MaxAgeDays)
+	p.KeepLast = stricterLimit(p.KeepLast, other.KeepLast)
+	return p
+}
+
+// stricterLimit returns the smaller limit, where zero means unlimited
+func stricterLimit(a, b int) int {
+	if a <= 0 || (b > 0 && b < a) {
+		return b
+	}
+	return a
+}
+
+// ConversationStats summarizes the stored messages of a conversation.
+type ConversationStats struct {
+	ConversationID string    `bson:"_id"`
+	Count          int64     `bson:"count"`
+	Oldest         time.Time `bson:"oldest"`
+}
+
+// ConversationPurge reports the messages removed, or that would be removed, from one conversation.
+type ConversationPurge struct {
+	ConversationID string          `json:"conversation_id"`
+	Policy         RetentionPolicy `json:"policy"`
+	Messages       int64           `json:"messages"`
+	Attachments    int64           `json:"attachments"`
+}
+
+// PurgeReport is the outcome of a retention run.
+type PurgeReport struct {
+	DryRun        bool                 `json:"dry_run"`
+	StartedAt     time.Time            `json:"started_at"`
+	FinishedAt    time.Time            `json:"finished_at"`
+	Messages      int64                `json:"messages"`
+	Attachments   int64                `json:"attachments"`
+	Conversations []*ConversationPurge `json:"conversations"`
+}
@@ -0,0 +1,45 @@
+package models
+
+import "testing"
+
+func TestRetentionPolicyCombine(t *testing.T) {
+	tests := []struct {
+		name   string
+		global RetentionPolicy
+		other  *RetentionPolicy
+		want   RetentionPolicy
+	}{
+		{"no conversation policy", RetentionPolicy{MaxAgeDays: 30}, nil, RetentionPolicy{MaxAgeDays: 30}},
+		{"shorter age wins", RetentionPolicy{MaxAgeDays: 30}, &RetentionPolicy{MaxAgeDays: 7}, RetentionPolicy{MaxAgeDays: 7}},
+		{"cannot extend", RetentionPolicy{MaxAgeDays: 30, KeepLast: 100}, &RetentionPolicy{MaxAgeDays: 90, KeepLast: 1000}, RetentionPolicy{MaxAgeDays: 30, KeepLast: 100}},
+		{"unlimited global", RetentionPolicy{}, &RetentionPolicy{KeepLast: 50}, RetentionPolicy{KeepLast: 50}},
+		{"limits combine", RetentionPolicy{MaxAgeDays: 30}, &RetentionPolicy{KeepLast: 50}, RetentionPolicy{MaxAgeDays: 30, KeepLast: 50}},
+		{"negative means unlimited", RetentionPolicy{MaxAgeDays: -1}, &RetentionPolicy{MaxAgeDays: 10, KeepLast: -5}, RetentionPolicy{MaxAgeDays: 10, KeepLast: -5}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.global.Combine(tt.other); got != tt.want {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetentionPolicyIsZero(t *testing.T) {
+	tests := []struct {
+		policy RetentionPolicy
+		want   bool
+	}{
+		{RetentionPolicy{}, true},
+		{RetentionPolicy{MaxAgeDays: -1, KeepLast: 0}, true},
+		{RetentionPolicy{MaxAgeDays: 1}, false},
+		{RetentionPolicy{KeepLast: 1}, false},
+	}
+
+	for _, tt := range tests {
+		if got := tt.policy.IsZero(); got != tt.want {
+			t.Errorf("%+v.IsZero(): got %v, want %v", tt.policy, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type ScheduledStatus string
+
+const (
+	ScheduledPending  ScheduledStatus = "scheduled"
+	ScheduledSent     ScheduledStatus = "sent"
+	ScheduledCanceled ScheduledStatus = "canceled"
+	ScheduledFailed   ScheduledStatus = "failed"
+)
+
+// ScheduledMessage is a message composed now and dispatched by the scheduler once SendAt has passed.
+type ScheduledMessage struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	SenderID   string             `bson:"sender_id" json:"sender_id"`
+	ReceiverID string             `bson:"receiver_id" json:"receiver_id"`
+	Content    string             `bson:"content" json:"content"`
+	FileURL    string             `bson:"file_url,omitempty" json:"file_url,omitempty"`
+	SendAt     time.Time          `bson:"send_at" json:"send_at"`
+	Status     ScheduledStatus    `bson:"status" json:"status"`
+	CreatedAt  time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt  time.Time          `bson:"updated_at" json:"updated_at"`
+	SentAt     time.Time          `bson:"sent_at,omitempty" json:"sent_at,omitempty"`
+	Attempts   int                `bson:"attempts" json:"-"`
+	LastError  string             `bson:"last_error,omitempty" json:"last_error,omitempty"`
+	LeaseOwner string             `bson:"lease_owner,omitempty" json:"-"` // Instance currently dispatching the message
+	LeaseUntil time.Time          `bson:"lease_until,omitempty" json:"-"`
+	Sealed     *SealedFields      `bson:"sealed,omitempty" json:"-"` // Content and FileURL, when encrypted at rest
+}
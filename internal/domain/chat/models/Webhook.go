@@ -0,0 +1,38 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// IncomingWebhook lets an external service, such as a CI server, post messages into a
+// conversation by sending JSON to a URL with a secret token. Conversations are one-to-one,
+// so a hook belongs to a conversation between the user who created it and a bot account,
+// which is the sender of everything posted through it.
+type IncomingWebhook struct {
+	ID             primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	ConversationID string             `bson:"conversation_id" json:"conversation_id"`
+	SenderID       string             `bson:"sender_id" json:"sender_id"`     // The bot account messages are posted as
+	ReceiverID     string             `bson:"receiver_id" json:"receiver_id"` // The user who created the hook
+	Name           string             `bson:"name" json:"name"`
+	TokenHash      string             `bson:"token_hash,omitempty" json:"-"` // Unset while the token is revoked
+	CreatedAt      time.Time          `bson:"created_at" json:"created_at"`
+	RevokedAt      time.Time          `bson:"revoked_at,omitempty" json:"revoked_at,omitempty"`
+	LastUsedAt     time.Time          `bson:"last_used_at,omitempty" json:"last_used_at,omitempty"`
+	ErrorCount     int64              `bson:"error_count" json:"error_count"`
+	LastError      string             `bson:"last_error,omitempty" json:"last_error,omitempty"`
+	LastErrorAt    time.Time          `bson:"last_error_at,omitempty" json:"last_error_at,omitempty"`
+
+	// Rate limit state: requests accepted in the window starting at WindowStart
+	WindowStart time.Time `bson:"window_start,omitempty" json:"-"`
+	WindowCount int       `bson:"window_count,omitempty" json:"-"`
+}
+
+// IncomingWebhookCredentials is returned once when a hook's token is issued. URL is the
+// address to post to, with the token in it.
+type IncomingWebhookCredentials struct {
+	Webhook *IncomingWebhook `json:"webhook"`
+	Token   string           `json:"token"`
+	URL     string           `json:"url"`
+}
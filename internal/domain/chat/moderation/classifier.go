@@ -0,0 +1,119 @@
+package moderation
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/dk5761/go-serv/internal/domain/chat/models"
+)
+
+// Classification is a classifier's opinion of some content. Score runs from 0, harmless,
+// to 1, certainly abusive.
+type Classification struct {
+	Label string  `json:"label"`
+	Score float64 `json:"score"`
+}
+
+// Classifier rates content, typically by calling an external service.
+type Classifier interface {
+	Classify(ctx context.Context, content string) (*Classification, error)
+}
+
+// ClassifierHook rejects content the classifier scores at or above the threshold.
+type ClassifierHook struct {
+	classifier Classifier
+	threshold  float64
+}
+
+func NewClassifierHook(classifier Classifier, threshold float64) *ClassifierHook {
+	return &ClassifierHook{classifier: classifier, threshold: threshold}
+}
+
+func (h *ClassifierHook) Name() string {
+	return StageClassifier
+}
+
+func (h *ClassifierHook) Check(ctx context.Context, content string) (Decision, error) {
+	classification, err := h.classifier.Classify(ctx, content)
+	if err != nil {
+		return Decision{}, err
+	}
+	if classification.Score < h.threshold {
+		return Allow, nil
+	}
+	return Decision{
+		Action: models.ModerationReject,
+		Reason: fmt.Sprintf("message was classified as %s", classification.Label),
+	}, nil
+}
+
+// HTTPClassifier posts {"content": "..."} to an external service, which answers with a
+// Classification as JSON.
+type HTTPClassifier struct {
+	url    string
+	client *http.Client
+}
+
+func NewHTTPClassifier(url string, timeout time.Duration) *HTTPClassifier {
+	return &HTTPClassifier{url: url, client: &http.Client{Timeout: timeout}}
+}
+
+func (c *HTTPClassifier) Classify(ctx context.Context, content string) (*Classification, error) {
+	body, err := json.Marshal(map[string]string{"content": content})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("classifier returned %s", resp.Status)
+	}
+
+	var classification Classification
+	if err := json.NewDecoder(resp.Body).Decode(&classification); err != nil {
+		return nil, fmt.Errorf("invalid classifier response: %w", err)
+	}
+	return &classification, nil
+}
+
+// FakeClassifier is a local stand-in for an external classifier, for development and
+// deployments without one. It scores content containing any of its terms as 1.
+type FakeClassifier struct {
+	terms []string
+}
+
+func NewFakeClassifier(terms []string) *FakeClassifier {
+	lowered := make([]string, 0, len(terms))
+	for _, term := range terms {
+		if term = strings.ToLower(strings.TrimSpace(term)); term != "" {
+			lowered = append(lowered, term)
+		}
+	}
+	return &FakeClassifier{terms: lowered}
+}
+
+func (c *FakeClassifier) Classify(_ context.Context, content string) (*Classification, error) {
+	lowered := strings.ToLower(content)
+	for _, term := range c.terms {
+		if strings.Contains(lowered, term) {
+			return &Classification{Label: "abusive", Score: 1}, nil
+		}
+	}
+	return &Classification{Label: "ok", Score: 0}, nil
+}
@@ -0,0 +1,86 @@
+package moderation
+
+import (
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/dk5761/go-serv/configs"
+	"github.com/dk5761/go-serv/internal/domain/chat/models"
+	"github.com/dk5761/go-serv/internal/domain/chat/repository"
+	"github.com/dk5761/go-serv/internal/infrastructure/logging"
+)
+
+const (
+	defaultClassifierTimeout   = 2 * time.Second
+	defaultClassifierThreshold = 0.8
+)
+
+// NewPipelineFromConfig builds the pipeline from the stages listed in the configuration.
+// Unknown stages and stages without settings are logged and left out.
+func NewPipelineFromConfig(cfg configs.ModerationConfig, moderationRepo repository.ModerationRepository) *Pipeline {
+	var hooks []Hook
+	for _, stage := range cfg.Stages {
+		hook := newHook(stage, cfg)
+		if hook == nil {
+			continue
+		}
+		hooks = append(hooks, hook)
+	}
+	return NewPipeline(moderationRepo, hooks...)
+}
+
+func newHook(stage string, cfg configs.ModerationConfig) Hook {
+	switch stage {
+	case StageMaxLength:
+		if cfg.MaxLength <= 0 {
+			logging.Logger.Warn("Moderation stage max_length needs a positive MaxLength, skipping it")
+			return nil
+		}
+		return NewMaxLength(cfg.MaxLength)
+
+	case StageProfanity:
+		if len(cfg.ProfanityWords) == 0 {
+			logging.Logger.Warn("Moderation stage profanity has no words, skipping it")
+			return nil
+		}
+		return NewWordFilter(cfg.ProfanityWords, action(cfg.ProfanityAction, models.ModerationRedact))
+
+	case StageLinks:
+		if len(cfg.BlockedDomains) == 0 {
+			logging.Logger.Warn("Moderation stage links has no blocked domains, skipping it")
+			return nil
+		}
+		return NewLinkBlocklist(cfg.BlockedDomains, action(cfg.LinkAction, models.ModerationReject))
+
+	case StageClassifier:
+		threshold := cfg.Classifier.Threshold
+		if threshold <= 0 {
+			threshold = defaultClassifierThreshold
+		}
+		if cfg.Classifier.URL == "" {
+			return NewClassifierHook(NewFakeClassifier(cfg.Classifier.FakeTerms), threshold)
+		}
+		timeout := time.Duration(cfg.Classifier.Timeout) * time.Second
+		if timeout <= 0 {
+			timeout = defaultClassifierTimeout
+		}
+		return NewClassifierHook(NewHTTPClassifier(cfg.Classifier.URL, timeout), threshold)
+
+	default:
+		logging.Logger.Warn("Unknown moderation stage, skipping it", zap.String("stage", stage))
+		return nil
+	}
+}
+
+// action parses a configured stage action, falling back to def
+func action(configured string, def models.ModerationAction) models.ModerationAction {
+	switch models.ModerationAction(configured) {
+	case models.ModerationRedact:
+		return models.ModerationRedact
+	case models.ModerationReject:
+		return models.ModerationReject
+	default:
+		return def
+	}
+}
@@ -0,0 +1,155 @@
+package moderation
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/dk5761/go-serv/internal/domain/chat/models"
+)
+
+// Stage names, as used in the moderation configuration
+const (
+	StageMaxLength  = "max_length"
+	StageProfanity  = "profanity"
+	StageLinks      = "links"
+	StageClassifier = "classifier"
+)
+
+// MaxLength rejects content longer than a number of characters.
+type MaxLength struct {
+	max int
+}
+
+func NewMaxLength(max int) *MaxLength {
+	return &MaxLength{max: max}
+}
+
+func (h *MaxLength) Name() string {
+	return StageMaxLength
+}
+
+func (h *MaxLength) Check(_ context.Context, content string) (Decision, error) {
+	if utf8.RuneCountInString(content) <= h.max {
+		return Allow, nil
+	}
+	return Decision{
+		Action: models.ModerationReject,
+		Reason: fmt.Sprintf("message exceeds %d characters", h.max),
+	}, nil
+}
+
+// WordFilter matches whole words against a word list, ignoring case. Redaction masks each
+// matched word with asterisks.
+type WordFilter struct {
+	words  map[string]struct{}
+	action models.ModerationAction
+}
+
+func NewWordFilter(words []string, action models.ModerationAction) *WordFilter {
+	set := make(map[string]struct{}, len(words))
+	for _, word := range words {
+		if word = strings.ToLower(strings.TrimSpace(word)); word != "" {
+			set[word] = struct{}{}
+		}
+	}
+	return &WordFilter{words: set, action: action}
+}
+
+func (h *WordFilter) Name() string {
+	return StageProfanity
+}
+
+func (h *WordFilter) Check(_ context.Context, content string) (Decision, error) {
+	runes := []rune(content)
+	matched := false
+
+	for start := 0; start < len(runes); {
+		if !isWordRune(runes[start]) {
+			start++
+			continue
+		}
+		end := start
+		for end < len(runes) && isWordRune(runes[end]) {
+			end++
+		}
+		if _, ok := h.words[strings.ToLower(string(runes[start:end]))]; ok {
+			if h.action == models.ModerationReject {
+				return Decision{Action: models.ModerationReject, Reason: "message contains a blocked word"}, nil
+			}
+			matched = true
+			for i := start; i < end; i++ {
+				runes[i] = '*'
+			}
+		}
+		start = end
+	}
+
+	if !matched {
+		return Allow, nil
+	}
+	return Decision{Action: models.ModerationRedact, Content: string(runes)}, nil
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '\''
+}
+
+// linkPattern finds URLs and bare domain names; the first group is the host
+var linkPattern = regexp.MustCompile(`(?i)\b(?:https?://)?((?:[a-z0-9](?:[a-z0-9-]*[a-z0-9])?\.)+[a-z]{2,})(?::\d+)?(?:/[^\s]*)?`)
+
+// linkRedaction replaces a blocked link in redacted content
+const linkRedaction = "[link removed]"
+
+// LinkBlocklist matches links whose host is a blocked domain or one of its subdomains.
+type LinkBlocklist struct {
+	domains []string
+	action  models.ModerationAction
+}
+
+func NewLinkBlocklist(domains []string, action models.ModerationAction) *LinkBlocklist {
+	blocked := make([]string, 0, len(domains))
+	for _, domain := range domains {
+		if domain = strings.Trim(strings.ToLower(strings.TrimSpace(domain)), "."); domain != "" {
+			blocked = append(blocked, domain)
+		}
+	}
+	return &LinkBlocklist{domains: blocked, action: action}
+}
+
+func (h *LinkBlocklist) Name() string {
+	return StageLinks
+}
+
+func (h *LinkBlocklist) Check(_ context.Context, content string) (Decision, error) {
+	matched := false
+	redacted := linkPattern.ReplaceAllStringFunc(content, func(link string) string {
+		host := strings.ToLower(linkPattern.FindStringSubmatch(link)[1])
+		if !h.blocked(host) {
+			return link
+		}
+		matched = true
+		return linkRedaction
+	})
+
+	switch {
+	case !matched:
+		return Allow, nil
+	case h.action == models.ModerationReject:
+		return Decision{Action: models.ModerationReject, Reason: "message links to a blocked site"}, nil
+	default:
+		return Decision{Action: models.ModerationRedact, Content: redacted}, nil
+	}
+}
+
+func (h *LinkBlocklist) blocked(host string) bool {
+	for _, domain := range h.domains {
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			return true
+		}
+	}
+	return false
+}
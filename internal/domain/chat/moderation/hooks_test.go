@@ -0,0 +1,123 @@
+package moderation
+
+import (
+	"context"
+	"testing"
+
+	"github.com/dk5761/go-serv/internal/domain/chat/models"
+)
+
+func TestMaxLength(t *testing.T) {
+	hook := NewMaxLength(5)
+	tests := []struct {
+		content string
+		want    models.ModerationAction
+	}{
+		{"hello", models.ModerationAllow},
+		{"héllo", models.ModerationAllow}, // Characters, not bytes
+		{"hello!", models.ModerationReject},
+	}
+
+	for _, tt := range tests {
+		decision, err := hook.Check(context.Background(), tt.content)
+		if err != nil {
+			t.Fatalf("Check(%q): %v", tt.content, err)
+		}
+		if decision.Action != tt.want {
+			t.Errorf("Check(%q): got %s, want %s", tt.content, decision.Action, tt.want)
+		}
+	}
+
+	decision, _ := hook.Check(context.Background(), "too long")
+	if want := "message exceeds 5 characters"; decision.Reason != want {
+		t.Errorf("got reason %q, want %q", decision.Reason, want)
+	}
+}
+
+func TestWordFilter(t *testing.T) {
+	words := []string{" Darn ", "heck", ""}
+	tests := []struct {
+		name        string
+		action      models.ModerationAction
+		content     string
+		wantAction  models.ModerationAction
+		wantContent string
+	}{
+		{"clean", models.ModerationRedact, "hello there", models.ModerationAllow, ""},
+		{"redacts whole words", models.ModerationRedact, "Darn it, what the heck!", models.ModerationRedact, "**** it, what the ****!"},
+		{"ignores words inside words", models.ModerationRedact, "darned checkpoint", models.ModerationAllow, ""},
+		{"apostrophes belong to the word", models.ModerationRedact, "heck's bells", models.ModerationAllow, ""},
+		{"masks characters, not bytes", models.ModerationRedact, "ÄRGER darn", models.ModerationRedact, "ÄRGER ****"},
+		{"rejects", models.ModerationReject, "oh heck", models.ModerationReject, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decision, err := NewWordFilter(words, tt.action).Check(context.Background(), tt.content)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if decision.Action != tt.wantAction || decision.Content != tt.wantContent {
+				t.Errorf("got (%s, %q), want (%s, %q)", decision.Action, decision.Content, tt.wantAction, tt.wantContent)
+			}
+		})
+	}
+}
+
+func TestLinkBlocklist(t *testing.T) {
+	domains := []string{"Spam.example", ".scam.test.", " "}
+	tests := []struct {
+		name        string
+		action      models.ModerationAction
+		content     string
+		wantAction  models.ModerationAction
+		wantContent string
+	}{
+		{"no links", models.ModerationRedact, "see you at 5.30", models.ModerationAllow, ""},
+		{"allowed link", models.ModerationRedact, "https://go.dev/doc", models.ModerationAllow, ""},
+		{"URL", models.ModerationRedact, "visit https://spam.example/win?x=1 now", models.ModerationRedact, "visit [link removed] now"},
+		{"bare domain", models.ModerationRedact, "go to SPAM.example", models.ModerationRedact, "go to [link removed]"},
+		{"subdomain with port", models.ModerationRedact, "http://www.scam.test:8080/", models.ModerationRedact, "[link removed]"},
+		{"lookalike domain", models.ModerationRedact, "notspam.example.org", models.ModerationAllow, ""},
+		{"only blocked links", models.ModerationRedact, "go.dev or spam.example", models.ModerationRedact, "go.dev or [link removed]"},
+		{"rejects", models.ModerationReject, "spam.example", models.ModerationReject, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decision, err := NewLinkBlocklist(domains, tt.action).Check(context.Background(), tt.content)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if decision.Action != tt.wantAction || decision.Content != tt.wantContent {
+				t.Errorf("got (%s, %q), want (%s, %q)", decision.Action, decision.Content, tt.wantAction, tt.wantContent)
+			}
+		})
+	}
+}
+
+func TestClassifierHook(t *testing.T) {
+	classifier := NewFakeClassifier([]string{"Scam"})
+	tests := []struct {
+		threshold float64
+		content   string
+		want      models.ModerationAction
+	}{
+		{0.5, "hello", models.ModerationAllow},
+		{0.5, "this is a SCAM", models.ModerationReject},
+		{1, "scammer", models.ModerationReject}, // At the threshold
+	}
+
+	for _, tt := range tests {
+		decision, err := NewClassifierHook(classifier, tt.threshold).Check(context.Background(), tt.content)
+		if err != nil {
+			t.Fatalf("Check(%q): %v", tt.content, err)
+		}
+		if decision.Action != tt.want {
+			t.Errorf("Check(%q): got %s, want %s", tt.content, decision.Action, tt.want)
+		}
+		if decision.Action == models.ModerationReject && decision.Reason != "message was classified as abusive" {
+			t.Errorf("Check(%q): got reason %q", tt.content, decision.Reason)
+		}
+	}
+}
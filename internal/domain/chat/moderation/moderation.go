@@ -0,0 +1,107 @@
+// Package moderation runs message content through a chain of hooks before the message is
+// stored. Each hook allows the content, redacts parts of it or rejects the message.
+package moderation
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+
+	"github.com/dk5761/go-serv/internal/domain/chat/models"
+	"github.com/dk5761/go-serv/internal/domain/chat/repository"
+	"github.com/dk5761/go-serv/internal/domain/common"
+	"github.com/dk5761/go-serv/internal/infrastructure/logging"
+)
+
+// Decision is a hook's verdict on some content. Content holds the redacted text when
+// Action is ModerationRedact; Reason explains a rejection to the sender.
+type Decision struct {
+	Action  models.ModerationAction
+	Content string
+	Reason  string
+}
+
+// Allow is the decision of a hook that has no objection to the content.
+var Allow = Decision{Action: models.ModerationAllow}
+
+// Hook is one stage of the moderation chain.
+type Hook interface {
+	// Name identifies the stage in rejections and in the moderation log
+	Name() string
+	Check(ctx context.Context, content string) (Decision, error)
+}
+
+// RejectedError is returned by Moderate when a hook rejects a message. It wraps
+// common.ErrInvalidInput.
+type RejectedError struct {
+	Stage  string
+	Reason string
+}
+
+func (e *RejectedError) Error() string {
+	return "message rejected: " + e.Reason
+}
+
+func (e *RejectedError) Unwrap() error {
+	return common.ErrInvalidInput
+}
+
+// Pipeline runs the hooks in order. Later hooks see the content as redacted by earlier ones.
+type Pipeline struct {
+	hooks          []Hook
+	moderationRepo repository.ModerationRepository
+}
+
+func NewPipeline(moderationRepo repository.ModerationRepository, hooks ...Hook) *Pipeline {
+	return &Pipeline{hooks: hooks, moderationRepo: moderationRepo}
+}
+
+// Moderate checks the message's content, redacting it in place. When a hook rejects the
+// message, the content as sent is logged for review and a *RejectedError is returned.
+//
+// A hook that fails, e.g. because an external classifier is unreachable, is skipped:
+// moderation problems must not stop people from chatting.
+func (p *Pipeline) Moderate(ctx context.Context, message *models.Message) error {
+	// The server cannot read end-to-end encrypted messages
+	if p == nil || len(p.hooks) == 0 || message.Content == "" || message.Type == models.EncryptedMessage {
+		return nil
+	}
+
+	original := message.Content
+	for _, hook := range p.hooks {
+		decision, err := hook.Check(ctx, message.Content)
+		if err != nil {
+			logging.Logger.Warn("Moderation stage failed, skipping it",
+				zap.String("stage", hook.Name()),
+				zap.String("sender_id", message.SenderID),
+				zap.Error(err),
+			)
+			continue
+		}
+
+		switch decision.Action {
+		case models.ModerationRedact:
+			message.Content = decision.Content
+		case models.ModerationReject:
+			p.logRejection(ctx, message, original, hook.Name(), decision.Reason)
+			return &RejectedError{Stage: hook.Name(), Reason: decision.Reason}
+		}
+	}
+
+	return nil
+}
+
+func (p *Pipeline) logRejection(ctx context.Context, message *models.Message, content, stage, reason string) {
+	record := &models.ModerationRecord{
+		SenderID:       message.SenderID,
+		ReceiverID:     message.ReceiverID,
+		ConversationID: models.ConversationID(message.SenderID, message.ReceiverID),
+		Type:           message.Type,
+		Content:        content,
+		Stage:          stage,
+		Reason:         reason,
+	}
+	if err := p.moderationRepo.LogRejection(ctx, record); err != nil {
+		logging.Logger.Error("Failed to log rejected message", zap.String("stage", stage), zap.Error(err))
+	}
+}
@@ -0,0 +1,108 @@
+package moderation
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"go.uber.org/zap"
+
+	"github.com/dk5761/go-serv/internal/domain/chat/models"
+	"github.com/dk5761/go-serv/internal/domain/common"
+	"github.com/dk5761/go-serv/internal/infrastructure/logging"
+)
+
+// fakeModerationRepo keeps logged rejections in memory
+type fakeModerationRepo struct {
+	records []*models.ModerationRecord
+}
+
+func (r *fakeModerationRepo) LogRejection(_ context.Context, record *models.ModerationRecord) error {
+	r.records = append(r.records, record)
+	return nil
+}
+
+func (r *fakeModerationRepo) ListRejections(context.Context, string, int, int) ([]*models.ModerationRecord, error) {
+	return r.records, nil
+}
+
+func (r *fakeModerationRepo) RewrapDataKeys(context.Context, int) (int, error) { return 0, nil }
+
+func (r *fakeModerationRepo) SealPlaintext(context.Context, int) (int, error) { return 0, nil }
+
+// failingHook stands in for an unreachable classifier
+type failingHook struct{}
+
+func (failingHook) Name() string { return StageClassifier }
+
+func (failingHook) Check(context.Context, string) (Decision, error) {
+	return Decision{}, errors.New("classifier unavailable")
+}
+
+func TestPipelineModerate(t *testing.T) {
+	logging.Logger = zap.NewNop()
+
+	newPipeline := func(repo *fakeModerationRepo) *Pipeline {
+		return NewPipeline(repo,
+			failingHook{},
+			NewWordFilter([]string{"darn"}, models.ModerationRedact),
+			NewMaxLength(10),
+		)
+	}
+
+	t.Run("redacts", func(t *testing.T) {
+		repo := &fakeModerationRepo{}
+		message := &models.Message{SenderID: "a", ReceiverID: "b", Content: "darn it"}
+		if err := newPipeline(repo).Moderate(context.Background(), message); err != nil {
+			t.Fatal(err)
+		}
+		if message.Content != "**** it" {
+			t.Errorf("got content %q, want %q", message.Content, "**** it")
+		}
+		if len(repo.records) != 0 {
+			t.Errorf("got %d logged rejections, want 0", len(repo.records))
+		}
+	})
+
+	t.Run("rejects", func(t *testing.T) {
+		repo := &fakeModerationRepo{}
+		message := &models.Message{SenderID: "a", ReceiverID: "b", Content: "darn, this is long"}
+		err := newPipeline(repo).Moderate(context.Background(), message)
+
+		var rejected *RejectedError
+		if !errors.As(err, &rejected) {
+			t.Fatalf("got error %v, want a *RejectedError", err)
+		}
+		if rejected.Stage != StageMaxLength {
+			t.Errorf("got stage %q, want %q", rejected.Stage, StageMaxLength)
+		}
+		if !errors.Is(err, common.ErrInvalidInput) {
+			t.Error("rejection does not wrap common.ErrInvalidInput")
+		}
+		if len(repo.records) != 1 {
+			t.Fatalf("got %d logged rejections, want 1", len(repo.records))
+		}
+		// The log keeps the message as sent, not as redacted by earlier stages
+		if record := repo.records[0]; record.Content != "darn, this is long" || record.ConversationID != models.ConversationID("a", "b") {
+			t.Errorf("got logged content %q in %q", record.Content, record.ConversationID)
+		}
+	})
+
+	t.Run("skips encrypted messages", func(t *testing.T) {
+		repo := &fakeModerationRepo{}
+		message := &models.Message{Type: models.EncryptedMessage, Content: "darn, this is long"}
+		if err := newPipeline(repo).Moderate(context.Background(), message); err != nil {
+			t.Fatal(err)
+		}
+		if message.Content != "darn, this is long" {
+			t.Errorf("encrypted content was changed to %q", message.Content)
+		}
+	})
+
+	t.Run("nil pipeline", func(t *testing.T) {
+		var pipeline *Pipeline
+		if err := pipeline.Moderate(context.Background(), &models.Message{Content: "darn"}); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
@@ -0,0 +1,170 @@
+package push
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/dk5761/go-serv/internal/domain/chat/models"
+)
+
+const (
+	apnsProductionURL = "https://api.push.apple.com"
+	apnsSandboxURL    = "https://api.sandbox.push.apple.com"
+
+	// APNs accepts a provider token for an hour and rejects refreshing it more often than
+	// every 20 minutes
+	apnsTokenLifetime = 50 * time.Minute
+
+	// apns-collapse-id may be at most 64 bytes
+	maxAPNsCollapseID = 64
+)
+
+// APNsProvider sends notifications through Apple's HTTP/2 provider API, authenticating
+// with a token signed by a .p8 signing key.
+type APNsProvider struct {
+	baseURL string
+	keyID   string
+	teamID  string
+	topic   string // The app's bundle ID
+	key     *ecdsa.PrivateKey
+	client  *http.Client
+
+	mu        sync.Mutex
+	token     string
+	tokenTime time.Time
+}
+
+// NewAPNsProvider reads the PEM-encoded signing key from keyFile. Sandbox sends to
+// development builds of the app.
+func NewAPNsProvider(keyFile, keyID, teamID, topic string, sandbox bool) (*APNsProvider, error) {
+	pem, err := os.ReadFile(keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read APNs key: %w", err)
+	}
+	key, err := jwt.ParseECPrivateKeyFromPEM(pem)
+	if err != nil {
+		return nil, fmt.Errorf("invalid APNs key: %w", err)
+	}
+
+	baseURL := apnsProductionURL
+	if sandbox {
+		baseURL = apnsSandboxURL
+	}
+	return &APNsProvider{
+		baseURL: baseURL,
+		keyID:   keyID,
+		teamID:  teamID,
+		topic:   topic,
+		key:     key,
+		client:  &http.Client{Timeout: 10 * time.Second},
+	}, nil
+}
+
+func (p *APNsProvider) Platform() models.DevicePlatform {
+	return models.PlatformAPNs
+}
+
+// providerToken returns the signed token, signing a new one once the current one is old
+func (p *APNsProvider) providerToken() (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.token != "" && time.Since(p.tokenTime) < apnsTokenLifetime {
+		return p.token, nil
+	}
+
+	now := time.Now()
+	token := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.MapClaims{
+		"iss": p.teamID,
+		"iat": now.Unix(),
+	})
+	token.Header["kid"] = p.keyID
+	signed, err := token.SignedString(p.key)
+	if err != nil {
+		return "", err
+	}
+	p.token, p.tokenTime = signed, now
+	return signed, nil
+}
+
+type apnsAlert struct {
+	Title string `json:"title"`
+	Body  string `json:"body"`
+}
+
+type apnsAps struct {
+	Alert    apnsAlert `json:"alert"`
+	Badge    int       `json:"badge"`
+	Sound    string    `json:"sound"`
+	ThreadID string    `json:"thread-id,omitempty"` // Groups the conversation's notifications
+}
+
+func (p *APNsProvider) Send(ctx context.Context, notification *Notification) error {
+	// Custom data sits next to aps at the top level of the payload
+	payload := map[string]interface{}{
+		"aps": apnsAps{
+			Alert:    apnsAlert{Title: notification.Title, Body: notification.Body},
+			Badge:    notification.Badge,
+			Sound:    "default",
+			ThreadID: notification.CollapseKey,
+		},
+	}
+	for key, value := range notification.Data {
+		payload[key] = value
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	providerToken, err := p.providerToken()
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.baseURL+"/3/device/"+notification.Token, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("authorization", "bearer "+providerToken)
+	req.Header.Set("apns-topic", p.topic)
+	req.Header.Set("apns-push-type", "alert")
+	req.Header.Set("apns-priority", "10")
+	if collapseID := notification.CollapseKey; collapseID != "" && len(collapseID) <= maxAPNsCollapseID {
+		req.Header.Set("apns-collapse-id", collapseID)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrTemporary, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusOK {
+		return nil
+	}
+
+	var failure struct {
+		Reason string `json:"reason"`
+	}
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	_ = json.Unmarshal(data, &failure)
+
+	switch {
+	case resp.StatusCode == http.StatusGone || failure.Reason == "BadDeviceToken" || failure.Reason == "Unregistered":
+		return fmt.Errorf("%w: APNs answered %d %s", ErrInvalidToken, resp.StatusCode, failure.Reason)
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return fmt.Errorf("%w: APNs answered %d %s", ErrTemporary, resp.StatusCode, failure.Reason)
+	default:
+		return fmt.Errorf("APNs rejected the notification: %d %s", resp.StatusCode, failure.Reason)
+	}
+}
@@ -0,0 +1,51 @@
+package push
+
+import (
+	"go.uber.org/zap"
+
+	"github.com/dk5761/go-serv/configs"
+	authRepo "github.com/dk5761/go-serv/internal/domain/auth/repository"
+	"github.com/dk5761/go-serv/internal/domain/chat/models"
+	"github.com/dk5761/go-serv/internal/domain/chat/repository"
+	"github.com/dk5761/go-serv/internal/infrastructure/logging"
+)
+
+// NewNotifierFromConfig builds a Notifier with a provider for every configured platform.
+// Providers that fail to load are logged and left out. Without any provider it returns
+// nil, which disables push notifications.
+func NewNotifierFromConfig(
+	cfg configs.PushConfig,
+	deviceRepo repository.DeviceRepository,
+	settingsRepo repository.ConversationSettingsRepository,
+	userRepo authRepo.UserRepository,
+) *Notifier {
+	var providers []Provider
+
+	if cfg.Fake {
+		providers = append(providers, NewFakeProvider(models.PlatformFCM), NewFakeProvider(models.PlatformAPNs))
+		return NewNotifier(deviceRepo, settingsRepo, userRepo, cfg, providers...)
+	}
+
+	if cfg.FCM.ProjectID != "" {
+		provider, err := NewFCMProvider(cfg.FCM.ProjectID, cfg.FCM.CredentialsFile)
+		if err != nil {
+			logging.Logger.Error("Failed to load FCM push provider, skipping it", zap.Error(err))
+		} else {
+			providers = append(providers, provider)
+		}
+	}
+
+	if cfg.APNs.KeyFile != "" {
+		provider, err := NewAPNsProvider(cfg.APNs.KeyFile, cfg.APNs.KeyID, cfg.APNs.TeamID, cfg.APNs.Topic, cfg.APNs.Sandbox)
+		if err != nil {
+			logging.Logger.Error("Failed to load APNs push provider, skipping it", zap.Error(err))
+		} else {
+			providers = append(providers, provider)
+		}
+	}
+
+	if len(providers) == 0 {
+		return nil
+	}
+	return NewNotifier(deviceRepo, settingsRepo, userRepo, cfg, providers...)
+}
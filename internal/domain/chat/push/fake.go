@@ -0,0 +1,52 @@
+package push
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/dk5761/go-serv/internal/domain/chat/models"
+)
+
+// FakeProvider keeps notifications in memory instead of sending them, for tests and local
+// development. Tokens marked invalid fail with ErrInvalidToken.
+type FakeProvider struct {
+	platform models.DevicePlatform
+
+	mu      sync.Mutex
+	sent    []*Notification
+	invalid map[string]bool
+}
+
+func NewFakeProvider(platform models.DevicePlatform) *FakeProvider {
+	return &FakeProvider{platform: platform, invalid: make(map[string]bool)}
+}
+
+func (p *FakeProvider) Platform() models.DevicePlatform {
+	return p.platform
+}
+
+func (p *FakeProvider) Send(ctx context.Context, notification *Notification) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.invalid[notification.Token] {
+		return fmt.Errorf("%w: %s", ErrInvalidToken, notification.Token)
+	}
+	p.sent = append(p.sent, notification)
+	return nil
+}
+
+// Invalidate makes sends to token fail as if the app had been uninstalled
+func (p *FakeProvider) Invalidate(token string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.invalid[token] = true
+}
+
+// Sent returns the notifications sent so far, oldest first
+func (p *FakeProvider) Sent() []*Notification {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]*Notification(nil), p.sent...)
+}
@@ -0,0 +1,138 @@
+package push
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+
+	"github.com/dk5761/go-serv/internal/domain/chat/models"
+)
+
+const fcmScope = "https://www.googleapis.com/auth/firebase.messaging"
+
+// FCMProvider sends notifications through the Firebase Cloud Messaging HTTP v1 API,
+// authenticating with a service account.
+type FCMProvider struct {
+	url    string
+	client *http.Client
+}
+
+// NewFCMProvider reads the service account's JSON credentials from credentialsFile
+func NewFCMProvider(projectID, credentialsFile string) (*FCMProvider, error) {
+	credentialsJSON, err := os.ReadFile(credentialsFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read FCM credentials: %w", err)
+	}
+	credentials, err := google.CredentialsFromJSON(context.Background(), credentialsJSON, fcmScope)
+	if err != nil {
+		return nil, fmt.Errorf("invalid FCM credentials: %w", err)
+	}
+
+	return &FCMProvider{
+		url:    fmt.Sprintf("https://fcm.googleapis.com/v1/projects/%s/messages:send", projectID),
+		client: oauth2.NewClient(context.Background(), credentials.TokenSource),
+	}, nil
+}
+
+func (p *FCMProvider) Platform() models.DevicePlatform {
+	return models.PlatformFCM
+}
+
+type fcmRequest struct {
+	Message fcmMessage `json:"message"`
+}
+
+type fcmMessage struct {
+	Token        string            `json:"token"`
+	Notification fcmNotification   `json:"notification"`
+	Data         map[string]string `json:"data,omitempty"`
+	Android      fcmAndroid        `json:"android"`
+}
+
+type fcmNotification struct {
+	Title string `json:"title"`
+	Body  string `json:"body"`
+}
+
+type fcmAndroid struct {
+	CollapseKey  string                 `json:"collapse_key,omitempty"`
+	Notification fcmAndroidNotification `json:"notification"`
+}
+
+type fcmAndroidNotification struct {
+	Tag               string `json:"tag,omitempty"` // Replaces the shown notification with the same tag
+	NotificationCount int    `json:"notification_count"`
+}
+
+type fcmError struct {
+	Error struct {
+		Status  string `json:"status"`
+		Message string `json:"message"`
+		Details []struct {
+			ErrorCode string `json:"errorCode"`
+		} `json:"details"`
+	} `json:"error"`
+}
+
+func (p *FCMProvider) Send(ctx context.Context, notification *Notification) error {
+	body, err := json.Marshal(fcmRequest{Message: fcmMessage{
+		Token:        notification.Token,
+		Notification: fcmNotification{Title: notification.Title, Body: notification.Body},
+		Data:         notification.Data,
+		Android: fcmAndroid{
+			CollapseKey: notification.CollapseKey,
+			Notification: fcmAndroidNotification{
+				Tag:               notification.CollapseKey,
+				NotificationCount: notification.Badge,
+			},
+		},
+	}})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrTemporary, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusOK {
+		return nil
+	}
+
+	var failure fcmError
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	_ = json.Unmarshal(data, &failure)
+	reason := failure.Error.Status
+	for _, detail := range failure.Error.Details {
+		if detail.ErrorCode != "" {
+			reason = detail.ErrorCode
+		}
+	}
+	if reason == "" {
+		reason = strconv.Itoa(resp.StatusCode)
+	}
+
+	switch {
+	case reason == "UNREGISTERED" || resp.StatusCode == http.StatusNotFound:
+		return fmt.Errorf("%w: FCM answered %s", ErrInvalidToken, reason)
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return fmt.Errorf("%w: FCM answered %s", ErrTemporary, reason)
+	default:
+		return fmt.Errorf("FCM rejected the notification: %s %s", reason, failure.Error.Message)
+	}
+}
@@ -0,0 +1,288 @@
+package push
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"github.com/dk5761/go-serv/configs"
+	authRepo "github.com/dk5761/go-serv/internal/domain/auth/repository"
+	"github.com/dk5761/go-serv/internal/domain/chat/models"
+	"github.com/dk5761/go-serv/internal/domain/chat/repository"
+	"github.com/dk5761/go-serv/internal/domain/common"
+	"github.com/dk5761/go-serv/internal/infrastructure/logging"
+)
+
+const (
+	defaultPushWorkers        = 4
+	defaultPushQueueSize      = 1024
+	defaultPushMaxAttempts    = 4
+	defaultPushCollapseWindow = 10 * time.Second
+
+	pushSendTimeout  = 10 * time.Second
+	pushRetryBackoff = time.Second
+	maxPreviewLength = 120
+)
+
+// Notifier pushes notifications for messages whose receiver has no connected device.
+//
+// Notify only queues the notification; Run's workers send it, retrying temporary
+// failures and deleting tokens the push service rejects. The first message of a
+// conversation is pushed right away. Further messages within the collapse window are
+// summed up in one notification at the end of the window, which replaces the first one on
+// the device. Muted conversations are not pushed, except for messages mentioning the
+// receiver.
+type Notifier struct {
+	providers    map[models.DevicePlatform]Provider
+	deviceRepo   repository.DeviceRepository
+	settingsRepo repository.ConversationSettingsRepository
+	userRepo     authRepo.UserRepository
+
+	workers        int
+	maxAttempts    int
+	collapseWindow time.Duration
+	queue          chan *burst
+
+	mu      sync.Mutex
+	windows map[string]*burst // Open collapse windows by receiver and conversation
+}
+
+// burst is the messages of one conversation covered by one notification
+type burst struct {
+	receiverID     string
+	conversationID string
+	count          int
+	last           *models.Message
+	mentioned      bool
+}
+
+func NewNotifier(
+	deviceRepo repository.DeviceRepository,
+	settingsRepo repository.ConversationSettingsRepository,
+	userRepo authRepo.UserRepository,
+	cfg configs.PushConfig,
+	providers ...Provider,
+) *Notifier {
+	n := &Notifier{
+		providers:      make(map[models.DevicePlatform]Provider, len(providers)),
+		deviceRepo:     deviceRepo,
+		settingsRepo:   settingsRepo,
+		userRepo:       userRepo,
+		workers:        cfg.Workers,
+		maxAttempts:    cfg.MaxAttempts,
+		collapseWindow: time.Duration(cfg.CollapseWindow) * time.Second,
+		windows:        make(map[string]*burst),
+	}
+	for _, provider := range providers {
+		n.providers[provider.Platform()] = provider
+	}
+	if n.workers <= 0 {
+		n.workers = defaultPushWorkers
+	}
+	if n.maxAttempts <= 0 {
+		n.maxAttempts = defaultPushMaxAttempts
+	}
+	if n.collapseWindow <= 0 {
+		n.collapseWindow = defaultPushCollapseWindow
+	}
+	queueSize := cfg.QueueSize
+	if queueSize <= 0 {
+		queueSize = defaultPushQueueSize
+	}
+	n.queue = make(chan *burst, queueSize)
+	return n
+}
+
+// Notify queues a push for a message its receiver could not be sent. It never blocks, and
+// a nil Notifier does nothing.
+func (n *Notifier) Notify(message *models.Message) {
+	if n == nil || message.Type == models.SystemMessage {
+		return
+	}
+
+	key := message.ReceiverID + ":" + message.ConversationID
+	mentioned := mentions(message, message.ReceiverID)
+
+	n.mu.Lock()
+	if window, ok := n.windows[key]; ok {
+		window.count++
+		window.last = message
+		window.mentioned = window.mentioned || mentioned
+		n.mu.Unlock()
+		return
+	}
+	n.windows[key] = &burst{receiverID: message.ReceiverID, conversationID: message.ConversationID, count: 1}
+	n.mu.Unlock()
+
+	time.AfterFunc(n.collapseWindow, func() { n.closeWindow(key) })
+	n.enqueue(&burst{
+		receiverID:     message.ReceiverID,
+		conversationID: message.ConversationID,
+		count:          1,
+		last:           message,
+		mentioned:      mentioned,
+	})
+}
+
+// closeWindow pushes the summary of the messages that arrived during the window, if any
+func (n *Notifier) closeWindow(key string) {
+	n.mu.Lock()
+	window := n.windows[key]
+	delete(n.windows, key)
+	n.mu.Unlock()
+
+	if window != nil && window.last != nil {
+		n.enqueue(window)
+	}
+}
+
+func (n *Notifier) enqueue(b *burst) {
+	select {
+	case n.queue <- b:
+	default:
+		logging.Logger.Warn("Push queue is full, dropping notification", zap.String("user_id", b.receiverID))
+	}
+}
+
+func mentions(message *models.Message, userID string) bool {
+	for _, mention := range message.Mentions {
+		if mention.UserID == userID {
+			return true
+		}
+	}
+	return false
+}
+
+// Run sends queued notifications until ctx is cancelled
+func (n *Notifier) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for i := 0; i < n.workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case b := <-n.queue:
+					n.deliver(ctx, b)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func (n *Notifier) deliver(ctx context.Context, b *burst) {
+	now := time.Now()
+
+	settings, err := n.settingsRepo.GetSettings(ctx, b.receiverID, b.conversationID)
+	if err != nil && !errors.Is(err, common.ErrNotFound) {
+		logging.Logger.Error("Failed to fetch conversation settings for push", zap.Error(err))
+		return
+	}
+	if settings != nil && settings.IsMuted(now) && !b.mentioned {
+		return
+	}
+
+	devices, err := n.deviceRepo.ListDevices(ctx, b.receiverID)
+	if err != nil {
+		logging.Logger.Error("Failed to fetch devices for push", zap.String("user_id", b.receiverID), zap.Error(err))
+		return
+	}
+	if len(devices) == 0 {
+		return
+	}
+
+	badge, err := n.settingsRepo.CountUnread(ctx, b.receiverID, now)
+	if err != nil {
+		logging.Logger.Error("Failed to count unread messages for push", zap.String("user_id", b.receiverID), zap.Error(err))
+	}
+
+	notification := Notification{
+		Title:       n.title(ctx, b.last),
+		Body:        body(b),
+		Badge:       badge,
+		CollapseKey: b.conversationID,
+		Data: map[string]string{
+			"conversation_id": b.conversationID,
+			"message_id":      b.last.ID.Hex(),
+			"seq":             strconv.FormatInt(b.last.Seq, 10),
+		},
+	}
+
+	var invalid []string
+	for _, device := range devices {
+		provider, ok := n.providers[device.Platform]
+		if !ok {
+			continue
+		}
+
+		notification := notification
+		notification.Token = device.Token
+		err := n.send(ctx, provider, &notification)
+		switch {
+		case err == nil:
+		case errors.Is(err, ErrInvalidToken):
+			invalid = append(invalid, device.Token)
+		default:
+			logging.Logger.Error("Failed to push notification",
+				zap.String("user_id", b.receiverID),
+				zap.String("platform", string(device.Platform)),
+				zap.Error(err),
+			)
+		}
+	}
+
+	if len(invalid) > 0 {
+		if _, err := n.deviceRepo.DeleteTokens(ctx, invalid); err != nil {
+			logging.Logger.Error("Failed to delete invalid device tokens", zap.Error(err))
+		}
+	}
+}
+
+// send retries temporary failures, waiting twice as long after every attempt
+func (n *Notifier) send(ctx context.Context, provider Provider, notification *Notification) error {
+	backoff := pushRetryBackoff
+	for attempt := 1; ; attempt++ {
+		sendCtx, cancel := context.WithTimeout(ctx, pushSendTimeout)
+		err := provider.Send(sendCtx, notification)
+		cancel()
+		if err == nil || !errors.Is(err, ErrTemporary) || attempt >= n.maxAttempts {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// title names the sender, falling back to a generic title
+func (n *Notifier) title(ctx context.Context, message *models.Message) string {
+	senderID, err := uuid.Parse(message.SenderID)
+	if err != nil {
+		return "New message"
+	}
+	sender, err := n.userRepo.GetUserByID(ctx, senderID)
+	if err != nil || sender == nil {
+		return "New message"
+	}
+	return sender.Username
+}
+
+func body(b *burst) string {
+	if b.count > 1 {
+		return fmt.Sprintf("%d new messages", b.count)
+	}
+	return b.last.Preview(maxPreviewLength)
+}
@@ -0,0 +1,38 @@
+// Package push notifies users of messages that arrive while none of their devices is
+// connected, through the push service of each device's platform.
+package push
+
+import (
+	"context"
+	"errors"
+
+	"github.com/dk5761/go-serv/internal/domain/chat/models"
+)
+
+var (
+	// ErrInvalidToken is returned by a Provider for a token the push service no longer
+	// accepts, e.g. because the app was uninstalled. Such tokens are deleted.
+	ErrInvalidToken = errors.New("device token is no longer valid")
+	// ErrTemporary is wrapped by Provider errors that are worth retrying, such as rate
+	// limits and server errors.
+	ErrTemporary = errors.New("push service temporarily unavailable")
+)
+
+// Notification is one push to one device.
+type Notification struct {
+	Token string
+	Title string
+	Body  string
+	Badge int // Total unread count to show on the app icon
+
+	// CollapseKey makes a newer notification replace an older one with the same key on the
+	// device; it is the conversation ID, so a conversation shows one notification at a time
+	CollapseKey string
+	Data        map[string]string
+}
+
+// Provider delivers notifications through a platform's push service.
+type Provider interface {
+	Platform() models.DevicePlatform
+	Send(ctx context.Context, notification *Notification) error
+}
@@ -0,0 +1,16 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/dk5761/go-serv/internal/domain/chat/models"
+)
+
+// AttachmentRepository stores the metadata of uploaded files.
+type AttachmentRepository interface {
+	SaveAttachment(ctx context.Context, attachment *models.Attachment) error
+	// GetAttachment returns the attachment the user uploaded at fileURL, or common.ErrNotFound.
+	GetAttachment(ctx context.Context, fileURL, ownerID string) (*models.Attachment, error)
+	// DeleteAttachment removes the metadata of the file at fileURL, if there is any.
+	DeleteAttachment(ctx context.Context, fileURL string) error
+}
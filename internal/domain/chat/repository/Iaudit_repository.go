@@ -0,0 +1,11 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/dk5761/go-serv/internal/domain/chat/models"
+)
+
+type AuditRepository interface {
+	RecordAudit(ctx context.Context, entry *models.AuditEntry) error
+}
@@ -0,0 +1,17 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/dk5761/go-serv/internal/domain/chat/models"
+)
+
+type ConversationRepository interface {
+	// GetConversation returns the conversation settings, or common.ErrNotFound if none were ever saved.
+	GetConversation(ctx context.Context, conversationID string) (*models.Conversation, error)
+	SetMessageTTL(ctx context.Context, conversationID string, ttlSeconds int64, updatedBy string) (*models.Conversation, error)
+	// SetRetention sets the conversation's retention policy; a zero policy leaves only the global one.
+	SetRetention(ctx context.Context, conversationID string, policy models.RetentionPolicy, updatedBy string) (*models.Conversation, error)
+	// GetRetentionPolicies returns the conversations that have their own retention policy.
+	GetRetentionPolicies(ctx context.Context) (map[string]*models.RetentionPolicy, error)
+}
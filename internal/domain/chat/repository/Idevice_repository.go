@@ -0,0 +1,18 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/dk5761/go-serv/internal/domain/chat/models"
+)
+
+// DeviceRepository stores the push tokens of users' devices.
+type DeviceRepository interface {
+	// RegisterDevice saves the token for the user, taking it over from any other user.
+	RegisterDevice(ctx context.Context, device *models.Device) (*models.Device, error)
+	// UnregisterDevice removes one of the user's tokens, or returns common.ErrNotFound.
+	UnregisterDevice(ctx context.Context, userID, token string) error
+	ListDevices(ctx context.Context, userID string) ([]*models.Device, error)
+	// DeleteTokens removes tokens a push service reported as no longer valid.
+	DeleteTokens(ctx context.Context, tokens []string) (int64, error)
+}
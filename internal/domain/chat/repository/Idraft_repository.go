@@ -0,0 +1,19 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/dk5761/go-serv/internal/domain/chat/models"
+)
+
+type DraftRepository interface {
+	// SaveDraft stores the draft unless a newer one is already saved. It returns the draft
+	// that is current after the call and whether the given draft won.
+	SaveDraft(ctx context.Context, draft *models.Draft) (*models.Draft, bool, error)
+	GetDraft(ctx context.Context, userID, conversationID string) (*models.Draft, error)
+	// ClearDraft empties a draft last written before at, reporting whether anything was cleared.
+	ClearDraft(ctx context.Context, userID, conversationID string, at time.Time) (bool, error)
+
+	EncryptedRepository
+}
@@ -0,0 +1,15 @@
+package repository
+
+import "context"
+
+// EncryptedRepository is implemented by repositories that encrypt their documents at rest,
+// so that the key rotator can keep them current.
+type EncryptedRepository interface {
+	// RewrapDataKeys re-wraps the data keys of up to limit encrypted documents whose key is
+	// wrapped by a master key other than the active one, returning how many it re-wrapped.
+	// The encrypted fields themselves are not rewritten.
+	RewrapDataKeys(ctx context.Context, limit int) (int, error)
+	// SealPlaintext encrypts up to limit documents stored in plaintext, returning how many
+	// it encrypted. Both are no-ops when encryption at rest is off.
+	SealPlaintext(ctx context.Context, limit int) (int, error)
+}
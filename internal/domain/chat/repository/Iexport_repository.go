@@ -0,0 +1,22 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/dk5761/go-serv/internal/domain/chat/models"
+)
+
+type ExportJobRepository interface {
+	CreateExportJob(ctx context.Context, job *models.ExportJob) error
+	// GetExportJob returns the user's export job, or common.ErrNotFound if it does not exist or belongs to someone else.
+	GetExportJob(ctx context.Context, id primitive.ObjectID, userID string) (*models.ExportJob, error)
+
+	// ClaimExportJob leases the oldest pending export to owner, also picking up exports whose
+	// previous owner stopped renewing its lease. It returns nil when there is nothing to do.
+	ClaimExportJob(ctx context.Context, owner string, now time.Time, lease time.Duration) (*models.ExportJob, error)
+	// FinishExportJob records the outcome of an export leased by owner and releases it.
+	FinishExportJob(ctx context.Context, id primitive.ObjectID, owner string, status models.ExportStatus, fileURL, lastErr string) (*models.ExportJob, error)
+}
@@ -0,0 +1,14 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/dk5761/go-serv/internal/domain/chat/models"
+)
+
+type ModerationRepository interface {
+	LogRejection(ctx context.Context, record *models.ModerationRecord) error
+	ListRejections(ctx context.Context, stage string, limit, offset int) ([]*models.ModerationRecord, error)
+
+	EncryptedRepository
+}
@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"github.com/google/uuid"
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -13,10 +14,69 @@ type MessageRepository interface {
 	SaveMessage(ctx context.Context, msg *models.Message) (primitive.ObjectID, error)
 	GetMessages(ctx context.Context, userID1, userID2 uuid.UUID, limit, offset int) ([]*models.Message, error)
 	GetUndeliveredMessages(ctx context.Context, receiverID string) ([]*models.Message, error)
-	MarkMessageAsDelivered(ctx context.Context, messageID primitive.ObjectID) error
-	StoreUndeliveredMessage(ctx context.Context, msg *models.Message) (primitive.ObjectID, error)
-	UpdateMessageStatus(ctx context.Context, messageID primitive.ObjectID, status models.MessageStatus) error
 	GetMessage(ctx context.Context, messageID primitive.ObjectID) (*models.Message, error)
+
+	// AdvanceStatus moves a message forward to status, recording when it reached status and
+	// any status it skipped. It returns the updated message, or nil if the message is
+	// already at or past status, so statuses never go backwards and every transition happens
+	// once. A non-empty receiverID only matches messages addressed to that user.
+	AdvanceStatus(ctx context.Context, messageID primitive.ObjectID, status models.MessageStatus, receiverID string) (*models.Message, error)
+	// MarkAcknowledgmentPending records that the sender could not be told the message's
+	// current status. Its status and delivery state are left as they are.
 	MarkAcknowledgmentPending(ctx context.Context, messageID primitive.ObjectID) error
-	GetPendingAcknowledgments(ctx context.Context, receiverID string) ([]*models.Message, error)
+	// ClearAcknowledgmentPending clears the pending acknowledgment once the sender was told
+	// status, unless the message has moved on since and the sender still needs to hear about it.
+	ClearAcknowledgmentPending(ctx context.Context, messageID primitive.ObjectID, status models.MessageStatus) error
+	// GetPendingAcknowledgments retrieves up to limit messages of the senders with a pending
+	// acknowledgment, oldest first. A limit of 0 retrieves them all.
+	GetPendingAcknowledgments(ctx context.Context, senderIDs []string, limit int) ([]*models.Message, error)
+
+	// ClaimRedelivery leases the oldest message still in Stored for one of receiverIDs that
+	// is due for another delivery attempt, counting the attempt. Messages created after
+	// settledBefore are left to the live send path. It returns nil when nothing is due.
+	ClaimRedelivery(ctx context.Context, receiverIDs []string, now, settledBefore time.Time, lease time.Duration) (*models.Message, error)
+	// RecordDeliveryFailure stores why a delivery attempt failed and when to try again, or
+	// marks the delivery as permanently failed.
+	RecordDeliveryFailure(ctx context.Context, messageID primitive.ObjectID, lastErr string, retryAt time.Time, failed bool) error
+	GetExpiredMessages(ctx context.Context, now time.Time, limit int) ([]*models.Message, error)
+	DeleteMessage(ctx context.Context, messageID primitive.ObjectID) (bool, error)
+	GetMentions(ctx context.Context, userID string, limit, offset int) ([]*models.Message, error)
+
+	// StreamConversation calls fn for every message of the conversation, oldest first, reading
+	// them from a cursor one at a time. It stops at the first error returned by fn.
+	StreamConversation(ctx context.Context, conversationID string, fn func(*models.Message) error) error
+	CountConversationMessages(ctx context.Context, conversationID string) (int64, error)
+
+	// InsertImportedMessages bulk-inserts messages as given, keeping their timestamps and
+	// delivery state. Messages whose ImportKey was already imported are skipped, and the
+	// messages that were inserted are returned. History older than a conversation's first
+	// message is numbered before it, with numbers below 1; the rest after its last message.
+	InsertImportedMessages(ctx context.Context, messages []*models.Message) ([]*models.Message, error)
+
+	// StreamConversationStats calls fn with the message count and oldest message time of every conversation.
+	StreamConversationStats(ctx context.Context, fn func(*models.ConversationStats) error) error
+	// GetNthNewestMessage returns the n-th newest message of a conversation, counting from 1,
+	// or nil when the conversation has fewer messages.
+	GetNthNewestMessage(ctx context.Context, conversationID string, n int) (*models.Message, error)
+	// FindMessagesUpTo returns up to limit messages of a conversation that sort at or before
+	// the (createdAt, id) position, oldest first. A nil id excludes messages created at createdAt.
+	FindMessagesUpTo(ctx context.Context, conversationID string, createdAt time.Time, id primitive.ObjectID, limit int) ([]*models.Message, error)
+	// CountMessagesUpTo counts the messages FindMessagesUpTo would visit and how many have an attachment.
+	CountMessagesUpTo(ctx context.Context, conversationID string, createdAt time.Time, id primitive.ObjectID) (int64, int64, error)
+	DeleteMessages(ctx context.Context, messageIDs []primitive.ObjectID) (int64, error)
+
+	// GetMessagesAround returns up to before messages preceding the (createdAt, id) position
+	// and up to after messages following it, oldest first, leaving out the message at the position.
+	GetMessagesAround(ctx context.Context, conversationID string, createdAt time.Time, id primitive.ObjectID, before, after int) ([]*models.Message, error)
+
+	// LastSeq returns the highest sequence number allocated in the conversation, 0 if none was.
+	LastSeq(ctx context.Context, conversationID string) (int64, error)
+	// GetMessagesAfterSeq returns up to limit messages of the conversation with afterSeq < seq < beforeSeq,
+	// in sequence order. A beforeSeq of 0 leaves the range open-ended.
+	GetMessagesAfterSeq(ctx context.Context, conversationID string, afterSeq, beforeSeq int64, limit int) ([]*models.Message, error)
+	// GetMessagesBeforeSeq returns the newest limit messages of the conversation with seq < beforeSeq,
+	// in sequence order. A beforeSeq of 0 returns the newest messages.
+	GetMessagesBeforeSeq(ctx context.Context, conversationID string, beforeSeq int64, limit int) ([]*models.Message, error)
+
+	EncryptedRepository
 }
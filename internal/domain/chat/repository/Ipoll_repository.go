@@ -0,0 +1,19 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/dk5761/go-serv/internal/domain/chat/models"
+)
+
+type PollRepository interface {
+	// ReplaceVote atomically replaces the user's vote, an empty optionIDs retracting it, and
+	// returns the new tally. It reports false without voting if the poll is closed at now.
+	ReplaceVote(ctx context.Context, pollID primitive.ObjectID, poll *models.PollPayload, userID string, optionIDs []string, now time.Time) (*models.PollResults, bool, error)
+	// ClosePoll freezes the results and returns them, reporting false if the poll was already closed.
+	ClosePoll(ctx context.Context, pollID primitive.ObjectID, closedAt time.Time) (*models.PollResults, bool, error)
+	GetDuePolls(ctx context.Context, now time.Time, limit int) ([]*models.Message, error)
+}
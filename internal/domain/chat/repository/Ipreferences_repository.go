@@ -0,0 +1,22 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/dk5761/go-serv/internal/domain/chat/models"
+)
+
+type UserPreferencesRepository interface {
+	// GetPreferences returns the user's preferences, or common.ErrNotFound if none were ever saved.
+	GetPreferences(ctx context.Context, userID string) (*models.UserPreferences, error)
+	// SavePreferences stores the settings the user chooses, leaving the digest bookkeeping alone.
+	SavePreferences(ctx context.Context, preferences *models.UserPreferences) error
+	SetDigestFrequency(ctx context.Context, userID string, frequency models.DigestFrequency) error
+
+	// ClaimDigest records that a digest is being sent to the user at now, unless one was sent
+	// after dueBefore, e.g. by another instance. It reports whether the claim succeeded.
+	ClaimDigest(ctx context.Context, userID string, dueBefore, now time.Time) (bool, error)
+	// ReleaseDigest undoes a claim made at claimedAt after the digest could not be sent.
+	ReleaseDigest(ctx context.Context, userID string, claimedAt time.Time, previous *time.Time) error
+}
@@ -0,0 +1,27 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/dk5761/go-serv/internal/domain/chat/models"
+)
+
+type ReportRepository interface {
+	// CreateReport stores a new open report. It fails with common.ErrConflict while the
+	// reporter has an unresolved report with the same dedupe key.
+	CreateReport(ctx context.Context, report *models.Report) error
+	GetReport(ctx context.Context, reportID primitive.ObjectID) (*models.Report, error)
+	ListReports(ctx context.Context, filter models.ReportFilter, limit, offset int) ([]*models.Report, error)
+
+	// ClaimReport assigns an unresolved report to a moderator. Reports claimed by another
+	// moderator more than lease ago can be claimed again; otherwise it fails with common.ErrConflict.
+	ClaimReport(ctx context.Context, reportID primitive.ObjectID, moderatorID string, now time.Time, lease time.Duration) (*models.Report, error)
+	// ResolveReport resolves a report claimed by the moderator, failing with
+	// common.ErrConflict when the moderator does not hold the claim.
+	ResolveReport(ctx context.Context, reportID primitive.ObjectID, moderatorID string, resolution *models.ReportResolution) (*models.Report, error)
+
+	EncryptedRepository
+}
@@ -0,0 +1,25 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/dk5761/go-serv/internal/domain/chat/models"
+)
+
+type ScheduledMessageRepository interface {
+	CreateScheduledMessage(ctx context.Context, msg *models.ScheduledMessage) (primitive.ObjectID, error)
+	GetScheduledMessage(ctx context.Context, id primitive.ObjectID, senderID string) (*models.ScheduledMessage, error)
+	ListScheduledMessages(ctx context.Context, senderID string) ([]*models.ScheduledMessage, error)
+	UpdateScheduledMessage(ctx context.Context, id primitive.ObjectID, senderID, content string, sendAt time.Time) error
+	CancelScheduledMessage(ctx context.Context, id primitive.ObjectID, senderID string) error
+
+	// ClaimDueMessage leases one due message to owner, returning nil when nothing is due.
+	ClaimDueMessage(ctx context.Context, owner string, now time.Time, lease time.Duration) (*models.ScheduledMessage, error)
+	MarkScheduledSent(ctx context.Context, id primitive.ObjectID, owner string) error
+	ReleaseScheduledMessage(ctx context.Context, id primitive.ObjectID, owner string, lastErr string, failed bool) error
+
+	EncryptedRepository
+}
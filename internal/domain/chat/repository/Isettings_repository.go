@@ -0,0 +1,36 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/dk5761/go-serv/internal/domain/chat/models"
+)
+
+// ConversationSettingsRepository stores each user's per-conversation inbox settings. Every
+// setter creates the settings document when the user has none yet.
+type ConversationSettingsRepository interface {
+	// GetSettings returns the user's settings, or common.ErrNotFound if none were ever saved.
+	GetSettings(ctx context.Context, userID, conversationID string) (*models.ConversationSettings, error)
+	// ListSettings returns the user's conversations matching filter, most recently active first.
+	ListSettings(ctx context.Context, userID string, filter models.InboxFilter, limit, offset int) ([]*models.ConversationSettings, error)
+	SetMute(ctx context.Context, userID, conversationID string, until *time.Time, forever bool) (*models.ConversationSettings, error)
+	SetArchived(ctx context.Context, userID, conversationID string, archived bool) (*models.ConversationSettings, error)
+	SetFolders(ctx context.Context, userID, conversationID string, folders []string) (*models.ConversationSettings, error)
+	MarkRead(ctx context.Context, userID, conversationID string) (*models.ConversationSettings, error)
+
+	// RecordMessage moves the conversation's last activity to message, counting it as unread
+	// when it was received by the user.
+	RecordMessage(ctx context.Context, userID string, message *models.Message, incoming bool) (*models.ConversationSettings, error)
+	// CountUnread totals the user's unread messages the way badges show them, leaving out
+	// conversations muted at now.
+	CountUnread(ctx context.Context, userID string, now time.Time) (int, error)
+	// ListDigestUsers pages through the users with unread messages in a conversation that
+	// has been quiet since idleBefore, in user ID order, starting after afterUserID.
+	ListDigestUsers(ctx context.Context, idleBefore time.Time, afterUserID string, limit int) ([]string, error)
+	// ListUnread returns the user's conversations with unread messages whose last message
+	// arrived in (since, before], leaving out conversations muted at now.
+	ListUnread(ctx context.Context, userID string, since, before, now time.Time, limit int) ([]*models.ConversationSettings, error)
+	// Unarchive moves an archived conversation back to the inbox and reports whether it was archived.
+	Unarchive(ctx context.Context, userID, conversationID string) (bool, error)
+}
@@ -0,0 +1,29 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/dk5761/go-serv/internal/domain/chat/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// WebhookRepository stores incoming webhooks. Methods looking a hook up return
+// common.ErrNotFound when it does not exist.
+type WebhookRepository interface {
+	CreateWebhook(ctx context.Context, webhook *models.IncomingWebhook) (*models.IncomingWebhook, error)
+	GetWebhook(ctx context.Context, id primitive.ObjectID) (*models.IncomingWebhook, error)
+	GetWebhookByTokenHash(ctx context.Context, tokenHash string) (*models.IncomingWebhook, error)
+	ListWebhooks(ctx context.Context, conversationID string) ([]*models.IncomingWebhook, error)
+	// SetTokenHash replaces the hook's token, reinstating a revoked hook.
+	SetTokenHash(ctx context.Context, id primitive.ObjectID, tokenHash string) (*models.IncomingWebhook, error)
+	// RevokeToken unsets the hook's token so that posting to it fails until a new one is set.
+	RevokeToken(ctx context.Context, id primitive.ObjectID, now time.Time) (*models.IncomingWebhook, error)
+	DeleteWebhook(ctx context.Context, id primitive.ObjectID) error
+
+	// TakeRateSlot counts a request against the hook's limit of requests per window and
+	// records its use. It reports false, without recording anything, when the limit is reached.
+	TakeRateSlot(ctx context.Context, id primitive.ObjectID, now time.Time, window time.Duration, limit int) (bool, error)
+	// RecordError counts a failed request and keeps its error.
+	RecordError(ctx context.Context, id primitive.ObjectID, message string, now time.Time) error
+}
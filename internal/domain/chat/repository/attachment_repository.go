@@ -0,0 +1,46 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/dk5761/go-serv/internal/domain/chat/models"
+	"github.com/dk5761/go-serv/internal/domain/common"
+)
+
+type mongoAttachmentRepository struct {
+	collection *mongo.Collection
+}
+
+// NewMongoAttachmentRepository initializes a new instance of mongoAttachmentRepository
+func NewMongoAttachmentRepository(db *mongo.Database) AttachmentRepository {
+	return &mongoAttachmentRepository{
+		collection: db.Collection("attachments"),
+	}
+}
+
+func (r *mongoAttachmentRepository) SaveAttachment(ctx context.Context, attachment *models.Attachment) error {
+	_, err := r.collection.InsertOne(ctx, attachment)
+	return err
+}
+
+func (r *mongoAttachmentRepository) GetAttachment(ctx context.Context, fileURL, ownerID string) (*models.Attachment, error) {
+	var attachment models.Attachment
+	err := r.collection.FindOne(ctx, bson.M{"_id": fileURL, "owner_id": ownerID}).Decode(&attachment)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, fmt.Errorf("%w: attachment not found", common.ErrNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &attachment, nil
+}
+
+func (r *mongoAttachmentRepository) DeleteAttachment(ctx context.Context, fileURL string) error {
+	_, err := r.collection.DeleteOne(ctx, bson.M{"_id": fileURL})
+	return err
+}
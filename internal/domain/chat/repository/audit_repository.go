@@ -0,0 +1,31 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/dk5761/go-serv/internal/domain/chat/models"
+)
+
+type mongoAuditRepository struct {
+	collection *mongo.Collection
+}
+
+// NewMongoAuditRepository initializes a new instance of mongoAuditRepository
+func NewMongoAuditRepository(db *mongo.Database) AuditRepository {
+	return &mongoAuditRepository{
+		collection: db.Collection("audit_log"),
+	}
+}
+
+// RecordAudit appends an entry to the audit log
+func (r *mongoAuditRepository) RecordAudit(ctx context.Context, entry *models.AuditEntry) error {
+	entry.ID = primitive.NewObjectID()
+	entry.CreatedAt = time.Now()
+
+	_, err := r.collection.InsertOne(ctx, entry)
+	return err
+}
@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/dk5761/go-serv/internal/domain/chat/models"
+	"github.com/dk5761/go-serv/internal/domain/common"
+)
+
+type mongoConversationRepository struct {
+	collection *mongo.Collection
+}
+
+// NewMongoConversationRepository initializes a new instance of mongoConversationRepository
+func NewMongoConversationRepository(db *mongo.Database) ConversationRepository {
+	return &mongoConversationRepository{
+		collection: db.Collection("conversations"),
+	}
+}
+
+// GetConversation retrieves the settings of a conversation
+func (r *mongoConversationRepository) GetConversation(ctx context.Context, conversationID string) (*models.Conversation, error) {
+	var conversation models.Conversation
+	err := r.collection.FindOne(ctx, bson.M{"_id": conversationID}).Decode(&conversation)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, common.ErrNotFound
+		}
+		return nil, err
+	}
+	return &conversation, nil
+}
+
+// SetMessageTTL sets the lifetime applied to new messages, creating the conversation document if needed
+func (r *mongoConversationRepository) SetMessageTTL(ctx context.Context, conversationID string, ttlSeconds int64, updatedBy string) (*models.Conversation, error) {
+	userID1, userID2, ok := models.ConversationParticipants(conversationID)
+	if !ok {
+		return nil, common.ErrInvalidInput
+	}
+
+	update := bson.M{
+		"$set": bson.M{
+			"message_ttl": ttlSeconds,
+			"updated_by":  updatedBy,
+			"updated_at":  time.Now(),
+		},
+		"$setOnInsert": bson.M{
+			"participants": []string{userID1, userID2},
+		},
+	}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+
+	var conversation models.Conversation
+	if err := r.collection.FindOneAndUpdate(ctx, bson.M{"_id": conversationID}, update, opts).Decode(&conversation); err != nil {
+		return nil, err
+	}
+	return &conversation, nil
+}
+
+// SetRetention sets or clears the conversation's retention policy, creating the conversation document if needed
+func (r *mongoConversationRepository) SetRetention(ctx context.Context, conversationID string, policy models.RetentionPolicy, updatedBy string) (*models.Conversation, error) {
+	userID1, userID2, ok := models.ConversationParticipants(conversationID)
+	if !ok {
+		return nil, common.ErrInvalidInput
+	}
+
+	update := bson.M{
+		"$set": bson.M{
+			"updated_by": updatedBy,
+			"updated_at": time.Now(),
+		},
+		"$setOnInsert": bson.M{
+			"participants": []string{userID1, userID2},
+			"message_ttl":  0,
+		},
+	}
+	if policy.IsZero() {
+		update["$unset"] = bson.M{"retention": ""}
+	} else {
+		update["$set"].(bson.M)["retention"] = policy
+	}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+
+	var conversation models.Conversation
+	if err := r.collection.FindOneAndUpdate(ctx, bson.M{"_id": conversationID}, update, opts).Decode(&conversation); err != nil {
+		return nil, err
+	}
+	return &conversation, nil
+}
+
+// GetRetentionPolicies retrieves every per-conversation retention policy
+func (r *mongoConversationRepository) GetRetentionPolicies(ctx context.Context) (map[string]*models.RetentionPolicy, error) {
+	findOptions := options.Find().SetProjection(bson.M{"retention": 1})
+	cursor, err := r.collection.Find(ctx, bson.M{"retention": bson.M{"$exists": true}}, findOptions)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	policies := make(map[string]*models.RetentionPolicy)
+	for cursor.Next(ctx) {
+		var conversation models.Conversation
+		if err := cursor.Decode(&conversation); err != nil {
+			return nil, err
+		}
+		policies[conversation.ID] = conversation.Retention
+	}
+	return policies, cursor.Err()
+}
@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/dk5761/go-serv/internal/domain/chat/models"
+	"github.com/dk5761/go-serv/internal/domain/common"
+)
+
+type mongoDeviceRepository struct {
+	collection *mongo.Collection
+}
+
+// NewMongoDeviceRepository initializes a new instance of mongoDeviceRepository
+func NewMongoDeviceRepository(db *mongo.Database) DeviceRepository {
+	return &mongoDeviceRepository{
+		collection: db.Collection("device_tokens"),
+	}
+}
+
+func (r *mongoDeviceRepository) RegisterDevice(ctx context.Context, device *models.Device) (*models.Device, error) {
+	now := time.Now()
+	update := bson.M{
+		"$set": bson.M{
+			"user_id":    device.UserID,
+			"platform":   device.Platform,
+			"updated_at": now,
+		},
+		"$setOnInsert": bson.M{"created_at": now},
+	}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+
+	var saved models.Device
+	err := r.collection.FindOneAndUpdate(ctx, bson.M{"_id": device.Token}, update, opts).Decode(&saved)
+	if mongo.IsDuplicateKeyError(err) {
+		// A concurrent registration created the token first; it exists now, so retry as an update
+		err = r.collection.FindOneAndUpdate(ctx, bson.M{"_id": device.Token}, update, opts).Decode(&saved)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &saved, nil
+}
+
+func (r *mongoDeviceRepository) UnregisterDevice(ctx context.Context, userID, token string) error {
+	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": token, "user_id": userID})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return fmt.Errorf("%w: device not registered", common.ErrNotFound)
+	}
+	return nil
+}
+
+func (r *mongoDeviceRepository) ListDevices(ctx context.Context, userID string) ([]*models.Device, error) {
+	findOptions := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}})
+
+	cursor, err := r.collection.Find(ctx, bson.M{"user_id": userID}, findOptions)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	devices := []*models.Device{}
+	if err := cursor.All(ctx, &devices); err != nil {
+		return nil, err
+	}
+	return devices, nil
+}
+
+func (r *mongoDeviceRepository) DeleteTokens(ctx context.Context, tokens []string) (int64, error) {
+	if len(tokens) == 0 {
+		return 0, nil
+	}
+	result, err := r.collection.DeleteMany(ctx, bson.M{"_id": bson.M{"$in": tokens}})
+	if err != nil {
+		return 0, err
+	}
+	return result.DeletedCount, nil
+}
@@ -0,0 +1,162 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/dk5761/go-serv/internal/domain/chat/models"
+	"github.com/dk5761/go-serv/internal/domain/common"
+	"github.com/dk5761/go-serv/internal/infrastructure/encryption"
+)
+
+type mongoDraftRepository struct {
+	collection *mongo.Collection
+	envelope   envelope
+}
+
+// NewMongoDraftRepository initializes a new instance of mongoDraftRepository. With a
+// keyring, draft content is encrypted at rest like that of messages.
+func NewMongoDraftRepository(db *mongo.Database, keyring *encryption.Keyring) DraftRepository {
+	return &mongoDraftRepository{
+		collection: db.Collection("drafts"),
+		envelope:   envelope{keyring: keyring},
+	}
+}
+
+// SaveDraft upserts the draft only if it is newer than the stored one
+func (r *mongoDraftRepository) SaveDraft(ctx context.Context, draft *models.Draft) (*models.Draft, bool, error) {
+	draft.ID = models.DraftID(draft.UserID, draft.ConversationID)
+
+	filter := bson.M{
+		"_id":        draft.ID,
+		"updated_at": bson.M{"$lt": draft.UpdatedAt},
+	}
+	set := bson.M{
+		"user_id":         draft.UserID,
+		"conversation_id": draft.ConversationID,
+		"content":         draft.Content,
+		"device_id":       draft.DeviceID,
+		"updated_at":      draft.UpdatedAt,
+	}
+	update := bson.M{"$set": set}
+
+	sealed, err := r.envelope.sealText(draft.ID, draft.Content, "")
+	if err != nil {
+		return nil, false, err
+	}
+	if sealed != nil {
+		set["content"] = ""
+		set["sealed"] = sealed
+	} else {
+		update["$unset"] = bson.M{"sealed": ""}
+	}
+
+	_, err = r.collection.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	if err == nil {
+		return draft, true, nil
+	}
+	if !mongo.IsDuplicateKeyError(err) {
+		return nil, false, err
+	}
+
+	// The upsert collided with an existing draft that is at least as new, which wins
+	current, err := r.GetDraft(ctx, draft.UserID, draft.ConversationID)
+	if err != nil {
+		return nil, false, err
+	}
+	return current, false, nil
+}
+
+// GetDraft retrieves a user's draft in a conversation
+func (r *mongoDraftRepository) GetDraft(ctx context.Context, userID, conversationID string) (*models.Draft, error) {
+	var draft models.Draft
+	err := r.collection.FindOne(ctx, bson.M{"_id": models.DraftID(userID, conversationID)}).Decode(&draft)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, common.ErrNotFound
+		}
+		return nil, err
+	}
+	if err := r.envelope.openText("draft", draft.ID, draft.Sealed, &draft.Content, nil); err != nil {
+		return nil, err
+	}
+	draft.Sealed = nil
+	return &draft, nil
+}
+
+// ClearDraft empties the draft, keeping the document so that its timestamp still wins over stale writes
+func (r *mongoDraftRepository) ClearDraft(ctx context.Context, userID, conversationID string, at time.Time) (bool, error) {
+	filter := bson.M{
+		"_id":        models.DraftID(userID, conversationID),
+		"updated_at": bson.M{"$lt": at},
+		"$or": []bson.M{
+			{"content": bson.M{"$ne": ""}},
+			{"sealed": bson.M{"$exists": true}},
+		},
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"content":    "",
+			"updated_at": at,
+		},
+		"$unset": bson.M{"device_id": "", "sealed": ""},
+	}
+
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return false, err
+	}
+	return result.ModifiedCount > 0, nil
+}
+
+// RewrapDataKeys re-wraps the data keys of encrypted drafts with the active master key
+func (r *mongoDraftRepository) RewrapDataKeys(ctx context.Context, limit int) (int, error) {
+	return r.envelope.rewrapDataKeys(ctx, r.collection, "draft", limit)
+}
+
+// SealPlaintext encrypts drafts stored in plaintext, such as those written before a master
+// key was configured
+func (r *mongoDraftRepository) SealPlaintext(ctx context.Context, limit int) (int, error) {
+	if !r.envelope.enabled() {
+		return 0, nil
+	}
+
+	filter := bson.M{
+		"sealed":  bson.M{"$exists": false},
+		"content": bson.M{"$exists": true, "$ne": ""},
+	}
+	cursor, err := r.collection.Find(ctx, filter, options.Find().SetLimit(int64(limit)))
+	if err != nil {
+		return 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var drafts []*models.Draft
+	if err := cursor.All(ctx, &drafts); err != nil {
+		return 0, err
+	}
+
+	sealed := 0
+	for _, draft := range drafts {
+		fields, err := r.envelope.sealText(draft.ID, draft.Content, "")
+		if err != nil {
+			return sealed, err
+		}
+
+		// Guarding on the timestamp keeps a newer draft saved since the read from being overwritten
+		result, err := r.collection.UpdateOne(ctx,
+			bson.M{"_id": draft.ID, "sealed": bson.M{"$exists": false}, "updated_at": draft.UpdatedAt},
+			bson.M{"$set": bson.M{"sealed": fields, "content": ""}},
+		)
+		if err != nil {
+			return sealed, err
+		}
+		sealed += int(result.ModifiedCount)
+	}
+	return sealed, nil
+}
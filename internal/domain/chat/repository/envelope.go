@@ -0,0 +1,166 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/dk5761/go-serv/internal/domain/chat/models"
+	"github.com/dk5761/go-serv/internal/infrastructure/encryption"
+)
+
+// envelope encrypts fields of stored documents at rest. Each document gets its own data
+// key, stored wrapped by a master key of the keyring in the document's sealed field. A nil
+// keyring stores everything in plaintext.
+type envelope struct {
+	keyring *encryption.Keyring
+}
+
+// Names of the sealed fields, which are part of the additional data
+const (
+	sealedContent  = "content"
+	sealedFileURL  = "file_url"
+	sealedSnapshot = "snapshot"
+)
+
+// sealedAD binds a sealed field to its document, so that ciphertexts cannot be swapped
+// between documents or fields
+func sealedAD(id, field string) []byte { return []byte(id + "/" + field) }
+
+func (e envelope) enabled() bool {
+	return e.keyring != nil
+}
+
+// newSealed generates a data key for a document, returning it with the sealed fields that
+// hold it wrapped
+func (e envelope) newSealed() ([]byte, *models.SealedFields, error) {
+	dataKey, wrappedKey, keyID, err := e.keyring.GenerateDataKey()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to generate data key: %w", err)
+	}
+	return dataKey, &models.SealedFields{KeyID: keyID, WrappedKey: wrappedKey}, nil
+}
+
+// sealText encrypts a document's content and file URL under a new data key. It returns nil
+// when encryption is off or there is nothing to encrypt.
+func (e envelope) sealText(id, content, fileURL string) (*models.SealedFields, error) {
+	if !e.enabled() || (content == "" && fileURL == "") {
+		return nil, nil
+	}
+
+	dataKey, sealed, err := e.newSealed()
+	if err != nil {
+		return nil, err
+	}
+	if content != "" {
+		if sealed.Content, err = encryption.Seal(dataKey, []byte(content), sealedAD(id, sealedContent)); err != nil {
+			return nil, err
+		}
+	}
+	if fileURL != "" {
+		if sealed.FileURL, err = encryption.Seal(dataKey, []byte(fileURL), sealedAD(id, sealedFileURL)); err != nil {
+			return nil, err
+		}
+	}
+	return sealed, nil
+}
+
+// dataKey unwraps the data key of the document id of the given kind
+func (e envelope) dataKey(kind, id string, sealed *models.SealedFields) ([]byte, error) {
+	if !e.enabled() {
+		return nil, fmt.Errorf("%s %s is encrypted but no master key is configured", kind, id)
+	}
+	dataKey, err := e.keyring.UnwrapDataKey(sealed.KeyID, sealed.WrappedKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unwrap data key of %s %s: %w", kind, id, err)
+	}
+	return dataKey, nil
+}
+
+// openText decrypts sealed back into content and fileURL. A nil sealed leaves them as stored.
+func (e envelope) openText(kind, id string, sealed *models.SealedFields, content, fileURL *string) error {
+	if sealed == nil {
+		return nil
+	}
+	dataKey, err := e.dataKey(kind, id, sealed)
+	if err != nil {
+		return err
+	}
+	if sealed.Content != nil {
+		plaintext, err := encryption.Open(dataKey, sealed.Content, sealedAD(id, sealedContent))
+		if err != nil {
+			return fmt.Errorf("failed to decrypt %s %s: %w", kind, id, err)
+		}
+		*content = string(plaintext)
+	}
+	if sealed.FileURL != nil && fileURL != nil {
+		plaintext, err := encryption.Open(dataKey, sealed.FileURL, sealedAD(id, sealedFileURL))
+		if err != nil {
+			return fmt.Errorf("failed to decrypt %s %s: %w", kind, id, err)
+		}
+		*fileURL = string(plaintext)
+	}
+	return nil
+}
+
+// rewrapDataKeys re-wraps the data keys of up to limit documents of collection with the
+// active master key. Only the wrapped key is rewritten, and only if no one else rewrapped
+// it in the meantime.
+func (e envelope) rewrapDataKeys(ctx context.Context, collection *mongo.Collection, kind string, limit int) (int, error) {
+	if !e.enabled() {
+		return 0, nil
+	}
+
+	filter := bson.M{
+		"sealed":        bson.M{"$exists": true},
+		"sealed.key_id": bson.M{"$ne": e.keyring.ActiveKeyID()},
+	}
+	findOptions := options.Find().
+		SetProjection(bson.M{"sealed.key_id": 1, "sealed.wrapped_key": 1}).
+		SetLimit(int64(limit))
+
+	cursor, err := collection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return 0, err
+	}
+	defer cursor.Close(ctx)
+
+	// Drafts are keyed by string, everything else by ObjectID
+	var documents []struct {
+		ID     interface{}         `bson:"_id"`
+		Sealed models.SealedFields `bson:"sealed"`
+	}
+	if err := cursor.All(ctx, &documents); err != nil {
+		return 0, err
+	}
+
+	rewrapped := 0
+	for _, document := range documents {
+		wrappedKey, keyID, err := e.keyring.RewrapDataKey(document.Sealed.KeyID, document.Sealed.WrappedKey)
+		if err != nil {
+			return rewrapped, fmt.Errorf("failed to rewrap data key of %s %s: %w", kind, documentID(document.ID), err)
+		}
+
+		result, err := collection.UpdateOne(ctx,
+			bson.M{"_id": document.ID, "sealed.wrapped_key": document.Sealed.WrappedKey},
+			bson.M{"$set": bson.M{"sealed.key_id": keyID, "sealed.wrapped_key": wrappedKey}},
+		)
+		if err != nil {
+			return rewrapped, err
+		}
+		rewrapped += int(result.ModifiedCount)
+	}
+	return rewrapped, nil
+}
+
+// documentID formats a document ID the way it appears in the additional data
+func documentID(id interface{}) string {
+	if oid, ok := id.(primitive.ObjectID); ok {
+		return oid.Hex()
+	}
+	return fmt.Sprint(id)
+}
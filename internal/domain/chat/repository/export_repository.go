@@ -0,0 +1,113 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/dk5761/go-serv/internal/domain/chat/models"
+	"github.com/dk5761/go-serv/internal/domain/common"
+)
+
+type mongoExportJobRepository struct {
+	collection *mongo.Collection
+}
+
+// NewMongoExportJobRepository initializes a new instance of mongoExportJobRepository
+func NewMongoExportJobRepository(db *mongo.Database) ExportJobRepository {
+	return &mongoExportJobRepository{
+		collection: db.Collection("export_jobs"),
+	}
+}
+
+// CreateExportJob stores a new export in the pending state
+func (r *mongoExportJobRepository) CreateExportJob(ctx context.Context, job *models.ExportJob) error {
+	job.ID = primitive.NewObjectID()
+	job.Status = models.ExportPending
+	job.CreatedAt = time.Now()
+
+	_, err := r.collection.InsertOne(ctx, job)
+	return err
+}
+
+// GetExportJob retrieves an export job owned by the user
+func (r *mongoExportJobRepository) GetExportJob(ctx context.Context, id primitive.ObjectID, userID string) (*models.ExportJob, error) {
+	var job models.ExportJob
+	err := r.collection.FindOne(ctx, bson.M{"_id": id, "user_id": userID}).Decode(&job)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, common.ErrNotFound
+		}
+		return nil, err
+	}
+	return &job, nil
+}
+
+// ClaimExportJob atomically leases an export to owner so that only one server instance runs it
+func (r *mongoExportJobRepository) ClaimExportJob(ctx context.Context, owner string, now time.Time, lease time.Duration) (*models.ExportJob, error) {
+	filter := bson.M{
+		"status": bson.M{"$in": []models.ExportStatus{models.ExportPending, models.ExportRunning}},
+	}
+	for k, v := range unleased(now) {
+		filter[k] = v
+	}
+
+	update := bson.M{
+		"$set": bson.M{
+			"status":      models.ExportRunning,
+			"lease_owner": owner,
+			"lease_until": now.Add(lease),
+		},
+		"$inc": bson.M{"attempts": 1},
+	}
+
+	opts := options.FindOneAndUpdate().
+		SetSort(bson.D{{Key: "created_at", Value: 1}}).
+		SetReturnDocument(options.After)
+
+	var job models.ExportJob
+	err := r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&job)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &job, nil
+}
+
+// FinishExportJob marks the export as done or failed
+func (r *mongoExportJobRepository) FinishExportJob(ctx context.Context, id primitive.ObjectID, owner string, status models.ExportStatus, fileURL, lastErr string) (*models.ExportJob, error) {
+	set := bson.M{
+		"status":       status,
+		"completed_at": time.Now(),
+	}
+	if fileURL != "" {
+		set["file_url"] = fileURL
+	}
+	if lastErr != "" {
+		set["error"] = lastErr
+	}
+
+	filter := bson.M{"_id": id, "lease_owner": owner}
+	update := bson.M{
+		"$set":   set,
+		"$unset": bson.M{"lease_owner": "", "lease_until": ""},
+	}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var job models.ExportJob
+	err := r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&job)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, common.ErrNotFound // The lease was lost to another instance
+		}
+		return nil, err
+	}
+	return &job, nil
+}
@@ -0,0 +1,124 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/dk5761/go-serv/internal/domain/chat/models"
+	"github.com/dk5761/go-serv/internal/infrastructure/encryption"
+)
+
+type mongoModerationRepository struct {
+	collection *mongo.Collection
+	envelope   envelope
+}
+
+// NewMongoModerationRepository initializes a new instance of mongoModerationRepository.
+// With a keyring, rejected content is encrypted at rest like that of messages.
+func NewMongoModerationRepository(db *mongo.Database, keyring *encryption.Keyring) ModerationRepository {
+	return &mongoModerationRepository{
+		collection: db.Collection("moderation_log"),
+		envelope:   envelope{keyring: keyring},
+	}
+}
+
+// LogRejection stores rejected content for review
+func (r *mongoModerationRepository) LogRejection(ctx context.Context, record *models.ModerationRecord) error {
+	record.ID = primitive.NewObjectID()
+	record.CreatedAt = time.Now()
+
+	sealed, err := r.envelope.sealText(record.ID.Hex(), record.Content, "")
+	if err != nil {
+		return err
+	}
+	document := *record
+	if sealed != nil {
+		document.Content = ""
+		document.Sealed = sealed
+	}
+
+	_, err = r.collection.InsertOne(ctx, &document)
+	return err
+}
+
+// ListRejections retrieves rejected content, newest first, optionally only from one stage
+func (r *mongoModerationRepository) ListRejections(ctx context.Context, stage string, limit, offset int) ([]*models.ModerationRecord, error) {
+	filter := bson.M{}
+	if stage != "" {
+		filter["stage"] = stage
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}}).
+		SetLimit(int64(limit)).
+		SetSkip(int64(offset))
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	records := []*models.ModerationRecord{}
+	if err := cursor.All(ctx, &records); err != nil {
+		return nil, err
+	}
+	for _, record := range records {
+		if err := r.envelope.openText("moderation record", record.ID.Hex(), record.Sealed, &record.Content, nil); err != nil {
+			return nil, err
+		}
+		record.Sealed = nil
+	}
+	return records, nil
+}
+
+// RewrapDataKeys re-wraps the data keys of encrypted moderation records with the active master key
+func (r *mongoModerationRepository) RewrapDataKeys(ctx context.Context, limit int) (int, error) {
+	return r.envelope.rewrapDataKeys(ctx, r.collection, "moderation record", limit)
+}
+
+// SealPlaintext encrypts moderation records stored in plaintext, such as those written
+// before a master key was configured. Records are never edited, so no guard is needed.
+func (r *mongoModerationRepository) SealPlaintext(ctx context.Context, limit int) (int, error) {
+	if !r.envelope.enabled() {
+		return 0, nil
+	}
+
+	filter := bson.M{
+		"sealed":  bson.M{"$exists": false},
+		"content": bson.M{"$exists": true, "$ne": ""},
+	}
+	cursor, err := r.collection.Find(ctx, filter, options.Find().SetLimit(int64(limit)))
+	if err != nil {
+		return 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var records []*models.ModerationRecord
+	if err := cursor.All(ctx, &records); err != nil {
+		return 0, err
+	}
+
+	sealed := 0
+	for _, record := range records {
+		fields, err := r.envelope.sealText(record.ID.Hex(), record.Content, "")
+		if err != nil {
+			return sealed, err
+		}
+
+		result, err := r.collection.UpdateOne(ctx,
+			bson.M{"_id": record.ID, "sealed": bson.M{"$exists": false}},
+			bson.M{"$set": bson.M{"sealed": fields, "content": ""}},
+		)
+		if err != nil {
+			return sealed, err
+		}
+		sealed += int(result.ModifiedCount)
+	}
+	return sealed, nil
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sort"
 	"time"
 
 	"github.com/google/uuid"
@@ -13,16 +14,34 @@ import (
 	"go.mongodb.org/mongo-driver/mongo/options"
 
 	"github.com/dk5761/go-serv/internal/domain/chat/models"
+	"github.com/dk5761/go-serv/internal/domain/common"
+	"github.com/dk5761/go-serv/internal/infrastructure/encryption"
 )
 
 type mongoMessageRepository struct {
 	collection *mongo.Collection
+	counters   *mongo.Collection
+	envelope   envelope
 }
 
-// NewMongoMessageRepository initializes a new instance of mongoMessageRepository
-func NewMongoMessageRepository(db *mongo.Database) MessageRepository {
+// NewMongoMessageRepository initializes a new instance of mongoMessageRepository. With a
+// keyring, message content and file URLs are encrypted at rest; a nil keyring stores them
+// in plaintext.
+func NewMongoMessageRepository(db *mongo.Database, keyring *encryption.Keyring) MessageRepository {
 	return &mongoMessageRepository{
 		collection: db.Collection("messages"),
+		counters:   db.Collection("conversation_counters"),
+		envelope:   envelope{keyring: keyring},
+	}
+}
+
+// notExpired matches messages whose disappearing timer, if any, has not run out yet
+func notExpired(now time.Time) bson.M {
+	return bson.M{
+		"$or": []bson.M{
+			{"expires_at": bson.M{"$exists": false}},
+			{"expires_at": bson.M{"$gt": now}},
+		},
 	}
 }
 
@@ -30,13 +49,27 @@ func NewMongoMessageRepository(db *mongo.Database) MessageRepository {
 func (r *mongoMessageRepository) SaveMessage(ctx context.Context, msg *models.Message) (primitive.ObjectID, error) {
 	// Set the creation timestamp
 	msg.CreatedAt = time.Now()
+	if msg.ConversationID == "" {
+		msg.ConversationID = models.ConversationID(msg.SenderID, msg.ReceiverID)
+	}
+	if msg.Type == "" {
+		msg.Type = models.TextMessage
+	}
+
+	seq, err := r.allocateSeqs(ctx, msg.ConversationID, 1)
+	if err != nil {
+		return primitive.NilObjectID, err
+	}
+	msg.Seq = seq
 
-	fmt.Println("save message", msg)
+	document, err := r.seal(msg)
+	if err != nil {
+		return primitive.NilObjectID, err
+	}
 
 	// Insert the message into MongoDB
-	result, err := r.collection.InsertOne(ctx, msg)
+	result, err := r.collection.InsertOne(ctx, document)
 	if err != nil {
-		fmt.Println("save message err", err)
 		return primitive.NilObjectID, err
 	}
 
@@ -53,21 +86,26 @@ func (r *mongoMessageRepository) SaveMessage(ctx context.Context, msg *models.Me
 func (r *mongoMessageRepository) GetMessages(ctx context.Context, userID1, userID2 uuid.UUID, limit, offset int) ([]*models.Message, error) {
 	// Create a filter to match messages between userID1 and userID2
 	filter := bson.M{
-		"$or": []bson.M{
-			{
-				"sender_id":   userID1.String(),
-				"receiver_id": userID2.String(),
-			},
+		"$and": []bson.M{
 			{
-				"sender_id":   userID2.String(),
-				"receiver_id": userID1.String(),
+				"$or": []bson.M{
+					{
+						"sender_id":   userID1.String(),
+						"receiver_id": userID2.String(),
+					},
+					{
+						"sender_id":   userID2.String(),
+						"receiver_id": userID1.String(),
+					},
+				},
 			},
+			notExpired(time.Now()),
 		},
 	}
 
-	// Define options to apply pagination and sorting by timestamp
+	// Define options to apply pagination and sorting by position in the conversation
 	findOptions := options.Find()
-	findOptions.SetSort(bson.D{{"timestamp", 1}}) // 1 for ascending order
+	findOptions.SetSort(bson.D{{Key: "seq", Value: 1}, {Key: "_id", Value: 1}}) // 1 for ascending order
 	findOptions.SetLimit(int64(limit))
 	findOptions.SetSkip(int64(offset))
 
@@ -85,6 +123,9 @@ func (r *mongoMessageRepository) GetMessages(ctx context.Context, userID1, userI
 		if err := cursor.Decode(&msg); err != nil {
 			return nil, err
 		}
+		if err := r.open(&msg); err != nil {
+			return nil, err
+		}
 		messages = append(messages, &msg)
 	}
 
@@ -107,6 +148,9 @@ func (r *mongoMessageRepository) GetUndeliveredMessages(ctx context.Context, rec
 			"$gte": fiveDaysAgo, // Only include messages created within the last 5 days
 		},
 	}
+	for k, v := range notExpired(time.Now()) {
+		filter[k] = v
+	}
 
 	// Execute the query with the filter
 	cursor, err := r.collection.Find(ctx, filter)
@@ -121,87 +165,178 @@ func (r *mongoMessageRepository) GetUndeliveredMessages(ctx context.Context, rec
 		return nil, err
 	}
 
-	return messages, nil
+	return messages, r.openAll(messages)
 }
 
-// MarkMessageAsDelivered updates the delivery status of a message.
-func (r *mongoMessageRepository) MarkMessageAsDelivered(ctx context.Context, messageID primitive.ObjectID) error {
-	update := bson.M{
-		"$set": bson.M{
+func (r *mongoMessageRepository) GetMessage(ctx context.Context, messageID primitive.ObjectID) (*models.Message, error) {
+	// Define the filter for the message ID
+	filter := bson.M{"_id": messageID}
 
-			"delivered":    true,
-			"delivered_at": time.Now(),
-		},
+	// Prepare a variable to hold the result
+	var message models.Message
+
+	// Execute the query
+	err := r.collection.FindOne(ctx, filter).Decode(&message)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, common.ErrNotFound
+		}
+		return nil, err
 	}
 
-	_, err := r.collection.UpdateByID(ctx, messageID, update, options.Update())
-	return err
+	return &message, r.open(&message)
 }
 
-func (r *mongoMessageRepository) StoreUndeliveredMessage(ctx context.Context, msg *models.Message) (primitive.ObjectID, error) {
-	// Set default fields for undelivered messages
-	msg.CreatedAt = time.Now()
-	msg.Delivered = false // Mark as undelivered
+// AdvanceStatus applies a status transition as a single conditional update. The update is
+// a pipeline so that the timestamps of skipped statuses are filled in without overwriting
+// ones already recorded.
+func (r *mongoMessageRepository) AdvanceStatus(ctx context.Context, messageID primitive.ObjectID, status models.MessageStatus, receiverID string) (*models.Message, error) {
+	prior := status.PriorStatuses()
+	if len(prior) == 0 {
+		return nil, fmt.Errorf("%w: a message cannot move to status %q", common.ErrInvalidInput, status)
+	}
 
-	// Insert the message into MongoDB
-	result, err := r.collection.InsertOne(ctx, msg)
+	filter := bson.M{"_id": messageID, "status": bson.M{"$in": prior}}
+	if receiverID != "" {
+		filter["receiver_id"] = receiverID
+	}
+
+	// Every status past Stored means the message reached the receiver's device
+	now := time.Now()
+	set := bson.M{
+		"status":       status,
+		"delivered":    true,
+		"delivered_at": bson.M{"$ifNull": bson.A{"$delivered_at", now}},
+	}
+	for _, reached := range append(prior[1:], status) {
+		field := statusTimestampFields[reached]
+		if reached == status {
+			set[field] = now
+		} else {
+			set[field] = bson.M{"$ifNull": bson.A{"$" + field, now}}
+		}
+	}
+
+	// A message that got through is no longer waiting for redelivery
+	update := mongo.Pipeline{
+		{{Key: "$set", Value: set}},
+		{{Key: "$unset", Value: bson.A{"next_attempt_at", "delivery_failed_at"}}},
+	}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var message models.Message
+	err := r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&message)
 	if err != nil {
-		return primitive.NilObjectID, err
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, err
 	}
+	return &message, r.open(&message)
+}
 
-	return result.InsertedID.(primitive.ObjectID), nil
+// statusTimestampFields names the field recording when a message reached each status past Stored
+var statusTimestampFields = map[models.MessageStatus]string{
+	models.Sent:     "sent_at",
+	models.Received: "received_at",
+	models.Read:     "read_at",
 }
 
-func (r *mongoMessageRepository) UpdateMessageStatus(ctx context.Context, messageID primitive.ObjectID, status models.MessageStatus) error {
-	filter := bson.M{"_id": messageID}
-	update := bson.M{"$set": bson.M{"status": status}}
+func (r *mongoMessageRepository) MarkAcknowledgmentPending(ctx context.Context, messageID primitive.ObjectID) error {
+	_, err := r.collection.UpdateByID(ctx, messageID, bson.M{"$set": bson.M{"ack_pending": true}})
+	return err
+}
 
-	_, err := r.collection.UpdateOne(ctx, filter, update)
+func (r *mongoMessageRepository) ClearAcknowledgmentPending(ctx context.Context, messageID primitive.ObjectID, status models.MessageStatus) error {
+	filter := bson.M{"_id": messageID, "status": status}
+	_, err := r.collection.UpdateOne(ctx, filter, bson.M{"$unset": bson.M{"ack_pending": ""}})
 	return err
 }
 
-func (r *mongoMessageRepository) GetMessage(ctx context.Context, messageID primitive.ObjectID) (*models.Message, error) {
-	// Define the filter for the message ID
-	filter := bson.M{"_id": messageID}
+func (r *mongoMessageRepository) GetPendingAcknowledgments(ctx context.Context, senderIDs []string, limit int) ([]*models.Message, error) {
+	filter := bson.M{
+		"sender_id":   bson.M{"$in": senderIDs},
+		"ack_pending": true,
+	}
 
-	// Prepare a variable to hold the result
-	var message models.Message
+	// Optional: sort by created_at to deliver in order
+	findOptions := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: 1}}).
+		SetLimit(int64(limit))
 
-	// Execute the query
-	err := r.collection.FindOne(ctx, filter).Decode(&message)
+	cursor, err := r.collection.Find(ctx, filter, findOptions)
 	if err != nil {
-		if err == mongo.ErrNoDocuments {
-			return nil, errors.New("message not found")
-		}
 		return nil, err
 	}
+	defer cursor.Close(ctx)
 
-	return &message, nil
+	var messages []*models.Message
+	if err := cursor.All(ctx, &messages); err != nil {
+		return nil, err
+	}
+
+	return messages, r.openAll(messages)
 }
 
-func (r *mongoMessageRepository) MarkAcknowledgmentPending(ctx context.Context, messageID primitive.ObjectID) error {
-	filter := bson.M{"_id": messageID}
-	update := bson.M{
-		"$set": bson.M{
-			"status":       models.Pending, // Use a status constant like "Pending"
-			"delivered":    false,
-			"delivered_at": time.Time{}, // Clear the delivered time if previously set
+func (r *mongoMessageRepository) ClaimRedelivery(ctx context.Context, receiverIDs []string, now, settledBefore time.Time, lease time.Duration) (*models.Message, error) {
+	filter := bson.M{
+		"status":             models.Stored,
+		"receiver_id":        bson.M{"$in": receiverIDs},
+		"created_at":         bson.M{"$lte": settledBefore},
+		"delivery_failed_at": bson.M{"$exists": false},
+		"$and": []bson.M{
+			notExpired(now),
+			{"$or": []bson.M{
+				{"next_attempt_at": bson.M{"$exists": false}},
+				{"next_attempt_at": bson.M{"$lte": now}},
+			}},
 		},
 	}
 
-	_, err := r.collection.UpdateOne(ctx, filter, update)
+	// Until the lease runs out, no other attempt claims the message
+	update := bson.M{
+		"$set": bson.M{"next_attempt_at": now.Add(lease)},
+		"$inc": bson.M{"delivery_attempts": 1},
+	}
+
+	opts := options.FindOneAndUpdate().
+		SetSort(bson.D{{Key: "created_at", Value: 1}}).
+		SetReturnDocument(options.After)
+
+	var message models.Message
+	err := r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&message)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &message, r.open(&message)
+}
+
+func (r *mongoMessageRepository) RecordDeliveryFailure(ctx context.Context, messageID primitive.ObjectID, lastErr string, retryAt time.Time, failed bool) error {
+	set := bson.M{
+		"last_delivery_error": lastErr,
+		"next_attempt_at":     retryAt,
+	}
+	if failed {
+		set["delivery_failed_at"] = time.Now()
+	}
+
+	// Only a message that is still stored can have failed delivery
+	filter := bson.M{"_id": messageID, "status": models.Stored}
+	_, err := r.collection.UpdateOne(ctx, filter, bson.M{"$set": set})
 	return err
 }
 
-// GetPendingAcknowledgments retrieves all undelivered messages for a user
-func (r *mongoMessageRepository) GetPendingAcknowledgments(ctx context.Context, receiverID string) ([]*models.Message, error) {
+// GetExpiredMessages returns up to limit messages whose disappearing timer has run out
+func (r *mongoMessageRepository) GetExpiredMessages(ctx context.Context, now time.Time, limit int) ([]*models.Message, error) {
 	filter := bson.M{
-		"sender_id": receiverID,
-		"status":    models.Pending, // Fetch messages with the "Pending" status
+		"expires_at": bson.M{"$lte": now},
 	}
-
-	// Optional: sort by created_at to deliver in order
-	findOptions := options.Find().SetSort(bson.D{{"created_at", 1}})
+	findOptions := options.Find().
+		SetSort(bson.D{{Key: "expires_at", Value: 1}}).
+		SetLimit(int64(limit))
 
 	cursor, err := r.collection.Find(ctx, filter, findOptions)
 	if err != nil {
@@ -214,5 +349,632 @@ func (r *mongoMessageRepository) GetPendingAcknowledgments(ctx context.Context,
 		return nil, err
 	}
 
+	return messages, r.openAll(messages)
+}
+
+// DeleteMessage removes a message, reporting whether this call was the one that deleted it
+func (r *mongoMessageRepository) DeleteMessage(ctx context.Context, messageID primitive.ObjectID) (bool, error) {
+	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": messageID})
+	if err != nil {
+		return false, err
+	}
+	return result.DeletedCount > 0, nil
+}
+
+// GetMentions retrieves messages that mention the user in conversations they take part in, newest first
+func (r *mongoMessageRepository) GetMentions(ctx context.Context, userID string, limit, offset int) ([]*models.Message, error) {
+	filter := bson.M{
+		"mentions.user_id": userID,
+		"receiver_id":      userID,
+	}
+	for k, v := range notExpired(time.Now()) {
+		filter[k] = v
+	}
+
+	findOptions := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}}).
+		SetLimit(int64(limit)).
+		SetSkip(int64(offset))
+
+	cursor, err := r.collection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	messages := []*models.Message{}
+	if err := cursor.All(ctx, &messages); err != nil {
+		return nil, err
+	}
+
+	return messages, r.openAll(messages)
+}
+
+// conversationFilter matches the messages of a conversation that have not expired
+func conversationFilter(conversationID string) bson.M {
+	filter := bson.M{"conversation_id": conversationID}
+	for k, v := range notExpired(time.Now()) {
+		filter[k] = v
+	}
+	return filter
+}
+
+// streamFilter matches the messages of a conversation in history order. Every message is
+// numbered, and matching on seq lets its index serve the sort.
+func streamFilter(conversationID string) bson.M {
+	filter := conversationFilter(conversationID)
+	filter["seq"] = bson.M{"$exists": true}
+	return filter
+}
+
+// StreamConversation iterates over a conversation's messages in sequence order without
+// loading them all into memory
+func (r *mongoMessageRepository) StreamConversation(ctx context.Context, conversationID string, fn func(*models.Message) error) error {
+	findOptions := options.Find().SetSort(bson.D{{Key: "seq", Value: 1}})
+
+	cursor, err := r.collection.Find(ctx, streamFilter(conversationID), findOptions)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var message models.Message
+		if err := cursor.Decode(&message); err != nil {
+			return err
+		}
+		if err := r.open(&message); err != nil {
+			return err
+		}
+		if err := fn(&message); err != nil {
+			return err
+		}
+	}
+	return cursor.Err()
+}
+
+// CountConversationMessages counts the messages a StreamConversation call would visit
+func (r *mongoMessageRepository) CountConversationMessages(ctx context.Context, conversationID string) (int64, error) {
+	return r.collection.CountDocuments(ctx, streamFilter(conversationID))
+}
+
+// duplicateKeyCode is the server error code of a unique index violation
+const duplicateKeyCode = 11000
+
+// InsertImportedMessages inserts a batch without stopping at duplicates, so that re-running
+// an import only adds the messages that are still missing. Sequence numbers are allocated
+// before the insert, so a message is never stored without one; messages that an earlier
+// run stored without a number are numbered now.
+func (r *mongoMessageRepository) InsertImportedMessages(ctx context.Context, messages []*models.Message) ([]*models.Message, error) {
+	if len(messages) == 0 {
+		return nil, nil
+	}
+
+	stored, err := r.importedSeqs(ctx, messages)
+	if err != nil {
+		return nil, err
+	}
+	var fresh, unnumbered []*models.Message
+	for _, message := range messages {
+		if existing, ok := stored[message.ImportKey]; ok && message.ImportKey != "" {
+			if existing.Seq == 0 {
+				message.ID = existing.ID
+				unnumbered = append(unnumbered, message)
+			}
+			continue
+		}
+		if message.ID.IsZero() {
+			message.ID = primitive.NewObjectID()
+		}
+		fresh = append(fresh, message)
+	}
+
+	numbered := make([]*models.Message, 0, len(fresh)+len(unnumbered))
+	if err := r.numberImported(ctx, append(append(numbered, fresh...), unnumbered...)); err != nil {
+		return nil, err
+	}
+	if len(unnumbered) > 0 {
+		writes := make([]mongo.WriteModel, len(unnumbered))
+		for i, message := range unnumbered {
+			writes[i] = mongo.NewUpdateOneModel().
+				SetFilter(bson.M{"_id": message.ID, "seq": bson.M{"$exists": false}}).
+				SetUpdate(bson.M{"$set": bson.M{"seq": message.Seq}})
+		}
+		if _, err := r.collection.BulkWrite(ctx, writes, options.BulkWrite().SetOrdered(false)); err != nil {
+			return nil, err
+		}
+	}
+	if len(fresh) == 0 {
+		return nil, nil
+	}
+
+	documents := make([]interface{}, len(fresh))
+	for i, message := range fresh {
+		document, err := r.seal(message)
+		if err != nil {
+			return nil, err
+		}
+		documents[i] = document
+	}
+
+	_, err = r.collection.InsertMany(ctx, documents, options.InsertMany().SetOrdered(false))
+	if err == nil {
+		return fresh, nil
+	}
+
+	// Only a concurrent run of the same import gets here, leaving its numbers unused
+	var bulkErr mongo.BulkWriteException
+	if !errors.As(err, &bulkErr) || bulkErr.WriteConcernError != nil {
+		return nil, err
+	}
+
+	skipped := make(map[int]bool, len(bulkErr.WriteErrors))
+	for _, writeErr := range bulkErr.WriteErrors {
+		if writeErr.Code != duplicateKeyCode {
+			return nil, err
+		}
+		skipped[writeErr.Index] = true
+	}
+
+	inserted := make([]*models.Message, 0, len(fresh)-len(skipped))
+	for i, message := range fresh {
+		if !skipped[i] {
+			inserted = append(inserted, message)
+		}
+	}
+	return inserted, nil
+}
+
+// importedMessage is a message an earlier run of an import stored
+type importedMessage struct {
+	ID        primitive.ObjectID `bson:"_id"`
+	ImportKey string             `bson:"import_key"`
+	Seq       int64              `bson:"seq"` // 0 if it was stored without a number
+}
+
+// importedSeqs looks up the messages of the batch that an earlier run already stored, by
+// import key
+func (r *mongoMessageRepository) importedSeqs(ctx context.Context, messages []*models.Message) (map[string]importedMessage, error) {
+	keys := make([]string, 0, len(messages))
+	for _, message := range messages {
+		if message.ImportKey != "" {
+			keys = append(keys, message.ImportKey)
+		}
+	}
+
+	stored := make(map[string]importedMessage, len(keys))
+	if len(keys) == 0 {
+		return stored, nil
+	}
+
+	findOptions := options.Find().SetProjection(bson.M{"import_key": 1, "seq": 1})
+	cursor, err := r.collection.Find(ctx, bson.M{"import_key": bson.M{"$in": keys}}, findOptions)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var documents []importedMessage
+	if err := cursor.All(ctx, &documents); err != nil {
+		return nil, err
+	}
+	for _, document := range documents {
+		stored[document.ImportKey] = document
+	}
+	return stored, nil
+}
+
+// numberImported allocates sequence numbers to imported messages, in the order of their
+// original timestamps. History older than everything numbered in its conversation is
+// numbered downwards from the conversation's first message, so that it reads before it;
+// the rest is numbered after the conversation's last message.
+func (r *mongoMessageRepository) numberImported(ctx context.Context, messages []*models.Message) error {
+	byConversation := make(map[string][]*models.Message)
+	for _, message := range messages {
+		byConversation[message.ConversationID] = append(byConversation[message.ConversationID], message)
+	}
+
+	for conversationID, conversation := range byConversation {
+		sort.SliceStable(conversation, func(i, j int) bool {
+			return conversation[i].CreatedAt.Before(conversation[j].CreatedAt)
+		})
+
+		oldest, err := r.oldestNumbered(ctx, conversationID)
+		if err != nil {
+			return err
+		}
+		history := 0
+		if !oldest.IsZero() {
+			for history < len(conversation) && conversation[history].CreatedAt.Before(oldest) {
+				history++
+			}
+		}
+
+		if history > 0 {
+			first, err := r.allocateHistorySeqs(ctx, conversationID, int64(history))
+			if err != nil {
+				return err
+			}
+			for i, message := range conversation[:history] {
+				message.Seq = first + int64(i)
+			}
+		}
+		if rest := conversation[history:]; len(rest) > 0 {
+			first, err := r.allocateSeqs(ctx, conversationID, int64(len(rest)))
+			if err != nil {
+				return err
+			}
+			for i, message := range rest {
+				message.Seq = first + int64(i)
+			}
+		}
+	}
+	return nil
+}
+
+// oldestNumbered returns the creation time of the conversation's oldest numbered message,
+// or the zero time if it has none
+func (r *mongoMessageRepository) oldestNumbered(ctx context.Context, conversationID string) (time.Time, error) {
+	findOptions := options.FindOne().
+		SetSort(bson.D{{Key: "created_at", Value: 1}}).
+		SetProjection(bson.M{"created_at": 1})
+
+	var oldest struct {
+		CreatedAt time.Time `bson:"created_at"`
+	}
+	err := r.collection.FindOne(ctx, bson.M{"conversation_id": conversationID, "seq": bson.M{"$exists": true}}, findOptions).Decode(&oldest)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return time.Time{}, nil
+	}
+	return oldest.CreatedAt, err
+}
+
+// allocateSeqs reserves n consecutive sequence numbers in a conversation from its counter
+// document, returning the first one
+func (r *mongoMessageRepository) allocateSeqs(ctx context.Context, conversationID string, n int64) (int64, error) {
+	filter := bson.M{"_id": conversationID}
+	update := bson.M{"$inc": bson.M{"seq": n}}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+
+	var counter struct {
+		Seq int64 `bson:"seq"`
+	}
+	err := r.counters.FindOneAndUpdate(ctx, filter, update, opts).Decode(&counter)
+	if mongo.IsDuplicateKeyError(err) {
+		// A concurrent upsert created the counter first; it exists now, so retry as an update
+		err = r.counters.FindOneAndUpdate(ctx, filter, update, opts).Decode(&counter)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to allocate sequence number: %w", err)
+	}
+	return counter.Seq - n + 1, nil
+}
+
+// allocateHistorySeqs reserves n consecutive sequence numbers below the lowest one of a
+// conversation, returning the lowest of them. The counter's floor is the lowest number
+// allocated so far; upward allocations start at 1, and 0 is never allocated.
+func (r *mongoMessageRepository) allocateHistorySeqs(ctx context.Context, conversationID string, n int64) (int64, error) {
+	update := mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{
+			"floor": bson.M{"$subtract": bson.A{
+				bson.M{"$min": bson.A{bson.M{"$ifNull": bson.A{"$floor", 1}}, 0}},
+				n,
+			}},
+		}}},
+	}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var counter struct {
+		Floor int64 `bson:"floor"`
+	}
+	err := r.counters.FindOneAndUpdate(ctx, bson.M{"_id": conversationID}, update, opts).Decode(&counter)
+	if err != nil {
+		return 0, fmt.Errorf("failed to allocate sequence number: %w", err)
+	}
+	return counter.Floor, nil
+}
+
+// LastSeq returns the highest sequence number allocated in the conversation, 0 if none was
+func (r *mongoMessageRepository) LastSeq(ctx context.Context, conversationID string) (int64, error) {
+	var counter struct {
+		Seq int64 `bson:"seq"`
+	}
+	err := r.counters.FindOne(ctx, bson.M{"_id": conversationID}).Decode(&counter)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return 0, nil
+	}
+	return counter.Seq, err
+}
+
+// seqFilter matches the messages of a conversation with afterSeq < seq < beforeSeq, where
+// a beforeSeq of 0 leaves the range open-ended
+func seqFilter(conversationID string, afterSeq, beforeSeq int64) bson.M {
+	seq := bson.M{"$gt": afterSeq}
+	if beforeSeq != 0 {
+		seq["$lt"] = beforeSeq
+	}
+	filter := conversationFilter(conversationID)
+	filter["seq"] = seq
+	return filter
+}
+
+func (r *mongoMessageRepository) GetMessagesAfterSeq(ctx context.Context, conversationID string, afterSeq, beforeSeq int64, limit int) ([]*models.Message, error) {
+	opts := options.Find().
+		SetSort(bson.D{{Key: "seq", Value: 1}}).
+		SetLimit(int64(limit))
+
+	messages := []*models.Message{}
+	if err := r.findAll(ctx, seqFilter(conversationID, afterSeq, beforeSeq), opts, &messages); err != nil {
+		return nil, err
+	}
 	return messages, nil
 }
+
+func (r *mongoMessageRepository) GetMessagesBeforeSeq(ctx context.Context, conversationID string, beforeSeq int64, limit int) ([]*models.Message, error) {
+	opts := options.Find().
+		SetSort(bson.D{{Key: "seq", Value: -1}}).
+		SetLimit(int64(limit))
+
+	var newestFirst []*models.Message
+	if err := r.findAll(ctx, seqFilter(conversationID, 0, beforeSeq), opts, &newestFirst); err != nil {
+		return nil, err
+	}
+
+	messages := make([]*models.Message, 0, len(newestFirst))
+	for i := len(newestFirst) - 1; i >= 0; i-- {
+		messages = append(messages, newestFirst[i])
+	}
+	return messages, nil
+}
+
+// StreamConversationStats groups all messages by conversation
+func (r *mongoMessageRepository) StreamConversationStats(ctx context.Context, fn func(*models.ConversationStats) error) error {
+	pipeline := mongo.Pipeline{
+		{{Key: "$group", Value: bson.M{
+			"_id":    "$conversation_id",
+			"count":  bson.M{"$sum": 1},
+			"oldest": bson.M{"$min": "$created_at"},
+		}}},
+	}
+
+	cursor, err := r.collection.Aggregate(ctx, pipeline, options.Aggregate().SetAllowDiskUse(true))
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var stats models.ConversationStats
+		if err := cursor.Decode(&stats); err != nil {
+			return err
+		}
+		if err := fn(&stats); err != nil {
+			return err
+		}
+	}
+	return cursor.Err()
+}
+
+// GetNthNewestMessage finds the message at position n from the newest end of a conversation
+func (r *mongoMessageRepository) GetNthNewestMessage(ctx context.Context, conversationID string, n int) (*models.Message, error) {
+	findOptions := options.FindOne().
+		SetSort(bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}).
+		SetSkip(int64(n - 1))
+
+	var message models.Message
+	err := r.collection.FindOne(ctx, bson.M{"conversation_id": conversationID}, findOptions).Decode(&message)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &message, r.open(&message)
+}
+
+// upToFilter matches the messages of a conversation sorting at or before (createdAt, id)
+func upToFilter(conversationID string, createdAt time.Time, id primitive.ObjectID) bson.M {
+	return bson.M{
+		"conversation_id": conversationID,
+		"$or": []bson.M{
+			{"created_at": bson.M{"$lt": createdAt}},
+			{"created_at": createdAt, "_id": bson.M{"$lte": id}},
+		},
+	}
+}
+
+// FindMessagesUpTo retrieves a batch of the oldest messages up to a position in the conversation
+func (r *mongoMessageRepository) FindMessagesUpTo(ctx context.Context, conversationID string, createdAt time.Time, id primitive.ObjectID, limit int) ([]*models.Message, error) {
+	findOptions := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}}).
+		SetLimit(int64(limit))
+
+	cursor, err := r.collection.Find(ctx, upToFilter(conversationID, createdAt, id), findOptions)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	messages := []*models.Message{}
+	if err := cursor.All(ctx, &messages); err != nil {
+		return nil, err
+	}
+	return messages, r.openAll(messages)
+}
+
+// CountMessagesUpTo counts the messages and attachments up to a position in the conversation
+func (r *mongoMessageRepository) CountMessagesUpTo(ctx context.Context, conversationID string, createdAt time.Time, id primitive.ObjectID) (int64, int64, error) {
+	filter := upToFilter(conversationID, createdAt, id)
+	messages, err := r.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	filter["$and"] = []bson.M{{"$or": []bson.M{
+		{"file_url": bson.M{"$exists": true, "$ne": ""}},
+		{"sealed.file_url": bson.M{"$exists": true}},
+	}}}
+	attachments, err := r.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return 0, 0, err
+	}
+	return messages, attachments, nil
+}
+
+// DeleteMessages removes a batch of messages by ID
+func (r *mongoMessageRepository) DeleteMessages(ctx context.Context, messageIDs []primitive.ObjectID) (int64, error) {
+	result, err := r.collection.DeleteMany(ctx, bson.M{"_id": bson.M{"$in": messageIDs}})
+	if err != nil {
+		return 0, err
+	}
+	return result.DeletedCount, nil
+}
+
+// GetMessagesAround retrieves the messages on either side of a position in the conversation, oldest first
+func (r *mongoMessageRepository) GetMessagesAround(ctx context.Context, conversationID string, createdAt time.Time, id primitive.ObjectID, before, after int) ([]*models.Message, error) {
+	var earlier, later []*models.Message
+
+	if before > 0 {
+		filter := bson.M{
+			"conversation_id": conversationID,
+			"$or": []bson.M{
+				{"created_at": bson.M{"$lt": createdAt}},
+				{"created_at": createdAt, "_id": bson.M{"$lt": id}},
+			},
+		}
+		opts := options.Find().
+			SetSort(bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}).
+			SetLimit(int64(before))
+		if err := r.findAll(ctx, filter, opts, &earlier); err != nil {
+			return nil, err
+		}
+	}
+
+	if after > 0 {
+		filter := bson.M{
+			"conversation_id": conversationID,
+			"$or": []bson.M{
+				{"created_at": bson.M{"$gt": createdAt}},
+				{"created_at": createdAt, "_id": bson.M{"$gt": id}},
+			},
+		}
+		opts := options.Find().
+			SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}}).
+			SetLimit(int64(after))
+		if err := r.findAll(ctx, filter, opts, &later); err != nil {
+			return nil, err
+		}
+	}
+
+	messages := make([]*models.Message, 0, len(earlier)+len(later))
+	for i := len(earlier) - 1; i >= 0; i-- {
+		messages = append(messages, earlier[i])
+	}
+	return append(messages, later...), nil
+}
+
+func (r *mongoMessageRepository) findAll(ctx context.Context, filter bson.M, opts *options.FindOptions, messages *[]*models.Message) error {
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+	if err := cursor.All(ctx, messages); err != nil {
+		return err
+	}
+	return r.openAll(*messages)
+}
+
+// seal returns the document to store for msg: a copy with Content and FileURL encrypted
+// under a new data key, or msg itself when encryption is off or there is nothing to
+// encrypt. msg is given an ID first, since the ciphertexts are bound to it.
+func (r *mongoMessageRepository) seal(msg *models.Message) (*models.Message, error) {
+	if !r.envelope.enabled() || (msg.Content == "" && msg.FileURL == "") {
+		return msg, nil
+	}
+	if msg.ID.IsZero() {
+		msg.ID = primitive.NewObjectID()
+	}
+
+	sealed, err := r.envelope.sealText(msg.ID.Hex(), msg.Content, msg.FileURL)
+	if err != nil {
+		return nil, err
+	}
+
+	document := *msg
+	document.Content = ""
+	document.FileURL = ""
+	document.Sealed = sealed
+	return &document, nil
+}
+
+// open decrypts a stored message's sealed fields back into Content and FileURL
+func (r *mongoMessageRepository) open(msg *models.Message) error {
+	if err := r.envelope.openText("message", msg.ID.Hex(), msg.Sealed, &msg.Content, &msg.FileURL); err != nil {
+		return err
+	}
+	msg.Sealed = nil
+	return nil
+}
+
+func (r *mongoMessageRepository) openAll(messages []*models.Message) error {
+	for _, msg := range messages {
+		if err := r.open(msg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RewrapDataKeys re-wraps the data keys of encrypted messages with the active master key
+func (r *mongoMessageRepository) RewrapDataKeys(ctx context.Context, limit int) (int, error) {
+	return r.envelope.rewrapDataKeys(ctx, r.collection, "message", limit)
+}
+
+// SealPlaintext encrypts messages stored in plaintext, such as those written before
+// a master key was configured
+func (r *mongoMessageRepository) SealPlaintext(ctx context.Context, limit int) (int, error) {
+	if !r.envelope.enabled() {
+		return 0, nil
+	}
+
+	filter := bson.M{
+		"sealed": bson.M{"$exists": false},
+		"$or": []bson.M{
+			{"content": bson.M{"$exists": true, "$ne": ""}},
+			{"file_url": bson.M{"$exists": true, "$ne": ""}},
+		},
+	}
+	cursor, err := r.collection.Find(ctx, filter, options.Find().SetLimit(int64(limit)))
+	if err != nil {
+		return 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var messages []*models.Message
+	if err := cursor.All(ctx, &messages); err != nil {
+		return 0, err
+	}
+
+	sealed := 0
+	for _, message := range messages {
+		document, err := r.seal(message)
+		if err != nil {
+			return sealed, err
+		}
+
+		// Guarding on the plaintext keeps an edit made since the read from being overwritten
+		result, err := r.collection.UpdateOne(ctx,
+			bson.M{"_id": message.ID, "sealed": bson.M{"$exists": false}, "content": message.Content},
+			bson.M{
+				"$set":   bson.M{"sealed": document.Sealed, "content": ""},
+				"$unset": bson.M{"file_url": ""},
+			},
+		)
+		if err != nil {
+			return sealed, err
+		}
+		sealed += int(result.ModifiedCount)
+	}
+	return sealed, nil
+}
@@ -0,0 +1,148 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/dk5761/go-serv/internal/domain/chat/models"
+)
+
+type mongoPollRepository struct {
+	messages *mongo.Collection
+}
+
+// NewMongoPollRepository initializes a new instance of mongoPollRepository. Polls live in the
+// messages collection together with their votes, one per voter, so that a vote, the open
+// check and the tally it changes are a single document write.
+func NewMongoPollRepository(db *mongo.Database) PollRepository {
+	return &mongoPollRepository{
+		messages: db.Collection("messages"),
+	}
+}
+
+// ReplaceVote replaces the user's vote and recomputes the tally in one update, which only
+// matches while the poll is open at now
+func (r *mongoPollRepository) ReplaceVote(ctx context.Context, pollID primitive.ObjectID, poll *models.PollPayload, userID string, optionIDs []string, now time.Time) (*models.PollResults, bool, error) {
+	filter := bson.M{
+		"_id":         pollID,
+		"type":        models.PollMessage,
+		"poll.closed": false,
+		"$or": bson.A{
+			bson.M{"poll.closes_at": nil},
+			bson.M{"poll.closes_at": bson.M{"$gt": now}},
+		},
+	}
+
+	votes := bson.M{"$filter": bson.M{
+		"input": bson.M{"$ifNull": bson.A{"$poll.votes", bson.A{}}},
+		"cond":  bson.M{"$ne": bson.A{"$$this.user_id", userID}},
+	}}
+	if len(optionIDs) > 0 {
+		vote := models.PollVote{UserID: userID, OptionIDs: optionIDs, UpdatedAt: now}
+		votes = bson.M{"$concatArrays": bson.A{votes, bson.A{bson.M{"$literal": vote}}}}
+	}
+
+	update := mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{"poll.votes": votes}}},
+		{{Key: "$set", Value: bson.M{"poll.results": tallyExpression(poll)}}},
+	}
+	opts := options.FindOneAndUpdate().
+		SetReturnDocument(options.After).
+		SetProjection(bson.M{"poll.results": 1})
+
+	var updated struct {
+		Poll struct {
+			Results *models.PollResults `bson:"results"`
+		} `bson:"poll"`
+	}
+	err := r.messages.FindOneAndUpdate(ctx, filter, update, opts).Decode(&updated)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return updated.Poll.Results, true, nil
+}
+
+// tallyExpression counts the votes of poll.votes per option, in an update pipeline. Voters
+// are only listed for polls that are not anonymous.
+func tallyExpression(poll *models.PollPayload) bson.M {
+	counts := bson.M{}
+	voters := bson.M{}
+	for _, option := range poll.Options {
+		chosen := bson.M{"$filter": bson.M{
+			"input": "$poll.votes",
+			"cond":  bson.M{"$in": bson.A{option.ID, "$$this.option_ids"}},
+		}}
+		counts[option.ID] = bson.M{"$size": chosen}
+		voters[option.ID] = bson.M{"$map": bson.M{"input": chosen, "in": "$$this.user_id"}}
+	}
+
+	results := bson.M{
+		"counts":       counts,
+		"total_voters": bson.M{"$size": "$poll.votes"},
+	}
+	if !poll.Anonymous {
+		results["voters"] = voters
+	}
+	return results
+}
+
+// ClosePoll marks the poll closed, which freezes the tally every vote kept up to date
+func (r *mongoPollRepository) ClosePoll(ctx context.Context, pollID primitive.ObjectID, closedAt time.Time) (*models.PollResults, bool, error) {
+	filter := bson.M{"_id": pollID, "poll.closed": false}
+	update := bson.M{
+		"$set": bson.M{
+			"poll.closed":    true,
+			"poll.closed_at": closedAt,
+		},
+	}
+	opts := options.FindOneAndUpdate().
+		SetReturnDocument(options.After).
+		SetProjection(bson.M{"poll.results": 1})
+
+	var updated struct {
+		Poll struct {
+			Results *models.PollResults `bson:"results"`
+		} `bson:"poll"`
+	}
+	err := r.messages.FindOneAndUpdate(ctx, filter, update, opts).Decode(&updated)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return updated.Poll.Results, true, nil
+}
+
+// GetDuePolls returns open polls whose close time has passed
+func (r *mongoPollRepository) GetDuePolls(ctx context.Context, now time.Time, limit int) ([]*models.Message, error) {
+	filter := bson.M{
+		"type":           models.PollMessage,
+		"poll.closed":    false,
+		"poll.closes_at": bson.M{"$lte": now},
+	}
+	findOptions := options.Find().
+		SetSort(bson.D{{Key: "poll.closes_at", Value: 1}}).
+		SetLimit(int64(limit))
+
+	cursor, err := r.messages.Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var polls []*models.Message
+	if err := cursor.All(ctx, &polls); err != nil {
+		return nil, err
+	}
+	return polls, nil
+}
@@ -0,0 +1,89 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/dk5761/go-serv/internal/domain/chat/models"
+	"github.com/dk5761/go-serv/internal/domain/common"
+)
+
+type mongoUserPreferencesRepository struct {
+	collection *mongo.Collection
+}
+
+// NewMongoUserPreferencesRepository initializes a new instance of mongoUserPreferencesRepository
+func NewMongoUserPreferencesRepository(db *mongo.Database) UserPreferencesRepository {
+	return &mongoUserPreferencesRepository{
+		collection: db.Collection("user_preferences"),
+	}
+}
+
+// GetPreferences retrieves a user's chat preferences
+func (r *mongoUserPreferencesRepository) GetPreferences(ctx context.Context, userID string) (*models.UserPreferences, error) {
+	var preferences models.UserPreferences
+	err := r.collection.FindOne(ctx, bson.M{"_id": userID}).Decode(&preferences)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, common.ErrNotFound
+		}
+		return nil, err
+	}
+	return &preferences, nil
+}
+
+// SavePreferences updates the user's chat preferences
+func (r *mongoUserPreferencesRepository) SavePreferences(ctx context.Context, preferences *models.UserPreferences) error {
+	preferences.UpdatedAt = time.Now()
+	update := bson.M{"$set": bson.M{
+		"keep_archived":    preferences.KeepArchived,
+		"digest_frequency": preferences.DigestFrequency,
+		"updated_at":       preferences.UpdatedAt,
+	}}
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": preferences.UserID}, update, options.Update().SetUpsert(true))
+	return err
+}
+
+// SetDigestFrequency changes how often the user gets email digests
+func (r *mongoUserPreferencesRepository) SetDigestFrequency(ctx context.Context, userID string, frequency models.DigestFrequency) error {
+	update := bson.M{"$set": bson.M{"digest_frequency": frequency, "updated_at": time.Now()}}
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": userID}, update, options.Update().SetUpsert(true))
+	return err
+}
+
+// ClaimDigest sets last_digest_at to now if no digest was sent after dueBefore
+func (r *mongoUserPreferencesRepository) ClaimDigest(ctx context.Context, userID string, dueBefore, now time.Time) (bool, error) {
+	filter := bson.M{
+		"_id": userID,
+		"$or": bson.A{
+			bson.M{"last_digest_at": bson.M{"$exists": false}},
+			bson.M{"last_digest_at": bson.M{"$lte": dueBefore}},
+		},
+	}
+	update := bson.M{"$set": bson.M{"last_digest_at": now}}
+
+	result, err := r.collection.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	if err != nil {
+		// The upsert collides with an existing document when a recent digest was sent
+		if mongo.IsDuplicateKeyError(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return result.MatchedCount > 0 || result.UpsertedCount > 0, nil
+}
+
+// ReleaseDigest restores last_digest_at if it is still the claim's
+func (r *mongoUserPreferencesRepository) ReleaseDigest(ctx context.Context, userID string, claimedAt time.Time, previous *time.Time) error {
+	update := bson.M{"$unset": bson.M{"last_digest_at": ""}}
+	if previous != nil {
+		update = bson.M{"$set": bson.M{"last_digest_at": *previous}}
+	}
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": userID, "last_digest_at": claimedAt}, update)
+	return err
+}
@@ -0,0 +1,255 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/dk5761/go-serv/internal/domain/chat/models"
+	"github.com/dk5761/go-serv/internal/domain/common"
+	"github.com/dk5761/go-serv/internal/infrastructure/encryption"
+)
+
+type mongoReportRepository struct {
+	collection *mongo.Collection
+	envelope   envelope
+}
+
+// NewMongoReportRepository initializes a new instance of mongoReportRepository. With a
+// keyring, snapshots are encrypted at rest like the messages they copy.
+func NewMongoReportRepository(db *mongo.Database, keyring *encryption.Keyring) ReportRepository {
+	return &mongoReportRepository{
+		collection: db.Collection("reports"),
+		envelope:   envelope{keyring: keyring},
+	}
+}
+
+// CreateReport stores a new open report
+func (r *mongoReportRepository) CreateReport(ctx context.Context, report *models.Report) error {
+	report.ID = primitive.NewObjectID()
+	report.Status = models.ReportOpen
+	report.CreatedAt = time.Now()
+
+	sealed, err := r.sealSnapshot(report.ID, report.Snapshot)
+	if err != nil {
+		return err
+	}
+	document := *report
+	if sealed != nil {
+		document.Snapshot = nil
+		document.Sealed = sealed
+	}
+
+	_, err = r.collection.InsertOne(ctx, &document)
+	if mongo.IsDuplicateKeyError(err) {
+		return common.ErrConflict
+	}
+	return err
+}
+
+// GetReport retrieves a report with its snapshot
+func (r *mongoReportRepository) GetReport(ctx context.Context, reportID primitive.ObjectID) (*models.Report, error) {
+	var report models.Report
+	err := r.collection.FindOne(ctx, bson.M{"_id": reportID}).Decode(&report)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, common.ErrNotFound
+		}
+		return nil, err
+	}
+	return &report, r.openSnapshot(&report)
+}
+
+// ListReports retrieves reports without their snapshots. The moderator queue is listed
+// oldest first; a reporter's own reports newest first.
+func (r *mongoReportRepository) ListReports(ctx context.Context, filter models.ReportFilter, limit, offset int) ([]*models.Report, error) {
+	query := bson.M{}
+	if filter.Status != "" {
+		query["status"] = filter.Status
+	}
+	order := 1
+	if filter.ReporterID != "" {
+		query["reporter_id"] = filter.ReporterID
+		order = -1
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: order}}).
+		SetProjection(bson.M{"snapshot": 0, "sealed": 0}).
+		SetLimit(int64(limit)).
+		SetSkip(int64(offset))
+
+	cursor, err := r.collection.Find(ctx, query, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	reports := []*models.Report{}
+	if err := cursor.All(ctx, &reports); err != nil {
+		return nil, err
+	}
+	return reports, nil
+}
+
+// ClaimReport assigns the report to the moderator unless another moderator holds a live claim
+func (r *mongoReportRepository) ClaimReport(ctx context.Context, reportID primitive.ObjectID, moderatorID string, now time.Time, lease time.Duration) (*models.Report, error) {
+	filter := bson.M{
+		"_id": reportID,
+		"$or": []bson.M{
+			{"status": models.ReportOpen},
+			{"status": models.ReportClaimed, "claimed_by": moderatorID},
+			{"status": models.ReportClaimed, "claimed_at": bson.M{"$lt": now.Add(-lease)}},
+		},
+	}
+	update := bson.M{"$set": bson.M{
+		"status":     models.ReportClaimed,
+		"claimed_by": moderatorID,
+		"claimed_at": now,
+	}}
+
+	return r.transition(ctx, reportID, filter, update)
+}
+
+// ResolveReport records the resolution of a report the moderator has claimed
+func (r *mongoReportRepository) ResolveReport(ctx context.Context, reportID primitive.ObjectID, moderatorID string, resolution *models.ReportResolution) (*models.Report, error) {
+	filter := bson.M{
+		"_id":        reportID,
+		"status":     models.ReportClaimed,
+		"claimed_by": moderatorID,
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"status":     models.ReportResolved,
+			"resolution": resolution,
+		},
+		// The reporter may report the same target again from now on
+		"$unset": bson.M{"dedupe_key": ""},
+	}
+
+	return r.transition(ctx, reportID, filter, update)
+}
+
+// transition applies a conditional update, telling a missing report (ErrNotFound) apart
+// from one in the wrong state (ErrConflict)
+func (r *mongoReportRepository) transition(ctx context.Context, reportID primitive.ObjectID, filter, update bson.M) (*models.Report, error) {
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var report models.Report
+	err := r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&report)
+	if err == nil {
+		return &report, r.openSnapshot(&report)
+	}
+	if !errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, err
+	}
+
+	if _, err := r.GetReport(ctx, reportID); err != nil {
+		return nil, err
+	}
+	return nil, common.ErrConflict
+}
+
+// RewrapDataKeys re-wraps the data keys of encrypted snapshots with the active master key
+func (r *mongoReportRepository) RewrapDataKeys(ctx context.Context, limit int) (int, error) {
+	return r.envelope.rewrapDataKeys(ctx, r.collection, "report", limit)
+}
+
+// SealPlaintext encrypts snapshots stored in plaintext, such as those taken before a
+// master key was configured. Snapshots are never edited, so no guard is needed.
+func (r *mongoReportRepository) SealPlaintext(ctx context.Context, limit int) (int, error) {
+	if !r.envelope.enabled() {
+		return 0, nil
+	}
+
+	filter := bson.M{
+		"sealed":   bson.M{"$exists": false},
+		"snapshot": bson.M{"$type": "object"},
+	}
+	findOptions := options.Find().
+		SetProjection(bson.M{"snapshot": 1}).
+		SetLimit(int64(limit))
+
+	cursor, err := r.collection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var reports []*models.Report
+	if err := cursor.All(ctx, &reports); err != nil {
+		return 0, err
+	}
+
+	sealed := 0
+	for _, report := range reports {
+		fields, err := r.sealSnapshot(report.ID, report.Snapshot)
+		if err != nil {
+			return sealed, err
+		}
+
+		result, err := r.collection.UpdateOne(ctx,
+			bson.M{"_id": report.ID, "sealed": bson.M{"$exists": false}},
+			bson.M{
+				"$set":   bson.M{"sealed": fields},
+				"$unset": bson.M{"snapshot": ""},
+			},
+		)
+		if err != nil {
+			return sealed, err
+		}
+		sealed += int(result.ModifiedCount)
+	}
+	return sealed, nil
+}
+
+// sealSnapshot encrypts a snapshot, whole, under a new data key. It returns nil when
+// encryption is off or there is no snapshot.
+func (r *mongoReportRepository) sealSnapshot(id primitive.ObjectID, snapshot *models.ReportSnapshot) (*models.SealedFields, error) {
+	if !r.envelope.enabled() || snapshot == nil {
+		return nil, nil
+	}
+
+	data, err := bson.Marshal(snapshot)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode snapshot of report %s: %w", id.Hex(), err)
+	}
+	dataKey, sealed, err := r.envelope.newSealed()
+	if err != nil {
+		return nil, err
+	}
+	if sealed.Snapshot, err = encryption.Seal(dataKey, data, sealedAD(id.Hex(), sealedSnapshot)); err != nil {
+		return nil, err
+	}
+	return sealed, nil
+}
+
+// openSnapshot decrypts a stored report's sealed snapshot back into Snapshot
+func (r *mongoReportRepository) openSnapshot(report *models.Report) error {
+	if report.Sealed == nil {
+		return nil
+	}
+	id := report.ID.Hex()
+	dataKey, err := r.envelope.dataKey("report", id, report.Sealed)
+	if err != nil {
+		return err
+	}
+	data, err := encryption.Open(dataKey, report.Sealed.Snapshot, sealedAD(id, sealedSnapshot))
+	if err != nil {
+		return fmt.Errorf("failed to decrypt report %s: %w", id, err)
+	}
+
+	var snapshot models.ReportSnapshot
+	if err := bson.Unmarshal(data, &snapshot); err != nil {
+		return fmt.Errorf("failed to decode snapshot of report %s: %w", id, err)
+	}
+	report.Snapshot = &snapshot
+	report.Sealed = nil
+	return nil
+}
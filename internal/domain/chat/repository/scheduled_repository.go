@@ -0,0 +1,317 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/dk5761/go-serv/internal/domain/chat/models"
+	"github.com/dk5761/go-serv/internal/domain/common"
+	"github.com/dk5761/go-serv/internal/infrastructure/encryption"
+)
+
+type mongoScheduledMessageRepository struct {
+	collection *mongo.Collection
+	envelope   envelope
+}
+
+// NewMongoScheduledMessageRepository initializes a new instance of mongoScheduledMessageRepository.
+// With a keyring, content and file URLs are encrypted at rest like those of messages.
+func NewMongoScheduledMessageRepository(db *mongo.Database, keyring *encryption.Keyring) ScheduledMessageRepository {
+	return &mongoScheduledMessageRepository{
+		collection: db.Collection("scheduled_messages"),
+		envelope:   envelope{keyring: keyring},
+	}
+}
+
+// unleased matches documents that no scheduler instance currently holds.
+func unleased(now time.Time) bson.M {
+	return bson.M{
+		"$or": []bson.M{
+			{"lease_until": bson.M{"$exists": false}},
+			{"lease_until": bson.M{"$lt": now}},
+		},
+	}
+}
+
+// CreateScheduledMessage stores a new scheduled message in the pending state
+func (r *mongoScheduledMessageRepository) CreateScheduledMessage(ctx context.Context, msg *models.ScheduledMessage) (primitive.ObjectID, error) {
+	now := time.Now()
+	msg.ID = primitive.NewObjectID() // The ciphertexts are bound to it
+	msg.Status = models.ScheduledPending
+	msg.CreatedAt = now
+	msg.UpdatedAt = now
+
+	sealed, err := r.envelope.sealText(msg.ID.Hex(), msg.Content, msg.FileURL)
+	if err != nil {
+		return primitive.NilObjectID, err
+	}
+	document := *msg
+	if sealed != nil {
+		document.Content = ""
+		document.FileURL = ""
+		document.Sealed = sealed
+	}
+
+	if _, err := r.collection.InsertOne(ctx, &document); err != nil {
+		return primitive.NilObjectID, err
+	}
+	return msg.ID, nil
+}
+
+// GetScheduledMessage retrieves a scheduled message owned by senderID
+func (r *mongoScheduledMessageRepository) GetScheduledMessage(ctx context.Context, id primitive.ObjectID, senderID string) (*models.ScheduledMessage, error) {
+	var msg models.ScheduledMessage
+	err := r.collection.FindOne(ctx, bson.M{"_id": id, "sender_id": senderID}).Decode(&msg)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, common.ErrNotFound
+		}
+		return nil, err
+	}
+	return &msg, r.open(&msg)
+}
+
+// ListScheduledMessages returns the sender's messages that are still waiting to be sent, soonest first
+func (r *mongoScheduledMessageRepository) ListScheduledMessages(ctx context.Context, senderID string) ([]*models.ScheduledMessage, error) {
+	filter := bson.M{
+		"sender_id": senderID,
+		"status":    models.ScheduledPending,
+	}
+	findOptions := options.Find().SetSort(bson.D{{Key: "send_at", Value: 1}})
+
+	cursor, err := r.collection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	messages := []*models.ScheduledMessage{}
+	if err := cursor.All(ctx, &messages); err != nil {
+		return nil, err
+	}
+	for _, msg := range messages {
+		if err := r.open(msg); err != nil {
+			return nil, err
+		}
+	}
+	return messages, nil
+}
+
+// UpdateScheduledMessage edits the content and send time of a message that has not been picked up yet
+func (r *mongoScheduledMessageRepository) UpdateScheduledMessage(ctx context.Context, id primitive.ObjectID, senderID, content string, sendAt time.Time) error {
+	now := time.Now()
+	filter := bson.M{
+		"_id":       id,
+		"sender_id": senderID,
+		"status":    models.ScheduledPending,
+	}
+	for k, v := range unleased(now) {
+		filter[k] = v
+	}
+
+	update := bson.M{
+		"$set": bson.M{
+			"content":    content,
+			"send_at":    sendAt,
+			"updated_at": now,
+		},
+	}
+	if r.envelope.enabled() {
+		// The file URL shares the content's data key, so both are sealed again under a new
+		// one. Matching the time of the read keeps a concurrent edit from being lost.
+		current, err := r.GetScheduledMessage(ctx, id, senderID)
+		if err != nil {
+			return err
+		}
+		sealed, err := r.envelope.sealText(id.Hex(), content, current.FileURL)
+		if err != nil {
+			return err
+		}
+		filter["updated_at"] = current.UpdatedAt
+		update["$set"] = bson.M{
+			"content":    "",
+			"sealed":     sealed,
+			"send_at":    sendAt,
+			"updated_at": now,
+		}
+		update["$unset"] = bson.M{"file_url": ""}
+	}
+
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return common.ErrNotFound // Missing, not owned, already sent or currently being dispatched
+	}
+	return nil
+}
+
+// CancelScheduledMessage cancels a message that has not been picked up yet
+func (r *mongoScheduledMessageRepository) CancelScheduledMessage(ctx context.Context, id primitive.ObjectID, senderID string) error {
+	now := time.Now()
+	filter := bson.M{
+		"_id":       id,
+		"sender_id": senderID,
+		"status":    models.ScheduledPending,
+	}
+	for k, v := range unleased(now) {
+		filter[k] = v
+	}
+
+	update := bson.M{
+		"$set": bson.M{
+			"status":     models.ScheduledCanceled,
+			"updated_at": now,
+		},
+	}
+
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return common.ErrNotFound
+	}
+	return nil
+}
+
+// ClaimDueMessage atomically leases the oldest due message to owner so that only one
+// server instance dispatches it. It returns nil when nothing is due.
+func (r *mongoScheduledMessageRepository) ClaimDueMessage(ctx context.Context, owner string, now time.Time, lease time.Duration) (*models.ScheduledMessage, error) {
+	filter := bson.M{
+		"status":  models.ScheduledPending,
+		"send_at": bson.M{"$lte": now},
+	}
+	for k, v := range unleased(now) {
+		filter[k] = v
+	}
+
+	update := bson.M{
+		"$set": bson.M{
+			"lease_owner": owner,
+			"lease_until": now.Add(lease),
+		},
+		"$inc": bson.M{"attempts": 1},
+	}
+
+	opts := options.FindOneAndUpdate().
+		SetSort(bson.D{{Key: "send_at", Value: 1}}).
+		SetReturnDocument(options.After)
+
+	var msg models.ScheduledMessage
+	err := r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&msg)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &msg, r.open(&msg)
+}
+
+// MarkScheduledSent records that the message leased by owner has been dispatched
+func (r *mongoScheduledMessageRepository) MarkScheduledSent(ctx context.Context, id primitive.ObjectID, owner string) error {
+	now := time.Now()
+	filter := bson.M{"_id": id, "lease_owner": owner}
+	update := bson.M{
+		"$set": bson.M{
+			"status":     models.ScheduledSent,
+			"sent_at":    now,
+			"updated_at": now,
+		},
+		"$unset": bson.M{"lease_owner": "", "lease_until": "", "last_error": ""},
+	}
+
+	_, err := r.collection.UpdateOne(ctx, filter, update)
+	return err
+}
+
+// ReleaseScheduledMessage gives up the lease after a failed dispatch, either returning the
+// message to the queue for another attempt or marking it as permanently failed
+func (r *mongoScheduledMessageRepository) ReleaseScheduledMessage(ctx context.Context, id primitive.ObjectID, owner string, lastErr string, failed bool) error {
+	set := bson.M{
+		"last_error": lastErr,
+		"updated_at": time.Now(),
+	}
+	if failed {
+		set["status"] = models.ScheduledFailed
+	}
+
+	filter := bson.M{"_id": id, "lease_owner": owner}
+	update := bson.M{
+		"$set":   set,
+		"$unset": bson.M{"lease_owner": "", "lease_until": ""},
+	}
+
+	_, err := r.collection.UpdateOne(ctx, filter, update)
+	return err
+}
+
+// RewrapDataKeys re-wraps the data keys of encrypted scheduled messages with the active master key
+func (r *mongoScheduledMessageRepository) RewrapDataKeys(ctx context.Context, limit int) (int, error) {
+	return r.envelope.rewrapDataKeys(ctx, r.collection, "scheduled message", limit)
+}
+
+// SealPlaintext encrypts scheduled messages stored in plaintext, such as those written
+// before a master key was configured
+func (r *mongoScheduledMessageRepository) SealPlaintext(ctx context.Context, limit int) (int, error) {
+	if !r.envelope.enabled() {
+		return 0, nil
+	}
+
+	filter := bson.M{
+		"sealed": bson.M{"$exists": false},
+		"$or": []bson.M{
+			{"content": bson.M{"$exists": true, "$ne": ""}},
+			{"file_url": bson.M{"$exists": true, "$ne": ""}},
+		},
+	}
+	cursor, err := r.collection.Find(ctx, filter, options.Find().SetLimit(int64(limit)))
+	if err != nil {
+		return 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var messages []*models.ScheduledMessage
+	if err := cursor.All(ctx, &messages); err != nil {
+		return 0, err
+	}
+
+	sealed := 0
+	for _, msg := range messages {
+		fields, err := r.envelope.sealText(msg.ID.Hex(), msg.Content, msg.FileURL)
+		if err != nil {
+			return sealed, err
+		}
+
+		// Guarding on the plaintext keeps an edit made since the read from being overwritten
+		result, err := r.collection.UpdateOne(ctx,
+			bson.M{"_id": msg.ID, "sealed": bson.M{"$exists": false}, "content": msg.Content},
+			bson.M{
+				"$set":   bson.M{"sealed": fields, "content": ""},
+				"$unset": bson.M{"file_url": ""},
+			},
+		)
+		if err != nil {
+			return sealed, err
+		}
+		sealed += int(result.ModifiedCount)
+	}
+	return sealed, nil
+}
+
+// open decrypts a stored scheduled message's sealed fields back into Content and FileURL
+func (r *mongoScheduledMessageRepository) open(msg *models.ScheduledMessage) error {
+	if err := r.envelope.openText("scheduled message", msg.ID.Hex(), msg.Sealed, &msg.Content, &msg.FileURL); err != nil {
+		return err
+	}
+	msg.Sealed = nil
+	return nil
+}
@@ -0,0 +1,261 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/dk5761/go-serv/internal/domain/chat/models"
+	"github.com/dk5761/go-serv/internal/domain/common"
+)
+
+type mongoConversationSettingsRepository struct {
+	collection *mongo.Collection
+}
+
+// NewMongoConversationSettingsRepository initializes a new instance of mongoConversationSettingsRepository
+func NewMongoConversationSettingsRepository(db *mongo.Database) ConversationSettingsRepository {
+	return &mongoConversationSettingsRepository{
+		collection: db.Collection("conversation_settings"),
+	}
+}
+
+// GetSettings retrieves a user's settings for a conversation
+func (r *mongoConversationSettingsRepository) GetSettings(ctx context.Context, userID, conversationID string) (*models.ConversationSettings, error) {
+	var settings models.ConversationSettings
+	err := r.collection.FindOne(ctx, bson.M{"_id": models.ConversationSettingsID(userID, conversationID)}).Decode(&settings)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, common.ErrNotFound
+		}
+		return nil, err
+	}
+	return &settings, nil
+}
+
+// ListSettings retrieves the user's conversations in a folder or archive state
+func (r *mongoConversationSettingsRepository) ListSettings(ctx context.Context, userID string, filter models.InboxFilter, limit, offset int) ([]*models.ConversationSettings, error) {
+	query := bson.M{
+		"user_id":  userID,
+		"archived": filter.Archived,
+	}
+	if filter.Folder != "" {
+		query["folders"] = filter.Folder
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "last_message_at", Value: -1}, {Key: "updated_at", Value: -1}}).
+		SetLimit(int64(limit)).
+		SetSkip(int64(offset))
+
+	cursor, err := r.collection.Find(ctx, query, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	settings := []*models.ConversationSettings{}
+	if err := cursor.All(ctx, &settings); err != nil {
+		return nil, err
+	}
+	return settings, nil
+}
+
+// SetMute mutes the conversation until the given time or forever; a nil until and false forever unmutes it
+func (r *mongoConversationSettingsRepository) SetMute(ctx context.Context, userID, conversationID string, until *time.Time, forever bool) (*models.ConversationSettings, error) {
+	update := bson.M{"$set": bson.M{"muted_forever": forever}}
+	if until != nil {
+		update["$set"].(bson.M)["muted_until"] = *until
+	} else {
+		update["$unset"] = bson.M{"muted_until": ""}
+	}
+	return r.upsert(ctx, userID, conversationID, update)
+}
+
+// SetArchived moves the conversation into or out of the archive
+func (r *mongoConversationSettingsRepository) SetArchived(ctx context.Context, userID, conversationID string, archived bool) (*models.ConversationSettings, error) {
+	return r.upsert(ctx, userID, conversationID, bson.M{"$set": bson.M{"archived": archived}})
+}
+
+// SetFolders replaces the folders the conversation is filed under
+func (r *mongoConversationSettingsRepository) SetFolders(ctx context.Context, userID, conversationID string, folders []string) (*models.ConversationSettings, error) {
+	return r.upsert(ctx, userID, conversationID, bson.M{"$set": bson.M{"folders": folders}})
+}
+
+// MarkRead clears the unread count
+func (r *mongoConversationSettingsRepository) MarkRead(ctx context.Context, userID, conversationID string) (*models.ConversationSettings, error) {
+	return r.upsert(ctx, userID, conversationID, bson.M{"$set": bson.M{"unread_count": 0}})
+}
+
+// RecordMessage updates the last activity and, for received messages, the unread count
+func (r *mongoConversationSettingsRepository) RecordMessage(ctx context.Context, userID string, message *models.Message, incoming bool) (*models.ConversationSettings, error) {
+	update := bson.M{
+		"$set": bson.M{
+			"last_message_id": message.ID.Hex(),
+			"last_message_at": message.CreatedAt,
+		},
+		"$max": bson.M{"last_message_seq": message.Seq},
+	}
+	if incoming {
+		update["$inc"] = bson.M{"unread_count": 1}
+	}
+	return r.upsert(ctx, userID, message.ConversationID, update)
+}
+
+func (r *mongoConversationSettingsRepository) CountUnread(ctx context.Context, userID string, now time.Time) (int, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: unmuted(bson.M{
+			"user_id":      userID,
+			"unread_count": bson.M{"$gt": 0},
+		}, now)}},
+		{{Key: "$group", Value: bson.M{"_id": nil, "unread": bson.M{"$sum": "$unread_count"}}}},
+	}
+
+	cursor, err := r.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var totals []struct {
+		Unread int `bson:"unread"`
+	}
+	if err := cursor.All(ctx, &totals); err != nil {
+		return 0, err
+	}
+	if len(totals) == 0 {
+		return 0, nil
+	}
+	return totals[0].Unread, nil
+}
+
+// ListDigestUsers pages through the users with unread messages in a conversation that has
+// been quiet since idleBefore, in user ID order
+func (r *mongoConversationSettingsRepository) ListDigestUsers(ctx context.Context, idleBefore time.Time, afterUserID string, limit int) ([]string, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{
+			"user_id":         bson.M{"$gt": afterUserID},
+			"unread_count":    bson.M{"$gt": 0},
+			"last_message_at": bson.M{"$lte": idleBefore},
+		}}},
+		{{Key: "$group", Value: bson.M{"_id": "$user_id"}}},
+		{{Key: "$sort", Value: bson.M{"_id": 1}}},
+		{{Key: "$limit", Value: limit}},
+	}
+
+	cursor, err := r.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var users []struct {
+		ID string `bson:"_id"`
+	}
+	if err := cursor.All(ctx, &users); err != nil {
+		return nil, err
+	}
+
+	userIDs := make([]string, len(users))
+	for i, user := range users {
+		userIDs[i] = user.ID
+	}
+	return userIDs, nil
+}
+
+// ListUnread returns the user's unmuted conversations with unread messages whose last
+// message arrived after since and no later than before, most recently active first
+func (r *mongoConversationSettingsRepository) ListUnread(ctx context.Context, userID string, since, before, now time.Time, limit int) ([]*models.ConversationSettings, error) {
+	query := unmuted(bson.M{
+		"user_id":         userID,
+		"unread_count":    bson.M{"$gt": 0},
+		"last_message_at": bson.M{"$gt": since, "$lte": before},
+	}, now)
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "last_message_at", Value: -1}}).
+		SetLimit(int64(limit))
+
+	cursor, err := r.collection.Find(ctx, query, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	settings := []*models.ConversationSettings{}
+	if err := cursor.All(ctx, &settings); err != nil {
+		return nil, err
+	}
+	return settings, nil
+}
+
+// unmuted narrows query to conversations not muted at now
+func unmuted(query bson.M, now time.Time) bson.M {
+	query["muted_forever"] = false
+	query["$or"] = []bson.M{
+		{"muted_until": bson.M{"$exists": false}},
+		{"muted_until": nil},
+		{"muted_until": bson.M{"$lte": now}},
+	}
+	return query
+}
+
+// Unarchive clears the archived flag only when it is set, so the caller learns whether it changed
+func (r *mongoConversationSettingsRepository) Unarchive(ctx context.Context, userID, conversationID string) (bool, error) {
+	filter := bson.M{
+		"_id":      models.ConversationSettingsID(userID, conversationID),
+		"archived": true,
+	}
+	update := bson.M{"$set": bson.M{"archived": false, "updated_at": time.Now()}}
+
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return false, err
+	}
+	return result.ModifiedCount > 0, nil
+}
+
+// upsert applies update to the settings document, creating it with default values first if needed
+func (r *mongoConversationSettingsRepository) upsert(ctx context.Context, userID, conversationID string, update bson.M) (*models.ConversationSettings, error) {
+	set, _ := update["$set"].(bson.M)
+	if set == nil {
+		set = bson.M{}
+		update["$set"] = set
+	}
+	set["updated_at"] = time.Now()
+
+	// Defaults for a new document, leaving out the fields the update writes itself
+	defaults := bson.M{
+		"user_id":         userID,
+		"conversation_id": conversationID,
+		"muted_forever":   false,
+		"archived":        false,
+		"folders":         []string{},
+		"unread_count":    0,
+	}
+	for _, operator := range []string{"$set", "$unset", "$inc"} {
+		fields, _ := update[operator].(bson.M)
+		for field := range fields {
+			delete(defaults, field)
+		}
+	}
+	update["$setOnInsert"] = defaults
+
+	filter := bson.M{"_id": models.ConversationSettingsID(userID, conversationID)}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+
+	var settings models.ConversationSettings
+	err := r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&settings)
+	if mongo.IsDuplicateKeyError(err) {
+		// A concurrent upsert created the document first; it exists now, so retry as an update
+		err = r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&settings)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &settings, nil
+}
@@ -0,0 +1,172 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/dk5761/go-serv/internal/domain/chat/models"
+	"github.com/dk5761/go-serv/internal/domain/common"
+)
+
+const maxWebhookErrorLength = 500
+
+type mongoWebhookRepository struct {
+	collection *mongo.Collection
+}
+
+// NewMongoWebhookRepository initializes a new instance of mongoWebhookRepository
+func NewMongoWebhookRepository(db *mongo.Database) WebhookRepository {
+	return &mongoWebhookRepository{
+		collection: db.Collection("incoming_webhooks"),
+	}
+}
+
+func (r *mongoWebhookRepository) CreateWebhook(ctx context.Context, webhook *models.IncomingWebhook) (*models.IncomingWebhook, error) {
+	result, err := r.collection.InsertOne(ctx, webhook)
+	if err != nil {
+		return nil, err
+	}
+	webhook.ID = result.InsertedID.(primitive.ObjectID)
+	return webhook, nil
+}
+
+func (r *mongoWebhookRepository) GetWebhook(ctx context.Context, id primitive.ObjectID) (*models.IncomingWebhook, error) {
+	return r.findOne(ctx, bson.M{"_id": id})
+}
+
+func (r *mongoWebhookRepository) GetWebhookByTokenHash(ctx context.Context, tokenHash string) (*models.IncomingWebhook, error) {
+	return r.findOne(ctx, bson.M{"token_hash": tokenHash})
+}
+
+func (r *mongoWebhookRepository) ListWebhooks(ctx context.Context, conversationID string) ([]*models.IncomingWebhook, error) {
+	findOptions := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}})
+
+	cursor, err := r.collection.Find(ctx, bson.M{"conversation_id": conversationID}, findOptions)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	webhooks := []*models.IncomingWebhook{}
+	if err := cursor.All(ctx, &webhooks); err != nil {
+		return nil, err
+	}
+	return webhooks, nil
+}
+
+func (r *mongoWebhookRepository) SetTokenHash(ctx context.Context, id primitive.ObjectID, tokenHash string) (*models.IncomingWebhook, error) {
+	update := bson.M{
+		"$set":   bson.M{"token_hash": tokenHash},
+		"$unset": bson.M{"revoked_at": ""},
+	}
+	return r.update(ctx, id, update)
+}
+
+func (r *mongoWebhookRepository) RevokeToken(ctx context.Context, id primitive.ObjectID, now time.Time) (*models.IncomingWebhook, error) {
+	update := bson.M{
+		"$set":   bson.M{"revoked_at": now},
+		"$unset": bson.M{"token_hash": ""},
+	}
+	return r.update(ctx, id, update)
+}
+
+func (r *mongoWebhookRepository) DeleteWebhook(ctx context.Context, id primitive.ObjectID) error {
+	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return fmt.Errorf("%w: webhook not found", common.ErrNotFound)
+	}
+	return nil
+}
+
+// TakeRateSlot counts the request in the current window if it has room left, or else
+// starts a new window if the current one is over
+func (r *mongoWebhookRepository) TakeRateSlot(ctx context.Context, id primitive.ObjectID, now time.Time, window time.Duration, limit int) (bool, error) {
+	windowStart := now.Add(-window)
+
+	result, err := r.collection.UpdateOne(ctx,
+		bson.M{
+			"_id":          id,
+			"window_start": bson.M{"$gt": windowStart},
+			"window_count": bson.M{"$lt": limit},
+		},
+		bson.M{
+			"$inc": bson.M{"window_count": 1},
+			"$set": bson.M{"last_used_at": now},
+		},
+	)
+	if err != nil {
+		return false, err
+	}
+	if result.MatchedCount > 0 {
+		return true, nil
+	}
+
+	result, err = r.collection.UpdateOne(ctx,
+		bson.M{
+			"_id": id,
+			"$or": bson.A{
+				bson.M{"window_start": bson.M{"$exists": false}},
+				bson.M{"window_start": bson.M{"$lte": windowStart}},
+			},
+		},
+		bson.M{"$set": bson.M{
+			"window_start": now,
+			"window_count": 1,
+			"last_used_at": now,
+		}},
+	)
+	if err != nil {
+		return false, err
+	}
+	return result.MatchedCount > 0, nil
+}
+
+func (r *mongoWebhookRepository) RecordError(ctx context.Context, id primitive.ObjectID, message string, now time.Time) error {
+	if len(message) > maxWebhookErrorLength {
+		message = message[:maxWebhookErrorLength]
+	}
+	_, err := r.collection.UpdateOne(ctx,
+		bson.M{"_id": id},
+		bson.M{
+			"$inc": bson.M{"error_count": 1},
+			"$set": bson.M{"last_error": message, "last_error_at": now},
+		},
+	)
+	return err
+}
+
+func (r *mongoWebhookRepository) findOne(ctx context.Context, filter bson.M) (*models.IncomingWebhook, error) {
+	var webhook models.IncomingWebhook
+	err := r.collection.FindOne(ctx, filter).Decode(&webhook)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, fmt.Errorf("%w: webhook not found", common.ErrNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &webhook, nil
+}
+
+func (r *mongoWebhookRepository) update(ctx context.Context, id primitive.ObjectID, update bson.M) (*models.IncomingWebhook, error) {
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var webhook models.IncomingWebhook
+	err := r.collection.FindOneAndUpdate(ctx, bson.M{"_id": id}, update, opts).Decode(&webhook)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, fmt.Errorf("%w: webhook not found", common.ErrNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &webhook, nil
+}
@@ -0,0 +1,20 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/dk5761/go-serv/internal/domain/chat/models"
+)
+
+// CallService relays the signaling of one-to-one calls between the devices of their
+// participants. Media flows peer to peer; the server only tracks the state of each call.
+type CallService interface {
+	Invite(ctx context.Context, client *models.Client, calleeID string, media models.CallMedia, tempID string, sdp json.RawMessage) error
+	Answer(ctx context.Context, client *models.Client, callID string, sdp json.RawMessage) error
+	RelayCandidate(ctx context.Context, client *models.Client, callID string, candidate json.RawMessage) error
+	Hangup(ctx context.Context, client *models.Client, callID string) error
+	Reject(ctx context.Context, client *models.Client, callID string) error
+	Disconnected(client *models.Client)
+	ICEServers(userID string) []models.ICEServer
+}
@@ -12,5 +12,15 @@ type ChatService interface {
 	SendMessage(ctx context.Context, msg *models.Message, file multipart.File, fileName string) error
 	GetChatHistory(ctx context.Context, userID1, userID2 uuid.UUID, limit, offset int) ([]*models.Message, error)
 	UploadFile(ctx context.Context, file multipart.File, fileName string) (string, error)
+	// UploadVoice checks and uploads a voice note, keeping the duration and waveform it
+	// extracted for the voice message that will reference the file.
+	UploadVoice(ctx context.Context, userID uuid.UUID, file multipart.File, fileName string, size int64) (*models.Attachment, error)
 	SendToClient(receiverID string, msg *models.Message) error
+	GetMentions(ctx context.Context, userID uuid.UUID, limit, offset int) ([]*models.Message, error)
+
+	// GetConversationMessages returns a page of a conversation's history by sequence number.
+	// With afterSeq set, it returns the messages following afterSeq and preceding beforeSeq,
+	// if set, to resume after the last message seen or to fill a gap. Otherwise it returns
+	// the newest messages preceding beforeSeq, or the newest messages of all.
+	GetConversationMessages(ctx context.Context, conversationID string, userID uuid.UUID, afterSeq *int64, beforeSeq int64, limit int) (*models.MessagePage, error)
 }
@@ -0,0 +1,19 @@
+package service
+
+import (
+	"context"
+
+	authModels "github.com/dk5761/go-serv/internal/domain/auth/models"
+	"github.com/dk5761/go-serv/internal/domain/chat/command"
+)
+
+type CommandService interface {
+	// ListCommands returns the commands available to the user in a conversation: the
+	// built-ins, and the peer's commands if the peer is a bot. Without a conversation
+	// only the built-ins are listed.
+	ListCommands(ctx context.Context, userID, conversationID string) ([]*command.Command, error)
+	// SetBotCommands replaces the commands a bot handles.
+	SetBotCommands(ctx context.Context, botID string, commands []*authModels.BotCommand) ([]*authModels.BotCommand, error)
+	// Reply sends a bot's reply to a command to the user who ran it, visible only to them.
+	Reply(ctx context.Context, botID, userID, commandName, content string) error
+}
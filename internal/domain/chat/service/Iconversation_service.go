@@ -0,0 +1,12 @@
+package service
+
+import (
+	"context"
+
+	"github.com/dk5761/go-serv/internal/domain/chat/models"
+)
+
+type ConversationService interface {
+	GetConversation(ctx context.Context, conversationID, userID string) (*models.Conversation, error)
+	SetMessageTimer(ctx context.Context, conversationID, userID, timer string) (*models.Conversation, error)
+}
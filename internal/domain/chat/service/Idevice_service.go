@@ -0,0 +1,13 @@
+package service
+
+import (
+	"context"
+
+	"github.com/dk5761/go-serv/internal/domain/chat/models"
+)
+
+type DeviceService interface {
+	RegisterDevice(ctx context.Context, userID, token string, platform models.DevicePlatform) (*models.Device, error)
+	UnregisterDevice(ctx context.Context, userID, token string) error
+	ListDevices(ctx context.Context, userID string) ([]*models.Device, error)
+}
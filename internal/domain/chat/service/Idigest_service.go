@@ -0,0 +1,8 @@
+package service
+
+import "context"
+
+type DigestService interface {
+	// Unsubscribe turns email digests off for the user of a signed unsubscribe link.
+	Unsubscribe(ctx context.Context, userID, signature string) error
+}
@@ -0,0 +1,13 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/dk5761/go-serv/internal/domain/chat/models"
+)
+
+type DraftService interface {
+	SaveDraft(ctx context.Context, conversationID, userID, content, deviceID string, updatedAt time.Time) (*models.Draft, bool, error)
+	GetDraft(ctx context.Context, conversationID, userID string) (*models.Draft, error)
+}
@@ -0,0 +1,19 @@
+package service
+
+import (
+	"context"
+	"io"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/dk5761/go-serv/internal/domain/chat/models"
+)
+
+type ExportService interface {
+	// StartExport checks the export request and queues it as a background job when the
+	// conversation is too large to stream or background is requested. It returns nil when
+	// the caller should stream the export with StreamExport instead.
+	StartExport(ctx context.Context, userID string, opts models.ExportOptions, background bool) (*models.ExportJob, error)
+	StreamExport(ctx context.Context, w io.Writer, opts models.ExportOptions) error
+	GetExportJob(ctx context.Context, id primitive.ObjectID, userID string) (*models.ExportJob, error)
+}
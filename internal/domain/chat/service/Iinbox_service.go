@@ -0,0 +1,20 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/dk5761/go-serv/internal/domain/chat/models"
+)
+
+type InboxService interface {
+	ListConversations(ctx context.Context, userID string, filter models.InboxFilter, limit, offset int) ([]*models.ConversationSettings, error)
+	GetSettings(ctx context.Context, conversationID, userID string) (*models.ConversationSettings, error)
+	Mute(ctx context.Context, conversationID, userID string, until *time.Time, forever bool) (*models.ConversationSettings, error)
+	Unmute(ctx context.Context, conversationID, userID string) (*models.ConversationSettings, error)
+	SetArchived(ctx context.Context, conversationID, userID string, archived bool) (*models.ConversationSettings, error)
+	SetFolders(ctx context.Context, conversationID, userID string, folders []string) (*models.ConversationSettings, error)
+	MarkRead(ctx context.Context, conversationID, userID string) (*models.ConversationSettings, error)
+	GetPreferences(ctx context.Context, userID string) (*models.UserPreferences, error)
+	SavePreferences(ctx context.Context, preferences *models.UserPreferences) error
+}
@@ -0,0 +1,11 @@
+package service
+
+import (
+	"context"
+
+	"github.com/dk5761/go-serv/internal/domain/chat/models"
+)
+
+type ModerationService interface {
+	ListRejections(ctx context.Context, stage string, limit, offset int) ([]*models.ModerationRecord, error)
+}
@@ -0,0 +1,15 @@
+package service
+
+import (
+	"context"
+
+	"github.com/dk5761/go-serv/internal/domain/chat/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type PollService interface {
+	GetPoll(ctx context.Context, pollID primitive.ObjectID, userID string) (*models.Message, error)
+	Vote(ctx context.Context, pollID primitive.ObjectID, userID string, optionIDs []string) (*models.PollResults, error)
+	ClosePoll(ctx context.Context, pollID primitive.ObjectID, userID string) (*models.PollResults, error)
+	CloseDuePolls(ctx context.Context, limit int) (int, error)
+}
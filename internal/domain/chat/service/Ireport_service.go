@@ -0,0 +1,22 @@
+package service
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/dk5761/go-serv/internal/domain/chat/models"
+)
+
+type ReportService interface {
+	// ReportMessage files a report about a message of a conversation the reporter takes part in
+	ReportMessage(ctx context.Context, reporterID string, messageID primitive.ObjectID, reason models.ReportReason, details string) (*models.Report, error)
+	ReportUser(ctx context.Context, reporterID, reportedUserID string, reason models.ReportReason, details string) (*models.Report, error)
+	ListOwnReports(ctx context.Context, reporterID string, limit, offset int) ([]*models.Report, error)
+
+	// Moderator queue
+	ListReports(ctx context.Context, status models.ReportStatus, limit, offset int) ([]*models.Report, error)
+	GetReport(ctx context.Context, reportID primitive.ObjectID) (*models.Report, error)
+	ClaimReport(ctx context.Context, reportID primitive.ObjectID, moderatorID string) (*models.Report, error)
+	ResolveReport(ctx context.Context, reportID primitive.ObjectID, moderatorID string, action models.ModeratorAction, note string, suspendDays int) (*models.Report, error)
+}
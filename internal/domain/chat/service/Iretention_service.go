@@ -0,0 +1,14 @@
+package service
+
+import (
+	"context"
+
+	"github.com/dk5761/go-serv/internal/domain/chat/models"
+)
+
+type RetentionService interface {
+	// Purge deletes the messages that fall outside the retention policies, or in dry-run
+	// mode only reports what it would delete.
+	Purge(ctx context.Context, dryRun bool) (*models.PurgeReport, error)
+	SetConversationRetention(ctx context.Context, conversationID, userID string, policy models.RetentionPolicy) (*models.Conversation, error)
+}
@@ -0,0 +1,16 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/dk5761/go-serv/internal/domain/chat/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type ScheduledService interface {
+	ScheduleMessage(ctx context.Context, msg *models.ScheduledMessage) error
+	ListScheduledMessages(ctx context.Context, senderID string) ([]*models.ScheduledMessage, error)
+	UpdateScheduledMessage(ctx context.Context, id primitive.ObjectID, senderID, content string, sendAt time.Time) (*models.ScheduledMessage, error)
+	CancelScheduledMessage(ctx context.Context, id primitive.ObjectID, senderID string) error
+}
@@ -0,0 +1,22 @@
+package service
+
+import (
+	"context"
+
+	"github.com/dk5761/go-serv/internal/domain/chat/models"
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// WebhookService manages incoming webhooks and posts the messages sent to them. Hooks are
+// managed by the user who created them.
+type WebhookService interface {
+	CreateWebhook(ctx context.Context, conversationID string, userID uuid.UUID, name string) (*models.IncomingWebhookCredentials, error)
+	ListWebhooks(ctx context.Context, conversationID string, userID uuid.UUID) ([]*models.IncomingWebhook, error)
+	RegenerateToken(ctx context.Context, id primitive.ObjectID, userID uuid.UUID) (*models.IncomingWebhookCredentials, error)
+	RevokeToken(ctx context.Context, id primitive.ObjectID, userID uuid.UUID) (*models.IncomingWebhook, error)
+	DeleteWebhook(ctx context.Context, id primitive.ObjectID, userID uuid.UUID) error
+
+	// Post sends content as a message through the hook the token belongs to.
+	Post(ctx context.Context, token, content string) (*models.Message, error)
+}
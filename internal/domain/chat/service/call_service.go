@@ -0,0 +1,471 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"github.com/dk5761/go-serv/configs"
+	authRepo "github.com/dk5761/go-serv/internal/domain/auth/repository"
+	"github.com/dk5761/go-serv/internal/domain/chat/models"
+	"github.com/dk5761/go-serv/internal/domain/chat/websocket"
+	"github.com/dk5761/go-serv/internal/domain/common"
+	"github.com/dk5761/go-serv/internal/infrastructure/logging"
+)
+
+const (
+	defaultRingTimeout   = 45 * time.Second
+	defaultCredentialTTL = 24 * time.Hour
+	maxSignalSize        = 32 << 10 // 32 KB, per SDP or candidate
+	recordTimeout        = 10 * time.Second
+)
+
+// call is the state of a ringing or answered call. The callee's device is only known once
+// one of them answered; until then the invite rings every device of the callee.
+type call struct {
+	id             string
+	conversationID string
+	callerID       string
+	calleeID       string
+	media          models.CallMedia
+	callerDevice   *models.Client
+	calleeDevice   *models.Client
+	startedAt      time.Time
+	answeredAt     time.Time
+	timer          *time.Timer
+}
+
+func (c *call) answered() bool {
+	return c.calleeDevice != nil
+}
+
+type callService struct {
+	blockRepo   authRepo.BlockRepository
+	wsManager   *websocket.WebSocketManager
+	ringTimeout time.Duration
+	iceServers  []configs.ICEServerConfig
+
+	mu    sync.Mutex
+	calls map[string]*call
+	busy  map[string]*call // The call each user is in, ringing or answered
+}
+
+func NewCallService(blockRepo authRepo.BlockRepository, wsManager *websocket.WebSocketManager, cfg configs.CallConfig) CallService {
+	ringTimeout := time.Duration(cfg.RingTimeout) * time.Second
+	if ringTimeout <= 0 {
+		ringTimeout = defaultRingTimeout
+	}
+
+	return &callService{
+		blockRepo:   blockRepo,
+		wsManager:   wsManager,
+		ringTimeout: ringTimeout,
+		iceServers:  cfg.ICEServers,
+		calls:       make(map[string]*call),
+		busy:        make(map[string]*call),
+	}
+}
+
+// Invite starts a call from the client's device and rings every device of the callee. A
+// callee who is offline or already in a call is reported back to the caller right away
+// with a call_hangup event, and the call is recorded as missed or busy.
+func (s *callService) Invite(ctx context.Context, client *models.Client, calleeID string, media models.CallMedia, tempID string, sdp json.RawMessage) error {
+	if media == "" {
+		media = models.CallAudio
+	}
+	if !media.Valid() {
+		return fmt.Errorf("%w: media must be audio or video", common.ErrInvalidInput)
+	}
+	if err := checkSignal("sdp", sdp); err != nil {
+		return err
+	}
+	callee, err := uuid.Parse(calleeID)
+	if err != nil {
+		return fmt.Errorf("%w: invalid receiver_id", common.ErrInvalidInput)
+	}
+	if calleeID == client.ID {
+		return fmt.Errorf("%w: cannot call yourself", common.ErrInvalidInput)
+	}
+
+	caller, err := uuid.Parse(client.ID)
+	if err != nil {
+		return err
+	}
+	blocked, err := s.blockRepo.IsBlocked(ctx, caller, callee)
+	if err != nil {
+		return err
+	}
+	if blocked {
+		return fmt.Errorf("%w: you have blocked this user, unblock them to call", common.ErrForbidden)
+	}
+
+	c := &call{
+		id:             uuid.NewString(),
+		conversationID: models.ConversationID(client.ID, calleeID),
+		callerID:       client.ID,
+		calleeID:       calleeID,
+		media:          media,
+		callerDevice:   client,
+		startedAt:      time.Now(),
+	}
+	ringing := &models.Event{
+		EventType: models.EventCallRinging,
+		Data: models.CallSignalData{
+			CallID:         c.id,
+			ConversationID: c.conversationID,
+			CallerID:       c.callerID,
+			CalleeID:       c.calleeID,
+			Media:          c.media,
+			TempID:         tempID,
+		},
+	}
+
+	// A callee who blocked the caller looks unavailable, and the attempt is not recorded
+	calleeBlocked, err := s.blockRepo.IsBlocked(ctx, callee, caller)
+	if err != nil {
+		return err
+	}
+	if calleeBlocked {
+		s.wsManager.SendEventToClient(client, ringing)
+		s.sendHangup(client, c, models.CallReasonUnavailable)
+		return nil
+	}
+
+	s.mu.Lock()
+	if s.busy[client.ID] != nil {
+		s.mu.Unlock()
+		return fmt.Errorf("%w: you are already in a call", common.ErrInvalidInput)
+	}
+	if s.busy[calleeID] != nil {
+		s.mu.Unlock()
+		s.wsManager.SendEventToClient(client, ringing)
+		s.sendHangup(client, c, models.CallReasonBusy)
+		s.record(c, models.CallBusy)
+		return nil
+	}
+	s.calls[c.id] = c
+	s.busy[c.callerID] = c
+	s.busy[c.calleeID] = c
+	c.timer = time.AfterFunc(s.ringTimeout, func() { s.timeout(c.id) })
+	s.mu.Unlock()
+
+	s.wsManager.SendEventToClient(client, ringing)
+	rang := s.wsManager.SendEvent(calleeID, &models.Event{
+		EventType: models.EventCallInvite,
+		Data: models.CallSignalData{
+			CallID:         c.id,
+			ConversationID: c.conversationID,
+			CallerID:       c.callerID,
+			CalleeID:       c.calleeID,
+			Media:          c.media,
+			SDP:            sdp,
+		},
+	})
+	if !rang && s.end(c.id, c) {
+		s.sendHangup(client, c, models.CallReasonUnavailable)
+		s.record(c, models.CallMissed)
+	}
+	return nil
+}
+
+// Answer connects the call to the callee's device that answered it and relays its SDP
+// answer to the caller. The callee's other devices stop ringing.
+func (s *callService) Answer(ctx context.Context, client *models.Client, callID string, sdp json.RawMessage) error {
+	if err := checkSignal("sdp", sdp); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	c, err := s.lookup(callID, client)
+	if err != nil {
+		s.mu.Unlock()
+		return err
+	}
+	if client.ID != c.calleeID {
+		s.mu.Unlock()
+		return fmt.Errorf("%w: only the callee can answer a call", common.ErrForbidden)
+	}
+	if c.answered() {
+		s.mu.Unlock()
+		return fmt.Errorf("%w: call was already answered", common.ErrInvalidInput)
+	}
+	c.timer.Stop()
+	c.calleeDevice = client
+	c.answeredAt = time.Now()
+	callerDevice := c.callerDevice
+	s.mu.Unlock()
+
+	s.wsManager.SendEventToClient(callerDevice, &models.Event{
+		EventType: models.EventCallAnswer,
+		Data:      models.CallSignalData{CallID: c.id, SDP: sdp},
+	})
+	s.wsManager.SendEventExcept(c.calleeID, client.DeviceID, &models.Event{
+		EventType: models.EventCallHangup,
+		Data:      models.CallSignalData{CallID: c.id, Reason: models.CallReasonAnsweredElsewhere},
+	})
+	return nil
+}
+
+// RelayCandidate forwards an ICE candidate to the other participant. Candidates from the
+// caller reach every ringing device of the callee until one of them answered.
+func (s *callService) RelayCandidate(ctx context.Context, client *models.Client, callID string, candidate json.RawMessage) error {
+	if err := checkSignal("candidate", candidate); err != nil {
+		return err
+	}
+
+	event := &models.Event{
+		EventType: models.EventICECandidate,
+		Data:      models.CallSignalData{CallID: callID, Candidate: candidate},
+	}
+
+	s.mu.Lock()
+	c, err := s.lookup(callID, client)
+	if err != nil {
+		s.mu.Unlock()
+		return err
+	}
+	if client.ID == c.calleeID && !c.answered() {
+		s.mu.Unlock()
+		return fmt.Errorf("%w: answer the call before sending candidates", common.ErrInvalidInput)
+	}
+	device := s.peerDevice(client, c)
+	s.mu.Unlock()
+
+	s.send(device, c, event)
+	return nil
+}
+
+// Hangup ends the call. Hanging up a ringing call cancels it, or rejects it when the callee
+// does so.
+func (s *callService) Hangup(ctx context.Context, client *models.Client, callID string) error {
+	s.mu.Lock()
+	c, err := s.lookup(callID, client)
+	if err != nil {
+		s.mu.Unlock()
+		return err
+	}
+	if client.ID == c.calleeID && !c.answered() {
+		s.mu.Unlock()
+		return s.Reject(ctx, client, callID)
+	}
+	s.remove(c)
+	s.mu.Unlock()
+
+	s.sendHangup(s.peerDevice(client, c), c, models.CallReasonHangup)
+	if c.answered() {
+		s.record(c, models.CallCompleted)
+	} else {
+		s.record(c, models.CallCancelled)
+	}
+	return nil
+}
+
+// Reject declines a ringing call on behalf of the callee, on every device.
+func (s *callService) Reject(ctx context.Context, client *models.Client, callID string) error {
+	s.mu.Lock()
+	c, err := s.lookup(callID, client)
+	if err != nil {
+		s.mu.Unlock()
+		return err
+	}
+	if client.ID != c.calleeID {
+		s.mu.Unlock()
+		return fmt.Errorf("%w: only the callee can reject a call", common.ErrForbidden)
+	}
+	if c.answered() {
+		s.mu.Unlock()
+		return fmt.Errorf("%w: call was already answered, hang up instead", common.ErrInvalidInput)
+	}
+	s.remove(c)
+	s.mu.Unlock()
+
+	s.wsManager.SendEventToClient(c.callerDevice, &models.Event{
+		EventType: models.EventCallReject,
+		Data:      models.CallSignalData{CallID: c.id},
+	})
+	s.wsManager.SendEventExcept(c.calleeID, client.DeviceID, &models.Event{
+		EventType: models.EventCallHangup,
+		Data:      models.CallSignalData{CallID: c.id, Reason: models.CallReasonHangup},
+	})
+	s.record(c, models.CallRejected)
+	return nil
+}
+
+// Disconnected ends the call a device was taking part in when its connection dropped. A
+// callee's device that was only ringing leaves the call to the user's other devices.
+func (s *callService) Disconnected(client *models.Client) {
+	s.mu.Lock()
+	c := s.busy[client.ID]
+	if c == nil || (c.callerDevice != client && c.calleeDevice != client) {
+		s.mu.Unlock()
+		return
+	}
+	s.remove(c)
+	s.mu.Unlock()
+
+	s.sendHangup(s.peerDevice(client, c), c, models.CallReasonDisconnected)
+	if c.answered() {
+		s.record(c, models.CallCompleted)
+	} else {
+		s.record(c, models.CallCancelled)
+	}
+}
+
+// ICEServers returns the STUN and TURN servers the user's clients should gather candidates
+// from, with fresh credentials for TURN servers configured with a shared secret.
+func (s *callService) ICEServers(userID string) []models.ICEServer {
+	servers := make([]models.ICEServer, 0, len(s.iceServers))
+	for _, server := range s.iceServers {
+		iceServer := models.ICEServer{
+			URLs:       server.URLs,
+			Username:   server.Username,
+			Credential: server.Credential,
+		}
+		if server.Secret != "" {
+			ttl := time.Duration(server.CredentialTTL) * time.Second
+			if ttl <= 0 {
+				ttl = defaultCredentialTTL
+			}
+			iceServer.Username = strconv.FormatInt(time.Now().Add(ttl).Unix(), 10) + ":" + userID
+			mac := hmac.New(sha1.New, []byte(server.Secret))
+			mac.Write([]byte(iceServer.Username))
+			iceServer.Credential = base64.StdEncoding.EncodeToString(mac.Sum(nil))
+		}
+		servers = append(servers, iceServer)
+	}
+	return servers
+}
+
+// timeout ends a call nobody answered within the ring timeout
+func (s *callService) timeout(callID string) {
+	s.mu.Lock()
+	c := s.calls[callID]
+	if c == nil || c.answered() {
+		s.mu.Unlock()
+		return
+	}
+	s.remove(c)
+	s.mu.Unlock()
+
+	s.sendHangup(c.callerDevice, c, models.CallReasonTimeout)
+	s.sendHangup(nil, c, models.CallReasonTimeout)
+	s.record(c, models.CallMissed)
+}
+
+// end removes the call if it is still c and reports whether it did
+func (s *callService) end(callID string, c *call) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.calls[callID] != c {
+		return false
+	}
+	s.remove(c)
+	return true
+}
+
+// lookup finds a call the client's device takes part in. Calls of other users are reported
+// as not found. s.mu must be held.
+func (s *callService) lookup(callID string, client *models.Client) (*call, error) {
+	c := s.calls[callID]
+	if c == nil || (client.ID != c.callerID && client.ID != c.calleeID) {
+		return nil, fmt.Errorf("%w: call not found", common.ErrNotFound)
+	}
+	if client.ID == c.callerID && client != c.callerDevice {
+		return nil, fmt.Errorf("%w: call was started on another device", common.ErrInvalidInput)
+	}
+	if client.ID == c.calleeID && c.answered() && client != c.calleeDevice {
+		return nil, fmt.Errorf("%w: call was answered on another device", common.ErrInvalidInput)
+	}
+	return c, nil
+}
+
+// remove forgets a call and stops its ring timer. s.mu must be held.
+func (s *callService) remove(c *call) {
+	delete(s.calls, c.id)
+	for _, userID := range []string{c.callerID, c.calleeID} {
+		if s.busy[userID] == c {
+			delete(s.busy, userID)
+		}
+	}
+	if c.timer != nil {
+		c.timer.Stop()
+	}
+}
+
+// peerDevice returns the device on the other side of the call from client. It is nil for
+// a callee that has not answered yet, standing for all of the callee's devices.
+func (s *callService) peerDevice(client *models.Client, c *call) *models.Client {
+	if client.ID == c.callerID {
+		return c.calleeDevice
+	}
+	return c.callerDevice
+}
+
+// sendHangup tells a device that the call ended
+func (s *callService) sendHangup(device *models.Client, c *call, reason string) {
+	s.send(device, c, &models.Event{
+		EventType: models.EventCallHangup,
+		Data:      models.CallSignalData{CallID: c.id, Reason: reason},
+	})
+}
+
+// send pushes an event to a device taking part in the call. A nil device stands for every
+// device of the callee.
+func (s *callService) send(device *models.Client, c *call, event *models.Event) {
+	if device == nil {
+		s.wsManager.SendEvent(c.calleeID, event)
+		return
+	}
+	s.wsManager.SendEventToClient(device, event)
+}
+
+// record stores the ended call as a call message in the conversation's history, from the
+// caller to the callee. It runs in the background as the call may end on a timer.
+func (s *callService) record(c *call, outcome models.CallOutcome) {
+	endedAt := time.Now()
+	payload := &models.CallPayload{
+		CallID:    c.id,
+		Media:     c.media,
+		Outcome:   outcome,
+		StartedAt: c.startedAt,
+		EndedAt:   endedAt,
+	}
+	if !c.answeredAt.IsZero() {
+		payload.AnsweredAt = c.answeredAt
+		payload.Duration = int64(endedAt.Sub(c.answeredAt).Seconds())
+	}
+	message := &models.Message{
+		Type:       models.CallMessage,
+		SenderID:   c.callerID,
+		ReceiverID: c.calleeID,
+		Call:       payload,
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), recordTimeout)
+		defer cancel()
+		if err := s.wsManager.DispatchMessage(ctx, message); err != nil {
+			logging.Logger.Error("Failed to record call", zap.String("call_id", c.id), zap.Error(err))
+		}
+	}()
+}
+
+// checkSignal validates an SDP or ICE candidate relayed as is
+func checkSignal(name string, signal json.RawMessage) error {
+	if len(signal) == 0 || string(signal) == "null" {
+		return fmt.Errorf("%w: %s is required", common.ErrInvalidInput, name)
+	}
+	if len(signal) > maxSignalSize {
+		return fmt.Errorf("%w: %s exceeds %d bytes", common.ErrInvalidInput, name, maxSignalSize)
+	}
+	return nil
+}
@@ -3,24 +3,53 @@ package service
 import (
 	"context"
 	"errors"
+	"fmt"
+	"io"
 	"mime/multipart"
 	"time"
 
+	"github.com/dk5761/go-serv/configs"
+	"github.com/dk5761/go-serv/internal/domain/chat/audio"
 	"github.com/dk5761/go-serv/internal/domain/chat/models"
 	"github.com/dk5761/go-serv/internal/domain/chat/repository"
 	"github.com/dk5761/go-serv/internal/domain/chat/websocket"
+	"github.com/dk5761/go-serv/internal/domain/common"
 	"github.com/dk5761/go-serv/internal/infrastructure/storage"
 	"github.com/google/uuid"
 )
 
+const (
+	defaultMaxVoiceSize        = 16 << 20 // 16 MB
+	defaultMaxVoiceDuration    = 15 * 60  // in seconds
+	defaultVoiceWaveformLength = 64
+)
+
 type chatService struct {
 	msgRepo        repository.MessageRepository
+	attachmentRepo repository.AttachmentRepository
 	storageService storage.StorageService
 	wsManager      *websocket.WebSocketManager
+	voice          configs.VoiceConfig
 }
 
-func NewChatService(msgRepo repository.MessageRepository, storageService storage.StorageService, wsManager *websocket.WebSocketManager) ChatService {
-	return &chatService{msgRepo: msgRepo, storageService: storageService, wsManager: wsManager}
+func NewChatService(msgRepo repository.MessageRepository, attachmentRepo repository.AttachmentRepository, storageService storage.StorageService, wsManager *websocket.WebSocketManager, voice configs.VoiceConfig) ChatService {
+	if voice.MaxSize <= 0 {
+		voice.MaxSize = defaultMaxVoiceSize
+	}
+	if voice.MaxDuration <= 0 {
+		voice.MaxDuration = defaultMaxVoiceDuration
+	}
+	if voice.WaveformSamples <= 0 {
+		voice.WaveformSamples = defaultVoiceWaveformLength
+	}
+
+	return &chatService{
+		msgRepo:        msgRepo,
+		attachmentRepo: attachmentRepo,
+		storageService: storageService,
+		wsManager:      wsManager,
+		voice:          voice,
+	}
 }
 
 // UploadFile uploads a file and returns its URL.
@@ -28,8 +57,59 @@ func (s *chatService) UploadFile(ctx context.Context, file multipart.File, fileN
 	return s.storageService.UploadFile(ctx, file, fileName)
 }
 
-// SendMessage validates and saves a message to the repository.
-// If a file is attached, it uploads the file and saves the URL in the message.
+// UploadVoice rejects voice notes that are too large, too long, or not Ogg/Opus or M4A/AAC,
+// then uploads the file and records its metadata under the returned URL.
+func (s *chatService) UploadVoice(ctx context.Context, userID uuid.UUID, file multipart.File, fileName string, size int64) (*models.Attachment, error) {
+	if size <= 0 {
+		return nil, fmt.Errorf("%w: voice note is empty", common.ErrInvalidInput)
+	}
+	if size > s.voice.MaxSize {
+		return nil, fmt.Errorf("%w: voice note exceeds %d bytes", common.ErrInvalidInput, s.voice.MaxSize)
+	}
+
+	info, err := audio.Probe(file, size, s.voice.WaveformSamples)
+	if err != nil {
+		if errors.Is(err, audio.ErrUnsupported) || errors.Is(err, audio.ErrMalformed) {
+			return nil, fmt.Errorf("%w: %v", common.ErrInvalidInput, err)
+		}
+		return nil, err
+	}
+	if info.Duration <= 0 {
+		return nil, fmt.Errorf("%w: voice note has no audio", common.ErrInvalidInput)
+	}
+	if info.Duration > float64(s.voice.MaxDuration) {
+		return nil, fmt.Errorf("%w: voice note is %.0f seconds long, the limit is %d seconds", common.ErrInvalidInput, info.Duration, s.voice.MaxDuration)
+	}
+
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	fileURL, err := s.storageService.UploadFile(ctx, file, fileName)
+	if err != nil {
+		return nil, err
+	}
+
+	attachment := &models.Attachment{
+		FileURL: fileURL,
+		OwnerID: userID.String(),
+		Voice: &models.VoicePayload{
+			Duration: info.Duration,
+			MimeType: info.MimeType,
+			Codec:    info.Codec,
+			Size:     size,
+			Waveform: info.Waveform,
+		},
+		CreatedAt: time.Now(),
+	}
+	if err := s.attachmentRepo.SaveAttachment(ctx, attachment); err != nil {
+		return nil, err
+	}
+	return attachment, nil
+}
+
+// SendMessage validates a message and runs it through the WebSocket send pipeline, which
+// persists it and delivers it to the receiver. If a file is attached, it uploads the file
+// and saves the URL in the message.
 func (s *chatService) SendMessage(ctx context.Context, msg *models.Message, file multipart.File, fileName string) error {
 
 	// Set message timestamp
@@ -44,22 +124,11 @@ func (s *chatService) SendMessage(ctx context.Context, msg *models.Message, file
 		msg.FileURL = fileURL
 	}
 
-	// Save the message in the repository
-	_, err := s.msgRepo.SaveMessage(ctx, msg)
-	if err != nil {
+	if err := msg.Validate(); err != nil {
 		return err
 	}
 
-	// Send the message to the receiver over WebSocket
-	// if msg.ReceiverID != "" {
-	// 	err = s.wsManager.SendToClient(msg.ReceiverID, msg)
-	// 	if err != nil {
-	// 		fmt.Printf("Failed to send message over WebSocket: %v\n", err)
-	// 		return errors.New("failed to send message over WebSocket")
-	// 	}
-	// }
-
-	return nil
+	return s.wsManager.DispatchMessage(ctx, msg)
 }
 
 func (s *chatService) SendToClient(receiverID string, msg *models.Message) error {
@@ -75,3 +144,59 @@ func (s *chatService) GetChatHistory(ctx context.Context, userID1, userID2 uuid.
 	// Retrieve messages from the repository with pagination
 	return s.msgRepo.GetMessages(ctx, userID1, userID2, limit, offset)
 }
+
+// GetMentions retrieves the messages in which the user was mentioned, newest first.
+func (s *chatService) GetMentions(ctx context.Context, userID uuid.UUID, limit, offset int) ([]*models.Message, error) {
+	if limit <= 0 {
+		limit = 20 // Default limit
+	}
+
+	return s.msgRepo.GetMentions(ctx, userID.String(), limit, offset)
+}
+
+const maxHistoryPageSize = 200
+
+// GetConversationMessages returns a page of the conversation's history in sequence order.
+func (s *chatService) GetConversationMessages(ctx context.Context, conversationID string, userID uuid.UUID, afterSeq *int64, beforeSeq int64, limit int) (*models.MessagePage, error) {
+	if _, ok := models.ConversationPeer(conversationID, userID.String()); !ok {
+		return nil, fmt.Errorf("%w: not a participant of this conversation", common.ErrForbidden)
+	}
+	if afterSeq != nil && beforeSeq != 0 && *afterSeq >= beforeSeq {
+		return nil, fmt.Errorf("%w: after_seq must be lower than before_seq", common.ErrInvalidInput)
+	}
+	if limit <= 0 {
+		limit = 50
+	}
+	if limit > maxHistoryPageSize {
+		limit = maxHistoryPageSize
+	}
+
+	// Read the counter first: messages stored after it are beyond LastSeq, so a client
+	// that syncs up to LastSeq never skips one
+	lastSeq, err := s.msgRepo.LastSeq(ctx, conversationID)
+	if err != nil {
+		return nil, err
+	}
+
+	// One extra message tells whether there are more
+	var messages []*models.Message
+	if afterSeq != nil {
+		messages, err = s.msgRepo.GetMessagesAfterSeq(ctx, conversationID, *afterSeq, beforeSeq, limit+1)
+	} else {
+		messages, err = s.msgRepo.GetMessagesBeforeSeq(ctx, conversationID, beforeSeq, limit+1)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	page := &models.MessagePage{Messages: messages, LastSeq: lastSeq}
+	if len(messages) > limit {
+		page.HasMore = true
+		if afterSeq != nil {
+			page.Messages = messages[:limit]
+		} else {
+			page.Messages = messages[1:]
+		}
+	}
+	return page, nil
+}
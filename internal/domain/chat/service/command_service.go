@@ -0,0 +1,263 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	authModels "github.com/dk5761/go-serv/internal/domain/auth/models"
+	authRepo "github.com/dk5761/go-serv/internal/domain/auth/repository"
+	"github.com/dk5761/go-serv/internal/domain/chat/command"
+	"github.com/dk5761/go-serv/internal/domain/chat/models"
+	"github.com/dk5761/go-serv/internal/domain/chat/websocket"
+	"github.com/dk5761/go-serv/internal/domain/common"
+)
+
+const (
+	maxBotCommands          = 50
+	maxCommandTextLength    = 200
+	maxCommandReplyLength   = 4000
+	defaultMuteCommandUntil = "forever"
+)
+
+type commandService struct {
+	registry     *command.Registry
+	inboxService InboxService
+	botRepo      authRepo.BotRepository
+	userRepo     authRepo.UserRepository
+	blockRepo    authRepo.BlockRepository
+	wsManager    *websocket.WebSocketManager
+}
+
+// NewCommandService returns a CommandService and registers the built-in commands with registry.
+func NewCommandService(
+	registry *command.Registry,
+	inboxService InboxService,
+	botRepo authRepo.BotRepository,
+	userRepo authRepo.UserRepository,
+	blockRepo authRepo.BlockRepository,
+	wsManager *websocket.WebSocketManager,
+) CommandService {
+	s := &commandService{
+		registry:     registry,
+		inboxService: inboxService,
+		botRepo:      botRepo,
+		userRepo:     userRepo,
+		blockRepo:    blockRepo,
+		wsManager:    wsManager,
+	}
+
+	registry.Register("mute", "/mute [1h|8h|1d|1w|forever|off]", "Mute or unmute this conversation", s.mute)
+	registry.Register("me", "/me <action>", "Send an action, e.g. /me waves", s.me)
+	registry.Register("poll", `/poll "Question" "Option 1" "Option 2" ...`, "Start a poll", s.poll)
+	return s
+}
+
+func (s *commandService) ListCommands(ctx context.Context, userID, conversationID string) ([]*command.Command, error) {
+	commands := s.registry.List()
+	if conversationID == "" {
+		return commands, nil
+	}
+
+	peerID, ok := models.ConversationPeer(conversationID, userID)
+	if !ok {
+		return nil, fmt.Errorf("%w: not a participant of this conversation", common.ErrForbidden)
+	}
+	botID, err := uuid.Parse(peerID)
+	if err != nil {
+		return commands, nil
+	}
+	if _, err := s.botRepo.GetBot(ctx, botID); err != nil {
+		if errors.Is(err, common.ErrNotFound) {
+			return commands, nil
+		}
+		return nil, err
+	}
+
+	botCommands, err := s.botRepo.ListCommands(ctx, botID)
+	if err != nil {
+		return nil, err
+	}
+	for _, cmd := range botCommands {
+		commands = append(commands, &command.Command{
+			Name:        cmd.Name,
+			Usage:       cmd.Usage,
+			Description: cmd.Description,
+			BotID:       peerID,
+		})
+	}
+	return commands, nil
+}
+
+// SetBotCommands validates and stores a bot's commands. Names taken by built-ins are
+// rejected, since the built-ins would always win.
+func (s *commandService) SetBotCommands(ctx context.Context, botID string, commands []*authModels.BotCommand) ([]*authModels.BotCommand, error) {
+	id, err := uuid.Parse(botID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: only bots can register commands", common.ErrForbidden)
+	}
+	if len(commands) > maxBotCommands {
+		return nil, fmt.Errorf("%w: a bot can register at most %d commands", common.ErrInvalidInput, maxBotCommands)
+	}
+
+	builtins := make(map[string]bool)
+	for _, cmd := range s.registry.List() {
+		builtins[cmd.Name] = true
+	}
+	seen := make(map[string]bool, len(commands))
+	for _, cmd := range commands {
+		cmd.Name = strings.ToLower(strings.TrimPrefix(cmd.Name, "/"))
+		switch {
+		case !command.ValidName(cmd.Name):
+			return nil, fmt.Errorf("%w: invalid command name %q", common.ErrInvalidInput, cmd.Name)
+		case builtins[cmd.Name]:
+			return nil, fmt.Errorf("%w: /%s is a built-in command", common.ErrInvalidInput, cmd.Name)
+		case seen[cmd.Name]:
+			return nil, fmt.Errorf("%w: /%s is registered twice", common.ErrInvalidInput, cmd.Name)
+		case len(cmd.Usage) > maxCommandTextLength || len(cmd.Description) > maxCommandTextLength:
+			return nil, fmt.Errorf("%w: usage and description are limited to %d characters", common.ErrInvalidInput, maxCommandTextLength)
+		}
+		seen[cmd.Name] = true
+		if cmd.Usage == "" {
+			cmd.Usage = "/" + cmd.Name
+		}
+	}
+
+	if _, err := s.botRepo.GetBot(ctx, id); err != nil {
+		if errors.Is(err, common.ErrNotFound) {
+			return nil, fmt.Errorf("%w: only bots can register commands", common.ErrForbidden)
+		}
+		return nil, err
+	}
+	if err := s.botRepo.SetCommands(ctx, id, commands); err != nil {
+		return nil, err
+	}
+	return s.botRepo.ListCommands(ctx, id)
+}
+
+// Reply sends an ephemeral reply from a bot, unless the user blocked the bot
+func (s *commandService) Reply(ctx context.Context, botID, userID, commandName, content string) error {
+	if strings.TrimSpace(content) == "" || len(content) > maxCommandReplyLength {
+		return fmt.Errorf("%w: content must be 1 to %d characters", common.ErrInvalidInput, maxCommandReplyLength)
+	}
+	bot, err := uuid.Parse(botID)
+	if err != nil {
+		return fmt.Errorf("%w: only bots can reply to commands", common.ErrForbidden)
+	}
+	user, err := uuid.Parse(userID)
+	if err != nil {
+		return fmt.Errorf("%w: invalid user ID", common.ErrInvalidInput)
+	}
+
+	blocked, err := s.blockRepo.IsBlocked(ctx, user, bot)
+	if err != nil {
+		return err
+	}
+	if !blocked {
+		s.wsManager.SendEvent(userID, &models.Event{
+			EventType: models.EventCommandReply,
+			Data: models.CommandReplyData{
+				Command:        commandName,
+				ConversationID: models.ConversationID(botID, userID),
+				Content:        content,
+			},
+		})
+	}
+	return nil
+}
+
+// mute mutes the conversation for a while, forever, or unmutes it with "off"
+func (s *commandService) mute(ctx context.Context, inv *command.Invocation) (*command.Result, error) {
+	conversationID, userID := inv.Message.ConversationID, inv.Message.SenderID
+	arg := strings.ToLower(inv.Args)
+	if arg == "" {
+		arg = defaultMuteCommandUntil
+	}
+
+	switch arg {
+	case "off":
+		if _, err := s.inboxService.Unmute(ctx, conversationID, userID); err != nil {
+			return nil, err
+		}
+		return &command.Result{Reply: "Unmuted this conversation"}, nil
+	case "forever":
+		if _, err := s.inboxService.Mute(ctx, conversationID, userID, nil, true); err != nil {
+			return nil, err
+		}
+		return &command.Result{Reply: "Muted this conversation"}, nil
+	}
+
+	duration, err := parseMuteDuration(arg)
+	if err != nil {
+		return nil, err
+	}
+	until := time.Now().Add(duration)
+	if _, err := s.inboxService.Mute(ctx, conversationID, userID, &until, false); err != nil {
+		return nil, err
+	}
+	return &command.Result{Reply: "Muted this conversation until " + until.UTC().Format("Jan 2, 15:04 MST")}, nil
+}
+
+// parseMuteDuration accepts Go durations plus days and weeks, e.g. 30m, 8h, 1d or 2w
+func parseMuteDuration(arg string) (time.Duration, error) {
+	unit := map[byte]time.Duration{'d': 24 * time.Hour, 'w': 7 * 24 * time.Hour}[arg[len(arg)-1]]
+	if unit > 0 {
+		n, err := strconv.Atoi(arg[:len(arg)-1])
+		if err == nil && n > 0 {
+			return time.Duration(n) * unit, nil
+		}
+	} else if duration, err := time.ParseDuration(arg); err == nil && duration > 0 {
+		return duration, nil
+	}
+	return 0, fmt.Errorf("%w: usage: /mute [1h|8h|1d|1w|forever|off]", common.ErrInvalidInput)
+}
+
+// me sends the action in the third person, e.g. "*alice waves*"
+func (s *commandService) me(ctx context.Context, inv *command.Invocation) (*command.Result, error) {
+	if inv.Args == "" {
+		return nil, fmt.Errorf("%w: usage: /me <action>", common.ErrInvalidInput)
+	}
+
+	name := "Someone"
+	if senderID, err := uuid.Parse(inv.Message.SenderID); err == nil {
+		if sender, err := s.userRepo.GetUserByID(ctx, senderID); err == nil {
+			name = sender.Username
+		}
+	}
+
+	return s.message(inv, &models.Message{
+		Type:    models.TextMessage,
+		Content: "*" + name + " " + inv.Args + "*",
+	})
+}
+
+// poll starts a poll from quoted arguments, the question first
+func (s *commandService) poll(ctx context.Context, inv *command.Invocation) (*command.Result, error) {
+	args := command.SplitArgs(inv.Args)
+	if len(args) < 3 {
+		return nil, fmt.Errorf(`%w: usage: /poll "Question" "Option 1" "Option 2" ...`, common.ErrInvalidInput)
+	}
+
+	poll := &models.PollPayload{Question: args[0]}
+	for _, option := range args[1:] {
+		poll.Options = append(poll.Options, models.PollOption{Text: option})
+	}
+	return s.message(inv, &models.Message{Type: models.PollMessage, Poll: poll})
+}
+
+// message completes a message a command sends on behalf of the sender
+func (s *commandService) message(inv *command.Invocation, message *models.Message) (*command.Result, error) {
+	message.SenderID = inv.Message.SenderID
+	message.ReceiverID = inv.Message.ReceiverID
+	message.TempID = inv.Message.TempID
+	message.CreatedAt = time.Now()
+	if err := message.Validate(); err != nil {
+		return nil, err
+	}
+	return &command.Result{Message: message}, nil
+}
@@ -0,0 +1,117 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/dk5761/go-serv/internal/domain/chat/models"
+	"github.com/dk5761/go-serv/internal/domain/chat/repository"
+	"github.com/dk5761/go-serv/internal/domain/chat/websocket"
+	"github.com/dk5761/go-serv/internal/domain/common"
+	"github.com/dk5761/go-serv/internal/infrastructure/logging"
+)
+
+// messageTimers lists the disappearing message lifetimes participants can choose from
+var messageTimers = map[string]time.Duration{
+	"off": 0,
+	"1h":  time.Hour,
+	"1d":  24 * time.Hour,
+	"7d":  7 * 24 * time.Hour,
+}
+
+type conversationService struct {
+	conversationRepo repository.ConversationRepository
+	wsManager        *websocket.WebSocketManager
+}
+
+func NewConversationService(conversationRepo repository.ConversationRepository, wsManager *websocket.WebSocketManager) ConversationService {
+	return &conversationService{conversationRepo: conversationRepo, wsManager: wsManager}
+}
+
+// GetConversation returns the conversation settings, falling back to the defaults when none were saved.
+func (s *conversationService) GetConversation(ctx context.Context, conversationID, userID string) (*models.Conversation, error) {
+	if _, ok := models.ConversationPeer(conversationID, userID); !ok {
+		return nil, fmt.Errorf("%w: not a participant of this conversation", common.ErrForbidden)
+	}
+
+	conversation, err := s.conversationRepo.GetConversation(ctx, conversationID)
+	if errors.Is(err, common.ErrNotFound) {
+		userID1, userID2, _ := models.ConversationParticipants(conversationID)
+		return &models.Conversation{ID: conversationID, Participants: []string{userID1, userID2}}, nil
+	}
+	return conversation, err
+}
+
+// SetMessageTimer changes the lifetime of new messages in the conversation and records the
+// change in the conversation as a system message.
+func (s *conversationService) SetMessageTimer(ctx context.Context, conversationID, userID, timer string) (*models.Conversation, error) {
+	peerID, ok := models.ConversationPeer(conversationID, userID)
+	if !ok {
+		return nil, fmt.Errorf("%w: not a participant of this conversation", common.ErrForbidden)
+	}
+
+	ttl, ok := messageTimers[timer]
+	if !ok {
+		return nil, fmt.Errorf("%w: timer must be one of off, 1h, 1d or 7d", common.ErrInvalidInput)
+	}
+
+	// The change is announced with a system message, so whoever cannot send one cannot
+	// make the change either
+	suspended, err := s.wsManager.IsSuspended(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if suspended {
+		return nil, websocket.ErrSenderSuspended
+	}
+
+	previous, err := s.GetConversation(ctx, conversationID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Blocks apply as they do to messages: the change looks applied to a user the peer has
+	// blocked, but is not
+	dropped, err := s.wsManager.CheckBlocks(ctx, userID, peerID)
+	if err != nil {
+		return nil, err
+	}
+	if dropped {
+		previous.MessageTTL = int64(ttl.Seconds())
+		return previous, nil
+	}
+
+	conversation, err := s.conversationRepo.SetMessageTTL(ctx, conversationID, int64(ttl.Seconds()), userID)
+	if err != nil {
+		return nil, err
+	}
+
+	content := "Disappearing messages turned off"
+	if ttl > 0 {
+		content = "Disappearing messages set to " + timer
+	}
+	systemMessage := &models.Message{
+		Type:       models.SystemMessage,
+		SenderID:   userID,
+		ReceiverID: peerID,
+		Content:    content,
+		SystemEvent: &models.SystemEvent{
+			Action:     models.SystemActionTimerChanged,
+			ActorID:    userID,
+			TTLSeconds: conversation.MessageTTL,
+		},
+	}
+	if err := s.wsManager.DispatchMessage(ctx, systemMessage); err != nil {
+		// A change the peer is not told about must not take effect
+		if _, restoreErr := s.conversationRepo.SetMessageTTL(ctx, conversationID, previous.MessageTTL, previous.UpdatedBy); restoreErr != nil {
+			logging.Logger.Error("Failed to restore message timer", zap.String("conversation_id", conversationID), zap.Error(restoreErr))
+		}
+		return nil, err
+	}
+
+	return conversation, nil
+}
@@ -0,0 +1,52 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/dk5761/go-serv/internal/domain/chat/models"
+	"github.com/dk5761/go-serv/internal/domain/chat/repository"
+	"github.com/dk5761/go-serv/internal/domain/common"
+)
+
+// maxDeviceTokenLength is well above the length of FCM and APNs tokens
+const maxDeviceTokenLength = 4096
+
+type deviceService struct {
+	deviceRepo repository.DeviceRepository
+}
+
+func NewDeviceService(deviceRepo repository.DeviceRepository) DeviceService {
+	return &deviceService{deviceRepo: deviceRepo}
+}
+
+// RegisterDevice saves a push token for the user. Registering a token again refreshes it,
+// and a token registered by another user moves to this one.
+func (s *deviceService) RegisterDevice(ctx context.Context, userID, token string, platform models.DevicePlatform) (*models.Device, error) {
+	token = strings.TrimSpace(token)
+	if token == "" || len(token) > maxDeviceTokenLength {
+		return nil, fmt.Errorf("%w: token must be 1 to %d characters", common.ErrInvalidInput, maxDeviceTokenLength)
+	}
+	if !platform.Valid() {
+		return nil, fmt.Errorf("%w: platform must be fcm or apns", common.ErrInvalidInput)
+	}
+
+	now := time.Now()
+	return s.deviceRepo.RegisterDevice(ctx, &models.Device{
+		Token:     token,
+		UserID:    userID,
+		Platform:  platform,
+		CreatedAt: now,
+		UpdatedAt: now,
+	})
+}
+
+func (s *deviceService) UnregisterDevice(ctx context.Context, userID, token string) error {
+	return s.deviceRepo.UnregisterDevice(ctx, userID, token)
+}
+
+func (s *deviceService) ListDevices(ctx context.Context, userID string) ([]*models.Device, error) {
+	return s.deviceRepo.ListDevices(ctx, userID)
+}
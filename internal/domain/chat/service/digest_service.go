@@ -0,0 +1,27 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/dk5761/go-serv/internal/domain/chat/digest"
+	"github.com/dk5761/go-serv/internal/domain/chat/models"
+	"github.com/dk5761/go-serv/internal/domain/chat/repository"
+	"github.com/dk5761/go-serv/internal/domain/common"
+)
+
+type digestService struct {
+	preferencesRepo repository.UserPreferencesRepository
+	signer          *digest.Signer
+}
+
+func NewDigestService(preferencesRepo repository.UserPreferencesRepository, signer *digest.Signer) DigestService {
+	return &digestService{preferencesRepo: preferencesRepo, signer: signer}
+}
+
+func (s *digestService) Unsubscribe(ctx context.Context, userID, signature string) error {
+	if userID == "" || !s.signer.Verify(userID, signature) {
+		return fmt.Errorf("%w: invalid unsubscribe link", common.ErrForbidden)
+	}
+	return s.preferencesRepo.SetDigestFrequency(ctx, userID, models.DigestOff)
+}
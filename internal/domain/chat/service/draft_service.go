@@ -0,0 +1,74 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/dk5761/go-serv/internal/domain/chat/models"
+	"github.com/dk5761/go-serv/internal/domain/chat/repository"
+	"github.com/dk5761/go-serv/internal/domain/chat/websocket"
+	"github.com/dk5761/go-serv/internal/domain/common"
+)
+
+// maxDraftLength caps how much unsent text is kept per conversation
+const maxDraftLength = 10000
+
+type draftService struct {
+	draftRepo repository.DraftRepository
+	wsManager *websocket.WebSocketManager
+}
+
+func NewDraftService(draftRepo repository.DraftRepository, wsManager *websocket.WebSocketManager) DraftService {
+	return &draftService{draftRepo: draftRepo, wsManager: wsManager}
+}
+
+// SaveDraft stores the user's draft with last-writer-wins semantics and pushes it to the user's
+// other devices. It returns the winning draft and whether the given one was applied.
+func (s *draftService) SaveDraft(ctx context.Context, conversationID, userID, content, deviceID string, updatedAt time.Time) (*models.Draft, bool, error) {
+	if _, ok := models.ConversationPeer(conversationID, userID); !ok {
+		return nil, false, fmt.Errorf("%w: not a participant of this conversation", common.ErrForbidden)
+	}
+	if len(content) > maxDraftLength {
+		return nil, false, fmt.Errorf("%w: draft exceeds %d characters", common.ErrInvalidInput, maxDraftLength)
+	}
+	// The device's clock orders its edits, but a time ahead of the server's would also win
+	// over the clear that follows a send, so it is capped at now
+	if now := time.Now(); updatedAt.IsZero() || updatedAt.After(now) {
+		updatedAt = now
+	}
+
+	draft, applied, err := s.draftRepo.SaveDraft(ctx, &models.Draft{
+		UserID:         userID,
+		ConversationID: conversationID,
+		Content:        content,
+		DeviceID:       deviceID,
+		UpdatedAt:      updatedAt,
+	})
+	if err != nil {
+		return nil, false, err
+	}
+
+	if applied {
+		// Only the author's own devices are told; drafts never reach the peer
+		s.wsManager.SendEventExcept(userID, deviceID, &models.Event{
+			EventType: models.EventDraftUpdated,
+			Data:      draft,
+		})
+	}
+	return draft, applied, nil
+}
+
+// GetDraft returns the user's draft in the conversation, or an empty draft if there is none.
+func (s *draftService) GetDraft(ctx context.Context, conversationID, userID string) (*models.Draft, error) {
+	if _, ok := models.ConversationPeer(conversationID, userID); !ok {
+		return nil, fmt.Errorf("%w: not a participant of this conversation", common.ErrForbidden)
+	}
+
+	draft, err := s.draftRepo.GetDraft(ctx, userID, conversationID)
+	if errors.Is(err, common.ErrNotFound) {
+		return &models.Draft{UserID: userID, ConversationID: conversationID}, nil
+	}
+	return draft, err
+}
@@ -0,0 +1,77 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/dk5761/go-serv/internal/domain/chat/export"
+	"github.com/dk5761/go-serv/internal/domain/chat/models"
+	"github.com/dk5761/go-serv/internal/domain/chat/repository"
+	"github.com/dk5761/go-serv/internal/domain/common"
+)
+
+// defaultMaxSyncMessages is the largest conversation exported within the request
+const defaultMaxSyncMessages = 5000
+
+type exportService struct {
+	msgRepo         repository.MessageRepository
+	exportRepo      repository.ExportJobRepository
+	exporter        *export.Exporter
+	maxSyncMessages int64
+}
+
+func NewExportService(msgRepo repository.MessageRepository, exportRepo repository.ExportJobRepository, exporter *export.Exporter, maxSyncMessages int) ExportService {
+	if maxSyncMessages <= 0 {
+		maxSyncMessages = defaultMaxSyncMessages
+	}
+	return &exportService{
+		msgRepo:         msgRepo,
+		exportRepo:      exportRepo,
+		exporter:        exporter,
+		maxSyncMessages: int64(maxSyncMessages),
+	}
+}
+
+// StartExport decides whether an export is streamed or run by the export worker
+func (s *exportService) StartExport(ctx context.Context, userID string, opts models.ExportOptions, background bool) (*models.ExportJob, error) {
+	if _, ok := models.ConversationPeer(opts.ConversationID, userID); !ok {
+		return nil, fmt.Errorf("%w: not a participant of this conversation", common.ErrForbidden)
+	}
+	switch opts.Format {
+	case models.ExportJSON, models.ExportHTML, models.ExportText:
+	default:
+		return nil, fmt.Errorf("%w: format must be one of json, html or txt", common.ErrInvalidInput)
+	}
+
+	if !background {
+		count, err := s.msgRepo.CountConversationMessages(ctx, opts.ConversationID)
+		if err != nil {
+			return nil, err
+		}
+		if count <= s.maxSyncMessages {
+			return nil, nil
+		}
+	}
+
+	job := &models.ExportJob{
+		UserID:        userID,
+		ExportOptions: opts,
+	}
+	if err := s.exportRepo.CreateExportJob(ctx, job); err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+// StreamExport writes the export to w as it is read from the database
+func (s *exportService) StreamExport(ctx context.Context, w io.Writer, opts models.ExportOptions) error {
+	return s.exporter.Export(ctx, w, opts)
+}
+
+// GetExportJob returns the state of one of the user's background exports
+func (s *exportService) GetExportJob(ctx context.Context, id primitive.ObjectID, userID string) (*models.ExportJob, error) {
+	return s.exportRepo.GetExportJob(ctx, id, userID)
+}
@@ -0,0 +1,177 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/dk5761/go-serv/internal/domain/chat/models"
+	"github.com/dk5761/go-serv/internal/domain/chat/repository"
+	"github.com/dk5761/go-serv/internal/domain/chat/websocket"
+	"github.com/dk5761/go-serv/internal/domain/common"
+)
+
+const (
+	maxFolders          = 20
+	maxFolderNameLength = 50
+)
+
+type inboxService struct {
+	settingsRepo    repository.ConversationSettingsRepository
+	preferencesRepo repository.UserPreferencesRepository
+	wsManager       *websocket.WebSocketManager
+}
+
+func NewInboxService(settingsRepo repository.ConversationSettingsRepository, preferencesRepo repository.UserPreferencesRepository, wsManager *websocket.WebSocketManager) InboxService {
+	return &inboxService{settingsRepo: settingsRepo, preferencesRepo: preferencesRepo, wsManager: wsManager}
+}
+
+// ListConversations returns the user's conversations in a folder or archive state, with
+// unread badges hidden for muted conversations.
+func (s *inboxService) ListConversations(ctx context.Context, userID string, filter models.InboxFilter, limit, offset int) ([]*models.ConversationSettings, error) {
+	if limit <= 0 {
+		limit = 20 // Default limit
+	}
+
+	conversations, err := s.settingsRepo.ListSettings(ctx, userID, filter, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	for _, settings := range conversations {
+		setBadge(settings, now)
+	}
+	return conversations, nil
+}
+
+// GetSettings returns the user's settings for a conversation, falling back to the defaults when none were saved.
+func (s *inboxService) GetSettings(ctx context.Context, conversationID, userID string) (*models.ConversationSettings, error) {
+	if _, ok := models.ConversationPeer(conversationID, userID); !ok {
+		return nil, fmt.Errorf("%w: not a participant of this conversation", common.ErrForbidden)
+	}
+
+	settings, err := s.settingsRepo.GetSettings(ctx, userID, conversationID)
+	if errors.Is(err, common.ErrNotFound) {
+		return &models.ConversationSettings{UserID: userID, ConversationID: conversationID, Folders: []string{}}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	setBadge(settings, time.Now())
+	return settings, nil
+}
+
+// Mute silences the conversation until the given time, or forever
+func (s *inboxService) Mute(ctx context.Context, conversationID, userID string, until *time.Time, forever bool) (*models.ConversationSettings, error) {
+	switch {
+	case forever:
+		until = nil
+	case until == nil:
+		return nil, fmt.Errorf("%w: either until or forever is required", common.ErrInvalidInput)
+	case !until.After(time.Now()):
+		return nil, fmt.Errorf("%w: until must be in the future", common.ErrInvalidInput)
+	}
+
+	return s.update(ctx, conversationID, userID, func() (*models.ConversationSettings, error) {
+		return s.settingsRepo.SetMute(ctx, userID, conversationID, until, forever)
+	})
+}
+
+// Unmute restores notifications for the conversation
+func (s *inboxService) Unmute(ctx context.Context, conversationID, userID string) (*models.ConversationSettings, error) {
+	return s.update(ctx, conversationID, userID, func() (*models.ConversationSettings, error) {
+		return s.settingsRepo.SetMute(ctx, userID, conversationID, nil, false)
+	})
+}
+
+// SetArchived moves the conversation into or out of the user's archive
+func (s *inboxService) SetArchived(ctx context.Context, conversationID, userID string, archived bool) (*models.ConversationSettings, error) {
+	return s.update(ctx, conversationID, userID, func() (*models.ConversationSettings, error) {
+		return s.settingsRepo.SetArchived(ctx, userID, conversationID, archived)
+	})
+}
+
+// SetFolders files the conversation under the given folders, replacing the previous ones
+func (s *inboxService) SetFolders(ctx context.Context, conversationID, userID string, folders []string) (*models.ConversationSettings, error) {
+	normalized := make([]string, 0, len(folders))
+	seen := make(map[string]bool, len(folders))
+	for _, folder := range folders {
+		folder = strings.TrimSpace(folder)
+		if folder == "" || seen[folder] {
+			continue
+		}
+		if len([]rune(folder)) > maxFolderNameLength {
+			return nil, fmt.Errorf("%w: folder names are limited to %d characters", common.ErrInvalidInput, maxFolderNameLength)
+		}
+		seen[folder] = true
+		normalized = append(normalized, folder)
+	}
+	if len(normalized) > maxFolders {
+		return nil, fmt.Errorf("%w: a conversation can be in at most %d folders", common.ErrInvalidInput, maxFolders)
+	}
+
+	return s.update(ctx, conversationID, userID, func() (*models.ConversationSettings, error) {
+		return s.settingsRepo.SetFolders(ctx, userID, conversationID, normalized)
+	})
+}
+
+// MarkRead clears the conversation's unread count
+func (s *inboxService) MarkRead(ctx context.Context, conversationID, userID string) (*models.ConversationSettings, error) {
+	return s.update(ctx, conversationID, userID, func() (*models.ConversationSettings, error) {
+		return s.settingsRepo.MarkRead(ctx, userID, conversationID)
+	})
+}
+
+// GetPreferences returns the user's chat preferences, falling back to the defaults when none were saved.
+func (s *inboxService) GetPreferences(ctx context.Context, userID string) (*models.UserPreferences, error) {
+	preferences, err := s.preferencesRepo.GetPreferences(ctx, userID)
+	if errors.Is(err, common.ErrNotFound) {
+		preferences, err = &models.UserPreferences{UserID: userID}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	preferences.DigestFrequency = preferences.Digest()
+	return preferences, nil
+}
+
+// SavePreferences stores the user's chat preferences
+func (s *inboxService) SavePreferences(ctx context.Context, preferences *models.UserPreferences) error {
+	preferences.DigestFrequency = preferences.Digest()
+	if !preferences.DigestFrequency.Valid() {
+		return fmt.Errorf("%w: digest_frequency must be off, hourly or daily", common.ErrInvalidInput)
+	}
+	return s.preferencesRepo.SavePreferences(ctx, preferences)
+}
+
+// update checks that the user takes part in the conversation, applies a settings change and
+// syncs the result to the user's devices.
+func (s *inboxService) update(ctx context.Context, conversationID, userID string, apply func() (*models.ConversationSettings, error)) (*models.ConversationSettings, error) {
+	if _, ok := models.ConversationPeer(conversationID, userID); !ok {
+		return nil, fmt.Errorf("%w: not a participant of this conversation", common.ErrForbidden)
+	}
+
+	settings, err := apply()
+	if err != nil {
+		return nil, err
+	}
+	setBadge(settings, time.Now())
+
+	s.wsManager.SendEvent(userID, &models.Event{
+		EventType: models.EventConversationSettingsUpdated,
+		Data:      settings,
+	})
+	return settings, nil
+}
+
+// setBadge fills in the unread badge, which muted conversations do not show
+func setBadge(settings *models.ConversationSettings, now time.Time) {
+	settings.Badge = settings.UnreadCount
+	if settings.IsMuted(now) {
+		settings.Badge = 0
+	}
+}
@@ -0,0 +1,29 @@
+package service
+
+import (
+	"context"
+
+	"github.com/dk5761/go-serv/internal/domain/chat/models"
+	"github.com/dk5761/go-serv/internal/domain/chat/repository"
+)
+
+const maxModerationPageSize = 100
+
+type moderationService struct {
+	moderationRepo repository.ModerationRepository
+}
+
+func NewModerationService(moderationRepo repository.ModerationRepository) ModerationService {
+	return &moderationService{moderationRepo: moderationRepo}
+}
+
+// ListRejections returns rejected messages for review, newest first
+func (s *moderationService) ListRejections(ctx context.Context, stage string, limit, offset int) ([]*models.ModerationRecord, error) {
+	if limit <= 0 || limit > maxModerationPageSize {
+		limit = maxModerationPageSize
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	return s.moderationRepo.ListRejections(ctx, stage, limit, offset)
+}
@@ -0,0 +1,204 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	authRepo "github.com/dk5761/go-serv/internal/domain/auth/repository"
+	"github.com/dk5761/go-serv/internal/domain/chat/models"
+	"github.com/dk5761/go-serv/internal/domain/chat/repository"
+	"github.com/dk5761/go-serv/internal/domain/chat/websocket"
+	"github.com/dk5761/go-serv/internal/domain/common"
+	"github.com/dk5761/go-serv/internal/infrastructure/logging"
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+var errPollClosed = fmt.Errorf("%w: poll is closed", common.ErrInvalidInput)
+
+type pollService struct {
+	msgRepo   repository.MessageRepository
+	pollRepo  repository.PollRepository
+	blockRepo authRepo.BlockRepository
+	wsManager *websocket.WebSocketManager
+}
+
+func NewPollService(msgRepo repository.MessageRepository, pollRepo repository.PollRepository, blockRepo authRepo.BlockRepository, wsManager *websocket.WebSocketManager) PollService {
+	return &pollService{msgRepo: msgRepo, pollRepo: pollRepo, blockRepo: blockRepo, wsManager: wsManager}
+}
+
+// GetPoll returns a poll message with its current tally.
+func (s *pollService) GetPoll(ctx context.Context, pollID primitive.ObjectID, userID string) (*models.Message, error) {
+	message, err := s.loadPoll(ctx, pollID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if message.Poll.Results == nil {
+		message.Poll.Results = &models.PollResults{Counts: map[string]int{}}
+	}
+	return message, nil
+}
+
+// Vote replaces the user's vote in a poll and broadcasts the new tally to both participants.
+// An empty optionIDs retracts the vote.
+//
+// Blocks between the participants apply as they do to messages: a user who blocked the
+// other cannot vote, and the vote of a user the other blocked is dropped, with the current
+// tally returned as if it counted.
+func (s *pollService) Vote(ctx context.Context, pollID primitive.ObjectID, userID string, optionIDs []string) (*models.PollResults, error) {
+	message, err := s.loadPoll(ctx, pollID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	poll := message.Poll
+	if !poll.IsOpen(time.Now()) {
+		return nil, errPollClosed
+	}
+	if !poll.Multiple && len(optionIDs) > 1 {
+		return nil, fmt.Errorf("%w: poll allows a single choice", common.ErrInvalidInput)
+	}
+	seen := make(map[string]bool, len(optionIDs))
+	for _, optionID := range optionIDs {
+		if !poll.HasOption(optionID) {
+			return nil, fmt.Errorf("%w: unknown poll option %q", common.ErrInvalidInput, optionID)
+		}
+		if seen[optionID] {
+			return nil, fmt.Errorf("%w: duplicate poll option %q", common.ErrInvalidInput, optionID)
+		}
+		seen[optionID] = true
+	}
+
+	peerID := message.ReceiverID
+	if peerID == userID {
+		peerID = message.SenderID
+	}
+	if peerID != userID {
+		dropped, err := s.checkBlocked(ctx, userID, peerID)
+		if err != nil {
+			return nil, err
+		}
+		if dropped {
+			if poll.Results == nil {
+				return &models.PollResults{Counts: map[string]int{}}, nil
+			}
+			return poll.Results, nil
+		}
+	}
+
+	// The poll may have closed since it was loaded; the vote only applies while it is open
+	results, open, err := s.pollRepo.ReplaceVote(ctx, pollID, poll, userID, optionIDs, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if !open {
+		return nil, errPollClosed
+	}
+
+	s.broadcast(message, models.EventPollUpdated, false, results)
+	return results, nil
+}
+
+// checkBlocked applies blocking between a voter and the other participant of the poll's
+// conversation. It fails if the voter blocked them and reports true if they blocked the voter.
+func (s *pollService) checkBlocked(ctx context.Context, voterID, peerID string) (bool, error) {
+	voter, err := uuid.Parse(voterID)
+	if err != nil {
+		return false, nil
+	}
+	peer, err := uuid.Parse(peerID)
+	if err != nil {
+		return false, nil
+	}
+
+	blocked, err := s.blockRepo.IsBlocked(ctx, voter, peer)
+	if err != nil {
+		return false, err
+	}
+	if blocked {
+		return false, fmt.Errorf("%w: you have blocked this user, unblock them to vote", common.ErrForbidden)
+	}
+	return s.blockRepo.IsBlocked(ctx, peer, voter)
+}
+
+// ClosePoll freezes the results of a poll. Only the poll's author can close it.
+func (s *pollService) ClosePoll(ctx context.Context, pollID primitive.ObjectID, userID string) (*models.PollResults, error) {
+	message, err := s.loadPoll(ctx, pollID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if message.SenderID != userID {
+		return nil, fmt.Errorf("%w: only the author can close a poll", common.ErrForbidden)
+	}
+	if message.Poll.Closed {
+		return message.Poll.Results, nil
+	}
+
+	return s.close(ctx, message)
+}
+
+// CloseDuePolls closes up to limit polls whose close time has passed, returning how many it found.
+func (s *pollService) CloseDuePolls(ctx context.Context, limit int) (int, error) {
+	polls, err := s.pollRepo.GetDuePolls(ctx, time.Now(), limit)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, message := range polls {
+		if _, err := s.close(ctx, message); err != nil {
+			logging.Logger.Error("Failed to close poll", zap.String("poll_id", message.ID.Hex()), zap.Error(err))
+		}
+	}
+	return len(polls), nil
+}
+
+func (s *pollService) close(ctx context.Context, message *models.Message) (*models.PollResults, error) {
+	results, closed, err := s.pollRepo.ClosePoll(ctx, message.ID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if !closed {
+		// Closed concurrently, which already told the participants
+		return message.Poll.Results, nil
+	}
+	if results == nil {
+		results = &models.PollResults{Counts: map[string]int{}}
+	}
+	s.broadcast(message, models.EventPollClosed, true, results)
+	return results, nil
+}
+
+// loadPoll fetches a poll message the user takes part in
+func (s *pollService) loadPoll(ctx context.Context, pollID primitive.ObjectID, userID string) (*models.Message, error) {
+	message, err := s.msgRepo.GetMessage(ctx, pollID)
+	if err != nil {
+		return nil, err
+	}
+	if message.Type != models.PollMessage || message.Poll == nil {
+		return nil, fmt.Errorf("%w: poll not found", common.ErrNotFound)
+	}
+	if message.SenderID != userID && message.ReceiverID != userID {
+		return nil, fmt.Errorf("%w: poll not found", common.ErrNotFound)
+	}
+	if !message.ExpiresAt.IsZero() && !message.ExpiresAt.After(time.Now()) {
+		return nil, fmt.Errorf("%w: poll not found", common.ErrNotFound)
+	}
+	return message, nil
+}
+
+func (s *pollService) broadcast(message *models.Message, eventType string, closed bool, results *models.PollResults) {
+	event := &models.Event{
+		EventType: eventType,
+		Data: models.PollUpdatedData{
+			PollID:         message.ID.Hex(),
+			ConversationID: message.ConversationID,
+			Closed:         closed,
+			Results:        results,
+		},
+	}
+	s.wsManager.SendEvent(message.SenderID, event)
+	s.wsManager.SendEvent(message.ReceiverID, event)
+}
@@ -0,0 +1,361 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+
+	authRepo "github.com/dk5761/go-serv/internal/domain/auth/repository"
+	"github.com/dk5761/go-serv/internal/domain/chat/models"
+	"github.com/dk5761/go-serv/internal/domain/chat/repository"
+	"github.com/dk5761/go-serv/internal/domain/chat/websocket"
+	"github.com/dk5761/go-serv/internal/domain/common"
+	"github.com/dk5761/go-serv/internal/infrastructure/logging"
+	"github.com/dk5761/go-serv/internal/infrastructure/storage"
+)
+
+const (
+	// Messages captured on each side of a reported message
+	reportContextBefore = 10
+	reportContextAfter  = 5
+	// Messages captured from the conversation with a reported user
+	reportUserContext = 20
+
+	maxReportDetailsLength = 2000
+	maxReportNoteLength    = 2000
+	maxSuspendDays         = 3650
+	maxReportPageSize      = 100
+
+	// A claim older than this no longer stops other moderators from taking the report
+	reportClaimLease = time.Hour
+)
+
+type reportService struct {
+	reportRepo     repository.ReportRepository
+	msgRepo        repository.MessageRepository
+	auditRepo      repository.AuditRepository
+	userRepo       authRepo.UserRepository
+	storageService storage.StorageService
+	wsManager      *websocket.WebSocketManager
+}
+
+func NewReportService(
+	reportRepo repository.ReportRepository,
+	msgRepo repository.MessageRepository,
+	auditRepo repository.AuditRepository,
+	userRepo authRepo.UserRepository,
+	storageService storage.StorageService,
+	wsManager *websocket.WebSocketManager,
+) ReportService {
+	return &reportService{
+		reportRepo:     reportRepo,
+		msgRepo:        msgRepo,
+		auditRepo:      auditRepo,
+		userRepo:       userRepo,
+		storageService: storageService,
+		wsManager:      wsManager,
+	}
+}
+
+// ReportMessage snapshots the message with the messages around it and queues a report about its sender
+func (s *reportService) ReportMessage(ctx context.Context, reporterID string, messageID primitive.ObjectID, reason models.ReportReason, details string) (*models.Report, error) {
+	if err := validateReport(reason, details); err != nil {
+		return nil, err
+	}
+
+	message, err := s.msgRepo.GetMessage(ctx, messageID)
+	if err != nil {
+		return nil, err
+	}
+	if message.SenderID != reporterID && message.ReceiverID != reporterID {
+		// Do not reveal that the message exists
+		return nil, fmt.Errorf("%w: message not found", common.ErrNotFound)
+	}
+	if message.SenderID == reporterID || message.Type == models.SystemMessage {
+		return nil, fmt.Errorf("%w: only messages from other users can be reported", common.ErrInvalidInput)
+	}
+
+	surrounding, err := s.msgRepo.GetMessagesAround(ctx, message.ConversationID, message.CreatedAt, message.ID, reportContextBefore, reportContextAfter)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &models.Report{
+		Kind:           models.ReportMessage,
+		ReporterID:     reporterID,
+		ReportedUserID: message.SenderID,
+		MessageID:      message.ID,
+		ConversationID: message.ConversationID,
+		Reason:         reason,
+		Details:        details,
+		Snapshot:       &models.ReportSnapshot{Message: message, Context: surrounding, TakenAt: time.Now()},
+		DedupeKey:      reporterID + ":message:" + message.ID.Hex(),
+	}
+	return s.create(ctx, report)
+}
+
+// ReportUser snapshots the latest messages between the reporter and the user and queues a report
+func (s *reportService) ReportUser(ctx context.Context, reporterID, reportedUserID string, reason models.ReportReason, details string) (*models.Report, error) {
+	if err := validateReport(reason, details); err != nil {
+		return nil, err
+	}
+	if reportedUserID == reporterID {
+		return nil, fmt.Errorf("%w: you cannot report yourself", common.ErrInvalidInput)
+	}
+
+	id, err := uuid.Parse(reportedUserID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid user ID", common.ErrInvalidInput)
+	}
+	if _, err := s.userRepo.GetUserByID(ctx, id); err != nil {
+		return nil, err
+	}
+
+	conversationID := models.ConversationID(reporterID, reportedUserID)
+	recent, err := s.msgRepo.GetMessagesAround(ctx, conversationID, time.Now(), primitive.NilObjectID, reportUserContext, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &models.Report{
+		Kind:           models.ReportUser,
+		ReporterID:     reporterID,
+		ReportedUserID: reportedUserID,
+		ConversationID: conversationID,
+		Reason:         reason,
+		Details:        details,
+		Snapshot:       &models.ReportSnapshot{Context: recent, TakenAt: time.Now()},
+		DedupeKey:      reporterID + ":user:" + reportedUserID,
+	}
+	return s.create(ctx, report)
+}
+
+func (s *reportService) create(ctx context.Context, report *models.Report) (*models.Report, error) {
+	if err := s.reportRepo.CreateReport(ctx, report); err != nil {
+		if errors.Is(err, common.ErrConflict) {
+			return nil, fmt.Errorf("%w: you already reported this and it is awaiting review", common.ErrConflict)
+		}
+		return nil, err
+	}
+	return report, nil
+}
+
+func validateReport(reason models.ReportReason, details string) error {
+	if !reason.Valid() {
+		return fmt.Errorf("%w: unknown reason %q", common.ErrInvalidInput, reason)
+	}
+	if len(details) > maxReportDetailsLength {
+		return fmt.Errorf("%w: details exceed %d characters", common.ErrInvalidInput, maxReportDetailsLength)
+	}
+	return nil
+}
+
+// ListOwnReports returns the reports the user filed, newest first, with their outcome
+func (s *reportService) ListOwnReports(ctx context.Context, reporterID string, limit, offset int) ([]*models.Report, error) {
+	limit, offset = reportPage(limit, offset)
+	reports, err := s.reportRepo.ListReports(ctx, models.ReportFilter{ReporterID: reporterID}, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	for i, report := range reports {
+		reports[i] = reporterView(report)
+	}
+	return reports, nil
+}
+
+// reporterView is a report as its reporter may see it: the outcome, without the snapshot
+// or which moderator handled it
+func reporterView(report *models.Report) *models.Report {
+	view := *report
+	view.Snapshot = nil
+	view.ClaimedBy = ""
+	if report.Resolution != nil {
+		resolution := *report.Resolution
+		resolution.ResolvedBy = ""
+		view.Resolution = &resolution
+	}
+	return &view
+}
+
+// ListReports returns the moderator queue, oldest first
+func (s *reportService) ListReports(ctx context.Context, status models.ReportStatus, limit, offset int) ([]*models.Report, error) {
+	switch status {
+	case "", models.ReportOpen, models.ReportClaimed, models.ReportResolved:
+	default:
+		return nil, fmt.Errorf("%w: unknown status %q", common.ErrInvalidInput, status)
+	}
+
+	limit, offset = reportPage(limit, offset)
+	return s.reportRepo.ListReports(ctx, models.ReportFilter{Status: status}, limit, offset)
+}
+
+func reportPage(limit, offset int) (int, int) {
+	if limit <= 0 || limit > maxReportPageSize {
+		limit = maxReportPageSize
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	return limit, offset
+}
+
+func (s *reportService) GetReport(ctx context.Context, reportID primitive.ObjectID) (*models.Report, error) {
+	return s.reportRepo.GetReport(ctx, reportID)
+}
+
+// ClaimReport assigns the report to the moderator so that others leave it alone
+func (s *reportService) ClaimReport(ctx context.Context, reportID primitive.ObjectID, moderatorID string) (*models.Report, error) {
+	report, err := s.reportRepo.ClaimReport(ctx, reportID, moderatorID, time.Now(), reportClaimLease)
+	if err != nil {
+		if errors.Is(err, common.ErrConflict) {
+			return nil, fmt.Errorf("%w: report is resolved or claimed by another moderator", common.ErrConflict)
+		}
+		return nil, err
+	}
+
+	s.audit(ctx, models.AuditReportClaimed, moderatorID, report, nil)
+	return report, nil
+}
+
+// ResolveReport carries out the moderator's action, records the resolution and tells the
+// reporter. The action runs before the report is marked resolved, so a failed action
+// leaves the report claimed and the moderator can retry.
+func (s *reportService) ResolveReport(ctx context.Context, reportID primitive.ObjectID, moderatorID string, action models.ModeratorAction, note string, suspendDays int) (*models.Report, error) {
+	note = strings.TrimSpace(note)
+	if len(note) > maxReportNoteLength {
+		return nil, fmt.Errorf("%w: note exceeds %d characters", common.ErrInvalidInput, maxReportNoteLength)
+	}
+
+	report, err := s.reportRepo.GetReport(ctx, reportID)
+	if err != nil {
+		return nil, err
+	}
+	if report.Status != models.ReportClaimed || report.ClaimedBy != moderatorID {
+		return nil, fmt.Errorf("%w: claim the report before resolving it", common.ErrConflict)
+	}
+
+	resolution := &models.ReportResolution{
+		Action:     action,
+		Note:       note,
+		ResolvedBy: moderatorID,
+		ResolvedAt: time.Now(),
+	}
+
+	switch action {
+	case models.ActionDismiss:
+	case models.ActionWarn:
+		s.wsManager.SendEvent(report.ReportedUserID, &models.Event{
+			EventType: models.EventModerationWarning,
+			Data: models.ModerationWarningData{
+				ReportID: report.ID.Hex(),
+				Reason:   report.Reason,
+				Note:     note,
+			},
+		})
+	case models.ActionDeleteMessage:
+		if report.Kind != models.ReportMessage {
+			return nil, fmt.Errorf("%w: only message reports can delete a message", common.ErrInvalidInput)
+		}
+		if err := s.deleteMessage(ctx, report); err != nil {
+			return nil, err
+		}
+	case models.ActionSuspendUser:
+		if suspendDays <= 0 || suspendDays > maxSuspendDays {
+			return nil, fmt.Errorf("%w: suspend_days must be between 1 and %d", common.ErrInvalidInput, maxSuspendDays)
+		}
+		until := resolution.ResolvedAt.AddDate(0, 0, suspendDays)
+		if err := s.suspendUser(ctx, report.ReportedUserID, until); err != nil {
+			return nil, err
+		}
+		resolution.SuspendedUntil = &until
+	default:
+		return nil, fmt.Errorf("%w: unknown action %q", common.ErrInvalidInput, action)
+	}
+
+	resolved, err := s.reportRepo.ResolveReport(ctx, reportID, moderatorID, resolution)
+	if err != nil {
+		if errors.Is(err, common.ErrConflict) {
+			return nil, fmt.Errorf("%w: report is no longer claimed by you", common.ErrConflict)
+		}
+		return nil, err
+	}
+
+	s.audit(ctx, models.AuditReportResolved, moderatorID, resolved, map[string]interface{}{
+		"action":       action,
+		"note":         note,
+		"suspend_days": suspendDays,
+	})
+
+	s.wsManager.SendEvent(resolved.ReporterID, &models.Event{EventType: models.EventReportResolved, Data: reporterView(resolved)})
+
+	return resolved, nil
+}
+
+// deleteMessage removes the reported message and its attachment and tells both participants
+func (s *reportService) deleteMessage(ctx context.Context, report *models.Report) error {
+	message := report.Snapshot.Message
+	if _, err := s.msgRepo.DeleteMessage(ctx, message.ID); err != nil {
+		return err
+	}
+
+	if message.FileURL != "" {
+		if err := s.storageService.DeleteFile(ctx, message.FileURL); err != nil {
+			logging.Logger.Error("Failed to delete attachment of removed message",
+				zap.String("message_id", message.ID.Hex()),
+				zap.String("file_url", message.FileURL),
+				zap.Error(err),
+			)
+		}
+	}
+
+	event := &models.Event{
+		EventType: models.EventMessageRemoved,
+		Data: models.MessageExpiredData{
+			MessageID:      message.ID.Hex(),
+			ConversationID: message.ConversationID,
+		},
+	}
+	s.wsManager.SendEvent(message.SenderID, event)
+	s.wsManager.SendEvent(message.ReceiverID, event)
+	return nil
+}
+
+// suspendUser suspends the user and closes their open connections
+func (s *reportService) suspendUser(ctx context.Context, userID string, until time.Time) error {
+	id, err := uuid.Parse(userID)
+	if err != nil {
+		return fmt.Errorf("%w: reported user is not a user", common.ErrInvalidInput)
+	}
+	if err := s.userRepo.SuspendUser(ctx, id, until); err != nil {
+		return err
+	}
+	s.wsManager.DisconnectUser(userID)
+	return nil
+}
+
+func (s *reportService) audit(ctx context.Context, action, moderatorID string, report *models.Report, details map[string]interface{}) {
+	if details == nil {
+		details = map[string]interface{}{}
+	}
+	details["reported_user_id"] = report.ReportedUserID
+	details["kind"] = report.Kind
+	if !report.MessageID.IsZero() {
+		details["message_id"] = report.MessageID.Hex()
+	}
+
+	entry := &models.AuditEntry{
+		Action:   action,
+		ActorID:  moderatorID,
+		TargetID: report.ID.Hex(),
+		Details:  details,
+	}
+	if err := s.auditRepo.RecordAudit(ctx, entry); err != nil {
+		logging.Logger.Error("Failed to record report audit entry", zap.String("report_id", report.ID.Hex()), zap.Error(err))
+	}
+}
@@ -0,0 +1,328 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+
+	"github.com/dk5761/go-serv/configs"
+	"github.com/dk5761/go-serv/internal/domain/chat/models"
+	"github.com/dk5761/go-serv/internal/domain/chat/repository"
+	"github.com/dk5761/go-serv/internal/domain/chat/websocket"
+	"github.com/dk5761/go-serv/internal/domain/common"
+	"github.com/dk5761/go-serv/internal/infrastructure/logging"
+	"github.com/dk5761/go-serv/internal/infrastructure/storage"
+)
+
+const (
+	defaultPurgeBatchSize = 500
+
+	maxRetentionDays     = 36500
+	maxRetentionMessages = 1000000
+)
+
+type retentionService struct {
+	msgRepo          repository.MessageRepository
+	conversationRepo repository.ConversationRepository
+	auditRepo        repository.AuditRepository
+	attachmentRepo   repository.AttachmentRepository
+	storageService   storage.StorageService
+	wsManager        *websocket.WebSocketManager
+	global           models.RetentionPolicy
+	batchSize        int
+}
+
+func NewRetentionService(
+	msgRepo repository.MessageRepository,
+	conversationRepo repository.ConversationRepository,
+	auditRepo repository.AuditRepository,
+	attachmentRepo repository.AttachmentRepository,
+	storageService storage.StorageService,
+	wsManager *websocket.WebSocketManager,
+	cfg configs.RetentionConfig,
+) RetentionService {
+	s := &retentionService{
+		msgRepo:          msgRepo,
+		conversationRepo: conversationRepo,
+		auditRepo:        auditRepo,
+		attachmentRepo:   attachmentRepo,
+		storageService:   storageService,
+		wsManager:        wsManager,
+		global:           models.RetentionPolicy{MaxAgeDays: cfg.MaxAgeDays, KeepLast: cfg.KeepLast},
+		batchSize:        cfg.BatchSize,
+	}
+	if s.batchSize <= 0 {
+		s.batchSize = defaultPurgeBatchSize
+	}
+	return s
+}
+
+// purgeCandidate is a conversation holding messages outside its retention policy
+type purgeCandidate struct {
+	conversationID string
+	policy         models.RetentionPolicy
+}
+
+// Purge applies the global policy combined with each conversation's own policy
+func (s *retentionService) Purge(ctx context.Context, dryRun bool) (*models.PurgeReport, error) {
+	report := &models.PurgeReport{
+		DryRun:        dryRun,
+		StartedAt:     time.Now(),
+		Conversations: []*models.ConversationPurge{},
+	}
+
+	policies, err := s.conversationRepo.GetRetentionPolicies(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if s.global.IsZero() && len(policies) == 0 {
+		report.FinishedAt = time.Now()
+		return report, nil
+	}
+
+	// Collect the candidates first so that no cursor stays open while messages are deleted
+	var candidates []purgeCandidate
+	err = s.msgRepo.StreamConversationStats(ctx, func(stats *models.ConversationStats) error {
+		policy := s.global.Combine(policies[stats.ConversationID])
+		if exceedsPolicy(stats, policy, report.StartedAt) {
+			candidates = append(candidates, purgeCandidate{conversationID: stats.ConversationID, policy: policy})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, candidate := range candidates {
+		purge, err := s.purgeConversation(ctx, candidate, report.StartedAt, dryRun)
+		if err != nil {
+			return nil, err
+		}
+		if purge.Messages == 0 {
+			continue
+		}
+		report.Conversations = append(report.Conversations, purge)
+		report.Messages += purge.Messages
+		report.Attachments += purge.Attachments
+	}
+
+	report.FinishedAt = time.Now()
+	return report, nil
+}
+
+// purgeConversation deletes, or counts in dry-run mode, the messages of one conversation
+// that sort at or before the cutoff of its policy
+func (s *retentionService) purgeConversation(ctx context.Context, candidate purgeCandidate, now time.Time, dryRun bool) (*models.ConversationPurge, error) {
+	purge := &models.ConversationPurge{ConversationID: candidate.conversationID, Policy: candidate.policy}
+
+	cutoffAt, cutoffID, err := s.cutoff(ctx, candidate, now)
+	if err != nil || cutoffAt.IsZero() {
+		return purge, err
+	}
+
+	if dryRun {
+		purge.Messages, purge.Attachments, err = s.msgRepo.CountMessagesUpTo(ctx, candidate.conversationID, cutoffAt, cutoffID)
+		return purge, err
+	}
+
+	for ctx.Err() == nil {
+		messages, err := s.msgRepo.FindMessagesUpTo(ctx, candidate.conversationID, cutoffAt, cutoffID, s.batchSize)
+		if err != nil {
+			return nil, err
+		}
+		if len(messages) == 0 {
+			break
+		}
+
+		ids := make([]primitive.ObjectID, len(messages))
+		for i, message := range messages {
+			ids[i] = message.ID
+		}
+		deleted, err := s.msgRepo.DeleteMessages(ctx, ids)
+		if err != nil {
+			return nil, err
+		}
+		purge.Messages += deleted
+
+		// Attachments go once their messages are gone, so no message points at a missing file
+		for _, message := range messages {
+			if message.FileURL == "" {
+				continue
+			}
+			if err := s.storageService.DeleteFile(ctx, message.FileURL); err != nil {
+				logging.Logger.Error("Failed to delete attachment of purged message",
+					zap.String("message_id", message.ID.Hex()),
+					zap.String("file_url", message.FileURL),
+					zap.Error(err),
+				)
+				continue
+			}
+			if err := s.attachmentRepo.DeleteAttachment(ctx, message.FileURL); err != nil {
+				logging.Logger.Error("Failed to delete attachment record of purged message",
+					zap.String("message_id", message.ID.Hex()),
+					zap.String("file_url", message.FileURL),
+					zap.Error(err),
+				)
+			}
+			purge.Attachments++
+		}
+
+		if len(messages) < s.batchSize {
+			break
+		}
+	}
+
+	if purge.Messages > 0 {
+		s.audit(ctx, purge)
+	}
+	return purge, ctx.Err()
+}
+
+// cutoff returns the newest position in the conversation that the policy no longer keeps,
+// or a zero time when every message is kept. A nil ID keeps messages created exactly at the cutoff time.
+func (s *retentionService) cutoff(ctx context.Context, candidate purgeCandidate, now time.Time) (time.Time, primitive.ObjectID, error) {
+	var cutoffAt time.Time
+	var cutoffID primitive.ObjectID
+
+	if candidate.policy.MaxAgeDays > 0 {
+		cutoffAt = ageCutoff(candidate.policy, now)
+	}
+
+	if candidate.policy.KeepLast > 0 {
+		// The first message past the ones to keep, and everything older, goes
+		boundary, err := s.msgRepo.GetNthNewestMessage(ctx, candidate.conversationID, candidate.policy.KeepLast+1)
+		if err != nil {
+			return time.Time{}, primitive.NilObjectID, err
+		}
+		if boundary != nil && (boundary.CreatedAt.After(cutoffAt) || boundary.CreatedAt.Equal(cutoffAt)) {
+			cutoffAt, cutoffID = boundary.CreatedAt, boundary.ID
+		}
+	}
+
+	return cutoffAt, cutoffID, nil
+}
+
+// exceedsPolicy reports whether a conversation holds messages older than its policy allows
+// or more messages than it keeps
+func exceedsPolicy(stats *models.ConversationStats, policy models.RetentionPolicy, now time.Time) bool {
+	expired := policy.MaxAgeDays > 0 && stats.Oldest.Before(ageCutoff(policy, now))
+	overflowing := policy.KeepLast > 0 && stats.Count > int64(policy.KeepLast)
+	return expired || overflowing
+}
+
+func ageCutoff(policy models.RetentionPolicy, now time.Time) time.Time {
+	return now.AddDate(0, 0, -policy.MaxAgeDays)
+}
+
+func (s *retentionService) audit(ctx context.Context, purge *models.ConversationPurge) {
+	entry := &models.AuditEntry{
+		Action:   models.AuditRetentionPurge,
+		ActorID:  models.ActorSystem,
+		TargetID: purge.ConversationID,
+		Details: map[string]interface{}{
+			"messages":     purge.Messages,
+			"attachments":  purge.Attachments,
+			"max_age_days": purge.Policy.MaxAgeDays,
+			"keep_last":    purge.Policy.KeepLast,
+		},
+	}
+	if err := s.auditRepo.RecordAudit(ctx, entry); err != nil {
+		logging.Logger.Error("Failed to record retention audit entry", zap.String("conversation_id", purge.ConversationID), zap.Error(err))
+	}
+}
+
+// SetConversationRetention changes the conversation's own retention policy and records the
+// change in the conversation as a system message.
+func (s *retentionService) SetConversationRetention(ctx context.Context, conversationID, userID string, policy models.RetentionPolicy) (*models.Conversation, error) {
+	peerID, ok := models.ConversationPeer(conversationID, userID)
+	if !ok {
+		return nil, fmt.Errorf("%w: not a participant of this conversation", common.ErrForbidden)
+	}
+	if policy.MaxAgeDays < 0 || policy.MaxAgeDays > maxRetentionDays {
+		return nil, fmt.Errorf("%w: max_age_days must be between 0 and %d", common.ErrInvalidInput, maxRetentionDays)
+	}
+	if policy.KeepLast < 0 || policy.KeepLast > maxRetentionMessages {
+		return nil, fmt.Errorf("%w: keep_last must be between 0 and %d", common.ErrInvalidInput, maxRetentionMessages)
+	}
+
+	// The change is announced with a system message, so whoever cannot send one cannot
+	// make the change either
+	suspended, err := s.wsManager.IsSuspended(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if suspended {
+		return nil, websocket.ErrSenderSuspended
+	}
+
+	previous, err := s.conversationRepo.GetConversation(ctx, conversationID)
+	if errors.Is(err, common.ErrNotFound) {
+		userID1, userID2, _ := models.ConversationParticipants(conversationID)
+		previous, err = &models.Conversation{ID: conversationID, Participants: []string{userID1, userID2}}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Blocks apply as they do to messages: the change looks applied to a user the peer has
+	// blocked, but is not
+	dropped, err := s.wsManager.CheckBlocks(ctx, userID, peerID)
+	if err != nil {
+		return nil, err
+	}
+	if dropped {
+		previous.Retention = nil
+		if !policy.IsZero() {
+			previous.Retention = &policy
+		}
+		return previous, nil
+	}
+
+	conversation, err := s.conversationRepo.SetRetention(ctx, conversationID, policy, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	systemMessage := &models.Message{
+		Type:       models.SystemMessage,
+		SenderID:   userID,
+		ReceiverID: peerID,
+		Content:    retentionDescription(policy),
+		SystemEvent: &models.SystemEvent{
+			Action:    models.SystemActionRetentionChanged,
+			ActorID:   userID,
+			Retention: conversation.Retention,
+		},
+	}
+	if err := s.wsManager.DispatchMessage(ctx, systemMessage); err != nil {
+		// A change the peer is not told about must not take effect
+		var restore models.RetentionPolicy
+		if previous.Retention != nil {
+			restore = *previous.Retention
+		}
+		if _, restoreErr := s.conversationRepo.SetRetention(ctx, conversationID, restore, previous.UpdatedBy); restoreErr != nil {
+			logging.Logger.Error("Failed to restore retention policy", zap.String("conversation_id", conversationID), zap.Error(restoreErr))
+		}
+		return nil, err
+	}
+
+	return conversation, nil
+}
+
+// retentionDescription is the text of the system message announcing a retention change
+func retentionDescription(policy models.RetentionPolicy) string {
+	switch {
+	case policy.IsZero():
+		return "Message retention limit removed"
+	case policy.MaxAgeDays > 0 && policy.KeepLast > 0:
+		return fmt.Sprintf("Messages are deleted after %d days, keeping at most the last %d", policy.MaxAgeDays, policy.KeepLast)
+	case policy.MaxAgeDays > 0:
+		return fmt.Sprintf("Messages are deleted after %d days", policy.MaxAgeDays)
+	default:
+		return fmt.Sprintf("Only the last %d messages are kept", policy.KeepLast)
+	}
+}
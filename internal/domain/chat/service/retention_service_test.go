@@ -0,0 +1,70 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dk5761/go-serv/internal/domain/chat/models"
+)
+
+func TestAgeCutoff(t *testing.T) {
+	now := time.Date(2024, time.March, 31, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		days int
+		want time.Time
+	}{
+		{1, time.Date(2024, time.March, 30, 12, 0, 0, 0, time.UTC)},
+		{31, time.Date(2024, time.February, 29, 12, 0, 0, 0, time.UTC)},
+		{366, time.Date(2023, time.March, 31, 12, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		if got := ageCutoff(models.RetentionPolicy{MaxAgeDays: tt.days}, now); !got.Equal(tt.want) {
+			t.Errorf("%d days: got %v, want %v", tt.days, got, tt.want)
+		}
+	}
+}
+
+func TestExceedsPolicy(t *testing.T) {
+	now := time.Date(2024, time.March, 31, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name   string
+		stats  models.ConversationStats
+		policy models.RetentionPolicy
+		want   bool
+	}{
+		{"no policy", models.ConversationStats{Count: 1000, Oldest: now.AddDate(-5, 0, 0)}, models.RetentionPolicy{}, false},
+		{"within age", models.ConversationStats{Count: 10, Oldest: now.AddDate(0, 0, -29)}, models.RetentionPolicy{MaxAgeDays: 30}, false},
+		{"at the age cutoff", models.ConversationStats{Count: 10, Oldest: now.AddDate(0, 0, -30)}, models.RetentionPolicy{MaxAgeDays: 30}, false},
+		{"expired", models.ConversationStats{Count: 10, Oldest: now.AddDate(0, 0, -31)}, models.RetentionPolicy{MaxAgeDays: 30}, true},
+		{"at the count limit", models.ConversationStats{Count: 100, Oldest: now}, models.RetentionPolicy{KeepLast: 100}, false},
+		{"overflowing", models.ConversationStats{Count: 101, Oldest: now}, models.RetentionPolicy{KeepLast: 100}, true},
+		{"either limit", models.ConversationStats{Count: 5, Oldest: now.AddDate(0, 0, -8)}, models.RetentionPolicy{MaxAgeDays: 7, KeepLast: 100}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := exceedsPolicy(&tt.stats, tt.policy, now); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetentionDescription(t *testing.T) {
+	tests := []struct {
+		policy models.RetentionPolicy
+		want   string
+	}{
+		{models.RetentionPolicy{}, "Message retention limit removed"},
+		{models.RetentionPolicy{MaxAgeDays: 30}, "Messages are deleted after 30 days"},
+		{models.RetentionPolicy{KeepLast: 500}, "Only the last 500 messages are kept"},
+		{models.RetentionPolicy{MaxAgeDays: 7, KeepLast: 50}, "Messages are deleted after 7 days, keeping at most the last 50"},
+	}
+
+	for _, tt := range tests {
+		if got := retentionDescription(tt.policy); got != tt.want {
+			t.Errorf("%+v: got %q, want %q", tt.policy, got, tt.want)
+		}
+	}
+}
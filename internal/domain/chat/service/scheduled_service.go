@@ -0,0 +1,99 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/dk5761/go-serv/internal/domain/chat/models"
+	"github.com/dk5761/go-serv/internal/domain/chat/repository"
+	"github.com/dk5761/go-serv/internal/domain/common"
+	"github.com/dk5761/go-serv/internal/infrastructure/storage"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type scheduledService struct {
+	scheduledRepo  repository.ScheduledMessageRepository
+	storageService storage.StorageService
+}
+
+func NewScheduledService(scheduledRepo repository.ScheduledMessageRepository, storageService storage.StorageService) ScheduledService {
+	return &scheduledService{scheduledRepo: scheduledRepo, storageService: storageService}
+}
+
+// ScheduleMessage validates and stores a message to be sent at msg.SendAt.
+func (s *scheduledService) ScheduleMessage(ctx context.Context, msg *models.ScheduledMessage) error {
+	if msg.ReceiverID == "" {
+		return fmt.Errorf("%w: receiver_id is required", common.ErrInvalidInput)
+	}
+	if msg.ReceiverID == msg.SenderID {
+		return fmt.Errorf("%w: cannot schedule a message to yourself", common.ErrInvalidInput)
+	}
+	if err := validateSendAt(msg.SendAt); err != nil {
+		return err
+	}
+	if err := s.validateMessage(msg.SenderID, msg.Content, msg.FileURL); err != nil {
+		return err
+	}
+
+	_, err := s.scheduledRepo.CreateScheduledMessage(ctx, msg)
+	return err
+}
+
+// ListScheduledMessages returns the sender's messages that have not been sent yet.
+func (s *scheduledService) ListScheduledMessages(ctx context.Context, senderID string) ([]*models.ScheduledMessage, error) {
+	return s.scheduledRepo.ListScheduledMessages(ctx, senderID)
+}
+
+// UpdateScheduledMessage edits a scheduled message as long as the scheduler has not picked it up.
+func (s *scheduledService) UpdateScheduledMessage(ctx context.Context, id primitive.ObjectID, senderID, content string, sendAt time.Time) (*models.ScheduledMessage, error) {
+	if err := validateSendAt(sendAt); err != nil {
+		return nil, err
+	}
+	existing, err := s.scheduledRepo.GetScheduledMessage(ctx, id, senderID)
+	if err != nil {
+		return nil, wrapScheduledNotFound(err)
+	}
+	if err := s.validateMessage(senderID, content, existing.FileURL); err != nil {
+		return nil, err
+	}
+
+	if err := s.scheduledRepo.UpdateScheduledMessage(ctx, id, senderID, content, sendAt); err != nil {
+		return nil, wrapScheduledNotFound(err)
+	}
+	return s.scheduledRepo.GetScheduledMessage(ctx, id, senderID)
+}
+
+// CancelScheduledMessage stops a scheduled message from being sent.
+func (s *scheduledService) CancelScheduledMessage(ctx context.Context, id primitive.ObjectID, senderID string) error {
+	return wrapScheduledNotFound(s.scheduledRepo.CancelScheduledMessage(ctx, id, senderID))
+}
+
+// validateMessage checks the message the scheduler will send the way the send pipeline
+// would, so that it does not fail only once it is due. The file must have been uploaded to
+// this server.
+func (s *scheduledService) validateMessage(senderID, content, fileURL string) error {
+	message := &models.Message{SenderID: senderID, Content: content, FileURL: fileURL}
+	if err := message.Validate(); err != nil {
+		return err
+	}
+	if fileURL != "" && !s.storageService.Stores(fileURL) {
+		return fmt.Errorf("%w: file_url must be a file uploaded to this server", common.ErrInvalidInput)
+	}
+	return nil
+}
+
+func validateSendAt(sendAt time.Time) error {
+	if !sendAt.After(time.Now()) {
+		return fmt.Errorf("%w: send_at must be in the future", common.ErrInvalidInput)
+	}
+	return nil
+}
+
+func wrapScheduledNotFound(err error) error {
+	if errors.Is(err, common.ErrNotFound) {
+		return fmt.Errorf("%w: scheduled message not found or already sent", common.ErrNotFound)
+	}
+	return err
+}
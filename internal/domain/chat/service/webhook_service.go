@@ -0,0 +1,245 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+
+	"github.com/dk5761/go-serv/configs"
+	authRepo "github.com/dk5761/go-serv/internal/domain/auth/repository"
+	"github.com/dk5761/go-serv/internal/domain/chat/models"
+	"github.com/dk5761/go-serv/internal/domain/chat/repository"
+	"github.com/dk5761/go-serv/internal/domain/chat/websocket"
+	"github.com/dk5761/go-serv/internal/domain/common"
+	"github.com/dk5761/go-serv/internal/infrastructure/logging"
+)
+
+// WebhookTokenPrefix starts every incoming webhook token, so they are easy to spot in
+// leaked text.
+const WebhookTokenPrefix = "whk_"
+
+const (
+	defaultWebhookRateLimit  = 30
+	defaultWebhookRateWindow = time.Minute
+	maxWebhookNameLength     = 64
+)
+
+// RateLimitedError is returned by Post when the hook used up its rate limit. RetryAfter
+// is when the current window ends.
+type RateLimitedError struct {
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitedError) Error() string {
+	return "rate limit exceeded, retry later"
+}
+
+type webhookService struct {
+	webhookRepo repository.WebhookRepository
+	userRepo    authRepo.UserRepository
+	wsManager   *websocket.WebSocketManager
+	rateLimit   int
+	rateWindow  time.Duration
+	baseURL     string
+}
+
+func NewWebhookService(webhookRepo repository.WebhookRepository, userRepo authRepo.UserRepository, wsManager *websocket.WebSocketManager, cfg configs.WebhookConfig) WebhookService {
+	rateLimit := cfg.RateLimit
+	if rateLimit <= 0 {
+		rateLimit = defaultWebhookRateLimit
+	}
+	rateWindow := time.Duration(cfg.RateWindow) * time.Second
+	if rateWindow <= 0 {
+		rateWindow = defaultWebhookRateWindow
+	}
+
+	return &webhookService{
+		webhookRepo: webhookRepo,
+		userRepo:    userRepo,
+		wsManager:   wsManager,
+		rateLimit:   rateLimit,
+		rateWindow:  rateWindow,
+		baseURL:     strings.TrimSuffix(cfg.BaseURL, "/"),
+	}
+}
+
+// CreateWebhook adds a hook to a conversation between the user and a bot account, which
+// will be the sender of the hook's messages.
+func (s *webhookService) CreateWebhook(ctx context.Context, conversationID string, userID uuid.UUID, name string) (*models.IncomingWebhookCredentials, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || len([]rune(name)) > maxWebhookNameLength {
+		return nil, fmt.Errorf("%w: name must be between 1 and %d characters", common.ErrInvalidInput, maxWebhookNameLength)
+	}
+
+	peerID, ok := models.ConversationPeer(conversationID, userID.String())
+	if !ok {
+		return nil, fmt.Errorf("%w: conversation not found", common.ErrNotFound)
+	}
+	peer, err := uuid.Parse(peerID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: conversation not found", common.ErrNotFound)
+	}
+	bot, err := s.userRepo.GetUserByID(ctx, peer)
+	if err != nil {
+		return nil, err
+	}
+	if !bot.IsBot {
+		return nil, fmt.Errorf("%w: incoming webhooks post as a bot, create the hook in a conversation with a bot account", common.ErrInvalidInput)
+	}
+
+	token := newWebhookToken()
+	webhook, err := s.webhookRepo.CreateWebhook(ctx, &models.IncomingWebhook{
+		ConversationID: conversationID,
+		SenderID:       peerID,
+		ReceiverID:     userID.String(),
+		Name:           name,
+		TokenHash:      hashWebhookToken(token),
+		CreatedAt:      time.Now(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.credentials(webhook, token), nil
+}
+
+// ListWebhooks lists the hooks of a conversation the user takes part in.
+func (s *webhookService) ListWebhooks(ctx context.Context, conversationID string, userID uuid.UUID) ([]*models.IncomingWebhook, error) {
+	if _, ok := models.ConversationPeer(conversationID, userID.String()); !ok {
+		return nil, fmt.Errorf("%w: conversation not found", common.ErrNotFound)
+	}
+	return s.webhookRepo.ListWebhooks(ctx, conversationID)
+}
+
+// RegenerateToken issues a new token for the hook, invalidating the previous one and
+// reinstating a revoked hook.
+func (s *webhookService) RegenerateToken(ctx context.Context, id primitive.ObjectID, userID uuid.UUID) (*models.IncomingWebhookCredentials, error) {
+	if _, err := s.ownWebhook(ctx, id, userID); err != nil {
+		return nil, err
+	}
+
+	token := newWebhookToken()
+	webhook, err := s.webhookRepo.SetTokenHash(ctx, id, hashWebhookToken(token))
+	if err != nil {
+		return nil, err
+	}
+	return s.credentials(webhook, token), nil
+}
+
+// RevokeToken disables the hook until a new token is generated. Its history and counters
+// are kept.
+func (s *webhookService) RevokeToken(ctx context.Context, id primitive.ObjectID, userID uuid.UUID) (*models.IncomingWebhook, error) {
+	if _, err := s.ownWebhook(ctx, id, userID); err != nil {
+		return nil, err
+	}
+	return s.webhookRepo.RevokeToken(ctx, id, time.Now())
+}
+
+func (s *webhookService) DeleteWebhook(ctx context.Context, id primitive.ObjectID, userID uuid.UUID) error {
+	if _, err := s.ownWebhook(ctx, id, userID); err != nil {
+		return err
+	}
+	return s.webhookRepo.DeleteWebhook(ctx, id)
+}
+
+// Post sends content from the hook's bot to the user who created it, through the same
+// pipeline as any other message. Rejected requests count as errors of the hook.
+func (s *webhookService) Post(ctx context.Context, token, content string) (*models.Message, error) {
+	if !strings.HasPrefix(token, WebhookTokenPrefix) {
+		return nil, fmt.Errorf("%w: webhook not found", common.ErrNotFound)
+	}
+	webhook, err := s.webhookRepo.GetWebhookByTokenHash(ctx, hashWebhookToken(token))
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	allowed, err := s.webhookRepo.TakeRateSlot(ctx, webhook.ID, now, s.rateWindow, s.rateLimit)
+	if err != nil {
+		return nil, err
+	}
+	if !allowed {
+		err := &RateLimitedError{RetryAfter: webhook.WindowStart.Add(s.rateWindow).Sub(now)}
+		if err.RetryAfter <= 0 {
+			err.RetryAfter = time.Second
+		}
+		s.recordError(ctx, webhook, err)
+		return nil, err
+	}
+
+	// Content is never run as a slash command
+	if strings.HasPrefix(content, "/") {
+		content = "/" + content
+	}
+	message := &models.Message{
+		Type:       models.TextMessage,
+		SenderID:   webhook.SenderID,
+		ReceiverID: webhook.ReceiverID,
+		Content:    content,
+		CreatedAt:  now,
+		WebhookID:  webhook.ID.Hex(),
+	}
+	if err := message.Validate(); err != nil {
+		s.recordError(ctx, webhook, err)
+		return nil, err
+	}
+	if err := s.wsManager.DispatchMessage(ctx, message); err != nil {
+		s.recordError(ctx, webhook, err)
+		return nil, err
+	}
+	return message, nil
+}
+
+// ownWebhook loads a hook the user created; other users' hooks are reported as not found
+func (s *webhookService) ownWebhook(ctx context.Context, id primitive.ObjectID, userID uuid.UUID) (*models.IncomingWebhook, error) {
+	webhook, err := s.webhookRepo.GetWebhook(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if webhook.ReceiverID != userID.String() {
+		return nil, fmt.Errorf("%w: webhook not found", common.ErrNotFound)
+	}
+	return webhook, nil
+}
+
+// recordError counts a failed request against the hook. Internal errors are kept as a
+// generic message so that storage details never reach the hook's owner.
+func (s *webhookService) recordError(ctx context.Context, webhook *models.IncomingWebhook, cause error) {
+	message := cause.Error()
+	var rateLimited *RateLimitedError
+	if !errors.As(cause, &rateLimited) && !errors.Is(cause, common.ErrInvalidInput) && !errors.Is(cause, common.ErrForbidden) {
+		logging.Logger.Error("Failed to post webhook message", zap.String("webhook_id", webhook.ID.Hex()), zap.Error(cause))
+		message = "failed to deliver message"
+	}
+	if err := s.webhookRepo.RecordError(ctx, webhook.ID, message, time.Now()); err != nil {
+		logging.Logger.Error("Failed to record webhook error", zap.String("webhook_id", webhook.ID.Hex()), zap.Error(err))
+	}
+}
+
+func (s *webhookService) credentials(webhook *models.IncomingWebhook, token string) *models.IncomingWebhookCredentials {
+	return &models.IncomingWebhookCredentials{
+		Webhook: webhook,
+		Token:   token,
+		URL:     s.baseURL + "/api/hooks/" + token,
+	}
+}
+
+func hashWebhookToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func newWebhookToken() string {
+	token := make([]byte, 32)
+	_, _ = rand.Read(token)
+	return WebhookTokenPrefix + base64.RawURLEncoding.EncodeToString(token)
+}
@@ -0,0 +1,28 @@
+package service
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWebhookToken(t *testing.T) {
+	token := newWebhookToken()
+	if !strings.HasPrefix(token, WebhookTokenPrefix) {
+		t.Errorf("token %q lacks the %q prefix", token, WebhookTokenPrefix)
+	}
+	if other := newWebhookToken(); other == token {
+		t.Error("two tokens are equal")
+	}
+
+	hash := hashWebhookToken(token)
+	if hash != hashWebhookToken(token) {
+		t.Error("hashing is not deterministic")
+	}
+	if len(hash) != 64 || strings.Contains(hash, token) {
+		t.Errorf("got hash %q", hash)
+	}
+	// The SHA-256 of "abc"
+	if got, want := hashWebhookToken("abc"), "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -9,33 +9,103 @@ import (
 	"sync"
 	"time"
 
+	"github.com/google/uuid"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.uber.org/zap"
 
+	authRepo "github.com/dk5761/go-serv/internal/domain/auth/repository"
+	"github.com/dk5761/go-serv/internal/domain/chat/bot"
+	"github.com/dk5761/go-serv/internal/domain/chat/command"
+	"github.com/dk5761/go-serv/internal/domain/chat/mention"
 	"github.com/dk5761/go-serv/internal/domain/chat/models"
+	"github.com/dk5761/go-serv/internal/domain/chat/moderation"
+	"github.com/dk5761/go-serv/internal/domain/chat/push"
 	"github.com/dk5761/go-serv/internal/domain/chat/repository"
+	"github.com/dk5761/go-serv/internal/domain/common"
 	"github.com/dk5761/go-serv/internal/infrastructure/logging"
 )
 
+// EventHandler processes a client frame for an event type registered with RegisterEventHandler.
+// Errors wrapping the common errors are reported to the client with a matching error code.
+type EventHandler func(ctx context.Context, client *models.Client, frame []byte) error
+
+// ErrSenderSuspended is returned by DispatchMessage when a moderator suspended the sender.
+var ErrSenderSuspended = fmt.Errorf("%w: account is suspended", common.ErrForbidden)
+
+// ErrRecipientBlocked is returned by DispatchMessage when the sender has blocked the receiver.
+var ErrRecipientBlocked = fmt.Errorf("%w: you have blocked this user, unblock them to send messages", common.ErrForbidden)
+
 type WebSocketManager struct {
-	clients map[string]*models.Client // Map userID to WebSocket client
-	mu      sync.RWMutex
-	msgRepo repository.MessageRepository
+	clients          map[string]map[*models.Client]struct{} // Map userID to the user's connected devices
+	handlers         map[string]EventHandler                // Client event types handled outside the manager
+	onDisconnect     []func(client *models.Client)
+	mu               sync.RWMutex
+	msgRepo          repository.MessageRepository
+	conversationRepo repository.ConversationRepository
+	draftRepo        repository.DraftRepository
+	attachmentRepo   repository.AttachmentRepository
+	mentionResolver  *mention.Resolver
+	settingsRepo     repository.ConversationSettingsRepository
+	preferencesRepo  repository.UserPreferencesRepository
+	blockRepo        authRepo.BlockRepository
+	userRepo         authRepo.UserRepository
+	moderator        *moderation.Pipeline
+	pusher           *push.Notifier
+	bots             *bot.Dispatcher
+	commands         *command.Registry
 }
 
-func NewWebSocketManager(msgRepo repository.MessageRepository) *WebSocketManager {
+func NewWebSocketManager(
+	msgRepo repository.MessageRepository,
+	conversationRepo repository.ConversationRepository,
+	draftRepo repository.DraftRepository,
+	attachmentRepo repository.AttachmentRepository,
+	settingsRepo repository.ConversationSettingsRepository,
+	preferencesRepo repository.UserPreferencesRepository,
+	mentionResolver *mention.Resolver,
+	blockRepo authRepo.BlockRepository,
+	userRepo authRepo.UserRepository,
+	moderator *moderation.Pipeline,
+	pusher *push.Notifier,
+	bots *bot.Dispatcher,
+	commands *command.Registry,
+) *WebSocketManager {
 	return &WebSocketManager{
-		clients: make(map[string]*models.Client),
-		msgRepo: msgRepo,
+		clients:          make(map[string]map[*models.Client]struct{}),
+		handlers:         make(map[string]EventHandler),
+		msgRepo:          msgRepo,
+		conversationRepo: conversationRepo,
+		draftRepo:        draftRepo,
+		attachmentRepo:   attachmentRepo,
+		settingsRepo:     settingsRepo,
+		preferencesRepo:  preferencesRepo,
+		mentionResolver:  mentionResolver,
+		blockRepo:        blockRepo,
+		userRepo:         userRepo,
+		moderator:        moderator,
+		pusher:           pusher,
+		bots:             bots,
+		commands:         commands,
 	}
 }
 
-// AddClient adds a new client to the manager
+// RegisterEventHandler routes client frames of eventType to handler. It must be called
+// before clients connect.
+func (m *WebSocketManager) RegisterEventHandler(eventType string, handler EventHandler) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.handlers[eventType] = handler
+}
+
+// AddClient adds a new client to the manager. A user may be connected from several devices at once.
 func (m *WebSocketManager) AddClient(client *models.Client) {
 
 	m.mu.Lock()
 	defer m.mu.Unlock()
-	m.clients[client.ID] = client
+	if m.clients[client.ID] == nil {
+		m.clients[client.ID] = make(map[*models.Client]struct{})
+	}
+	m.clients[client.ID][client] = struct{}{}
 	go client.Listen()
 
 	go m.listenToClient(client)
@@ -43,57 +113,478 @@ func (m *WebSocketManager) AddClient(client *models.Client) {
 	go m.sendPendingMessages(client)
 }
 
-func (m *WebSocketManager) RemoveClient(clientID string) {
+// RegisterDisconnectHandler calls handler with every device that disconnects. It must be
+// called before clients connect.
+func (m *WebSocketManager) RegisterDisconnectHandler(handler func(client *models.Client)) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
-	if client, ok := m.clients[clientID]; ok {
-		err := client.Conn.Close()
-		if err != nil {
-			return
-		}
-		delete(m.clients, clientID)
+	m.onDisconnect = append(m.onDisconnect, handler)
+}
+
+func (m *WebSocketManager) RemoveClient(client *models.Client) {
+	if !m.removeClient(client) {
+		return
+	}
+	m.mu.RLock()
+	handlers := m.onDisconnect
+	m.mu.RUnlock()
+	for _, handler := range handlers {
+		handler(client)
 	}
 }
 
-// SendToClient sends a message to the specified user if they are connected
-func (m *WebSocketManager) SendToClient(receiverID string, message *models.Message) error {
+// removeClient closes the device's connection and reports whether it was connected
+func (m *WebSocketManager) removeClient(client *models.Client) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	devices, ok := m.clients[client.ID]
+	if !ok {
+		return false
+	}
+	if _, ok := devices[client]; !ok {
+		return false
+	}
+	err := client.Conn.Close()
+	if err != nil {
+		return false
+	}
+	delete(devices, client)
+	if len(devices) == 0 {
+		delete(m.clients, client.ID)
+	}
+	return true
+}
+
+// DisconnectUser closes every connection of the user, e.g. after a moderator suspended them
+func (m *WebSocketManager) DisconnectUser(userID string) {
+	for _, client := range m.devices(userID) {
+		m.RemoveClient(client)
+	}
+}
+
+// devices returns a snapshot of the user's connected devices
+func (m *WebSocketManager) devices(userID string) []*models.Client {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 
-	client, exists := m.clients[receiverID]
-	if !exists {
-		return errors.New("receiver not connected")
+	devices := make([]*models.Client, 0, len(m.clients[userID]))
+	for client := range m.clients[userID] {
+		devices = append(devices, client)
 	}
+	return devices
+}
 
-	if !exists {
-		// Receiver is offline, store the message as undelivered
-		_, err := m.msgRepo.StoreUndeliveredMessage(context.Background(), message)
-		if err != nil {
-			logging.Logger.Error("Failed to store undelivered message",
+// ConnectedUsers returns the IDs of the users with at least one connected device
+func (m *WebSocketManager) ConnectedUsers() []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	userIDs := make([]string, 0, len(m.clients))
+	for userID := range m.clients {
+		userIDs = append(userIDs, userID)
+	}
+	return userIDs
+}
+
+var (
+	errReceiverOffline = errors.New("receiver not connected")
+	errSendBufferFull  = errors.New("the send buffer of every device of the receiver is full")
+)
+
+// SendToClient sends a message to every connected device of the specified user. When none
+// of the devices could take it, the failure is recorded and the redelivery worker retries.
+func (m *WebSocketManager) SendToClient(receiverID string, message *models.Message) error {
+	err := m.deliver(receiverID, message)
+	if errors.Is(err, errSendBufferFull) {
+		log.Printf("SendCh is full on every device of client %s; message left for redelivery", receiverID)
+		if err := m.msgRepo.RecordDeliveryFailure(context.Background(), message.ID, err.Error(), time.Now(), false); err != nil {
+			logging.Logger.Error("Failed to record delivery failure",
 				zap.String("receiver_id", receiverID),
 				zap.Error(err),
 			)
+		}
+	}
+	return err
+}
+
+// Redeliver retries delivering a message stuck in Stored to its receiver's connected devices
+func (m *WebSocketManager) Redeliver(message *models.Message) error {
+	return m.deliver(message.ReceiverID, message)
+}
+
+// deliver pushes a message to every connected device of the receiver and moves it to Sent
+// once at least one device took it
+func (m *WebSocketManager) deliver(receiverID string, message *models.Message) error {
+	devices := m.devices(receiverID)
+	if len(devices) == 0 {
+		return errReceiverOffline
+	}
+
+	delivered := false
+	for _, client := range devices {
+		select {
+		case client.SendCh <- message:
+			delivered = true
+		default:
+			log.Printf("SendCh is full; message not sent to a device of client %s", receiverID)
+		}
+	}
+	if !delivered {
+		return errSendBufferFull
+	}
+
+	m.advanceStatus(context.Background(), message.ID, models.Sent, "")
+	return nil
+}
+
+// DispatchMessage runs a message through the send pipeline: it is persisted, acknowledged
+// to the sender as stored and delivered to the receiver if they are connected.
+//
+// Messages to a user the sender has blocked fail with ErrRecipientBlocked. Messages to a
+// user who has blocked the sender are acknowledged as stored but dropped, so the sender
+// cannot tell they were blocked.
+//
+// Content rejected by the moderation pipeline fails with a *moderation.RejectedError, and
+// anything sent by a suspended user with ErrSenderSuspended.
+//
+// Text messages starting with "/" run a slash command instead of being sent. Unknown
+// commands fail with command.ErrUnknownCommand.
+func (m *WebSocketManager) DispatchMessage(ctx context.Context, message *models.Message) error {
+	suspended, err := m.IsSuspended(ctx, message.SenderID)
+	if err != nil {
+		return err
+	}
+	if suspended {
+		return ErrSenderSuspended
+	}
+
+	if name, args, ok := command.Parse(message); ok {
+		message.ConversationID = models.ConversationID(message.SenderID, message.ReceiverID)
+		return m.runCommand(ctx, &command.Invocation{Name: name, Args: args, Message: message})
+	}
+	return m.dispatch(ctx, message)
+}
+
+// runCommand runs a built-in command, or hands it to the bot the message is addressed to
+// if the bot registered it. Replies go to the sender's devices only; messages the command
+// produced are sent like any other.
+func (m *WebSocketManager) runCommand(ctx context.Context, inv *command.Invocation) error {
+	result, ok, err := m.commands.Run(ctx, inv)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		if m.bots.DeliverCommand(ctx, inv) {
+			return nil
+		}
+		return fmt.Errorf("%w /%s", command.ErrUnknownCommand, inv.Name)
+	}
+	if result == nil {
+		return nil
+	}
+
+	if result.Reply != "" {
+		m.SendEvent(inv.Message.SenderID, &models.Event{
+			EventType: models.EventCommandReply,
+			Data: models.CommandReplyData{
+				Command:        inv.Name,
+				ConversationID: inv.Message.ConversationID,
+				TempID:         inv.Message.TempID,
+				Content:        result.Reply,
+			},
+		})
+	}
+	if result.Message != nil {
+		return m.dispatch(ctx, result.Message)
+	}
+	return nil
+}
+
+// dispatch persists and delivers a message that is not a command
+func (m *WebSocketManager) dispatch(ctx context.Context, message *models.Message) error {
+	message.Status = models.Stored
+	message.EventType = "receive_message"
+	message.ConversationID = models.ConversationID(message.SenderID, message.ReceiverID)
+
+	// Blocks apply to system messages too, as they record a change a participant made
+	dropped, err := m.CheckBlocks(ctx, message.SenderID, message.ReceiverID)
+	if err != nil {
+		return err
+	}
+	if dropped {
+		// The acknowledgment carries the conversation's last sequence number rather than a
+		// new one, which would leave a gap in the receiver's history that never fills
+		seq, err := m.msgRepo.LastSeq(ctx, message.ConversationID)
+		if err != nil {
 			return err
 		}
-		log.Printf("Stored undelivered message for offline client %s", receiverID)
+		message.ID = primitive.NewObjectID()
+		message.Seq = seq
+		message.CreatedAt = time.Now()
+		m.sendAcknowledgment(message, models.Stored)
 		return nil
 	}
 
-	// Receiver is online, send the message directly
+	// System messages are exempt from moderation and disappearing message timers
+	if message.Type != models.SystemMessage {
+		if message.Type == models.VoiceMessage {
+			if err := m.attachVoice(ctx, message); err != nil {
+				return err
+			}
+		}
+
+		if err := m.moderator.Moderate(ctx, message); err != nil {
+			return err
+		}
+
+		// Apply the conversation's disappearing message timer
+		conversation, err := m.conversationRepo.GetConversation(ctx, message.ConversationID)
+		if err != nil && !errors.Is(err, common.ErrNotFound) {
+			return err
+		}
+		if conversation != nil && conversation.MessageTTL > 0 {
+			message.ExpiresAt = time.Now().Add(time.Duration(conversation.MessageTTL) * time.Second)
+		}
+
+		if message.Type != models.EncryptedMessage {
+			message.Mentions = m.mentionResolver.Resolve(ctx, message.Content)
+		}
+	}
+
+	messageID, err := m.msgRepo.SaveMessage(ctx, message)
+	if err != nil {
+		return err
+	}
+	message.ID = messageID
+
+	// Send acknowledgment back to sender client
+	m.sendAcknowledgment(message, models.Stored)
+
+	if message.Type != models.SystemMessage && message.Type != models.CallMessage {
+		m.clearDraft(ctx, message)
+	}
+	m.updateInbox(ctx, message)
+
+	// Try delivering to receiver if connected; SendToClient moves the message to Sent.
+	// Bots get the message at their webhook instead, and other receivers with no connected
+	// device get a push notification.
+	if err := m.SendToClient(message.ReceiverID, message); errors.Is(err, errReceiverOffline) {
+		delivered := func() { m.advanceStatus(context.Background(), message.ID, models.Sent, "") }
+		if !m.bots.Deliver(ctx, message, delivered) {
+			m.pusher.Notify(message)
+		}
+	}
+
+	m.notifyMentioned(message)
+
+	return nil
+}
+
+// attachVoice replaces a voice message's payload with the metadata extracted when its
+// sender uploaded the file
+func (m *WebSocketManager) attachVoice(ctx context.Context, message *models.Message) error {
+	attachment, err := m.attachmentRepo.GetAttachment(ctx, message.FileURL, message.SenderID)
+	if errors.Is(err, common.ErrNotFound) || (err == nil && attachment.Voice == nil) {
+		return fmt.Errorf("%w: file_url must be a voice note you uploaded", common.ErrInvalidInput)
+	}
+	if err != nil {
+		return err
+	}
+	message.Voice = attachment.Voice
+	return nil
+}
+
+// IsSuspended reports whether a moderator has suspended the user. IDs that are not user IDs
+// and unknown users are never suspended.
+func (m *WebSocketManager) IsSuspended(ctx context.Context, userID string) (bool, error) {
+	id, err := uuid.Parse(userID)
+	if err != nil {
+		return false, nil
+	}
+	user, err := m.userRepo.GetUserByID(ctx, id)
+	if errors.Is(err, common.ErrNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return user.IsSuspended(time.Now()), nil
+}
+
+// CheckBlocks applies blocks to an action of senderID towards receiverID. It fails with
+// ErrRecipientBlocked if the sender has blocked the receiver, and reports true if the
+// receiver has blocked the sender, in which case the action must look to the sender as if
+// it succeeded without taking effect.
+func (m *WebSocketManager) CheckBlocks(ctx context.Context, senderID, receiverID string) (bool, error) {
+	senderBlocked, err := m.isBlocked(ctx, senderID, receiverID)
+	if err != nil {
+		return false, err
+	}
+	if senderBlocked {
+		return false, ErrRecipientBlocked
+	}
+	return m.isBlocked(ctx, receiverID, senderID)
+}
+
+// isBlocked reports whether blockerID has blocked blockedID. IDs that are not user IDs can
+// never appear in a block list.
+func (m *WebSocketManager) isBlocked(ctx context.Context, blockerID, blockedID string) (bool, error) {
+	blocker, err := uuid.Parse(blockerID)
+	if err != nil {
+		return false, nil
+	}
+	blocked, err := uuid.Parse(blockedID)
+	if err != nil {
+		return false, nil
+	}
+	return m.blockRepo.IsBlocked(ctx, blocker, blocked)
+}
+
+// notifyMentioned sends a separate mentioned event to the receiver when they are mentioned.
+// Mentions of users outside the conversation only render as links and are never notified,
+// since those users cannot see the message.
+func (m *WebSocketManager) notifyMentioned(message *models.Message) {
+	for _, mentioned := range message.Mentions {
+		if mentioned.UserID != message.ReceiverID {
+			continue
+		}
+		m.SendEvent(message.ReceiverID, &models.Event{
+			EventType: models.EventMentioned,
+			Data: models.MentionedData{
+				MessageID:      message.ID.Hex(),
+				ConversationID: message.ConversationID,
+				SenderID:       message.SenderID,
+				Content:        message.Content,
+			},
+		})
+		return
+	}
+}
+
+// clearDraft empties the sender's draft once a message is sent and syncs the change to their devices
+func (m *WebSocketManager) clearDraft(ctx context.Context, message *models.Message) {
+	cleared, err := m.draftRepo.ClearDraft(ctx, message.SenderID, message.ConversationID, message.CreatedAt)
+	if err != nil {
+		logging.Logger.Error("Failed to clear draft", zap.String("client_id", message.SenderID), zap.Error(err))
+		return
+	}
+	if !cleared {
+		return
+	}
+
+	m.SendEvent(message.SenderID, &models.Event{
+		EventType: models.EventDraftUpdated,
+		Data: &models.Draft{
+			UserID:         message.SenderID,
+			ConversationID: message.ConversationID,
+			UpdatedAt:      message.CreatedAt,
+		},
+	})
+}
+
+// updateInbox records the message in both participants' inbox settings. The receiver gets
+// an unread message, and an archived conversation returns to their inbox unless they chose
+// to keep archived conversations archived.
+func (m *WebSocketManager) updateInbox(ctx context.Context, message *models.Message) {
+	if _, err := m.settingsRepo.RecordMessage(ctx, message.SenderID, message, false); err != nil {
+		logging.Logger.Error("Failed to update sender inbox", zap.String("client_id", message.SenderID), zap.Error(err))
+	}
+
+	unarchived := false
+	preferences, err := m.preferencesRepo.GetPreferences(ctx, message.ReceiverID)
+	if err != nil && !errors.Is(err, common.ErrNotFound) {
+		logging.Logger.Error("Failed to fetch preferences", zap.String("client_id", message.ReceiverID), zap.Error(err))
+	}
+	if preferences == nil || !preferences.KeepArchived {
+		unarchived, err = m.settingsRepo.Unarchive(ctx, message.ReceiverID, message.ConversationID)
+		if err != nil {
+			logging.Logger.Error("Failed to unarchive conversation", zap.String("client_id", message.ReceiverID), zap.Error(err))
+		}
+	}
+
+	settings, err := m.settingsRepo.RecordMessage(ctx, message.ReceiverID, message, true)
+	if err != nil {
+		logging.Logger.Error("Failed to update receiver inbox", zap.String("client_id", message.ReceiverID), zap.Error(err))
+		return
+	}
+
+	if unarchived {
+		m.SendEvent(message.ReceiverID, &models.Event{
+			EventType: models.EventConversationSettingsUpdated,
+			Data:      settings,
+		})
+	}
+}
+
+// errorCode maps an event handler error to the code and text sent in an error event
+func errorCode(err error) (string, string) {
+	switch {
+	case errors.Is(err, common.ErrInvalidInput):
+		return models.ErrCodeInvalidRequest, err.Error()
+	case errors.Is(err, common.ErrForbidden):
+		return models.ErrCodeForbidden, err.Error()
+	case errors.Is(err, common.ErrNotFound):
+		return models.ErrCodeNotFound, err.Error()
+	default:
+		logging.Logger.Error("Event handler failed", zap.Error(err))
+		return models.ErrCodeRequestFailed, "Request failed"
+	}
+}
+
+// sendError reports a rejected frame back to the connection that sent it
+func (m *WebSocketManager) sendError(client *models.Client, tempID, code, message string) {
+	m.sendErrorData(client, models.ErrorData{
+		Code:    code,
+		Message: message,
+		TempID:  tempID,
+	})
+}
+
+func (m *WebSocketManager) sendErrorData(client *models.Client, data models.ErrorData) {
+	event := &models.Event{
+		EventType: models.EventError,
+		Data:      data,
+	}
+
 	select {
-	case client.SendCh <- message:
-		m.markMessageAsDelivered(message.ID)
-		return nil
+	case client.SendCh <- event:
 	default:
-		// If the SendCh is full, mark the message as undelivered
-		_, err := m.msgRepo.StoreUndeliveredMessage(context.Background(), message)
-		if err != nil {
-			logging.Logger.Error("Failed to store undelivered message for full channel",
-				zap.String("receiver_id", receiverID),
-				zap.Error(err),
-			)
+		log.Printf("SendCh is full; error event not sent to client %s", client.ID)
+	}
+}
+
+// SendEvent pushes a non-message event to every connected device of a user. Events are not
+// stored, so it reports whether the event reached at least one device.
+func (m *WebSocketManager) SendEvent(userID string, event *models.Event) bool {
+	return m.SendEventExcept(userID, "", event)
+}
+
+// SendEventExcept is SendEvent that skips the device the change originated from.
+func (m *WebSocketManager) SendEventExcept(userID, exceptDeviceID string, event *models.Event) bool {
+	sent := false
+	for _, client := range m.devices(userID) {
+		if exceptDeviceID != "" && client.DeviceID == exceptDeviceID {
+			continue
 		}
-		return fmt.Errorf("client %s SendCh is full", receiverID)
+		select {
+		case client.SendCh <- event:
+			sent = true
+		default:
+			log.Printf("SendCh is full; %s event not sent to a device of client %s", event.EventType, userID)
+		}
+	}
+	return sent
+}
+
+// SendEventToClient pushes a non-message event to a single device, e.g. the one that
+// answered a call, and reports whether it was queued.
+func (m *WebSocketManager) SendEventToClient(client *models.Client, event *models.Event) bool {
+	select {
+	case client.SendCh <- event:
+		return true
+	default:
+		log.Printf("SendCh is full; %s event not sent to a device of client %s", event.EventType, client.ID)
+		return false
 	}
 }
 
@@ -107,25 +598,35 @@ func (m *WebSocketManager) deliverUndeliveredMessages(client *models.Client) {
 	for _, message := range undeliveredMessages {
 		select {
 		case client.SendCh <- message:
-			// Mark message as delivered after sending
-			m.markMessageAsDelivered(message.ID)
+			m.advanceStatus(context.Background(), message.ID, models.Sent, "")
 		default:
 			log.Printf("Failed to send message to client %s; SendCh full", client.ID)
 		}
 	}
 }
 
-// markMessageAsDelivered updates a message's status to delivered in MongoDB
-func (m *WebSocketManager) markMessageAsDelivered(messageID primitive.ObjectID) {
-	if err := m.msgRepo.MarkMessageAsDelivered(context.Background(), messageID); err != nil {
-		logging.Logger.Error("Failed to mark message as delivered", zap.Error(err))
+// advanceStatus moves a message forward in its lifecycle and acknowledges the new status to
+// the sender. Nothing is acknowledged when the message already was at or past status, so
+// the sender hears about every transition exactly once. A non-empty receiverID restricts
+// the change to messages addressed to that user.
+func (m *WebSocketManager) advanceStatus(ctx context.Context, messageID primitive.ObjectID, status models.MessageStatus, receiverID string) {
+	message, err := m.msgRepo.AdvanceStatus(ctx, messageID, status, receiverID)
+	if err != nil {
+		logging.Logger.Error("Failed to update message status",
+			zap.String("message_id", messageID.Hex()),
+			zap.String("status", string(status)),
+			zap.Error(err),
+		)
+		return
+	}
+	if message != nil {
+		m.sendAcknowledgment(message, status)
 	}
-
 }
 
 func (m *WebSocketManager) listenToClient(client *models.Client) {
 	defer func() {
-		m.RemoveClient(client.ID)
+		m.RemoveClient(client)
 		_ = client.Conn.Close() // Ensure the connection is closed when done
 	}()
 
@@ -152,135 +653,176 @@ func (m *WebSocketManager) listenToClient(client *models.Client) {
 		switch message.EventType {
 		case "send_message":
 			// Standard message event
-			message.Status = models.Stored
 			message.SenderID = client.ID
-			message.EventType = "receive_message"
 
-			messageID, err := m.msgRepo.SaveMessage(context.Background(), &message)
-			if err != nil {
-				logging.Logger.Error("Error saving message", zap.Error(err))
+			message.ClearServerFields()
+			if err := message.Validate(); err != nil {
+				m.sendError(client, message.TempID, models.ErrCodeInvalidMessage, err.Error())
 				continue
 			}
-			message.ID = messageID
 
-			// Send acknowledgment back to sender client
-			m.sendAcknowledgment(&message, models.Stored)
-
-			// Try delivering to receiver if connected
-			if err := m.SendToClient(message.ReceiverID, &message); err == nil {
-				// Update message status to Delivered in DB
-				m.msgRepo.UpdateMessageStatus(context.Background(), messageID, models.Sent)
+			if err := m.DispatchMessage(context.Background(), &message); err != nil {
+				if errors.Is(err, ErrRecipientBlocked) {
+					m.sendError(client, message.TempID, models.ErrCodeBlocked, err.Error())
+					continue
+				}
+				var rejected *moderation.RejectedError
+				if errors.As(err, &rejected) {
+					m.sendErrorData(client, models.ErrorData{
+						Code:    models.ErrCodeContentRejected,
+						Message: rejected.Error(),
+						TempID:  message.TempID,
+						Stage:   rejected.Stage,
+					})
+					continue
+				}
+				if errors.Is(err, common.ErrInvalidInput) || errors.Is(err, common.ErrForbidden) {
+					code, text := errorCode(err)
+					m.sendError(client, message.TempID, code, text)
+					continue
+				}
+				logging.Logger.Error("Error saving message", zap.Error(err))
+				m.sendError(client, message.TempID, models.ErrCodeSendFailed, "Failed to send message")
+				continue
 			}
 
 		case "ack_received":
-			// Handle acknowledgment from receiver client
-			messageID := message.ID // Assuming message ID is provided in acknowledgment
-			// update the local message body with the Receieved status
+			// The receiver's device got the message
+			m.advanceStatus(context.Background(), message.ID, models.Received, client.ID)
 
-			if err := m.msgRepo.UpdateMessageStatus(context.Background(), messageID, models.Received); err != nil {
-				logging.Logger.Error("Error updating message status", zap.Error(err))
-				continue
-			}
-			message.Status = models.Received
+		case "ack_read":
+			// The receiver read the message
+			m.advanceStatus(context.Background(), message.ID, models.Read, client.ID)
 
-			if err := m.msgRepo.MarkMessageAsDelivered(context.Background(), messageID); err != nil {
-				logging.Logger.Error("Error updating message status", zap.Error(err))
+		default:
+			m.mu.RLock()
+			handler, ok := m.handlers[message.EventType]
+			m.mu.RUnlock()
+			if !ok {
+				logging.Logger.Error("Unhandled event type",
+					zap.String("client_id", client.ID),
+					zap.String("event_type", message.EventType),
+				)
 				continue
 			}
 
-			message.Delivered = true // Update the lmb with Deliver == true and time.
-			message.DeliveredAt = time.Now()
-
-			m.sendAcknowledgment(&message, models.Received)
-
-		default:
-			logging.Logger.Error("Unhandled event type",
-				zap.String("client_id", client.ID),
-				zap.String("event_type", message.EventType),
-			)
+			if err := handler(context.Background(), client, msgData); err != nil {
+				code, text := errorCode(err)
+				m.sendError(client, message.TempID, code, text)
+			}
 		}
 	}
 }
 
+// acknowledgment builds the frame telling the sender a message reached status
+func acknowledgment(message *models.Message, status models.MessageStatus) *models.Message {
+	return &models.Message{
+		ID:             message.ID,
+		ConversationID: message.ConversationID,
+		Seq:            message.Seq,
+		SenderID:       message.SenderID,
+		ReceiverID:     message.ReceiverID,
+		EventType:      "acknowledgment",
+		TempID:         message.TempID,
+		Status:         status, // Send the status as acknowledgment type
+		CreatedAt:      time.Now(),
+		Delivered:      message.Delivered,
+		DeliveredAt:    message.DeliveredAt,
+		SentAt:         message.SentAt,
+		ReceivedAt:     message.ReceivedAt,
+		ReadAt:         message.ReadAt,
+		Content:        message.Content,
+		FileURL:        message.FileURL,
+
+		DeliveryFailedAt: message.DeliveryFailedAt,
+	}
+}
+
 func (m *WebSocketManager) sendAcknowledgment(message *models.Message, status models.MessageStatus) {
-	m.mu.RLock()
-	originalSenderClient, exists := m.clients[message.SenderID]
-	m.mu.RUnlock()
+	ackMessage := acknowledgment(message, status)
 
-	ackMessage := &models.Message{
-		ID:          message.ID,
-		SenderID:    message.SenderID,
-		ReceiverID:  message.ReceiverID,
-		EventType:   "acknowledgment",
-		TempID:      message.TempID,
-		Status:      status, // Send the status as acknowledgment type
-		CreatedAt:   time.Now(),
-		Delivered:   message.Delivered,
-		DeliveredAt: message.DeliveredAt,
-		Content:     message.Content,
-		FileURL:     message.FileURL,
-	}
-
-	if exists {
-		// If client is connected, send the acknowledgment over WebSocket
+	// Send the acknowledgment to each of the sender's connected devices over WebSocket
+	sent := false
+	for _, originalSenderClient := range m.devices(message.SenderID) {
 		select {
 		case originalSenderClient.SendCh <- ackMessage:
-			log.Printf("Acknowledgment sent to client %s", message.SenderID)
+			sent = true
 		default:
-			log.Printf("SendCh is full; acknowledgment not sent to client %s", message.SenderID)
-			// Optionally, mark acknowledgment as undelivered in the database for retry
-			if err := m.msgRepo.MarkAcknowledgmentPending(context.Background(), message.ID); err != nil {
-				logging.Logger.Error("Failed to mark acknowledgment as pending", zap.Error(err))
-			}
-		}
-	} else {
-		// If client is offline, store acknowledgment status as pending in the database
-		if err := m.msgRepo.MarkAcknowledgmentPending(context.Background(), message.ID); err != nil {
-			logging.Logger.Error("Failed to mark acknowledgment as pending", zap.Error(err))
+			log.Printf("SendCh is full; acknowledgment not sent to a device of client %s", message.SenderID)
 		}
-		log.Printf("Client %s is offline; acknowledgment stored as pending", message.SenderID)
 	}
+	if sent {
+		return
+	}
+
+	// The sender is offline or unreachable, so keep the acknowledgment for their next connection
+	if err := m.msgRepo.MarkAcknowledgmentPending(context.Background(), message.ID); err != nil {
+		logging.Logger.Error("Failed to mark acknowledgment as pending", zap.Error(err))
+	}
+	log.Printf("Client %s is unreachable; acknowledgment stored as pending", message.SenderID)
 }
 
-// sendPendingMessages retrieves and sends any pending messages to the reconnected client
+// sendPendingMessages sends the reconnected sender the acknowledgments they missed. Each
+// message is acknowledged with its current status, which covers every transition missed.
 func (m *WebSocketManager) sendPendingMessages(client *models.Client) {
-	// Retrieve pending messages for this client
-	messages, err := m.msgRepo.GetPendingAcknowledgments(context.Background(), client.ID)
-
+	messages, err := m.msgRepo.GetPendingAcknowledgments(context.Background(), []string{client.ID}, 0)
 	if err != nil {
 		logging.Logger.Error("Failed to retrieve pending messages", zap.String("client_id", client.ID), zap.Error(err))
 		return
 	}
 
 	for _, message := range messages {
-		// Send each pending message to the client\
-
-		ackMessage := &models.Message{
-			ID:          message.ID,
-			SenderID:    message.SenderID,
-			ReceiverID:  message.ReceiverID,
-			EventType:   "acknowledgment",
-			TempID:      message.TempID,
-			Status:      models.Received, // Send the status as acknowledgment type
-			CreatedAt:   time.Now(),
-			Delivered:   message.Delivered,
-			DeliveredAt: message.DeliveredAt,
-			Content:     message.Content,
-			FileURL:     message.FileURL,
-		}
-		client.SendCh <- ackMessage
-		messageID := message.ID // Assuming message ID is provided in acknowledgment
+		client.SendCh <- acknowledgment(message, message.Status)
 
-		fmt.Println("data", messageID)
-		if err := m.msgRepo.UpdateMessageStatus(context.Background(), messageID, models.Received); err != nil {
-			logging.Logger.Error("Error updating message status", zap.Error(err))
-			continue
+		if err := m.msgRepo.ClearAcknowledgmentPending(context.Background(), message.ID, message.Status); err != nil {
+			logging.Logger.Error("Failed to clear pending acknowledgment", zap.String("client_id", client.ID), zap.Error(err))
 		}
-		// Mark as delivered if sent successfully
-		if err := m.msgRepo.MarkMessageAsDelivered(context.Background(), message.ID); err != nil {
-			logging.Logger.Error("Error marking message as delivered", zap.String("client_id", client.ID), zap.Error(err))
+	}
+}
+
+// ResendAcknowledgment retries a pending acknowledgment, reporting whether a device of the
+// sender took it. The acknowledgment stays pending otherwise.
+func (m *WebSocketManager) ResendAcknowledgment(message *models.Message) bool {
+	ackMessage := acknowledgment(message, message.Status)
+
+	sent := false
+	for _, client := range m.devices(message.SenderID) {
+		select {
+		case client.SendCh <- ackMessage:
+			sent = true
+		default:
 		}
 	}
+	if !sent {
+		return false
+	}
+
+	if err := m.msgRepo.ClearAcknowledgmentPending(context.Background(), message.ID, message.Status); err != nil {
+		logging.Logger.Error("Failed to clear pending acknowledgment", zap.String("client_id", message.SenderID), zap.Error(err))
+	}
+	return true
+}
+
+// NotifyDeliveryFailed tells the sender that redelivery of a message gave up. If the event
+// reaches none of their devices, the acknowledgment is kept pending instead; it carries
+// DeliveryFailedAt.
+func (m *WebSocketManager) NotifyDeliveryFailed(message *models.Message) {
+	sent := m.SendEvent(message.SenderID, &models.Event{
+		EventType: models.EventDeliveryFailed,
+		Data: models.DeliveryFailedData{
+			MessageID:      message.ID.Hex(),
+			ConversationID: message.ConversationID,
+			TempID:         message.TempID,
+			Attempts:       message.DeliveryAttempts,
+			Error:          message.LastDeliveryError,
+		},
+	})
+	if sent {
+		return
+	}
+	if err := m.msgRepo.MarkAcknowledgmentPending(context.Background(), message.ID); err != nil {
+		logging.Logger.Error("Failed to mark acknowledgment as pending", zap.Error(err))
+	}
 }
 
 // processMessage handles the received message and routes it as needed
@@ -0,0 +1,184 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"github.com/dk5761/go-serv/configs"
+	authRepo "github.com/dk5761/go-serv/internal/domain/auth/repository"
+	"github.com/dk5761/go-serv/internal/domain/chat/digest"
+	"github.com/dk5761/go-serv/internal/domain/chat/models"
+	"github.com/dk5761/go-serv/internal/domain/chat/repository"
+	"github.com/dk5761/go-serv/internal/domain/chat/websocket"
+	"github.com/dk5761/go-serv/internal/domain/common"
+	"github.com/dk5761/go-serv/internal/infrastructure/logging"
+	"github.com/dk5761/go-serv/internal/infrastructure/mail"
+)
+
+const (
+	defaultDigestInterval  = 5 * time.Minute
+	defaultDigestBatchSize = 100
+	defaultDigestIdleAfter = 30 * time.Minute
+
+	digestSendTimeout = 30 * time.Second
+)
+
+// DigestSender emails users a summary of their unread conversations at the frequency they
+// chose. Users with a connected device are skipped, as are messages younger than IdleAfter,
+// which the user may still read in the app. A digest only covers conversations with
+// messages since the previous digest.
+type DigestSender struct {
+	settingsRepo    repository.ConversationSettingsRepository
+	preferencesRepo repository.UserPreferencesRepository
+	userRepo        authRepo.UserRepository
+	composer        *digest.Composer
+	mailer          mail.Mailer
+	wsManager       *websocket.WebSocketManager
+	interval        time.Duration
+	batchSize       int
+	idleAfter       time.Duration
+}
+
+func NewDigestSender(
+	settingsRepo repository.ConversationSettingsRepository,
+	preferencesRepo repository.UserPreferencesRepository,
+	userRepo authRepo.UserRepository,
+	composer *digest.Composer,
+	mailer mail.Mailer,
+	wsManager *websocket.WebSocketManager,
+	cfg configs.DigestConfig,
+) *DigestSender {
+	s := &DigestSender{
+		settingsRepo:    settingsRepo,
+		preferencesRepo: preferencesRepo,
+		userRepo:        userRepo,
+		composer:        composer,
+		mailer:          mailer,
+		wsManager:       wsManager,
+		interval:        time.Duration(cfg.Interval) * time.Second,
+		batchSize:       cfg.BatchSize,
+		idleAfter:       time.Duration(cfg.IdleAfter) * time.Second,
+	}
+	if s.interval <= 0 {
+		s.interval = defaultDigestInterval
+	}
+	if s.batchSize <= 0 {
+		s.batchSize = defaultDigestBatchSize
+	}
+	if s.idleAfter <= 0 {
+		s.idleAfter = defaultDigestIdleAfter
+	}
+	return s
+}
+
+// Run sends due digests on every tick until ctx is cancelled
+func (s *DigestSender) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.sendDue(ctx)
+		}
+	}
+}
+
+func (s *DigestSender) sendDue(ctx context.Context) {
+	// Mongo keeps milliseconds, and ReleaseDigest matches the claim time exactly
+	now := time.Now().Truncate(time.Millisecond)
+	idleBefore := now.Add(-s.idleAfter)
+
+	connected := make(map[string]bool)
+	for _, userID := range s.wsManager.ConnectedUsers() {
+		connected[userID] = true
+	}
+
+	after := ""
+	for ctx.Err() == nil {
+		userIDs, err := s.settingsRepo.ListDigestUsers(ctx, idleBefore, after, s.batchSize)
+		if err != nil {
+			logging.Logger.Error("Failed to list users for email digests", zap.Error(err))
+			return
+		}
+
+		for _, userID := range userIDs {
+			if connected[userID] {
+				continue
+			}
+			if err := s.send(ctx, userID, idleBefore, now); err != nil {
+				logging.Logger.Error("Failed to send email digest", zap.String("user_id", userID), zap.Error(err))
+			}
+		}
+
+		if len(userIDs) < s.batchSize {
+			return
+		}
+		after = userIDs[len(userIDs)-1]
+	}
+}
+
+// send emails the user a digest if one is due
+func (s *DigestSender) send(ctx context.Context, userID string, idleBefore, now time.Time) error {
+	preferences, err := s.preferencesRepo.GetPreferences(ctx, userID)
+	if errors.Is(err, common.ErrNotFound) {
+		preferences = &models.UserPreferences{UserID: userID}
+	} else if err != nil {
+		return err
+	}
+
+	interval := preferences.Digest().Interval()
+	if interval == 0 {
+		return nil
+	}
+	var since time.Time
+	if preferences.LastDigestAt != nil {
+		since = *preferences.LastDigestAt
+		if now.Sub(since) < interval {
+			return nil
+		}
+	}
+
+	id, err := uuid.Parse(userID)
+	if err != nil {
+		return nil
+	}
+	user, err := s.userRepo.GetUserByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if user == nil || user.Email == "" || user.IsBot || user.IsSuspended(now) {
+		return nil
+	}
+
+	summary, err := s.composer.Compose(ctx, user, since, idleBefore, now)
+	if err != nil || summary == nil {
+		return err
+	}
+	message, err := summary.Message(user.Email)
+	if err != nil {
+		return err
+	}
+
+	claimed, err := s.preferencesRepo.ClaimDigest(ctx, userID, now.Add(-interval), now)
+	if err != nil || !claimed {
+		return err
+	}
+
+	sendCtx, cancel := context.WithTimeout(ctx, digestSendTimeout)
+	defer cancel()
+	if err := s.mailer.Send(sendCtx, message); err != nil {
+		// Leave the digest due so the next tick tries again
+		if releaseErr := s.preferencesRepo.ReleaseDigest(ctx, userID, now, preferences.LastDigestAt); releaseErr != nil {
+			logging.Logger.Error("Failed to release email digest claim", zap.String("user_id", userID), zap.Error(releaseErr))
+		}
+		return err
+	}
+	return nil
+}
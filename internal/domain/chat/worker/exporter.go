@@ -0,0 +1,144 @@
+package worker
+
+import (
+	"context"
+	"os"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"github.com/dk5761/go-serv/configs"
+	"github.com/dk5761/go-serv/internal/domain/chat/export"
+	"github.com/dk5761/go-serv/internal/domain/chat/models"
+	"github.com/dk5761/go-serv/internal/domain/chat/repository"
+	"github.com/dk5761/go-serv/internal/domain/chat/websocket"
+	"github.com/dk5761/go-serv/internal/infrastructure/logging"
+	"github.com/dk5761/go-serv/internal/infrastructure/storage"
+)
+
+const (
+	defaultExportInterval    = 10 * time.Second
+	defaultExportLease       = 30 * time.Minute
+	defaultExportMaxAttempts = 3
+)
+
+// ExportWorker runs background conversation exports. Each export is written to a temporary
+// file, uploaded through the storage service and announced to the user with an
+// export_finished event.
+type ExportWorker struct {
+	exportRepo     repository.ExportJobRepository
+	exporter       *export.Exporter
+	storageService storage.StorageService
+	wsManager      *websocket.WebSocketManager
+	instanceID     string
+	interval       time.Duration
+	lease          time.Duration
+	maxAttempts    int
+}
+
+func NewExportWorker(exportRepo repository.ExportJobRepository, exporter *export.Exporter, storageService storage.StorageService, wsManager *websocket.WebSocketManager, cfg configs.ExportConfig) *ExportWorker {
+	w := &ExportWorker{
+		exportRepo:     exportRepo,
+		exporter:       exporter,
+		storageService: storageService,
+		wsManager:      wsManager,
+		instanceID:     uuid.NewString(),
+		interval:       time.Duration(cfg.PollInterval) * time.Second,
+		lease:          time.Duration(cfg.LeaseDuration) * time.Second,
+		maxAttempts:    cfg.MaxAttempts,
+	}
+	if w.interval <= 0 {
+		w.interval = defaultExportInterval
+	}
+	if w.lease <= 0 {
+		w.lease = defaultExportLease
+	}
+	if w.maxAttempts <= 0 {
+		w.maxAttempts = defaultExportMaxAttempts
+	}
+	return w
+}
+
+// Run processes queued exports until ctx is cancelled
+func (w *ExportWorker) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			w.runQueued(ctx)
+		}
+	}
+}
+
+func (w *ExportWorker) runQueued(ctx context.Context) {
+	for ctx.Err() == nil {
+		job, err := w.exportRepo.ClaimExportJob(ctx, w.instanceID, time.Now(), w.lease)
+		if err != nil {
+			logging.Logger.Error("Failed to claim export job", zap.Error(err))
+			return
+		}
+		if job == nil {
+			return
+		}
+		w.run(ctx, job)
+	}
+}
+
+func (w *ExportWorker) run(ctx context.Context, job *models.ExportJob) {
+	// A job that keeps losing its lease, e.g. because it crashes the instance, is given up
+	if job.Attempts > w.maxAttempts {
+		w.finish(ctx, job, models.ExportFailed, "", "export did not complete after several attempts")
+		return
+	}
+
+	fileURL, err := w.export(ctx, job)
+	if err != nil {
+		logging.Logger.Error("Failed to export conversation",
+			zap.String("export_id", job.ID.Hex()),
+			zap.String("conversation_id", job.ConversationID),
+			zap.Error(err),
+		)
+		w.finish(ctx, job, models.ExportFailed, "", "export failed")
+		return
+	}
+
+	w.finish(ctx, job, models.ExportDone, fileURL, "")
+}
+
+// export writes the transcript to a temporary file and uploads it
+func (w *ExportWorker) export(ctx context.Context, job *models.ExportJob) (string, error) {
+	file, err := os.CreateTemp("", "export-*")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(file.Name())
+	defer file.Close()
+
+	if err := w.exporter.Export(ctx, file, job.ExportOptions); err != nil {
+		return "", err
+	}
+	if _, err := file.Seek(0, 0); err != nil {
+		return "", err
+	}
+
+	fileName := "exports/" + job.ID.Hex() + "/" + export.FileName(job.ExportOptions)
+	return w.storageService.UploadFile(ctx, file, fileName)
+}
+
+func (w *ExportWorker) finish(ctx context.Context, job *models.ExportJob, status models.ExportStatus, fileURL, lastErr string) {
+	finished, err := w.exportRepo.FinishExportJob(ctx, job.ID, w.instanceID, status, fileURL, lastErr)
+	if err != nil {
+		logging.Logger.Error("Failed to finish export job", zap.String("export_id", job.ID.Hex()), zap.Error(err))
+		return
+	}
+
+	w.wsManager.SendEvent(finished.UserID, &models.Event{
+		EventType: models.EventExportFinished,
+		Data:      finished,
+	})
+}
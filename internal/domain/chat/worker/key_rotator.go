@@ -0,0 +1,96 @@
+package worker
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/dk5761/go-serv/configs"
+	"github.com/dk5761/go-serv/internal/domain/chat/repository"
+	"github.com/dk5761/go-serv/internal/infrastructure/logging"
+)
+
+const (
+	defaultRotationInterval  = 10 * time.Minute
+	defaultRotationBatchSize = 500
+)
+
+// KeyRotator keeps encryption at rest current: after the active master key changes it
+// re-wraps the data keys wrapped by older master keys, and it encrypts documents stored in
+// plaintext before a master key was configured. Once nothing is left wrapped by an old
+// master key in any of the repositories, that key can be dropped from the keyring.
+type KeyRotator struct {
+	repos     []repository.EncryptedRepository
+	interval  time.Duration
+	batchSize int
+}
+
+// NewKeyRotator creates a rotator for every repository that encrypts documents at rest
+func NewKeyRotator(repos []repository.EncryptedRepository, cfg configs.EncryptionConfig) *KeyRotator {
+	k := &KeyRotator{
+		repos:     repos,
+		interval:  time.Duration(cfg.RotationInterval) * time.Second,
+		batchSize: cfg.RotationBatchSize,
+	}
+	if k.interval <= 0 {
+		k.interval = defaultRotationInterval
+	}
+	if k.batchSize <= 0 {
+		k.batchSize = defaultRotationBatchSize
+	}
+	return k
+}
+
+// Run rotates right away, so a new master key takes over soon after a restart, and then
+// on every tick until ctx is cancelled
+func (k *KeyRotator) Run(ctx context.Context) {
+	ticker := time.NewTicker(k.interval)
+	defer ticker.Stop()
+
+	for {
+		k.rotate(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (k *KeyRotator) rotate(ctx context.Context) {
+	rewrapped, sealed := 0, 0
+	for _, repo := range k.repos {
+		n, err := k.drain(ctx, repo.RewrapDataKeys)
+		rewrapped += n
+		if err != nil {
+			logging.Logger.Error("Failed to rewrap data keys", zap.Error(err))
+		}
+		n, err = k.drain(ctx, repo.SealPlaintext)
+		sealed += n
+		if err != nil {
+			logging.Logger.Error("Failed to encrypt plaintext documents", zap.Error(err))
+		}
+	}
+
+	if rewrapped > 0 || sealed > 0 {
+		logging.Logger.Info("Rotated encryption keys",
+			zap.Int("rewrapped", rewrapped),
+			zap.Int("encrypted", sealed),
+		)
+	}
+}
+
+// drain calls step with full batches until it runs out of work
+func (k *KeyRotator) drain(ctx context.Context, step func(context.Context, int) (int, error)) (int, error) {
+	total := 0
+	for ctx.Err() == nil {
+		n, err := step(ctx, k.batchSize)
+		total += n
+		if err != nil || n < k.batchSize {
+			return total, err
+		}
+	}
+	return total, nil
+}
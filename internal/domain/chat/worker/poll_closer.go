@@ -0,0 +1,57 @@
+package worker
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/dk5761/go-serv/configs"
+	"github.com/dk5761/go-serv/internal/domain/chat/service"
+	"github.com/dk5761/go-serv/internal/infrastructure/logging"
+)
+
+const (
+	defaultPollCloserInterval  = 15 * time.Second
+	defaultPollCloserBatchSize = 100
+)
+
+// PollCloser closes polls once their close time passes, freezing the results and
+// notifying participants.
+type PollCloser struct {
+	pollService service.PollService
+	interval    time.Duration
+	batchSize   int
+}
+
+func NewPollCloser(pollService service.PollService, cfg configs.PollCloserConfig) *PollCloser {
+	p := &PollCloser{
+		pollService: pollService,
+		interval:    time.Duration(cfg.Interval) * time.Second,
+		batchSize:   cfg.BatchSize,
+	}
+	if p.interval <= 0 {
+		p.interval = defaultPollCloserInterval
+	}
+	if p.batchSize <= 0 {
+		p.batchSize = defaultPollCloserBatchSize
+	}
+	return p
+}
+
+// Run closes due polls until ctx is cancelled
+func (p *PollCloser) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := p.pollService.CloseDuePolls(ctx, p.batchSize); err != nil {
+				logging.Logger.Error("Failed to close due polls", zap.Error(err))
+			}
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package worker
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/dk5761/go-serv/configs"
+	"github.com/dk5761/go-serv/internal/domain/chat/service"
+	"github.com/dk5761/go-serv/internal/infrastructure/logging"
+)
+
+const defaultPurgeInterval = time.Hour
+
+// Purger periodically deletes the messages and attachments that fall outside the retention
+// policies. In dry-run mode it only logs what it would delete.
+type Purger struct {
+	retentionService service.RetentionService
+	interval         time.Duration
+	dryRun           bool
+}
+
+func NewPurger(retentionService service.RetentionService, cfg configs.RetentionConfig) *Purger {
+	p := &Purger{
+		retentionService: retentionService,
+		interval:         time.Duration(cfg.Interval) * time.Second,
+		dryRun:           cfg.DryRun,
+	}
+	if p.interval <= 0 {
+		p.interval = defaultPurgeInterval
+	}
+	return p
+}
+
+// Run purges on every tick until ctx is cancelled
+func (p *Purger) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			p.purge(ctx)
+		}
+	}
+}
+
+func (p *Purger) purge(ctx context.Context) {
+	report, err := p.retentionService.Purge(ctx, p.dryRun)
+	if err != nil {
+		logging.Logger.Error("Failed to purge expired messages", zap.Error(err))
+		return
+	}
+	if report.Messages == 0 {
+		return
+	}
+
+	logging.Logger.Info("Purged expired messages",
+		zap.Bool("dry_run", report.DryRun),
+		zap.Int64("messages", report.Messages),
+		zap.Int64("attachments", report.Attachments),
+		zap.Int("conversations", len(report.Conversations)),
+		zap.Duration("took", report.FinishedAt.Sub(report.StartedAt)),
+	)
+}
@@ -0,0 +1,166 @@
+package worker
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/dk5761/go-serv/configs"
+	"github.com/dk5761/go-serv/internal/domain/chat/models"
+	"github.com/dk5761/go-serv/internal/domain/chat/repository"
+	"github.com/dk5761/go-serv/internal/domain/chat/websocket"
+	"github.com/dk5761/go-serv/internal/infrastructure/logging"
+)
+
+const (
+	defaultRedeliveryInterval    = 15 * time.Second
+	defaultRedeliveryBatchSize   = 100
+	defaultRedeliveryMaxAttempts = 8
+	defaultRedeliveryBaseBackoff = 5 * time.Second
+	defaultRedeliveryMaxBackoff  = 10 * time.Minute
+	defaultRedeliveryGracePeriod = 30 * time.Second
+
+	// redeliveryLease keeps other attempts off a claimed message while it is being delivered
+	redeliveryLease = 30 * time.Second
+)
+
+// Redeliverer retries what the live send path could not get through to users who are
+// connected now, instead of waiting for them to reconnect: messages stuck in stored for a
+// connected receiver, and pending acknowledgments for a connected sender.
+//
+// Failed deliveries are retried with exponential backoff. After MaxAttempts the delivery
+// is marked as permanently failed and the sender is notified.
+type Redeliverer struct {
+	msgRepo     repository.MessageRepository
+	wsManager   *websocket.WebSocketManager
+	interval    time.Duration
+	batchSize   int
+	maxAttempts int
+	baseBackoff time.Duration
+	maxBackoff  time.Duration
+	gracePeriod time.Duration
+}
+
+func NewRedeliverer(msgRepo repository.MessageRepository, wsManager *websocket.WebSocketManager, cfg configs.RedeliveryConfig) *Redeliverer {
+	r := &Redeliverer{
+		msgRepo:     msgRepo,
+		wsManager:   wsManager,
+		interval:    time.Duration(cfg.Interval) * time.Second,
+		batchSize:   cfg.BatchSize,
+		maxAttempts: cfg.MaxAttempts,
+		baseBackoff: time.Duration(cfg.BaseBackoff) * time.Second,
+		maxBackoff:  time.Duration(cfg.MaxBackoff) * time.Second,
+		gracePeriod: time.Duration(cfg.GracePeriod) * time.Second,
+	}
+	if r.interval <= 0 {
+		r.interval = defaultRedeliveryInterval
+	}
+	if r.batchSize <= 0 {
+		r.batchSize = defaultRedeliveryBatchSize
+	}
+	if r.maxAttempts <= 0 {
+		r.maxAttempts = defaultRedeliveryMaxAttempts
+	}
+	if r.baseBackoff <= 0 {
+		r.baseBackoff = defaultRedeliveryBaseBackoff
+	}
+	if r.maxBackoff <= 0 {
+		r.maxBackoff = defaultRedeliveryMaxBackoff
+	}
+	if r.gracePeriod <= 0 {
+		r.gracePeriod = defaultRedeliveryGracePeriod
+	}
+	return r
+}
+
+// Run retries on every tick until ctx is cancelled
+func (r *Redeliverer) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			r.redeliver(ctx)
+		}
+	}
+}
+
+func (r *Redeliverer) redeliver(ctx context.Context) {
+	connected := r.wsManager.ConnectedUsers()
+	if len(connected) == 0 {
+		return
+	}
+
+	r.resendAcknowledgments(ctx, connected)
+
+	// Work through at most one batch per tick, so that a receiver whose devices never take
+	// a message cannot keep the worker busy
+	for i := 0; i < r.batchSize && ctx.Err() == nil; i++ {
+		now := time.Now()
+		message, err := r.msgRepo.ClaimRedelivery(ctx, connected, now, now.Add(-r.gracePeriod), redeliveryLease)
+		if err != nil {
+			logging.Logger.Error("Failed to claim message for redelivery", zap.Error(err))
+			return
+		}
+		if message == nil {
+			return
+		}
+		r.attempt(ctx, message)
+	}
+}
+
+func (r *Redeliverer) attempt(ctx context.Context, message *models.Message) {
+	err := r.wsManager.Redeliver(message)
+	if err == nil {
+		return
+	}
+
+	failed := message.DeliveryAttempts >= r.maxAttempts
+	logging.Logger.Warn("Failed to redeliver message",
+		zap.String("message_id", message.ID.Hex()),
+		zap.Int("attempts", message.DeliveryAttempts),
+		zap.Bool("failed", failed),
+		zap.Error(err),
+	)
+
+	retryAt := time.Now().Add(r.backoff(message.DeliveryAttempts))
+	if err := r.msgRepo.RecordDeliveryFailure(ctx, message.ID, err.Error(), retryAt, failed); err != nil {
+		logging.Logger.Error("Failed to record delivery failure", zap.Error(err))
+		return
+	}
+
+	if failed {
+		message.LastDeliveryError = err.Error()
+		message.DeliveryFailedAt = time.Now()
+		r.wsManager.NotifyDeliveryFailed(message)
+	}
+}
+
+// backoff is the delay before the attempt after the given one: BaseBackoff doubled for
+// every failed attempt, up to MaxBackoff
+func (r *Redeliverer) backoff(attempts int) time.Duration {
+	delay := r.baseBackoff
+	for i := 1; i < attempts && delay < r.maxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > r.maxBackoff {
+		delay = r.maxBackoff
+	}
+	return delay
+}
+
+func (r *Redeliverer) resendAcknowledgments(ctx context.Context, connected []string) {
+	messages, err := r.msgRepo.GetPendingAcknowledgments(ctx, connected, r.batchSize)
+	if err != nil {
+		logging.Logger.Error("Failed to fetch pending acknowledgments", zap.Error(err))
+		return
+	}
+
+	for _, message := range messages {
+		r.wsManager.ResendAcknowledgment(message)
+	}
+}
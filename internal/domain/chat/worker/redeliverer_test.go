@@ -0,0 +1,37 @@
+package worker
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dk5761/go-serv/configs"
+)
+
+func TestRedelivererBackoff(t *testing.T) {
+	r := NewRedeliverer(nil, nil, configs.RedeliveryConfig{BaseBackoff: 5, MaxBackoff: 60})
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{0, 5 * time.Second},
+		{1, 5 * time.Second},
+		{2, 10 * time.Second},
+		{3, 20 * time.Second},
+		{4, 40 * time.Second},
+		{5, 60 * time.Second},
+		{100, 60 * time.Second},
+	}
+
+	for _, tt := range tests {
+		if got := r.backoff(tt.attempts); got != tt.want {
+			t.Errorf("backoff(%d): got %v, want %v", tt.attempts, got, tt.want)
+		}
+	}
+}
+
+func TestRedelivererBackoffBaseAboveMax(t *testing.T) {
+	r := NewRedeliverer(nil, nil, configs.RedeliveryConfig{BaseBackoff: 120, MaxBackoff: 60})
+	if got := r.backoff(1); got != 60*time.Second {
+		t.Errorf("got %v, want %v", got, 60*time.Second)
+	}
+}
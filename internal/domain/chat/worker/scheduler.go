@@ -0,0 +1,122 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+
+	"github.com/dk5761/go-serv/configs"
+	"github.com/dk5761/go-serv/internal/domain/chat/models"
+	"github.com/dk5761/go-serv/internal/domain/chat/moderation"
+	"github.com/dk5761/go-serv/internal/domain/chat/repository"
+	"github.com/dk5761/go-serv/internal/domain/chat/websocket"
+	"github.com/dk5761/go-serv/internal/infrastructure/logging"
+)
+
+const (
+	defaultSchedulerInterval    = 5 * time.Second
+	defaultSchedulerLease       = 30 * time.Second
+	defaultSchedulerMaxAttempts = 5
+)
+
+// Scheduler dispatches scheduled messages once their send time has passed.
+//
+// Several server instances may run a Scheduler against the same database. Each due message
+// is leased to a single instance before it is dispatched, and the dispatched message reuses
+// the scheduled message's ID so a retry after a crash can never persist it twice.
+type Scheduler struct {
+	scheduledRepo repository.ScheduledMessageRepository
+	wsManager     *websocket.WebSocketManager
+	instanceID    string
+	interval      time.Duration
+	lease         time.Duration
+	maxAttempts   int
+}
+
+func NewScheduler(scheduledRepo repository.ScheduledMessageRepository, wsManager *websocket.WebSocketManager, cfg configs.SchedulerConfig) *Scheduler {
+	s := &Scheduler{
+		scheduledRepo: scheduledRepo,
+		wsManager:     wsManager,
+		instanceID:    uuid.NewString(),
+		interval:      time.Duration(cfg.PollInterval) * time.Second,
+		lease:         time.Duration(cfg.LeaseDuration) * time.Second,
+		maxAttempts:   cfg.MaxAttempts,
+	}
+	if s.interval <= 0 {
+		s.interval = defaultSchedulerInterval
+	}
+	if s.lease <= 0 {
+		s.lease = defaultSchedulerLease
+	}
+	if s.maxAttempts <= 0 {
+		s.maxAttempts = defaultSchedulerMaxAttempts
+	}
+	return s
+}
+
+// Run polls for due messages until ctx is cancelled
+func (s *Scheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.dispatchDue(ctx)
+		}
+	}
+}
+
+// dispatchDue drains every message that is currently due
+func (s *Scheduler) dispatchDue(ctx context.Context) {
+	for ctx.Err() == nil {
+		scheduled, err := s.scheduledRepo.ClaimDueMessage(ctx, s.instanceID, time.Now(), s.lease)
+		if err != nil {
+			logging.Logger.Error("Failed to claim scheduled message", zap.Error(err))
+			return
+		}
+		if scheduled == nil {
+			return
+		}
+		s.dispatch(ctx, scheduled)
+	}
+}
+
+func (s *Scheduler) dispatch(ctx context.Context, scheduled *models.ScheduledMessage) {
+	message := &models.Message{
+		ID:         scheduled.ID,
+		SenderID:   scheduled.SenderID,
+		ReceiverID: scheduled.ReceiverID,
+		Content:    scheduled.Content,
+		FileURL:    scheduled.FileURL,
+	}
+
+	err := s.wsManager.DispatchMessage(ctx, message)
+	if err != nil && !mongo.IsDuplicateKeyError(err) {
+		// Retrying cannot succeed while the sender has the receiver blocked or
+		// moderation rejects the content
+		var rejected *moderation.RejectedError
+		failed := scheduled.Attempts >= s.maxAttempts || errors.Is(err, websocket.ErrRecipientBlocked) || errors.As(err, &rejected)
+		logging.Logger.Error("Failed to dispatch scheduled message",
+			zap.String("scheduled_id", scheduled.ID.Hex()),
+			zap.Int("attempts", scheduled.Attempts),
+			zap.Bool("failed", failed),
+			zap.Error(err),
+		)
+		if err := s.scheduledRepo.ReleaseScheduledMessage(ctx, scheduled.ID, s.instanceID, err.Error(), failed); err != nil {
+			logging.Logger.Error("Failed to release scheduled message", zap.Error(err))
+		}
+		return
+	}
+
+	// A duplicate key means an earlier attempt already persisted the message
+	if err := s.scheduledRepo.MarkScheduledSent(ctx, scheduled.ID, s.instanceID); err != nil {
+		logging.Logger.Error("Failed to mark scheduled message as sent", zap.Error(err))
+	}
+}
@@ -0,0 +1,124 @@
+package worker
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/dk5761/go-serv/configs"
+	"github.com/dk5761/go-serv/internal/domain/chat/models"
+	"github.com/dk5761/go-serv/internal/domain/chat/repository"
+	"github.com/dk5761/go-serv/internal/domain/chat/websocket"
+	"github.com/dk5761/go-serv/internal/infrastructure/logging"
+	"github.com/dk5761/go-serv/internal/infrastructure/storage"
+)
+
+const (
+	defaultSweeperInterval  = 30 * time.Second
+	defaultSweeperBatchSize = 100
+)
+
+// Sweeper deletes disappearing messages once they expire, together with their attachments,
+// and tells both participants with a message_expired event.
+//
+// The messages collection also carries a TTL index on expires_at with a grace period, which
+// only acts as a backstop if the sweeper falls behind.
+type Sweeper struct {
+	msgRepo        repository.MessageRepository
+	attachmentRepo repository.AttachmentRepository
+	storageService storage.StorageService
+	wsManager      *websocket.WebSocketManager
+	interval       time.Duration
+	batchSize      int
+}
+
+func NewSweeper(msgRepo repository.MessageRepository, attachmentRepo repository.AttachmentRepository, storageService storage.StorageService, wsManager *websocket.WebSocketManager, cfg configs.SweeperConfig) *Sweeper {
+	s := &Sweeper{
+		msgRepo:        msgRepo,
+		attachmentRepo: attachmentRepo,
+		storageService: storageService,
+		wsManager:      wsManager,
+		interval:       time.Duration(cfg.Interval) * time.Second,
+		batchSize:      cfg.BatchSize,
+	}
+	if s.interval <= 0 {
+		s.interval = defaultSweeperInterval
+	}
+	if s.batchSize <= 0 {
+		s.batchSize = defaultSweeperBatchSize
+	}
+	return s
+}
+
+// Run sweeps expired messages until ctx is cancelled
+func (s *Sweeper) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.sweep(ctx)
+		}
+	}
+}
+
+func (s *Sweeper) sweep(ctx context.Context) {
+	for ctx.Err() == nil {
+		messages, err := s.msgRepo.GetExpiredMessages(ctx, time.Now(), s.batchSize)
+		if err != nil {
+			logging.Logger.Error("Failed to fetch expired messages", zap.Error(err))
+			return
+		}
+
+		for _, message := range messages {
+			s.expire(ctx, message)
+		}
+
+		if len(messages) < s.batchSize {
+			return
+		}
+	}
+}
+
+func (s *Sweeper) expire(ctx context.Context, message *models.Message) {
+	deleted, err := s.msgRepo.DeleteMessage(ctx, message.ID)
+	if err != nil {
+		logging.Logger.Error("Failed to delete expired message", zap.String("message_id", message.ID.Hex()), zap.Error(err))
+		return
+	}
+	if !deleted {
+		// Another instance got there first and owns the cleanup
+		return
+	}
+
+	if message.FileURL != "" {
+		if err := s.storageService.DeleteFile(ctx, message.FileURL); err != nil {
+			logging.Logger.Error("Failed to delete attachment of expired message",
+				zap.String("message_id", message.ID.Hex()),
+				zap.String("file_url", message.FileURL),
+				zap.Error(err),
+			)
+		}
+		if err := s.attachmentRepo.DeleteAttachment(ctx, message.FileURL); err != nil {
+			logging.Logger.Error("Failed to delete attachment record of expired message",
+				zap.String("message_id", message.ID.Hex()),
+				zap.String("file_url", message.FileURL),
+				zap.Error(err),
+			)
+		}
+	}
+
+	event := &models.Event{
+		EventType: models.EventMessageExpired,
+		Data: models.MessageExpiredData{
+			MessageID:      message.ID.Hex(),
+			ConversationID: message.ConversationID,
+		},
+	}
+	s.wsManager.SendEvent(message.SenderID, event)
+	s.wsManager.SendEvent(message.ReceiverID, event)
+}
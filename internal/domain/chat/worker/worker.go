@@ -0,0 +1,8 @@
+package worker
+
+import "context"
+
+// Worker is a background job started alongside the HTTP server. Run blocks until ctx is cancelled.
+type Worker interface {
+	Run(ctx context.Context)
+}
@@ -2,22 +2,59 @@ package container
 
 import (
 	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
 	"github.com/jackc/pgx/v4/pgxpool"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
 
 	"github.com/dk5761/go-serv/configs"
 	"github.com/dk5761/go-serv/internal/domain/auth"
 	authHandler "github.com/dk5761/go-serv/internal/domain/auth/handler"
 	"github.com/dk5761/go-serv/internal/domain/chat"
+	"github.com/dk5761/go-serv/internal/domain/chat/bot"
+	"github.com/dk5761/go-serv/internal/domain/chat/command"
+	"github.com/dk5761/go-serv/internal/domain/chat/digest"
+	"github.com/dk5761/go-serv/internal/domain/chat/export"
 	chatHandler "github.com/dk5761/go-serv/internal/domain/chat/handler"
+	"github.com/dk5761/go-serv/internal/domain/chat/importer"
+	"github.com/dk5761/go-serv/internal/domain/chat/mention"
+	chatModels "github.com/dk5761/go-serv/internal/domain/chat/models"
+	"github.com/dk5761/go-serv/internal/domain/chat/moderation"
+	"github.com/dk5761/go-serv/internal/domain/chat/push"
 	"github.com/dk5761/go-serv/internal/domain/chat/repository"
+	chatService "github.com/dk5761/go-serv/internal/domain/chat/service"
 	"github.com/dk5761/go-serv/internal/domain/chat/websocket"
+	"github.com/dk5761/go-serv/internal/domain/chat/worker"
+	"github.com/dk5761/go-serv/internal/infrastructure/encryption"
+	"github.com/dk5761/go-serv/internal/infrastructure/logging"
+	"github.com/dk5761/go-serv/internal/infrastructure/mail"
 	"github.com/dk5761/go-serv/internal/infrastructure/storage"
 )
 
 type Container struct {
-	AuthHandler *authHandler.AuthHandler
-	ChatHandler *chatHandler.ChatHandler
+	AuthHandler         *authHandler.AuthHandler
+	BlockHandler        *authHandler.BlockHandler
+	KeyHandler          *authHandler.KeyHandler
+	BotHandler          *authHandler.BotHandler
+	ChatHandler         *chatHandler.ChatHandler
+	ScheduledHandler    *chatHandler.ScheduledHandler
+	ConversationHandler *chatHandler.ConversationHandler
+	DraftHandler        *chatHandler.DraftHandler
+	PollHandler         *chatHandler.PollHandler
+	InboxHandler        *chatHandler.InboxHandler
+	ExportHandler       *chatHandler.ExportHandler
+	ImportHandler       *chatHandler.ImportHandler
+	RetentionHandler    *chatHandler.RetentionHandler
+	ModerationHandler   *chatHandler.ModerationHandler
+	ReportHandler       *chatHandler.ReportHandler
+	DeviceHandler       *chatHandler.DeviceHandler
+	DigestHandler       *chatHandler.DigestHandler
+	CommandHandler      *chatHandler.CommandHandler
+	CallHandler         *chatHandler.CallHandler
+	WebhookHandler      *chatHandler.WebhookHandler
+
+	// Workers are started by main alongside the HTTP server
+	Workers []worker.Worker
 }
 
 func NewContainer(
@@ -25,18 +62,132 @@ func NewContainer(
 	mongoDB *mongo.Database,
 	cacheClient *redis.Client,
 	storageService storage.StorageService,
+	keyring *encryption.Keyring,
 	config *configs.Config,
 ) *Container {
 
-	chatRepo := repository.NewMongoMessageRepository(mongoDB)
-	wsManager := websocket.NewWebSocketManager(chatRepo)
+	chatRepo := repository.NewMongoMessageRepository(mongoDB, keyring)
+	scheduledRepo := repository.NewMongoScheduledMessageRepository(mongoDB, keyring)
+	conversationRepo := repository.NewMongoConversationRepository(mongoDB)
+	draftRepo := repository.NewMongoDraftRepository(mongoDB, keyring)
+	pollRepo := repository.NewMongoPollRepository(mongoDB)
+	settingsRepo := repository.NewMongoConversationSettingsRepository(mongoDB)
+	preferencesRepo := repository.NewMongoUserPreferencesRepository(mongoDB)
+	exportRepo := repository.NewMongoExportJobRepository(mongoDB)
+	auditRepo := repository.NewMongoAuditRepository(mongoDB)
+	moderationRepo := repository.NewMongoModerationRepository(mongoDB, keyring)
+	reportRepo := repository.NewMongoReportRepository(mongoDB, keyring)
+	deviceRepo := repository.NewMongoDeviceRepository(mongoDB)
+	attachmentRepo := repository.NewMongoAttachmentRepository(mongoDB)
+	webhookRepo := repository.NewMongoWebhookRepository(mongoDB)
 
 	// Initialize Repositories
 	authHandlerInit := auth.NewAuthHandler(db, config)
-	chatHandlerInit := chat.NewChatHandler(mongoDB, config, wsManager)
+	blockHandlerInit := auth.NewBlockHandler(db, authHandlerInit.UserRepo)
+	botHandlerInit := auth.NewBotHandler(db, authHandlerInit.UserRepo)
+
+	mentionResolver := mention.NewResolver(authHandlerInit.UserRepo)
+	moderator := moderation.NewPipelineFromConfig(config.Moderation, moderationRepo)
+	commands := command.NewRegistry()
+	botDispatcher := bot.NewDispatcher(botHandlerInit.BotRepo, config.Bot)
+	pusher := push.NewNotifierFromConfig(config.Push, deviceRepo, settingsRepo, authHandlerInit.UserRepo)
+	wsManager := websocket.NewWebSocketManager(chatRepo, conversationRepo, draftRepo, attachmentRepo, settingsRepo, preferencesRepo, mentionResolver, blockHandlerInit.BlockRepo, authHandlerInit.UserRepo, moderator, pusher, botDispatcher, commands)
+	chatHandlerInit := chat.NewChatHandler(chatRepo, attachmentRepo, config, wsManager)
+	keyHandlerInit := auth.NewKeyHandler(db, blockHandlerInit.BlockRepo, func(userID uuid.UUID, remaining int) {
+		wsManager.SendEvent(userID.String(), &chatModels.Event{
+			EventType: chatModels.EventPrekeysLow,
+			Data:      chatModels.PrekeysLowData{Remaining: remaining},
+		})
+	})
+	scheduledHandlerInit := chat.NewScheduledHandler(scheduledRepo, storageService)
+	conversationHandlerInit := chat.NewConversationHandler(conversationRepo, wsManager)
+	draftHandlerInit := chat.NewDraftHandler(draftRepo, wsManager)
+	inboxHandlerInit := chat.NewInboxHandler(settingsRepo, preferencesRepo, wsManager)
+	deviceHandlerInit := chat.NewDeviceHandler(deviceRepo)
+
+	exporter := export.NewExporter(chatRepo, authHandlerInit.UserRepo, storageService)
+	exportService := chatService.NewExportService(chatRepo, exportRepo, exporter, config.Export.MaxSyncMessages)
+	exportHandlerInit := chatHandler.NewExportHandler(exportService)
+
+	transcriptImporter := importer.NewImporter(chatRepo, settingsRepo, authHandlerInit.UserRepo)
+	importHandlerInit := chatHandler.NewImportHandler(transcriptImporter)
+
+	retentionService := chatService.NewRetentionService(chatRepo, conversationRepo, auditRepo, attachmentRepo, storageService, wsManager, config.Retention)
+	retentionHandlerInit := chatHandler.NewRetentionHandler(retentionService)
+
+	moderationHandlerInit := chatHandler.NewModerationHandler(chatService.NewModerationService(moderationRepo))
+
+	reportService := chatService.NewReportService(reportRepo, chatRepo, auditRepo, authHandlerInit.UserRepo, storageService, wsManager)
+	reportHandlerInit := chatHandler.NewReportHandler(reportService)
+
+	pollService := chatService.NewPollService(chatRepo, pollRepo, blockHandlerInit.BlockRepo, wsManager)
+	pollHandlerInit := chatHandler.NewPollHandler(pollService, wsManager)
+
+	unsubscribeSecret := config.Digest.UnsubscribeSecret
+	if unsubscribeSecret == "" {
+		unsubscribeSecret = config.JWT.SecretKey
+	}
+	inboxService := chatService.NewInboxService(settingsRepo, preferencesRepo, wsManager)
+	commandService := chatService.NewCommandService(commands, inboxService, botHandlerInit.BotRepo, authHandlerInit.UserRepo, blockHandlerInit.BlockRepo, wsManager)
+	commandHandlerInit := chatHandler.NewCommandHandler(commandService)
+
+	callService := chatService.NewCallService(blockHandlerInit.BlockRepo, wsManager, config.Call)
+	callHandlerInit := chatHandler.NewCallHandler(callService, wsManager)
+
+	webhookService := chatService.NewWebhookService(webhookRepo, authHandlerInit.UserRepo, wsManager, config.Webhook)
+	webhookHandlerInit := chatHandler.NewWebhookHandler(webhookService)
+
+	digestSigner := digest.NewSigner(unsubscribeSecret, config.Digest.BaseURL)
+	digestHandlerInit := chatHandler.NewDigestHandler(chatService.NewDigestService(preferencesRepo, digestSigner))
+
+	// Initialize background workers
+	workers := []worker.Worker{
+		worker.NewScheduler(scheduledRepo, wsManager, config.Scheduler),
+		worker.NewSweeper(chatRepo, attachmentRepo, storageService, wsManager, config.Sweeper),
+		worker.NewPollCloser(pollService, config.PollCloser),
+		worker.NewExportWorker(exportRepo, exporter, storageService, wsManager, config.Export),
+		worker.NewPurger(retentionService, config.Retention),
+		worker.NewRedeliverer(chatRepo, wsManager, config.Redelivery),
+		botDispatcher,
+	}
+	if keyring != nil {
+		encryptedRepos := []repository.EncryptedRepository{chatRepo, scheduledRepo, draftRepo, moderationRepo, reportRepo}
+		workers = append(workers, worker.NewKeyRotator(encryptedRepos, config.Encryption))
+	}
+	if pusher != nil {
+		workers = append(workers, pusher)
+	}
+	if config.Mail.Host != "" {
+		mailer, err := mail.NewSMTPMailer(config.Mail)
+		if err != nil {
+			logging.Logger.Error("Failed to set up the mailer, email digests are off", zap.Error(err))
+		} else {
+			composer := digest.NewComposer(chatRepo, settingsRepo, authHandlerInit.UserRepo, digestSigner, config.Digest.MaxConversations)
+			workers = append(workers, worker.NewDigestSender(settingsRepo, preferencesRepo, authHandlerInit.UserRepo, composer, mailer, wsManager, config.Digest))
+		}
+	}
 
 	return &Container{
-		AuthHandler: authHandlerInit,
-		ChatHandler: chatHandlerInit,
+		AuthHandler:         authHandlerInit,
+		BlockHandler:        blockHandlerInit,
+		KeyHandler:          keyHandlerInit,
+		BotHandler:          botHandlerInit,
+		ChatHandler:         chatHandlerInit,
+		ScheduledHandler:    scheduledHandlerInit,
+		ConversationHandler: conversationHandlerInit,
+		DraftHandler:        draftHandlerInit,
+		PollHandler:         pollHandlerInit,
+		InboxHandler:        inboxHandlerInit,
+		ExportHandler:       exportHandlerInit,
+		ImportHandler:       importHandlerInit,
+		RetentionHandler:    retentionHandlerInit,
+		ModerationHandler:   moderationHandlerInit,
+		ReportHandler:       reportHandlerInit,
+		DeviceHandler:       deviceHandlerInit,
+		DigestHandler:       digestHandlerInit,
+		CommandHandler:      commandHandlerInit,
+		CallHandler:         callHandlerInit,
+		WebhookHandler:      webhookHandlerInit,
+		Workers:             workers,
 	}
 }
@@ -0,0 +1,173 @@
+// Package encryption implements envelope encryption: every record is encrypted with its own
+// random data key, and the data key is stored next to the record wrapped by a master key.
+// Rotating the master key only re-wraps data keys; records are never re-encrypted.
+package encryption
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/dk5761/go-serv/configs"
+)
+
+// KeySize is the size of master and data keys, for AES-256
+const KeySize = 32
+
+const defaultMasterKeyID = "config"
+
+// ErrUnknownKey is returned for data keys wrapped by a master key the keyring does not hold.
+var ErrUnknownKey = errors.New("unknown master key")
+
+// Keyring holds the master keys. New data keys are wrapped by the active one; the others
+// are kept to unwrap data keys that have not been re-wrapped yet.
+type Keyring struct {
+	keys   map[string][]byte
+	active string
+}
+
+func NewKeyring(active string, keys map[string][]byte) (*Keyring, error) {
+	if _, ok := keys[active]; !ok {
+		return nil, fmt.Errorf("active master key %q is not in the keyring", active)
+	}
+	for id, key := range keys {
+		if len(key) != KeySize {
+			return nil, fmt.Errorf("master key %q must be %d bytes, got %d", id, KeySize, len(key))
+		}
+	}
+	return &Keyring{keys: keys, active: active}, nil
+}
+
+// keyFile is the format of a local keyfile:
+//
+//	{"active": "2024-11", "keys": {"2024-05": "<base64>", "2024-11": "<base64>"}}
+type keyFile struct {
+	Active string            `json:"active"`
+	Keys   map[string]string `json:"keys"`
+}
+
+// LoadKeyring reads the master keys from the keyfile and the configured master key. The
+// keyfile's active key wins over the configured one. It returns nil when no master key is
+// configured, in which case records are stored in plaintext.
+func LoadKeyring(cfg configs.EncryptionConfig) (*Keyring, error) {
+	keys := make(map[string][]byte)
+	active := ""
+
+	if cfg.MasterKey != "" {
+		key, err := base64.StdEncoding.DecodeString(cfg.MasterKey)
+		if err != nil {
+			return nil, fmt.Errorf("master key is not valid base64: %w", err)
+		}
+		active = cfg.MasterKeyID
+		if active == "" {
+			active = defaultMasterKeyID
+		}
+		keys[active] = key
+	}
+
+	if cfg.KeyFile != "" {
+		data, err := os.ReadFile(cfg.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read keyfile: %w", err)
+		}
+		var file keyFile
+		if err := json.Unmarshal(data, &file); err != nil {
+			return nil, fmt.Errorf("invalid keyfile: %w", err)
+		}
+		for id, encoded := range file.Keys {
+			key, err := base64.StdEncoding.DecodeString(encoded)
+			if err != nil {
+				return nil, fmt.Errorf("keyfile key %q is not valid base64: %w", id, err)
+			}
+			keys[id] = key
+		}
+		active = file.Active
+	}
+
+	if len(keys) == 0 {
+		return nil, nil
+	}
+	return NewKeyring(active, keys)
+}
+
+// ActiveKeyID names the master key that wraps new data keys
+func (k *Keyring) ActiveKeyID() string {
+	return k.active
+}
+
+// GenerateDataKey returns a new data key, the same key wrapped by the active master key,
+// and the ID of that master key.
+func (k *Keyring) GenerateDataKey() ([]byte, []byte, string, error) {
+	dataKey := make([]byte, KeySize)
+	if _, err := io.ReadFull(rand.Reader, dataKey); err != nil {
+		return nil, nil, "", err
+	}
+	wrapped, err := Seal(k.keys[k.active], dataKey, []byte(k.active))
+	if err != nil {
+		return nil, nil, "", err
+	}
+	return dataKey, wrapped, k.active, nil
+}
+
+// UnwrapDataKey recovers a data key wrapped by the master key keyID
+func (k *Keyring) UnwrapDataKey(keyID string, wrapped []byte) ([]byte, error) {
+	masterKey, ok := k.keys[keyID]
+	if !ok {
+		return nil, fmt.Errorf("%w %q", ErrUnknownKey, keyID)
+	}
+	return Open(masterKey, wrapped, []byte(keyID))
+}
+
+// RewrapDataKey wraps a data key wrapped by keyID with the active master key instead
+func (k *Keyring) RewrapDataKey(keyID string, wrapped []byte) ([]byte, string, error) {
+	dataKey, err := k.UnwrapDataKey(keyID, wrapped)
+	if err != nil {
+		return nil, "", err
+	}
+	rewrapped, err := Seal(k.keys[k.active], dataKey, []byte(k.active))
+	if err != nil {
+		return nil, "", err
+	}
+	return rewrapped, k.active, nil
+}
+
+// Seal encrypts plaintext with AES-256-GCM. The nonce is prepended to the ciphertext, and
+// additionalData binds the ciphertext to its context without being stored in it.
+func Seal(key, plaintext, additionalData []byte) ([]byte, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, plaintext, additionalData), nil
+}
+
+// Open decrypts a ciphertext produced by Seal with the same key and additional data
+func Open(key, sealed, additionalData []byte) ([]byte, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < aead.NonceSize() {
+		return nil, errors.New("ciphertext too short")
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	return aead.Open(nil, nonce, ciphertext, additionalData)
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,18 @@
+// Package mail sends email. Mailer is implemented by SMTPMailer; tests can point it at a
+// local SMTP sink such as MailHog or smtp4dev.
+package mail
+
+import "context"
+
+// Message is an email with a plain text body and an optional HTML alternative.
+type Message struct {
+	To      string
+	Subject string
+	Text    string
+	HTML    string
+	Headers map[string]string // Extra headers, e.g. List-Unsubscribe
+}
+
+type Mailer interface {
+	Send(ctx context.Context, message *Message) error
+}
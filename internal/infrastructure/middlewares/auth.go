@@ -5,7 +5,9 @@ import (
 	"github.com/gorilla/websocket"
 	"net/http"
 	"strings"
+	"time"
 
+	authModels "github.com/dk5761/go-serv/internal/domain/auth/models"
 	authRepo "github.com/dk5761/go-serv/internal/domain/auth/repository"
 	authService "github.com/dk5761/go-serv/internal/domain/auth/service"
 	"github.com/dk5761/go-serv/internal/infrastructure/logging"
@@ -15,13 +17,17 @@ import (
 
 func JWTAuthMiddleware(jwtService authService.JWTService, userRepo authRepo.UserRepository) gin.HandlerFunc {
 	return func(c *gin.Context) {
-
-		if websocket.IsWebSocketUpgrade(c.Request) {
+		// Already authenticated with a bot token
+		if _, ok := c.Get("userID"); ok {
 			c.Next()
 			return
 		}
 
 		authHeader := c.GetHeader("Authorization")
+		// Browsers cannot set headers on WebSocket upgrades, so those pass the token in the URL
+		if authHeader == "" && websocket.IsWebSocketUpgrade(c.Request) && c.Query("token") != "" {
+			authHeader = "Bearer " + c.Query("token")
+		}
 		if authHeader == "" {
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Authorization header is missing"})
 			return
@@ -52,8 +58,63 @@ func JWTAuthMiddleware(jwtService authService.JWTService, userRepo authRepo.User
 			return
 		}
 
-		// Token is valid, set the user ID in context for further processing
+		if user.IsSuspended(time.Now()) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Account is suspended"})
+			return
+		}
+
+		// Token is valid, set the user ID and role in context for further processing
 		c.Set("userID", claims.UserID)
+		c.Set("userRole", user.Role)
+		c.Next()
+	}
+}
+
+// BotTokenMiddleware authenticates bots sending "Authorization: Bot <token>". It must run
+// before JWTAuthMiddleware, which lets the requests it authenticated through; other
+// requests are left to JWTAuthMiddleware.
+func BotTokenMiddleware(botService authService.BotService, userRepo authRepo.UserRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bot ")
+		if !ok {
+			c.Next()
+			return
+		}
+
+		bot, err := botService.Authenticate(c.Request.Context(), token)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid bot token"})
+			return
+		}
+
+		user, err := userRepo.GetUserByID(c.Request.Context(), bot.UserID)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+			return
+		}
+		if user.IsSuspended(time.Now()) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Account is suspended"})
+			return
+		}
+
+		c.Set("userID", user.ID)
+		c.Set("userRole", user.Role)
+		c.Set("isBot", true)
 		c.Next()
 	}
 }
+
+// RequireRole only lets through users with one of the given roles. It must run after
+// JWTAuthMiddleware.
+func RequireRole(roles ...authModels.Role) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		role, _ := c.Get("userRole")
+		for _, allowed := range roles {
+			if role == allowed {
+				c.Next()
+				return
+			}
+		}
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Insufficient permissions"})
+	}
+}
@@ -2,7 +2,9 @@ package storage
 
 import (
 	"context"
+	"io"
 	"mime/multipart"
+	"slices"
 
 	"github.com/dk5761/go-serv/configs"
 	"google.golang.org/api/drive/v3"
@@ -39,3 +41,43 @@ func (s *GDriveStorageService) UploadFile(ctx context.Context, file multipart.Fi
 	}
 	return res.Id, nil
 }
+
+// DeleteFile removes a file by the ID returned from UploadFile
+func (s *GDriveStorageService) DeleteFile(ctx context.Context, fileID string) error {
+	return s.service.Files.Delete(fileID).Context(ctx).Do()
+}
+
+// Stores reports whether fileID has the form of a Drive file ID
+func (s *GDriveStorageService) Stores(fileID string) bool {
+	if fileID == "" {
+		return false
+	}
+	for _, r := range fileID {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_') {
+			return false
+		}
+	}
+	return true
+}
+
+// OpenFile downloads a file by the ID returned from UploadFile. Files outside the upload
+// folder are refused, as the credentials may reach other files of the account.
+func (s *GDriveStorageService) OpenFile(ctx context.Context, fileID string) (io.ReadCloser, error) {
+	if !s.Stores(fileID) {
+		return nil, ErrNotStored
+	}
+
+	f, err := s.service.Files.Get(fileID).Fields("parents").Context(ctx).Do()
+	if err != nil {
+		return nil, err
+	}
+	if !slices.Contains(f.Parents, s.folderID) {
+		return nil, ErrNotStored
+	}
+
+	resp, err := s.service.Files.Get(fileID).Context(ctx).Download()
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
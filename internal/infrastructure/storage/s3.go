@@ -3,8 +3,10 @@ package storage
 import (
 	"bytes"
 	"context"
+	"io"
 	"mime/multipart"
 	"path/filepath"
+	"strings"
 
 	"github.com/dk5761/go-serv/configs"
 
@@ -50,10 +52,47 @@ func (s *S3StorageService) UploadFile(ctx context.Context, file multipart.File,
 		return "", err
 	}
 
-	fileURL := "https://" + s.bucketName + ".s3.amazonaws.com/" + fileName
+	fileURL := s.baseURL() + fileName
 	return fileURL, nil
 }
 
+// DeleteFile removes the object behind a URL returned by UploadFile
+func (s *S3StorageService) DeleteFile(ctx context.Context, fileURL string) error {
+	key := strings.TrimPrefix(fileURL, s.baseURL())
+
+	_, err := s.s3.DeleteObjectWithContext(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(s.bucketName),
+		Key:    aws.String(key),
+	})
+	return err
+}
+
+// Stores reports whether fileURL names an object in the bucket
+func (s *S3StorageService) Stores(fileURL string) bool {
+	key, ok := strings.CutPrefix(fileURL, s.baseURL())
+	return ok && key != ""
+}
+
+// OpenFile reads the object behind a URL returned by UploadFile
+func (s *S3StorageService) OpenFile(ctx context.Context, fileURL string) (io.ReadCloser, error) {
+	if !s.Stores(fileURL) {
+		return nil, ErrNotStored
+	}
+
+	output, err := s.s3.GetObjectWithContext(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.bucketName),
+		Key:    aws.String(strings.TrimPrefix(fileURL, s.baseURL())),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return output.Body, nil
+}
+
+func (s *S3StorageService) baseURL() string {
+	return "https://" + s.bucketName + ".s3.amazonaws.com/"
+}
+
 func getContentType(fileName string) string {
 	ext := filepath.Ext(fileName)
 	switch ext {
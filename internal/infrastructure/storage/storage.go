@@ -2,10 +2,23 @@ package storage
 
 import (
 	"context"
+	"errors"
+	"io"
 	"mime/multipart"
 )
 
+// ErrNotStored is returned by OpenFile for a file that this storage service did not store.
+var ErrNotStored = errors.New("file is not stored by this server")
+
 type StorageService interface {
 	UploadFile(ctx context.Context, file multipart.File, fileName string) (string, error)
+	// DeleteFile removes a file previously returned by UploadFile
+	DeleteFile(ctx context.Context, fileURL string) error
+	// Stores reports whether fileURL has the form of a file returned by UploadFile, without
+	// checking that the file exists
+	Stores(fileURL string) bool
+	// OpenFile reads a file previously returned by UploadFile. Anything else, such as a URL
+	// a client made up, fails with ErrNotStored and is never fetched.
+	OpenFile(ctx context.Context, fileURL string) (io.ReadCloser, error)
 	// Add other methods if needed
 }
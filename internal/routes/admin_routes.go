@@ -0,0 +1,27 @@
+package routes
+
+import (
+	authModels "github.com/dk5761/go-serv/internal/domain/auth/models"
+	"github.com/dk5761/go-serv/internal/infrastructure/container"
+	"github.com/dk5761/go-serv/internal/infrastructure/middlewares"
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterAdminRoutes(router *gin.Engine, container *container.Container) {
+	admin := router.Group("/api/admin")
+	admin.Use(middlewares.JWTAuthMiddleware(container.AuthHandler.JwtService, container.AuthHandler.UserRepo))
+	admin.Use(middlewares.RequireRole(authModels.RoleAdmin))
+	{
+		admin.POST("/import", container.ImportHandler.ImportTranscript)
+		admin.GET("/retention/preview", container.RetentionHandler.PreviewPurge)
+		admin.GET("/moderation/rejections", container.ModerationHandler.ListRejections)
+
+		admin.POST("/bots", container.BotHandler.CreateBot)
+		admin.GET("/bots", container.BotHandler.ListBots)
+		admin.GET("/bots/:id", container.BotHandler.GetBot)
+		admin.PUT("/bots/:id/webhook", container.BotHandler.SetWebhook)
+		admin.POST("/bots/:id/token", container.BotHandler.RegenerateToken)
+		admin.POST("/bots/:id/webhook-secret", container.BotHandler.RegenerateWebhookSecret)
+		admin.DELETE("/bots/:id", container.BotHandler.DeleteBot)
+	}
+}
@@ -20,5 +20,15 @@ func RegisterAuthRoutes(router *gin.Engine, container *container.Container) {
 		protected.GET("/details", container.AuthHandler.Profile)
 		protected.GET("/users", container.AuthHandler.GetUsers)
 
+		protected.GET("/blocks", container.BlockHandler.GetBlockedUsers)
+		protected.POST("/blocks/:id", container.BlockHandler.BlockUser)
+		protected.DELETE("/blocks/:id", container.BlockHandler.UnblockUser)
+
+		protected.PUT("/keys/identity", container.KeyHandler.SetIdentityKey)
+		protected.PUT("/keys/signed-prekey", container.KeyHandler.SetSignedPrekey)
+		protected.POST("/keys/prekeys", container.KeyHandler.UploadPrekeys)
+		protected.GET("/keys/prekeys/count", container.KeyHandler.CountPrekeys)
+		protected.GET("/keys/:id/bundle", container.KeyHandler.GetPrekeyBundle)
+
 	}
 }
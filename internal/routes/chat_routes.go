@@ -7,10 +7,72 @@ import (
 )
 
 func RegisterChatRoutes(router *gin.Engine, container *container.Container) {
+	// Reached from digest emails, authenticated by the link's signature
+	public := router.Group("/api/chat")
+	{
+		public.GET("/digest/unsubscribe", container.DigestHandler.Unsubscribe)
+		public.POST("/digest/unsubscribe", container.DigestHandler.Unsubscribe)
+	}
+
+	// Incoming webhooks, authenticated by the token in the URL
+	hooks := router.Group("/api/hooks")
+	{
+		hooks.POST("/:token", container.WebhookHandler.Post)
+	}
+
 	protected := router.Group("/api/chat")
+	protected.Use(middlewares.BotTokenMiddleware(container.BotHandler.BotService, container.AuthHandler.UserRepo))
 	protected.Use(middlewares.JWTAuthMiddleware(container.AuthHandler.JwtService, container.AuthHandler.UserRepo))
 	{
 		protected.GET("/ws", container.ChatHandler.HandleWebSocket)
 		protected.POST("/send", container.ChatHandler.SendMessage)
+		protected.POST("/upload", container.ChatHandler.UploadFile)
+		protected.GET("/mentions", container.ChatHandler.GetMentions)
+
+		protected.GET("/commands", container.CommandHandler.ListCommands)
+		protected.PUT("/commands", container.CommandHandler.SetBotCommands)
+		protected.POST("/commands/reply", container.CommandHandler.Reply)
+
+		protected.GET("/calls/ice-servers", container.CallHandler.GetICEServers)
+
+		protected.POST("/scheduled", container.ScheduledHandler.ScheduleMessage)
+		protected.GET("/scheduled", container.ScheduledHandler.ListScheduledMessages)
+		protected.PUT("/scheduled/:id", container.ScheduledHandler.UpdateScheduledMessage)
+		protected.DELETE("/scheduled/:id", container.ScheduledHandler.CancelScheduledMessage)
+
+		protected.GET("/conversations", container.InboxHandler.ListConversations)
+		protected.GET("/conversations/:id", container.ConversationHandler.GetConversation)
+		protected.GET("/conversations/:id/messages", container.ChatHandler.GetConversationMessages)
+		protected.PUT("/conversations/:id/timer", container.ConversationHandler.SetMessageTimer)
+		protected.PUT("/conversations/:id/retention", container.RetentionHandler.SetRetention)
+		protected.GET("/conversations/:id/draft", container.DraftHandler.GetDraft)
+		protected.PUT("/conversations/:id/draft", container.DraftHandler.SaveDraft)
+		protected.GET("/conversations/:id/settings", container.InboxHandler.GetSettings)
+		protected.PUT("/conversations/:id/mute", container.InboxHandler.Mute)
+		protected.DELETE("/conversations/:id/mute", container.InboxHandler.Unmute)
+		protected.PUT("/conversations/:id/archive", container.InboxHandler.Archive)
+		protected.DELETE("/conversations/:id/archive", container.InboxHandler.Unarchive)
+		protected.PUT("/conversations/:id/folders", container.InboxHandler.SetFolders)
+		protected.POST("/conversations/:id/read", container.InboxHandler.MarkRead)
+		protected.GET("/conversations/:id/export", container.ExportHandler.ExportConversation)
+		protected.POST("/conversations/:id/webhooks", container.WebhookHandler.CreateWebhook)
+		protected.GET("/conversations/:id/webhooks", container.WebhookHandler.ListWebhooks)
+		protected.POST("/webhooks/:id/token", container.WebhookHandler.RegenerateToken)
+		protected.DELETE("/webhooks/:id/token", container.WebhookHandler.RevokeToken)
+		protected.DELETE("/webhooks/:id", container.WebhookHandler.DeleteWebhook)
+		protected.POST("/reports", container.ReportHandler.CreateReport)
+		protected.GET("/reports", container.ReportHandler.ListOwnReports)
+		protected.GET("/exports/:id", container.ExportHandler.GetExportJob)
+
+		protected.POST("/devices", container.DeviceHandler.RegisterDevice)
+		protected.GET("/devices", container.DeviceHandler.ListDevices)
+		protected.DELETE("/devices/:token", container.DeviceHandler.UnregisterDevice)
+
+		protected.GET("/preferences", container.InboxHandler.GetPreferences)
+		protected.PUT("/preferences", container.InboxHandler.SavePreferences)
+
+		protected.GET("/polls/:id", container.PollHandler.GetPoll)
+		protected.POST("/polls/:id/vote", container.PollHandler.Vote)
+		protected.POST("/polls/:id/close", container.PollHandler.ClosePoll)
 	}
 }
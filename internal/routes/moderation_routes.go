@@ -0,0 +1,20 @@
+package routes
+
+import (
+	authModels "github.com/dk5761/go-serv/internal/domain/auth/models"
+	"github.com/dk5761/go-serv/internal/infrastructure/container"
+	"github.com/dk5761/go-serv/internal/infrastructure/middlewares"
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterModerationRoutes(router *gin.Engine, container *container.Container) {
+	moderation := router.Group("/api/moderation")
+	moderation.Use(middlewares.JWTAuthMiddleware(container.AuthHandler.JwtService, container.AuthHandler.UserRepo))
+	moderation.Use(middlewares.RequireRole(authModels.RoleModerator, authModels.RoleAdmin))
+	{
+		moderation.GET("/reports", container.ReportHandler.ListReports)
+		moderation.GET("/reports/:id", container.ReportHandler.GetReport)
+		moderation.POST("/reports/:id/claim", container.ReportHandler.ClaimReport)
+		moderation.POST("/reports/:id/resolve", container.ReportHandler.ResolveReport)
+	}
+}
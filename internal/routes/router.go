@@ -19,4 +19,6 @@ func InitRoutes(router *gin.Engine, container *container.Container) {
 	// Register feature routes
 	RegisterAuthRoutes(router, container)
 	RegisterChatRoutes(router, container)
+	RegisterAdminRoutes(router, container)
+	RegisterModerationRoutes(router, container)
 }
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS one_time_prekeys;
+DROP TABLE IF EXISTS signed_prekeys;
+DROP TABLE IF EXISTS identity_keys;
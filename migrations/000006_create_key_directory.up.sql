@@ -0,0 +1,24 @@
+-- Public keys for end-to-end encrypted chats. The server only stores and hands out public
+-- keys; private keys never leave the clients.
+CREATE TABLE IF NOT EXISTS identity_keys (
+    user_id UUID PRIMARY KEY REFERENCES users (id) ON DELETE CASCADE,
+    registration_id INTEGER NOT NULL,
+    public_key BYTEA NOT NULL,
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS signed_prekeys (
+    user_id UUID PRIMARY KEY REFERENCES identity_keys (user_id) ON DELETE CASCADE,
+    key_id INTEGER NOT NULL,
+    public_key BYTEA NOT NULL,
+    signature BYTEA NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS one_time_prekeys (
+    user_id UUID NOT NULL REFERENCES identity_keys (user_id) ON DELETE CASCADE,
+    key_id INTEGER NOT NULL,
+    public_key BYTEA NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, key_id)
+);
@@ -0,0 +1,13 @@
+ALTER TABLE users ADD COLUMN IF NOT EXISTS is_bot BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Bot accounts authenticate with a long-lived token, stored only as its SHA-256 hash, and
+-- receive the messages addressed to them at their webhook URL.
+CREATE TABLE IF NOT EXISTS bots (
+    user_id UUID PRIMARY KEY REFERENCES users (id) ON DELETE CASCADE,
+    token_hash VARCHAR(64) UNIQUE NOT NULL,
+    webhook_url TEXT NOT NULL,
+    webhook_secret VARCHAR(64) NOT NULL,
+    created_by UUID REFERENCES users (id) ON DELETE SET NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
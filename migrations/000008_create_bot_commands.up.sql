@@ -0,0 +1,8 @@
+-- Slash commands a bot handles in its conversations
+CREATE TABLE IF NOT EXISTS bot_commands (
+    bot_id UUID NOT NULL REFERENCES bots (user_id) ON DELETE CASCADE,
+    name VARCHAR(32) NOT NULL,
+    usage TEXT NOT NULL DEFAULT '',
+    description TEXT NOT NULL DEFAULT '',
+    PRIMARY KEY (bot_id, name)
+);
@@ -2,35 +2,87 @@ package migrations
 
 import (
 	"context"
+	"errors"
 	"log"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+// dataMigrationTimeout bounds all data migrations of a start together. They can scan whole
+// collections, so they do not share the short timeout of the schema changes.
+const dataMigrationTimeout = 30 * time.Minute
+
+// dataMigration rewrites existing documents. Each one runs once: it is recorded in the
+// schema_migrations collection when it succeeds and skipped on later starts. Migrations
+// must be safe to run again, as one that fails, or that two servers start at the same
+// time, will.
+type dataMigration struct {
+	name string
+	run  func(ctx context.Context, db *mongo.Database) error
+}
+
+// dataMigrations run in order after the schema and indexes are in place. Append new
+// migrations at the end and never rename one that has shipped.
+var dataMigrations = []dataMigration{
+	{name: "0001_backfill_message_types", run: backfillMessageTypes},
+	{name: "0002_backfill_message_seqs", run: backfillMessageSeqs},
+	{name: "0003_retire_pending_status", run: migrateMessageStatuses},
+}
+
 // RunMigrations runs MongoDB migrations, such as collection creation and schema validation
 func RunMigrations(db *mongo.Database) error {
+	if err := runSchemaMigrations(db); err != nil {
+		return err
+	}
+	return runDataMigrations(db)
+}
+
+func runSchemaMigrations(db *mongo.Database) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
+	if err := createMessagesCollection(ctx, db); err != nil {
+		return err
+	}
+
+	return createIndexes(ctx, db)
+}
+
+// createMessagesCollection creates the messages collection with schema validation, or
+// updates the validator of an existing collection to the current schema
+func createMessagesCollection(ctx context.Context, db *mongo.Database) error {
 	// Check if the collection already exists
 	collections, err := db.ListCollectionNames(ctx, bson.M{"name": "messages"})
 	if err != nil {
 		log.Printf("Failed to list collections: %v", err)
 		return err
 	}
-	// If the "messages" collection already exists, skip the migration
+	// If the "messages" collection already exists, migrate it in place
 	for _, collection := range collections {
 		if collection == "messages" {
-			log.Println("Collection 'messages' already exists, skipping migration.")
-			return nil
+			log.Println("Collection 'messages' already exists, updating schema.")
+			return updateMessagesSchema(ctx, db)
 		}
 	}
 
-	// Define schema for the messages collection
-	schema := bson.M{
+	// Create collection with validation
+	err = createCollectionWithValidation(ctx, db, "messages", messagesSchema())
+	if err != nil {
+		log.Printf("Failed to create messages collection: %v", err)
+		return err
+	}
+
+	log.Println("Migration ran successfully: messages collection created with schema validation.")
+	return nil
+}
+
+// messagesSchema defines the schema for the messages collection
+func messagesSchema() bson.M {
+	return bson.M{
 		"bsonType": "object",
 		"required": []string{"sender_id", "receiver_id", "content", "created_at"},
 		"properties": bson.M{
@@ -50,17 +102,302 @@ func RunMigrations(db *mongo.Database) error {
 				"bsonType":    "date",
 				"description": "must be a date and is required",
 			},
+			"type": bson.M{
+				"enum":        []string{"text", "system", "image", "file", "voice", "location", "contact", "poll", "encrypted", "call"},
+				"description": "must be a known message type if present",
+			},
+			"image": bson.M{
+				"bsonType": "object",
+				"required": []string{"width", "height"},
+				"properties": bson.M{
+					"width":  bson.M{"bsonType": []string{"int", "long"}, "minimum": 1},
+					"height": bson.M{"bsonType": []string{"int", "long"}, "minimum": 1},
+				},
+			},
+			"file": bson.M{
+				"bsonType": "object",
+				"required": []string{"name", "size", "mime_type"},
+				"properties": bson.M{
+					"name":      bson.M{"bsonType": "string"},
+					"size":      bson.M{"bsonType": []string{"int", "long"}, "minimum": 1},
+					"mime_type": bson.M{"bsonType": "string"},
+				},
+			},
+			"voice": bson.M{
+				"bsonType": "object",
+				"required": []string{"duration"},
+				"properties": bson.M{
+					"duration": bson.M{"bsonType": "double", "minimum": 0},
+				},
+			},
+			"location": bson.M{
+				"bsonType": "object",
+				"required": []string{"latitude", "longitude"},
+				"properties": bson.M{
+					"latitude":  bson.M{"bsonType": "double", "minimum": -90, "maximum": 90},
+					"longitude": bson.M{"bsonType": "double", "minimum": -180, "maximum": 180},
+				},
+			},
+			"contact": bson.M{
+				"bsonType": "object",
+				"required": []string{"name"},
+				"properties": bson.M{
+					"name": bson.M{"bsonType": "string"},
+				},
+			},
+			"poll": bson.M{
+				"bsonType": "object",
+				"required": []string{"question", "options", "closed"},
+				"properties": bson.M{
+					"question": bson.M{"bsonType": "string"},
+					"options":  bson.M{"bsonType": "array", "minItems": 2},
+					"closed":   bson.M{"bsonType": "bool"},
+				},
+			},
+			"call": bson.M{
+				"bsonType": "object",
+				"required": []string{"call_id", "media", "outcome", "started_at", "ended_at", "duration"},
+				"properties": bson.M{
+					"call_id":     bson.M{"bsonType": "string"},
+					"media":       bson.M{"enum": []string{"audio", "video"}},
+					"outcome":     bson.M{"enum": []string{"completed", "missed", "rejected", "cancelled", "busy"}},
+					"started_at":  bson.M{"bsonType": "date"},
+					"answered_at": bson.M{"bsonType": "date"},
+					"ended_at":    bson.M{"bsonType": "date"},
+					"duration":    bson.M{"bsonType": []string{"int", "long"}, "minimum": 0},
+				},
+			},
 		},
 	}
+}
+
+// updateMessagesSchema applies the current schema to an existing messages collection. The
+// moderate validation level leaves documents that never matched the schema editable.
+func updateMessagesSchema(ctx context.Context, db *mongo.Database) error {
+	command := bson.D{
+		{Key: "collMod", Value: "messages"},
+		{Key: "validator", Value: bson.M{"$jsonSchema": messagesSchema()}},
+		{Key: "validationLevel", Value: "moderate"},
+	}
+	if err := db.RunCommand(ctx, command).Err(); err != nil {
+		log.Printf("Failed to update messages schema: %v", err)
+		return err
+	}
 
-	// Create collection with validation
-	err = createCollectionWithValidation(ctx, db, "messages", schema)
+	log.Println("Migration ran successfully: messages schema updated.")
+	return nil
+}
+
+// runDataMigrations runs the data migrations that have not been applied yet
+func runDataMigrations(db *mongo.Database) error {
+	ctx, cancel := context.WithTimeout(context.Background(), dataMigrationTimeout)
+	defer cancel()
+
+	applied := db.Collection("schema_migrations")
+	for _, migration := range dataMigrations {
+		err := applied.FindOne(ctx, bson.M{"_id": migration.name}).Err()
+		if err == nil {
+			continue
+		}
+		if !errors.Is(err, mongo.ErrNoDocuments) {
+			log.Printf("Failed to check migration %s: %v", migration.name, err)
+			return err
+		}
+
+		if err := migration.run(ctx, db); err != nil {
+			log.Printf("Migration %s failed: %v", migration.name, err)
+			return err
+		}
+		_, err = applied.InsertOne(ctx, bson.M{"_id": migration.name, "applied_at": time.Now()})
+		if err != nil && !mongo.IsDuplicateKeyError(err) {
+			log.Printf("Failed to record migration %s: %v", migration.name, err)
+			return err
+		}
+		log.Printf("Migration %s applied.", migration.name)
+	}
+	return nil
+}
+
+// backfillMessageTypes marks messages written before message types existed as text messages
+func backfillMessageTypes(ctx context.Context, db *mongo.Database) error {
+	result, err := db.Collection("messages").UpdateMany(ctx,
+		bson.M{"type": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"type": "text"}},
+	)
 	if err != nil {
-		log.Printf("Failed to create messages collection: %v", err)
 		return err
 	}
 
-	log.Println("Migration ran successfully: messages collection created with schema validation.")
+	log.Printf("Migration ran successfully: %d messages marked as text.", result.ModifiedCount)
+	return nil
+}
+
+// migrateMessageStatuses moves messages off the retired "pending" status, which replaced
+// the message's status whenever an acknowledgment could not reach the sender. Those
+// messages also had their delivery state reset, so they restart as stored with their
+// acknowledgment pending, and are replayed to the receiver, who acknowledges them again.
+// Received and read messages whose delivery state was reset are marked delivered again.
+func migrateMessageStatuses(ctx context.Context, db *mongo.Database) error {
+	messages := db.Collection("messages")
+
+	pending, err := messages.UpdateMany(ctx,
+		bson.M{"status": "pending"},
+		bson.M{"$set": bson.M{"status": "stored", "ack_pending": true}},
+	)
+	if err != nil {
+		log.Printf("Failed to migrate pending messages: %v", err)
+		return err
+	}
+
+	redelivered, err := messages.UpdateMany(ctx,
+		bson.M{"status": bson.M{"$in": []string{"received", "read"}}, "delivered": false},
+		bson.M{"$set": bson.M{"delivered": true}},
+	)
+	if err != nil {
+		log.Printf("Failed to migrate message delivery state: %v", err)
+		return err
+	}
+
+	if pending.ModifiedCount > 0 || redelivered.ModifiedCount > 0 {
+		log.Printf("Migration ran successfully: %d pending messages restarted as stored, %d messages marked delivered.",
+			pending.ModifiedCount, redelivered.ModifiedCount)
+	}
+	return nil
+}
+
+// backfillMessageSeqs numbers the messages stored before messages had sequence numbers, in
+// creation order. In a conversation that already has numbered messages, the older ones are
+// numbered downwards from its first message, the same way imported history is, and any
+// others after its last message.
+func backfillMessageSeqs(ctx context.Context, db *mongo.Database) error {
+	messages := db.Collection("messages")
+	counters := db.Collection("conversation_counters")
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"seq": bson.M{"$exists": false}, "conversation_id": bson.M{"$type": "string"}}}},
+		{{Key: "$sort", Value: bson.D{{Key: "conversation_id", Value: 1}, {Key: "created_at", Value: 1}, {Key: "_id", Value: 1}}}},
+		{{Key: "$project", Value: bson.M{"conversation_id": 1, "created_at": 1}}},
+	}
+	cursor, err := messages.Aggregate(ctx, pipeline, options.Aggregate().SetAllowDiskUse(true))
+	if err != nil {
+		log.Printf("Failed to list messages without sequence numbers: %v", err)
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	type unnumbered struct {
+		ID             primitive.ObjectID `bson:"_id"`
+		ConversationID string             `bson:"conversation_id"`
+		CreatedAt      time.Time          `bson:"created_at"`
+	}
+
+	// number allocates the numbers of one conversation's messages, oldest first
+	number := func(conversationID string, documents []unnumbered) error {
+		findOptions := options.FindOne().
+			SetSort(bson.D{{Key: "created_at", Value: 1}}).
+			SetProjection(bson.M{"created_at": 1})
+		var oldest struct {
+			CreatedAt time.Time `bson:"created_at"`
+		}
+		err := messages.FindOne(ctx, bson.M{"conversation_id": conversationID, "seq": bson.M{"$exists": true}}, findOptions).Decode(&oldest)
+		if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+			return err
+		}
+
+		history := 0
+		if err == nil {
+			for history < len(documents) && documents[history].CreatedAt.Before(oldest.CreatedAt) {
+				history++
+			}
+		}
+
+		seqs := make([]int64, len(documents))
+		if history > 0 {
+			var counter struct {
+				Floor int64 `bson:"floor"`
+			}
+			update := mongo.Pipeline{
+				{{Key: "$set", Value: bson.M{
+					"floor": bson.M{"$subtract": bson.A{
+						bson.M{"$min": bson.A{bson.M{"$ifNull": bson.A{"$floor", 1}}, 0}},
+						history,
+					}},
+				}}},
+			}
+			err := counters.FindOneAndUpdate(ctx, bson.M{"_id": conversationID}, update,
+				options.FindOneAndUpdate().SetReturnDocument(options.After),
+			).Decode(&counter)
+			if err != nil {
+				return err
+			}
+			for i := 0; i < history; i++ {
+				seqs[i] = counter.Floor + int64(i)
+			}
+		}
+		if rest := len(documents) - history; rest > 0 {
+			var counter struct {
+				Seq int64 `bson:"seq"`
+			}
+			err := counters.FindOneAndUpdate(ctx,
+				bson.M{"_id": conversationID},
+				bson.M{"$inc": bson.M{"seq": rest}},
+				options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+			).Decode(&counter)
+			if err != nil {
+				return err
+			}
+			for i := 0; i < rest; i++ {
+				seqs[history+i] = counter.Seq - int64(rest) + 1 + int64(i)
+			}
+		}
+
+		writes := make([]mongo.WriteModel, len(documents))
+		for i, document := range documents {
+			writes[i] = mongo.NewUpdateOneModel().
+				SetFilter(bson.M{"_id": document.ID, "seq": bson.M{"$exists": false}}).
+				SetUpdate(bson.M{"$set": bson.M{"seq": seqs[i]}})
+		}
+		_, err = messages.BulkWrite(ctx, writes, options.BulkWrite().SetOrdered(false))
+		return err
+	}
+
+	total, conversations := 0, 0
+	var batch []unnumbered
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		conversationID := batch[0].ConversationID
+		if err := number(conversationID, batch); err != nil {
+			log.Printf("Failed to backfill sequence numbers for %s: %v", conversationID, err)
+			return err
+		}
+		total += len(batch)
+		conversations++
+		batch = batch[:0]
+		return nil
+	}
+
+	for cursor.Next(ctx) {
+		var document unnumbered
+		if err := cursor.Decode(&document); err != nil {
+			return err
+		}
+		if len(batch) > 0 && batch[0].ConversationID != document.ConversationID {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+		batch = append(batch, document)
+	}
+	if err := cursor.Err(); err != nil {
+		return err
+	}
+	if err := flush(); err != nil {
+		return err
+	}
+
+	log.Printf("Migration ran successfully: %d messages in %d conversations numbered.", total, conversations)
 	return nil
 }
 
@@ -75,3 +412,144 @@ func createCollectionWithValidation(ctx context.Context, db *mongo.Database, col
 	log.Printf("Collection %s created successfully with schema validation", collectionName)
 	return nil
 }
+
+// createIndexes ensures the indexes used by background workers exist. CreateMany is a
+// no-op for indexes that are already present, so this runs on every start.
+func createIndexes(ctx context.Context, db *mongo.Database) error {
+	indexes := map[string][]mongo.IndexModel{
+		"messages": {
+			{Keys: bson.D{{Key: "conversation_id", Value: 1}, {Key: "created_at", Value: 1}}},
+			// Sequence numbers are unique within a conversation
+			{
+				Keys: bson.D{{Key: "conversation_id", Value: 1}, {Key: "seq", Value: 1}},
+				Options: options.Index().SetUnique(true).SetPartialFilterExpression(bson.M{
+					"seq": bson.M{"$exists": true},
+				}),
+			},
+			{Keys: bson.D{{Key: "mentions.user_id", Value: 1}, {Key: "created_at", Value: -1}}},
+			// Makes imports idempotent: a message from a source is only ever inserted once
+			{
+				Keys: bson.D{{Key: "import_key", Value: 1}},
+				Options: options.Index().SetUnique(true).SetPartialFilterExpression(bson.M{
+					"import_key": bson.M{"$exists": true},
+				}),
+			},
+			{
+				Keys: bson.D{{Key: "poll.closes_at", Value: 1}},
+				Options: options.Index().SetPartialFilterExpression(bson.M{
+					"type":        "poll",
+					"poll.closed": false,
+				}),
+			},
+			// Backstop for disappearing messages: the sweeper normally deletes them at
+			// expires_at, this removes anything it missed a day later
+			{
+				Keys:    bson.D{{Key: "expires_at", Value: 1}},
+				Options: options.Index().SetExpireAfterSeconds(24 * 60 * 60),
+			},
+			{
+				Keys: bson.D{{Key: "receiver_id", Value: 1}, {Key: "created_at", Value: 1}},
+				Options: options.Index().SetPartialFilterExpression(bson.M{
+					"status": "stored",
+				}),
+			},
+			{
+				Keys: bson.D{{Key: "sender_id", Value: 1}, {Key: "created_at", Value: 1}},
+				Options: options.Index().SetPartialFilterExpression(bson.M{
+					"ack_pending": true,
+				}),
+			},
+			// Finds the messages whose data key still needs re-wrapping after a key rotation
+			{
+				Keys: bson.D{{Key: "sealed.key_id", Value: 1}},
+				Options: options.Index().SetPartialFilterExpression(bson.M{
+					"sealed": bson.M{"$exists": true},
+				}),
+			},
+		},
+		"audit_log": {
+			{Keys: bson.D{{Key: "action", Value: 1}, {Key: "created_at", Value: -1}}},
+			{Keys: bson.D{{Key: "target_id", Value: 1}, {Key: "created_at", Value: -1}}},
+		},
+		"conversation_settings": {
+			{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "archived", Value: 1}, {Key: "last_message_at", Value: -1}}},
+			{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "folders", Value: 1}, {Key: "last_message_at", Value: -1}}},
+			// Conversations with unread messages, for email digests
+			{
+				Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "last_message_at", Value: -1}},
+				Options: options.Index().SetPartialFilterExpression(bson.M{
+					"unread_count": bson.M{"$gt": 0},
+				}),
+			},
+		},
+		"device_tokens": {
+			{Keys: bson.D{{Key: "user_id", Value: 1}}},
+		},
+		"drafts": {
+			{
+				Keys: bson.D{{Key: "sealed.key_id", Value: 1}},
+				Options: options.Index().SetPartialFilterExpression(bson.M{
+					"sealed": bson.M{"$exists": true},
+				}),
+			},
+		},
+		"export_jobs": {
+			{Keys: bson.D{{Key: "status", Value: 1}, {Key: "created_at", Value: 1}}},
+		},
+		"incoming_webhooks": {
+			{Keys: bson.D{{Key: "conversation_id", Value: 1}, {Key: "created_at", Value: 1}}},
+			{
+				Keys: bson.D{{Key: "token_hash", Value: 1}},
+				Options: options.Index().SetUnique(true).SetPartialFilterExpression(bson.M{
+					"token_hash": bson.M{"$exists": true},
+				}),
+			},
+		},
+		"moderation_log": {
+			{Keys: bson.D{{Key: "created_at", Value: -1}}},
+			{Keys: bson.D{{Key: "stage", Value: 1}, {Key: "created_at", Value: -1}}},
+			{
+				Keys: bson.D{{Key: "sealed.key_id", Value: 1}},
+				Options: options.Index().SetPartialFilterExpression(bson.M{
+					"sealed": bson.M{"$exists": true},
+				}),
+			},
+		},
+		"reports": {
+			{Keys: bson.D{{Key: "status", Value: 1}, {Key: "created_at", Value: 1}}},
+			{Keys: bson.D{{Key: "reporter_id", Value: 1}, {Key: "created_at", Value: -1}}},
+			// One unresolved report per reporter and target
+			{
+				Keys: bson.D{{Key: "dedupe_key", Value: 1}},
+				Options: options.Index().SetUnique(true).SetPartialFilterExpression(bson.M{
+					"dedupe_key": bson.M{"$exists": true},
+				}),
+			},
+			{
+				Keys: bson.D{{Key: "sealed.key_id", Value: 1}},
+				Options: options.Index().SetPartialFilterExpression(bson.M{
+					"sealed": bson.M{"$exists": true},
+				}),
+			},
+		},
+		"scheduled_messages": {
+			{Keys: bson.D{{Key: "status", Value: 1}, {Key: "send_at", Value: 1}}},
+			{Keys: bson.D{{Key: "sender_id", Value: 1}, {Key: "status", Value: 1}}},
+			{
+				Keys: bson.D{{Key: "sealed.key_id", Value: 1}},
+				Options: options.Index().SetPartialFilterExpression(bson.M{
+					"sealed": bson.M{"$exists": true},
+				}),
+			},
+		},
+	}
+
+	for collectionName, models := range indexes {
+		if _, err := db.Collection(collectionName).Indexes().CreateMany(ctx, models); err != nil {
+			log.Printf("Failed to create indexes on %s: %v", collectionName, err)
+			return err
+		}
+	}
+
+	return nil
+}